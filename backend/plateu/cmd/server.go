@@ -15,6 +15,7 @@ import (
 	"github.com/fidellr/jastip/backend/plateu"
 	"github.com/fidellr/jastip/backend/plateu/image"
 	"github.com/fidellr/jastip/backend/plateu/utils"
+	"github.com/fidellr/jastip/backend/uranus/auth"
 
 	_httpDelivery "github.com/fidellr/jastip/backend/plateu/internal/delivery/http"
 	_mongoRepository "github.com/fidellr/jastip/backend/plateu/internal/delivery/mongo"
@@ -108,5 +109,36 @@ func initPlateuApplication(e *echo.Echo) {
 	)
 
 	e.HTTPErrorHandler = utils.HandleUncaughtHTTPError
+	initAuthMiddleware(e)
 	_httpDelivery.NewImageHandler(e, _httpDelivery.ImageService(imageService))
 }
+
+func initAuthMiddleware(e *echo.Echo) {
+	protectedGroups := viper.GetStringSlice("auth.protected_groups")
+	if len(protectedGroups) == 0 {
+		logrus.Warn("Plateu is running without authentication, no auth.protected_groups configured")
+		return
+	}
+
+	authSecret := viper.GetString("auth.secret")
+	if authSecret == "" {
+		logrus.Fatalln(errors.New("Please provide the uranus auth secret to verify access tokens"))
+	}
+
+	// Plateu doesn't ask uranus whether a token was revoked, it only takes tokens that were issued recently.
+	maxTokenAge := time.Duration(viper.GetInt("auth.max_token_age")) * time.Second
+	if maxTokenAge <= 0 {
+		logrus.Fatalln(errors.New("Please provide auth.max_token_age to bound how long a revoked token keeps working"))
+	}
+
+	tokenManager := auth.NewJWT(
+		auth.Secret(authSecret),
+		auth.Issuer(viper.GetString("auth.issuer")),
+	)
+	e.Use(auth.Middleware(
+		auth.TokenVerifier(tokenManager),
+		auth.MaxTokenAge(maxTokenAge),
+		auth.ProtectedGroups(protectedGroups...),
+		auth.PublicRoutes(viper.GetStringSlice("auth.public_routes")...),
+	))
+}
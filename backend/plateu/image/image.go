@@ -14,6 +14,8 @@ import (
 
 	"github.com/fidellr/jastip/backend/plateu"
 	"github.com/fidellr/jastip/backend/plateu/repository"
+	"github.com/fidellr/jastip/backend/uranus"
+	"github.com/fidellr/jastip/backend/uranus/auth"
 )
 
 type service struct {
@@ -135,11 +137,16 @@ func (s *service) StoreImage(ctx context.Context, m *models.Image) (err error) {
 		return err
 	}
 
+	if err = auth.Authorize(ctx, auth.ActionUploadImage); err != nil {
+		return err
+	}
+
 	if err = s.validator.ValidateStruct(m); err != nil {
 		err = errors.Wrap(err, "error validating image")
 		return err
 	}
 
+	m.Version = 1
 	m.CreatedAt = time.Now()
 
 	ctx, cancel := context.WithTimeout(ctx, s.contextTimeout)
@@ -154,10 +161,10 @@ func (s *service) StoreImage(ctx context.Context, m *models.Image) (err error) {
 	return nil
 }
 
-func (s *service) FetchImages(ctx context.Context, filter *plateu.Filter) ([]*models.Image, string, error) {
+func (s *service) FetchImages(ctx context.Context, filter *plateu.Filter) ([]*models.Image, uranus.Page, error) {
 	if ctx == nil {
 		err := plateu.ErrContextNil
-		return nil, "", err
+		return nil, uranus.Page{}, err
 	}
 
 	ctx, cancel := context.WithTimeout(ctx, s.contextTimeout)
@@ -167,12 +174,12 @@ func (s *service) FetchImages(ctx context.Context, filter *plateu.Filter) ([]*mo
 		filter.Num = int(3)
 	}
 
-	images, nextCursor, err := s.repository.FetchImages(ctx, filter)
+	images, page, err := s.repository.FetchImages(ctx, filter)
 	if err != nil {
-		return nil, "", err
+		return nil, uranus.Page{}, err
 	}
 
-	return images, nextCursor, nil
+	return images, page, nil
 }
 
 func (s *service) GetImageByID(ctx context.Context, imageID string) (*models.Image, error) {
@@ -198,9 +205,26 @@ func (s *service) UpdateImageByID(ctx context.Context, imageID string, m *models
 		return err
 	}
 
+	if err = auth.Authorize(ctx, auth.ActionEditImage); err != nil {
+		return err
+	}
+
 	ctx, cancel := context.WithTimeout(ctx, s.contextTimeout)
 	defer cancel()
 
+	existing, err := s.repository.GetImageByID(ctx, imageID)
+	if err != nil {
+		return err
+	}
+
+	if !uranus.VersionMatches(m.Version, existing.Version) {
+		return uranus.ErrVersionConflict
+	}
+
+	m.ID = existing.ID
+	m.Version = existing.Version + 1
+	m.CreatedAt = existing.CreatedAt
+
 	done := make(chan bool)
 	go func() {
 		if err != nil {
@@ -214,7 +238,7 @@ func (s *service) UpdateImageByID(ctx context.Context, imageID string, m *models
 	}()
 
 	if <-done {
-		err = s.repository.UpdateImageByID(ctx, imageID, m)
+		err = s.repository.UpdateImageByID(ctx, imageID, existing.Version, m)
 		if err != nil {
 			return err
 		}
@@ -229,6 +253,10 @@ func (s *service) RemoveImageByID(ctx context.Context, imageID string) (err erro
 		return err
 	}
 
+	if err = auth.Authorize(ctx, auth.ActionDeleteImage); err != nil {
+		return err
+	}
+
 	ctx, cancel := context.WithCancel(ctx)
 	defer cancel()
 
@@ -0,0 +1,72 @@
+package image
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/fidellr/jastip/backend/plateu"
+	"github.com/fidellr/jastip/backend/plateu/models"
+	"github.com/fidellr/jastip/backend/uranus"
+	"github.com/fidellr/jastip/backend/uranus/auth"
+)
+
+// mutableImageFields are the only fields a client may send in a merge patch,
+// the file link, needs and mime locate the stored file so they can't change.
+var mutableImageFields = []string{
+	"person_name",
+	"role_name",
+}
+
+func (s *service) PatchImageByID(ctx context.Context, imageID string, version int, patch []byte) (*models.Image, error) {
+	if ctx == nil {
+		err := plateu.ErrContextNil
+		return nil, err
+	}
+
+	if err := auth.Authorize(ctx, auth.ActionEditImage); err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, s.contextTimeout)
+	defer cancel()
+
+	existing, err := s.repository.GetImageByID(ctx, imageID)
+	if err != nil {
+		return nil, err
+	}
+
+	if !uranus.VersionMatches(version, existing.Version) {
+		return nil, uranus.ErrVersionConflict
+	}
+
+	target, err := json.Marshal(existing)
+	if err != nil {
+		return nil, err
+	}
+
+	merged, err := uranus.MergePatch(target, patch, mutableImageFields...)
+	if err != nil {
+		return nil, err
+	}
+
+	m := new(models.Image)
+	if err = json.Unmarshal(merged, m); err != nil {
+		return nil, plateu.ConstraintErrorf("%s", err.Error())
+	}
+
+	m.ID = existing.ID
+	m.Version = existing.Version + 1
+	m.CreatedAt = existing.CreatedAt
+	m.FileLink = existing.FileLink
+	m.Needs = existing.Needs
+	m.MIME = existing.MIME
+	if err = s.validator.ValidateStruct(m); err != nil {
+		return nil, err
+	}
+
+	if err = s.repository.UpdateImageByID(ctx, imageID, existing.Version, m); err != nil {
+		return nil, err
+	}
+
+	return m, nil
+}
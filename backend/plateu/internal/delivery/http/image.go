@@ -3,6 +3,7 @@ package http
 import (
 	"context"
 	"fmt"
+	"io/ioutil"
 	"log"
 	"net/http"
 	"os"
@@ -13,9 +14,16 @@ import (
 
 	"github.com/fidellr/jastip/backend/plateu"
 	"github.com/fidellr/jastip/backend/plateu/models"
+	"github.com/fidellr/jastip/backend/uranus"
+	"github.com/fidellr/jastip/backend/uranus/auth"
 	"github.com/labstack/echo"
 )
 
+const (
+	headerETag    = "ETag"
+	headerIfMatch = "If-Match"
+)
+
 type imageHandler struct {
 	service plateu.ImageUsecase
 }
@@ -33,6 +41,7 @@ func NewImageHandler(e *echo.Echo, reqs ...imageRequirements) {
 	e.GET("/images", handler.FetchImages)
 	e.GET("/image/:id", handler.GetImageByID)
 	e.PUT("/image/:id", handler.UpdateImageByID)
+	e.PATCH("/image/:id", handler.PatchImageByID)
 	e.DELETE("/image/:id", handler.RemoveImageByID)
 }
 
@@ -61,6 +70,11 @@ func (h *imageHandler) StoreImage(c echo.Context) (err error) {
 		image.FileLink = fmt.Sprintf("%s", strings.Join(strings.Split(strings.ToLower(image.PersonName), " "), "_"))
 	}
 
+	// The caller's role is checked before the file is written, a denied upload never reaches the disk.
+	if err = auth.Authorize(ctx, auth.ActionUploadImage); err != nil {
+		return err
+	}
+
 	done := make(chan bool)
 	log.Println("Upload starting...")
 	go func() {
@@ -109,12 +123,17 @@ func (h *imageHandler) FetchImages(c echo.Context) error {
 		RoleName: c.QueryParam("role"),
 	}
 
-	images, nextCursor, err := h.service.FetchImages(ctx, filter)
+	images, page, err := h.service.FetchImages(ctx, filter)
 	if err != nil {
+		if err == uranus.ErrInvalidCursor {
+			return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+		}
+
 		return plateu.ConstraintErrorf("%s", err.Error())
 	}
 
-	c.Response().Header().Set("X-Cursor", nextCursor)
+	c.Response().Header().Set("X-Cursor", page.Next)
+	c.Response().Header().Set("X-Prev-Cursor", page.Prev)
 	return c.JSON(http.StatusOK, images)
 }
 
@@ -156,6 +175,7 @@ func (h *imageHandler) GetImageByID(c echo.Context) (err error) {
 		}
 	}()
 
+	c.Response().Header().Set(headerETag, uranus.ETag(img.Version))
 	if err = c.File(newFileName); err != nil {
 		return plateu.ConstraintErrorf("Failed to send file : %s", err.Error())
 	}
@@ -179,14 +199,46 @@ func (h *imageHandler) UpdateImageByID(c echo.Context) (err error) {
 		return plateu.ConstraintErrorf("Failed to bind image model : %s", err.Error())
 	}
 
+	m.Version, err = uranus.IfMatchVersion(c.Request().Header.Get(headerIfMatch))
+	if err != nil {
+		return err
+	}
+
 	err = h.service.UpdateImageByID(ctx, imgID, m)
 	if err != nil {
-		return plateu.ConstraintErrorf("Failed to update image by id %s : %s", imgID, err.Error())
+		return err
 	}
 
+	c.Response().Header().Set(headerETag, uranus.ETag(m.Version))
 	return c.JSON(http.StatusOK, true)
 }
 
+func (h *imageHandler) PatchImageByID(c echo.Context) (err error) {
+	ctx := c.Request().Context()
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	imgID := c.Param("id")
+	version, err := uranus.IfMatchVersion(c.Request().Header.Get(headerIfMatch))
+	if err != nil {
+		return err
+	}
+
+	patch, err := ioutil.ReadAll(c.Request().Body)
+	if err != nil {
+		return plateu.ConstraintErrorf("Failed to read merge patch : %s", err.Error())
+	}
+
+	img, err := h.service.PatchImageByID(ctx, imgID, version, patch)
+	if err != nil {
+		return err
+	}
+
+	c.Response().Header().Set(headerETag, uranus.ETag(img.Version))
+	return c.JSON(http.StatusOK, img)
+}
+
 func (h *imageHandler) RemoveImageByID(c echo.Context) (err error) {
 	ctx := c.Request().Context()
 	if ctx == nil {
@@ -199,7 +251,7 @@ func (h *imageHandler) RemoveImageByID(c echo.Context) (err error) {
 	}
 
 	if err = h.service.RemoveImageByID(ctx, imgID); err != nil {
-		return plateu.ConstraintErrorf("Failed to remove image by id %s : %s", imgID, err.Error())
+		return err
 	}
 
 	return c.NoContent(http.StatusOK)
@@ -8,6 +8,7 @@ import (
 
 	"github.com/fidellr/jastip/backend/plateu/models"
 	"github.com/fidellr/jastip/backend/plateu/repository"
+	"github.com/fidellr/jastip/backend/uranus"
 	"github.com/globalsign/mgo"
 	"github.com/globalsign/mgo/bson"
 )
@@ -57,38 +58,59 @@ func (r *imageMongoRepository) StoreImage(ctx context.Context, m *models.Image)
 	return nil
 }
 
-func (r *imageMongoRepository) FetchImages(ctx context.Context, filter *plateu.Filter) ([]*models.Image, string, error) {
+func (r *imageMongoRepository) FetchImages(ctx context.Context, filter *plateu.Filter) ([]*models.Image, uranus.Page, error) {
 	session := r.Session.Clone()
 	defer session.Close()
 
 	query := make(bson.M)
+	var cursor *uranus.Cursor
 	if filter.Cursor != "" {
-		createdAt, err := plateu.DecodeTime(filter.Cursor)
-		if err == nil {
-			query["cursor"] = bson.M{"$lt": createdAt}
-		} else {
-			log.Printf("Failed to pass cursor %s : %s \n", filter.Cursor, err.Error())
+		var err error
+		cursor, err = uranus.DecodeCursor(filter.Cursor, plateu.ImageSort)
+		if err != nil {
+			return nil, uranus.Page{}, err
 		}
+
+		query["$and"] = []bson.M{cursor.Range()}
 	}
 
 	if filter.RoleName != "" {
 		query["role"] = bson.M{"role_name": filter.RoleName}
 	}
 
+	num := uranus.PageSize(filter.Num)
+	// One extra item tells whether there is another page.
 	var m []*models.Image
-	err := session.DB(r.DBName).C(imageCollectionName).Find(query).All(&m)
+	err := session.DB(r.DBName).C(imageCollectionName).Find(query).Limit(num + 1).Sort(uranus.SortOrder(plateu.ImageSort, cursor)...).All(&m)
 	if err != nil {
 		log.Printf("Failed to fetch screen content : %s \n", err.Error())
-		return nil, "", err
+		return nil, uranus.Page{}, err
+	}
+
+	if len(m) == 0 && cursor != nil && cursor.Backward {
+		// Nothing is left before the cursor, e.g. the items there got deleted, so the client goes on from the first page.
+		restart := *filter
+		restart.Cursor = ""
+		return r.FetchImages(ctx, &restart)
 	}
 
 	if len(m) == 0 {
-		return make([]*models.Image, 0), "", err
+		return make([]*models.Image, 0), uranus.Page{}, err
+	}
+
+	hasMore := len(m) > num
+	if hasMore {
+		m = m[:num]
 	}
 
-	lastImages := m[len(m)-1]
-	nextCursor := plateu.EncodeTime(lastImages.CreatedAt)
-	return m, nextCursor, nil
+	if cursor != nil && cursor.Backward {
+		for i, j := 0, len(m)-1; i < j; i, j = i+1, j-1 {
+			m[i], m[j] = m[j], m[i]
+		}
+	}
+
+	first, last := m[0], m[len(m)-1]
+	return m, uranus.NewPage(cursor, plateu.ImageSort, hasMore, first.CreatedAt, first.ID, last.CreatedAt, last.ID), nil
 }
 
 func (r *imageMongoRepository) GetImageByID(ctx context.Context, imageID string) (*models.Image, error) {
@@ -105,13 +127,17 @@ func (r *imageMongoRepository) GetImageByID(ctx context.Context, imageID string)
 	return m, nil
 }
 
-func (r *imageMongoRepository) UpdateImageByID(ctx context.Context, imageID string, image *models.Image) (err error) {
+func (r *imageMongoRepository) UpdateImageByID(ctx context.Context, imageID string, version int, image *models.Image) (err error) {
 	session := r.Session.Clone()
 	defer session.Close()
 
 	imageIDb := bson.ObjectIdHex(imageID)
-	err = session.DB(r.DBName).C(imageCollectionName).Update(bson.M{"_id": imageIDb}, image)
+	err = session.DB(r.DBName).C(imageCollectionName).Update(bson.M{"_id": imageIDb, "version": uranus.MatchVersion(version)}, image)
 	if err != nil {
+		if err == mgo.ErrNotFound {
+			return uranus.ErrVersionConflict
+		}
+
 		log.Printf("Failed to update image : %s", err.Error())
 		return err
 	}
@@ -10,6 +10,7 @@ import (
 
 type Image struct {
 	ID         bson.ObjectId    `json:"id,omitempty" bson:"_id,omitempty"`
+	Version    int              `json:"version" bson:"version"`
 	PersonName string           `json:"person_name" bson:"person_name"`
 	CreatedAt  time.Time        `json:"created_at,omitempty" bson:"created_at,omitempty"`
 	Needs      string           `json:"needs" bson:"needs" validate:"required"`
@@ -6,6 +6,7 @@ import (
 	"time"
 
 	"github.com/fidellr/jastip/backend/plateu/models"
+	"github.com/fidellr/jastip/backend/uranus"
 	"github.com/globalsign/mgo/bson"
 )
 
@@ -15,12 +16,16 @@ var (
 
 type ImageUsecase interface {
 	StoreImage(ctx context.Context, m *models.Image) error
-	FetchImages(ctx context.Context, filter *Filter) ([]*models.Image, string, error)
+	FetchImages(ctx context.Context, filter *Filter) ([]*models.Image, uranus.Page, error)
 	GetImageByID(ctx context.Context, imageID string) (*models.Image, error)
 	UpdateImageByID(ctx context.Context, imageID string, m *models.Image) error
+	PatchImageByID(ctx context.Context, imageID string, version int, patch []byte) (*models.Image, error)
 	RemoveImageByID(ctx context.Context, imageID string) error
 }
 
+// ImageSort lists the newest images first.
+const ImageSort = "-created_at"
+
 type Filter struct {
 	Num      int
 	Cursor   string
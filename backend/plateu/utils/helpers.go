@@ -11,6 +11,7 @@ import (
 	"strings"
 
 	"github.com/fidellr/jastip/backend/plateu/models"
+	"github.com/fidellr/jastip/backend/uranus"
 
 	"github.com/labstack/echo"
 	"github.com/sirupsen/logrus"
@@ -22,6 +23,25 @@ type ErrorHTTPResponse struct {
 
 func HandleUncaughtHTTPError(err error, c echo.Context) {
 	logrus.Error(err)
+	if he, ok := err.(*echo.HTTPError); ok {
+		c.JSON(he.Code, ErrorHTTPResponse{Message: fmt.Sprintf("%v", he.Message)})
+		return
+	}
+
+	if fe, ok := err.(*uranus.ForbiddenError); ok {
+		c.JSON(http.StatusForbidden, ErrorHTTPResponse{Message: fe.Error()})
+		return
+	}
+
+	switch err {
+	case uranus.ErrVersionConflict:
+		c.JSON(http.StatusPreconditionFailed, ErrorHTTPResponse{Message: err.Error()})
+		return
+	case uranus.ErrMissingIfMatch:
+		c.JSON(http.StatusPreconditionRequired, ErrorHTTPResponse{Message: err.Error()})
+		return
+	}
+
 	c.JSON(http.StatusInternalServerError, ErrorHTTPResponse{Message: err.Error()})
 }
 
@@ -17,6 +17,7 @@ import (
 	delivery "github.com/fidellr/jastip/backend/rover/internal/delivery"
 	_httpDelivery "github.com/fidellr/jastip/backend/rover/internal/delivery/http"
 	_mongoRepository "github.com/fidellr/jastip/backend/rover/internal/delivery/mongo"
+	"github.com/fidellr/jastip/backend/uranus/auth"
 )
 
 var roverServerCMD = &cobra.Command{
@@ -106,5 +107,36 @@ func initRoverApplication(e *echo.Echo) {
 		content.Validator(validator),
 	)
 	e.HTTPErrorHandler = delivery.HandleUncaughtHTTPError
+	initAuthMiddleware(e)
 	_httpDelivery.NewContentHandler(e, _httpDelivery.ContentService(roverService))
 }
+
+func initAuthMiddleware(e *echo.Echo) {
+	protectedGroups := viper.GetStringSlice("auth.protected_groups")
+	if len(protectedGroups) == 0 {
+		logrus.Warn("Rover is running without authentication, no auth.protected_groups configured")
+		return
+	}
+
+	authSecret := viper.GetString("auth.secret")
+	if authSecret == "" {
+		logrus.Fatalln(errors.New("Please provide the uranus auth secret to verify access tokens"))
+	}
+
+	// Rover doesn't ask uranus whether a token was revoked, it only takes tokens that were issued recently.
+	maxTokenAge := time.Duration(viper.GetInt("auth.max_token_age")) * time.Second
+	if maxTokenAge <= 0 {
+		logrus.Fatalln(errors.New("Please provide auth.max_token_age to bound how long a revoked token keeps working"))
+	}
+
+	tokenManager := auth.NewJWT(
+		auth.Secret(authSecret),
+		auth.Issuer(viper.GetString("auth.issuer")),
+	)
+	e.Use(auth.Middleware(
+		auth.TokenVerifier(tokenManager),
+		auth.MaxTokenAge(maxTokenAge),
+		auth.ProtectedGroups(protectedGroups...),
+		auth.PublicRoutes(viper.GetStringSlice("auth.public_routes")...),
+	))
+}
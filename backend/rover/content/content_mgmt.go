@@ -7,6 +7,8 @@ import (
 	"github.com/fidellr/jastip/backend/rover"
 	"github.com/fidellr/jastip/backend/rover/models"
 	"github.com/fidellr/jastip/backend/rover/repository"
+	"github.com/fidellr/jastip/backend/uranus"
+	"github.com/fidellr/jastip/backend/uranus/auth"
 	"github.com/pkg/errors"
 )
 
@@ -48,6 +50,11 @@ func NewService(reqs ...requirement) rover.ContentUsecase {
 func (s *service) CreateScreenContent(ctx context.Context, m *models.Screen) (err error) {
 	if ctx == nil {
 		err = rover.ErrContextNil
+		return err
+	}
+
+	if err = auth.Authorize(ctx, auth.ActionEditScreen); err != nil {
+		return err
 	}
 
 	ctx, cancel := context.WithTimeout(ctx, s.contextTimeout)
@@ -57,6 +64,7 @@ func (s *service) CreateScreenContent(ctx context.Context, m *models.Screen) (er
 		return err
 	}
 
+	m.Version = 1
 	m.CreatedAt = time.Now()
 	m.UpdateAt = time.Now()
 
@@ -73,10 +81,10 @@ func (s *service) CreateScreenContent(ctx context.Context, m *models.Screen) (er
 	return nil
 }
 
-func (s *service) FetchContent(ctx context.Context, filter *rover.Filter) ([]*models.Screen, string, error) {
+func (s *service) FetchContent(ctx context.Context, filter *rover.Filter) ([]*models.Screen, uranus.Page, error) {
 	if ctx == nil {
 		err := rover.ErrContextNil
-		return nil, "", err
+		return nil, uranus.Page{}, err
 	}
 
 	ctx, cancel := context.WithTimeout(ctx, s.contextTimeout)
@@ -86,12 +94,12 @@ func (s *service) FetchContent(ctx context.Context, filter *rover.Filter) ([]*mo
 		filter.Num = int(2)
 	}
 
-	content, nextCursor, err := s.repository.FetchContent(ctx, filter)
+	content, page, err := s.repository.FetchContent(ctx, filter)
 	if err != nil {
-		return nil, nextCursor, err
+		return nil, page, err
 	}
 
-	return content, nextCursor, nil
+	return content, page, nil
 
 }
 
@@ -118,12 +126,28 @@ func (s *service) UpdateByContentID(ctx context.Context, screenID string, m *mod
 		return err
 	}
 
+	if err = auth.Authorize(ctx, auth.ActionEditScreen); err != nil {
+		return err
+	}
+
 	ctx, cancel := context.WithTimeout(ctx, s.contextTimeout)
 	defer cancel()
 
+	existing, err := s.repository.GetContentByID(ctx, screenID)
+	if err != nil {
+		return err
+	}
+
+	if !uranus.VersionMatches(m.Version, existing.Version) {
+		return uranus.ErrVersionConflict
+	}
+
+	m.ID = existing.ID
+	m.Version = existing.Version + 1
+	m.CreatedAt = existing.CreatedAt
 	m.UpdateAt = time.Now()
 
-	err = s.repository.UpdateByContentID(ctx, screenID, m)
+	err = s.repository.UpdateByContentID(ctx, screenID, existing.Version, m)
 	if err != nil {
 		return err
 	}
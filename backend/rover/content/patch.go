@@ -0,0 +1,71 @@
+package content
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"github.com/fidellr/jastip/backend/rover"
+	"github.com/fidellr/jastip/backend/rover/models"
+	"github.com/fidellr/jastip/backend/uranus"
+	"github.com/fidellr/jastip/backend/uranus/auth"
+)
+
+// mutableScreenFields are the only fields a client may send in a merge patch.
+var mutableScreenFields = []string{
+	"screen_name",
+	"role",
+	"items",
+}
+
+func (s *service) PatchByContentID(ctx context.Context, screenID string, version int, patch []byte) (*models.Screen, error) {
+	if ctx == nil {
+		err := rover.ErrContextNil
+		return nil, err
+	}
+
+	if err := auth.Authorize(ctx, auth.ActionEditScreen); err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, s.contextTimeout)
+	defer cancel()
+
+	existing, err := s.repository.GetContentByID(ctx, screenID)
+	if err != nil {
+		return nil, err
+	}
+
+	if !uranus.VersionMatches(version, existing.Version) {
+		return nil, uranus.ErrVersionConflict
+	}
+
+	target, err := json.Marshal(existing)
+	if err != nil {
+		return nil, err
+	}
+
+	merged, err := uranus.MergePatch(target, patch, mutableScreenFields...)
+	if err != nil {
+		return nil, err
+	}
+
+	m := new(models.Screen)
+	if err = json.Unmarshal(merged, m); err != nil {
+		return nil, rover.ConstraintErrorf("%s", err.Error())
+	}
+
+	m.ID = existing.ID
+	m.Version = existing.Version + 1
+	m.CreatedAt = existing.CreatedAt
+	m.UpdateAt = time.Now()
+	if err = s.validator.ValidateStruct(m); err != nil {
+		return nil, err
+	}
+
+	if err = s.repository.UpdateByContentID(ctx, screenID, existing.Version, m); err != nil {
+		return nil, err
+	}
+
+	return m, nil
+}
@@ -4,6 +4,8 @@ import (
 	"errors"
 	"fmt"
 	"net/http"
+
+	"github.com/fidellr/jastip/backend/uranus"
 )
 
 var (
@@ -39,6 +41,8 @@ func ErrorFromResponseStatusCode(code int, message string) (err error) {
 		err = ConstraintErrorf(message)
 	case http.StatusNotModified:
 		err = ErrNotModified
+	case http.StatusPreconditionFailed:
+		err = uranus.ErrVersionConflict
 	default:
 		err = fmt.Errorf(message)
 	}
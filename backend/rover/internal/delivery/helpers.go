@@ -1,10 +1,13 @@
 package delivery
 
 import (
+	"fmt"
 	"net/http"
 
 	"github.com/labstack/echo"
 	"github.com/sirupsen/logrus"
+
+	"github.com/fidellr/jastip/backend/uranus"
 )
 
 type ErrorHTTPResponse struct {
@@ -13,5 +16,24 @@ type ErrorHTTPResponse struct {
 
 func HandleUncaughtHTTPError(err error, c echo.Context) {
 	logrus.Error(err)
+	if he, ok := err.(*echo.HTTPError); ok {
+		c.JSON(he.Code, ErrorHTTPResponse{Message: fmt.Sprintf("%v", he.Message)})
+		return
+	}
+
+	if fe, ok := err.(*uranus.ForbiddenError); ok {
+		c.JSON(http.StatusForbidden, ErrorHTTPResponse{Message: fe.Error()})
+		return
+	}
+
+	switch err {
+	case uranus.ErrVersionConflict:
+		c.JSON(http.StatusPreconditionFailed, ErrorHTTPResponse{Message: err.Error()})
+		return
+	case uranus.ErrMissingIfMatch:
+		c.JSON(http.StatusPreconditionRequired, ErrorHTTPResponse{Message: err.Error()})
+		return
+	}
+
 	c.JSON(http.StatusInternalServerError, ErrorHTTPResponse{Message: err.Error()})
 }
@@ -2,15 +2,22 @@ package http
 
 import (
 	"context"
+	"io/ioutil"
 	"net/http"
 	"strconv"
 
 	"github.com/fidellr/jastip/backend/rover/models"
 
 	"github.com/fidellr/jastip/backend/rover"
+	"github.com/fidellr/jastip/backend/uranus"
 	"github.com/labstack/echo"
 )
 
+const (
+	headerETag    = "ETag"
+	headerIfMatch = "If-Match"
+)
+
 type contentHandler struct {
 	service rover.ContentUsecase
 }
@@ -33,6 +40,7 @@ func NewContentHandler(e *echo.Echo, reqs ...contentRequirements) {
 	e.GET("/content/:screen_name", handler.GetScreenContent)
 	e.GET("/contents", handler.FetchContent)
 	e.PUT("/content/:content_id", handler.UpdateByContentID)
+	e.PATCH("/content/:content_id", handler.PatchByContentID)
 }
 
 func (h *contentHandler) CreateScreenContent(c echo.Context) (err error) {
@@ -48,7 +56,7 @@ func (h *contentHandler) CreateScreenContent(c echo.Context) (err error) {
 
 	err = h.service.CreateScreenContent(ctx, content)
 	if err != nil {
-		return rover.ConstraintErrorf("%s", err.Error())
+		return err
 	}
 
 	return c.JSON(http.StatusCreated, content)
@@ -74,12 +82,17 @@ func (h *contentHandler) FetchContent(c echo.Context) (err error) {
 		RoleName: c.QueryParam("rolve"),
 	}
 
-	contents, nextCursors, err := h.service.FetchContent(ctx, &filter)
+	contents, page, err := h.service.FetchContent(ctx, &filter)
 	if err != nil {
+		if err == uranus.ErrInvalidCursor {
+			return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+		}
+
 		return rover.ConstraintErrorf("%s", err.Error())
 	}
 
-	c.Response().Header().Set("X-Cursor", nextCursors)
+	c.Response().Header().Set("X-Cursor", page.Next)
+	c.Response().Header().Set("X-Prev-Cursor", page.Prev)
 	return c.JSON(http.StatusOK, contents)
 }
 
@@ -95,6 +108,7 @@ func (h *contentHandler) GetScreenContent(c echo.Context) (err error) {
 		return rover.ConstraintErrorf("%s", err.Error())
 	}
 
+	c.Response().Header().Set(headerETag, uranus.ETag(content.Version))
 	return c.JSON(http.StatusOK, content)
 }
 
@@ -110,10 +124,41 @@ func (h *contentHandler) UpdateByContentID(c echo.Context) (err error) {
 		return rover.ConstraintErrorf("%s", err.Error())
 	}
 
+	content.Version, err = uranus.IfMatchVersion(c.Request().Header.Get(headerIfMatch))
+	if err != nil {
+		return err
+	}
+
 	err = h.service.UpdateByContentID(ctx, contentID, content)
 	if err != nil {
-		return rover.ConstraintErrorf("%s", err.Error())
+		return err
 	}
 
+	c.Response().Header().Set(headerETag, uranus.ETag(content.Version))
 	return c.JSON(http.StatusOK, true)
 }
+
+func (h *contentHandler) PatchByContentID(c echo.Context) (err error) {
+	ctx := c.Request().Context()
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	version, err := uranus.IfMatchVersion(c.Request().Header.Get(headerIfMatch))
+	if err != nil {
+		return err
+	}
+
+	patch, err := ioutil.ReadAll(c.Request().Body)
+	if err != nil {
+		return rover.ConstraintErrorf("%s", err.Error())
+	}
+
+	content, err := h.service.PatchByContentID(ctx, c.Param("content_id"), version, patch)
+	if err != nil {
+		return err
+	}
+
+	c.Response().Header().Set(headerETag, uranus.ETag(content.Version))
+	return c.JSON(http.StatusOK, content)
+}
@@ -8,6 +8,7 @@ import (
 
 	"github.com/fidellr/jastip/backend/rover/models"
 	"github.com/fidellr/jastip/backend/rover/repository"
+	"github.com/fidellr/jastip/backend/uranus"
 	"github.com/globalsign/mgo"
 	"github.com/globalsign/mgo/bson"
 )
@@ -57,38 +58,59 @@ func (u *contentMongoRepository) CreateScreenContent(ctx context.Context, m *mod
 	return nil
 }
 
-func (u *contentMongoRepository) FetchContent(ctx context.Context, filter *rover.Filter) ([]*models.Screen, string, error) {
+func (u *contentMongoRepository) FetchContent(ctx context.Context, filter *rover.Filter) ([]*models.Screen, uranus.Page, error) {
 	session := u.Session.Clone()
 	defer session.Close()
 
 	query := make(bson.M)
+	var cursor *uranus.Cursor
 	if filter.Cursor != "" {
-		createdAt, err := rover.DecodeTime(filter.Cursor)
-		if err == nil {
-			query["cursor"] = bson.M{"$lt": createdAt}
-		} else {
-			log.Printf("Failed to pass cursor %s : %s \n", filter.Cursor, err.Error())
+		var err error
+		cursor, err = uranus.DecodeCursor(filter.Cursor, rover.ContentSort)
+		if err != nil {
+			return nil, uranus.Page{}, err
 		}
+
+		query["$and"] = []bson.M{cursor.Range()}
 	}
 
 	if filter.RoleName != "" {
 		query["role"] = bson.M{"role_name": filter.RoleName}
 	}
 
+	num := uranus.PageSize(filter.Num)
+	// One extra item tells whether there is another page.
 	var m []*models.Screen
-	err := session.DB(u.DBName).C(contentCollectionName).Find(query).Limit(filter.Num).Sort("-created_at").All(&m)
+	err := session.DB(u.DBName).C(contentCollectionName).Find(query).Limit(num + 1).Sort(uranus.SortOrder(rover.ContentSort, cursor)...).All(&m)
 	if err != nil {
 		log.Printf("Failed to fetch screen content : %s", err.Error())
-		return nil, "", err
+		return nil, uranus.Page{}, err
+	}
+
+	if len(m) == 0 && cursor != nil && cursor.Backward {
+		// Nothing is left before the cursor, e.g. the items there got deleted, so the client goes on from the first page.
+		restart := *filter
+		restart.Cursor = ""
+		return u.FetchContent(ctx, &restart)
 	}
 
 	if len(m) == 0 {
-		return make([]*models.Screen, 0), "", err
+		return make([]*models.Screen, 0), uranus.Page{}, err
+	}
+
+	hasMore := len(m) > num
+	if hasMore {
+		m = m[:num]
+	}
+
+	if cursor != nil && cursor.Backward {
+		for i, j := 0, len(m)-1; i < j; i, j = i+1, j-1 {
+			m[i], m[j] = m[j], m[i]
+		}
 	}
 
-	lastContents := m[len(m)-1]
-	nextCursor := rover.EncodeTime(lastContents.CreatedAt)
-	return m, nextCursor, nil
+	first, last := m[0], m[len(m)-1]
+	return m, uranus.NewPage(cursor, rover.ContentSort, hasMore, first.CreatedAt, first.ID, last.CreatedAt, last.ID), nil
 }
 
 func (u *contentMongoRepository) GetContentByScreen(ctx context.Context, screenName string) (*models.Screen, error) {
@@ -105,13 +127,43 @@ func (u *contentMongoRepository) GetContentByScreen(ctx context.Context, screenN
 	return m, nil
 }
 
-func (u *contentMongoRepository) UpdateByContentID(ctxt context.Context, contentID string, m *models.Screen) (err error) {
+func (u *contentMongoRepository) GetContentByID(ctx context.Context, contentID string) (*models.Screen, error) {
 	session := u.Session.Clone()
 	defer session.Close()
 
+	if !bson.IsObjectIdHex(contentID) {
+		return nil, rover.ErrNotFound
+	}
+
+	var m *models.Screen
 	idB := bson.ObjectIdHex(contentID)
-	err = session.DB(u.DBName).C(contentCollectionName).Update(bson.M{"_id": idB}, m)
+	if err := session.DB(u.DBName).C(contentCollectionName).FindId(idB).One(&m); err != nil {
+		if err == mgo.ErrNotFound {
+			return nil, rover.ErrNotFound
+		}
+
+		log.Printf("Failed to get content by id : %s", err.Error())
+		return nil, err
+	}
+
+	return m, nil
+}
+
+func (u *contentMongoRepository) UpdateByContentID(ctxt context.Context, contentID string, version int, m *models.Screen) (err error) {
+	session := u.Session.Clone()
+	defer session.Close()
+
+	if !bson.IsObjectIdHex(contentID) {
+		return rover.ErrNotFound
+	}
+
+	idB := bson.ObjectIdHex(contentID)
+	err = session.DB(u.DBName).C(contentCollectionName).Update(bson.M{"_id": idB, "version": uranus.MatchVersion(version)}, m)
 	if err != nil {
+		if err == mgo.ErrNotFound {
+			return uranus.ErrVersionConflict
+		}
+
 		log.Printf("Failed to update content by screen : %s", err.Error())
 		return err
 	}
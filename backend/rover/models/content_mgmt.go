@@ -11,6 +11,7 @@ type Screen struct {
 	CreatedAt  time.Time       `json:"created_at,omitempty" bson:"created_at,omitempty"`
 	UpdateAt   time.Time       `json:"updated_at,omitempty" bson:"updated_at,omitempty"`
 	ID         bson.ObjectId   `json:"id,omitempty" bson:"_id,omitempty"`
+	Version    int             `json:"version" bson:"version"`
 	ScreenName string          `json:"screen_name" bson:"screen_name" validate:"required"`
 	Role       models.UserRole `json:"role" bson:"role"`
 	Items      []Item          `json:"items" bson:"items"`
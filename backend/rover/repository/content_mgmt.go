@@ -5,11 +5,13 @@ import (
 
 	"github.com/fidellr/jastip/backend/rover"
 	"github.com/fidellr/jastip/backend/rover/models"
+	"github.com/fidellr/jastip/backend/uranus"
 )
 
 type ContentRepository interface {
 	CreateScreenContent(ctx context.Context, m *models.Screen) error
-	FetchContent(ctx context.Context, filter *rover.Filter) ([]*models.Screen, string, error)
-	UpdateByContentID(ctx context.Context, shopID string, m *models.Screen) error
+	FetchContent(ctx context.Context, filter *rover.Filter) ([]*models.Screen, uranus.Page, error)
+	UpdateByContentID(ctx context.Context, shopID string, version int, m *models.Screen) error
 	GetContentByScreen(ctx context.Context, screenName string) (*models.Screen, error)
+	GetContentByID(ctx context.Context, contentID string) (*models.Screen, error)
 }
@@ -6,6 +6,7 @@ import (
 	"time"
 
 	"github.com/fidellr/jastip/backend/rover/models"
+	"github.com/fidellr/jastip/backend/uranus"
 	"github.com/globalsign/mgo/bson"
 )
 
@@ -15,11 +16,15 @@ var (
 
 type ContentUsecase interface {
 	CreateScreenContent(ctx context.Context, m *models.Screen) error
-	FetchContent(ctx context.Context, filter *Filter) ([]*models.Screen, string, error)
+	FetchContent(ctx context.Context, filter *Filter) ([]*models.Screen, uranus.Page, error)
 	UpdateByContentID(ctx context.Context, screenID string, m *models.Screen) error
+	PatchByContentID(ctx context.Context, screenID string, version int, patch []byte) (*models.Screen, error)
 	GetContentByScreen(ctx context.Context, screenName string) (*models.Screen, error)
 }
 
+// ContentSort lists the newest screens first.
+const ContentSort = "-created_at"
+
 type Filter struct {
 	Num      int
 	Cursor   string
@@ -0,0 +1,191 @@
+package auth
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/labstack/echo"
+
+	"github.com/fidellr/jastip/backend/uranus"
+	"github.com/fidellr/jastip/backend/uranus/models"
+)
+
+type principalKey struct{}
+
+type guard struct {
+	tokenManager    uranus.TokenManager
+	revocations     uranus.TokenRevocations
+	maxAge          time.Duration
+	groups          []string
+	publicRoutes    map[string]bool
+	webSocketRoutes map[string]bool
+}
+
+type middlewareRequirement func(*guard)
+
+// TokenVerifier sets the TokenManager used to verify incoming access tokens.
+func TokenVerifier(tokenManager uranus.TokenManager) middlewareRequirement {
+	return func(g *guard) {
+		g.tokenManager = tokenManager
+	}
+}
+
+// RevocationChecker sets what tells revoked tokens apart, like those issued before a password reset.
+// Without one a token stays valid until it expires, or until MaxTokenAge runs out.
+func RevocationChecker(revocations uranus.TokenRevocations) middlewareRequirement {
+	return func(g *guard) {
+		g.revocations = revocations
+	}
+}
+
+// MaxTokenAge rejects tokens issued longer than age ago, even when they didn't expire yet.
+// Rover and plateu can't ask uranus about revocations, it bounds how long a revoked token keeps working there.
+func MaxTokenAge(age time.Duration) middlewareRequirement {
+	return func(g *guard) {
+		g.maxAge = age
+	}
+}
+
+// ProtectedGroups sets the route prefixes that require an access token, e.g. "/user" or "/image".
+func ProtectedGroups(groups ...string) middlewareRequirement {
+	return func(g *guard) {
+		g.groups = append(g.groups, groups...)
+	}
+}
+
+// PublicRoutes sets routes, written as "METHOD /path/:param", that stay open inside a protected group.
+func PublicRoutes(routes ...string) middlewareRequirement {
+	return func(g *guard) {
+		for _, route := range routes {
+			parts := strings.Fields(route)
+			if len(parts) != 2 {
+				continue
+			}
+
+			g.publicRoutes[routeKey(parts[0], parts[1])] = true
+		}
+	}
+}
+
+// WebSocketRoutes sets routes, written as "METHOD /path/:param", whose WebSocket handshake may carry the token
+// in the access_token query parameter. Anywhere else a token in the query would end up in access logs.
+func WebSocketRoutes(routes ...string) middlewareRequirement {
+	return func(g *guard) {
+		for _, route := range routes {
+			parts := strings.Fields(route)
+			if len(parts) != 2 {
+				continue
+			}
+
+			g.webSocketRoutes[routeKey(parts[0], parts[1])] = true
+		}
+	}
+}
+
+// Middleware verifies the bearer token of every request that hits a protected group
+// and puts the caller's Principal into the request context. Requests outside the
+// protected groups may still send a token, e.g. an admin creating another admin.
+func Middleware(reqs ...middlewareRequirement) echo.MiddlewareFunc {
+	g := &guard{publicRoutes: make(map[string]bool), webSocketRoutes: make(map[string]bool)}
+	for _, req := range reqs {
+		req(g)
+	}
+
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			token := bearerToken(c.Request().Header.Get(echo.HeaderAuthorization))
+			if token == "" && g.isWebSocket(c) {
+				// Browsers can't set headers on a WebSocket handshake, the token comes in the query instead.
+				token = c.QueryParam("access_token")
+			}
+
+			if token == "" {
+				if g.isProtected(c) {
+					return echo.NewHTTPError(http.StatusUnauthorized, "Missing access token")
+				}
+
+				return next(c)
+			}
+
+			principal, err := g.tokenManager.Verify(token)
+			if err == nil && g.maxAge > 0 && time.Since(principal.IssuedAt) > g.maxAge {
+				err = uranus.ErrInvalidToken
+			}
+
+			if err == nil && g.revocations != nil {
+				var revoked bool
+				revoked, err = g.revocations.IsTokenRevoked(c.Request().Context(), principal)
+				if err != nil {
+					return err
+				}
+
+				if revoked {
+					err = uranus.ErrInvalidToken
+				}
+			}
+
+			if err != nil {
+				if g.isProtected(c) {
+					return echo.NewHTTPError(http.StatusUnauthorized, err.Error())
+				}
+
+				// A stale token doesn't lock anyone out of a public route, the request goes on anonymously.
+				return next(c)
+			}
+
+			ctx := NewContext(c.Request().Context(), principal)
+			c.SetRequest(c.Request().WithContext(ctx))
+			return next(c)
+		}
+	}
+}
+
+func (g *guard) isProtected(c echo.Context) bool {
+	path := c.Path()
+	if g.publicRoutes[routeKey(c.Request().Method, path)] {
+		return false
+	}
+
+	for _, group := range g.groups {
+		if path == group || strings.HasPrefix(path, strings.TrimSuffix(group, "/")+"/") {
+			return true
+		}
+	}
+
+	return false
+}
+
+// isWebSocket reports whether the request is the handshake of one of the WebSocket routes.
+func (g *guard) isWebSocket(c echo.Context) bool {
+	if !g.webSocketRoutes[routeKey(c.Request().Method, c.Path())] {
+		return false
+	}
+
+	return strings.EqualFold(c.Request().Header.Get(echo.HeaderUpgrade), "websocket")
+}
+
+func routeKey(method, path string) string {
+	return strings.ToUpper(method) + " " + path
+}
+
+func bearerToken(header string) string {
+	parts := strings.SplitN(header, " ", 2)
+	if len(parts) != 2 || !strings.EqualFold(parts[0], tokenType) {
+		return ""
+	}
+
+	return strings.TrimSpace(parts[1])
+}
+
+// NewContext returns a copy of ctx carrying the authenticated caller.
+func NewContext(ctx context.Context, principal *models.Principal) context.Context {
+	return context.WithValue(ctx, principalKey{}, principal)
+}
+
+// FromContext returns the authenticated caller stored by Middleware, if any.
+func FromContext(ctx context.Context) (*models.Principal, bool) {
+	principal, ok := ctx.Value(principalKey{}).(*models.Principal)
+	return principal, ok
+}
@@ -0,0 +1,60 @@
+package auth
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/labstack/echo"
+
+	"github.com/fidellr/jastip/backend/uranus"
+	"github.com/fidellr/jastip/backend/uranus/models"
+)
+
+// issuedTokens verifies every token as issued by the same user at issuedAt.
+type issuedTokens struct {
+	uranus.TokenManager
+	issuedAt time.Time
+}
+
+func (m issuedTokens) Verify(token string) (*models.Principal, error) {
+	return &models.Principal{UserID: "5b7c1f0e9d1a2b3c4d5e6f70", IssuedAt: m.issuedAt}, nil
+}
+
+func TestMaxTokenAge(t *testing.T) {
+	tests := []struct {
+		name   string
+		age    time.Duration
+		path   string
+		status int
+	}{
+		{name: "fresh token", age: time.Minute, path: "/image", status: http.StatusOK},
+		{name: "old token on a protected route", age: time.Hour, path: "/image", status: http.StatusUnauthorized},
+		{name: "old token on a public route goes on anonymously", age: time.Hour, path: "/ping", status: http.StatusOK},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			e := echo.New()
+			e.Use(Middleware(
+				TokenVerifier(issuedTokens{issuedAt: time.Now().Add(-test.age)}),
+				MaxTokenAge(15*time.Minute),
+				ProtectedGroups("/image"),
+			))
+
+			handler := func(c echo.Context) error { return c.NoContent(http.StatusOK) }
+			e.GET("/image", handler)
+			e.GET("/ping", handler)
+
+			req := httptest.NewRequest(http.MethodGet, test.path, nil)
+			req.Header.Set(echo.HeaderAuthorization, "Bearer token")
+			rec := httptest.NewRecorder()
+			e.ServeHTTP(rec, req)
+
+			if rec.Code != test.status {
+				t.Errorf("GET %s with a token issued %s ago = %d, want %d", test.path, test.age, rec.Code, test.status)
+			}
+		})
+	}
+}
@@ -0,0 +1,157 @@
+package auth
+
+import (
+	"context"
+
+	"github.com/fidellr/jastip/backend/uranus"
+	"github.com/fidellr/jastip/backend/uranus/models"
+)
+
+// Action is a protected operation a role may be allowed to perform.
+type Action string
+
+const (
+	ActionFetchAccounts  Action = "fetch accounts"
+	ActionReadAccount    Action = "read account"
+	ActionUpdateAccount  Action = "update account"
+	ActionAssignRole     Action = "assign roles"
+	ActionSuspendAccount Action = "suspend accounts"
+	ActionRemoveAccount  Action = "remove accounts"
+
+	ActionEditScreen Action = "edit screens"
+
+	ActionPlanTrip    Action = "plan trips"
+	ActionManageTrips Action = "manage trips of other travelers"
+	ActionMakeOffer   Action = "make offers"
+
+	ActionRequestPurchase        Action = "request purchases"
+	ActionManagePurchaseRequests Action = "manage purchase requests of other buyers"
+
+	ActionManageOrders        Action = "manage orders of other buyers and travelers"
+	ActionManagePayments      Action = "manage payments and the escrow ledger"
+	ActionManageExchangeRates Action = "upload exchange rates"
+	ActionManageFees          Action = "manage fee schedules"
+	ActionModerateChats       Action = "read conversations of other users"
+	ActionMediateDisputes     Action = "mediate and resolve disputes"
+
+	// ActionEditReview is granted to no role, a review only ever says what its reviewer wrote.
+	ActionEditReview Action = "edit reviews of other users"
+
+	ActionUploadImage Action = "upload images"
+	ActionEditImage   Action = "edit images"
+	ActionDeleteImage Action = "delete images"
+)
+
+var policies = map[string][]Action{
+	models.RoleBuyer: {
+		ActionUploadImage,
+		ActionRequestPurchase,
+	},
+	models.RoleTraveler: {
+		ActionUploadImage,
+		ActionPlanTrip,
+		ActionMakeOffer,
+	},
+	models.RoleContentEditor: {
+		ActionEditScreen,
+		ActionUploadImage,
+		ActionEditImage,
+		ActionDeleteImage,
+	},
+	models.RoleAdmin: {
+		ActionFetchAccounts,
+		ActionReadAccount,
+		ActionUpdateAccount,
+		ActionAssignRole,
+		ActionSuspendAccount,
+		ActionRemoveAccount,
+		ActionPlanTrip,
+		ActionManageTrips,
+		ActionMakeOffer,
+		ActionRequestPurchase,
+		ActionManagePurchaseRequests,
+		ActionManageOrders,
+		ActionManagePayments,
+		ActionManageExchangeRates,
+		ActionManageFees,
+		ActionModerateChats,
+		ActionMediateDisputes,
+		ActionEditScreen,
+		ActionUploadImage,
+		ActionEditImage,
+		ActionDeleteImage,
+	},
+}
+
+// Can reports whether the role is allowed to perform the action.
+func Can(roleName string, action Action) bool {
+	for _, allowed := range policies[roleName] {
+		if allowed == action {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Authorize checks the caller stored in ctx against the policies
+// and returns a *uranus.ForbiddenError when the action is not allowed.
+func Authorize(ctx context.Context, action Action) error {
+	principal, ok := FromContext(ctx)
+	if !ok {
+		return &uranus.ForbiddenError{Action: string(action)}
+	}
+
+	if !Can(principal.Role.RoleName, action) {
+		return &uranus.ForbiddenError{RoleName: principal.Role.RoleName, Action: string(action)}
+	}
+
+	return nil
+}
+
+// AuthorizeOwner is Authorize that also lets the caller act on their own account.
+func AuthorizeOwner(ctx context.Context, action Action, ownerID string) error {
+	if principal, ok := FromContext(ctx); ok && principal.UserID == ownerID {
+		return nil
+	}
+
+	return Authorize(ctx, action)
+}
+
+// AuthorizeParty lets the caller act as the party partyOf says they are, e.g. the buyer of an order.
+// Callers who are no party need the action and act as an admin.
+func AuthorizeParty(ctx context.Context, action Action, partyOf func(userID string) string) (*models.Principal, string, error) {
+	principal, ok := FromContext(ctx)
+	if !ok {
+		return nil, "", &uranus.ForbiddenError{Action: string(action)}
+	}
+
+	if party := partyOf(principal.UserID); party != "" {
+		return principal, party, nil
+	}
+
+	if err := Authorize(ctx, action); err != nil {
+		return nil, "", err
+	}
+
+	return principal, models.OrderPartyAdmin, nil
+}
+
+// PartyScope gives the user a listing is limited to, callers allowed the action see everyone's and get none.
+func PartyScope(ctx context.Context, action Action) (string, error) {
+	principal, ok := FromContext(ctx)
+	if !ok {
+		return "", &uranus.ForbiddenError{Action: string(action)}
+	}
+
+	if Can(principal.Role.RoleName, action) {
+		return "", nil
+	}
+
+	return principal.UserID, nil
+}
+
+// IsSelfServiceRole reports whether anyone may sign up with the role without an admin assigning it.
+func IsSelfServiceRole(roleName string) bool {
+	return roleName == models.RoleBuyer || roleName == models.RoleTraveler
+}
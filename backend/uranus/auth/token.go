@@ -0,0 +1,112 @@
+package auth
+
+import (
+	"fmt"
+	"time"
+
+	jwt "github.com/dgrijalva/jwt-go"
+
+	"github.com/fidellr/jastip/backend/uranus"
+	"github.com/fidellr/jastip/backend/uranus/models"
+)
+
+const tokenType = "Bearer"
+
+// Claims is the payload of every access token issued by uranus.
+type Claims struct {
+	RoleName string `json:"role_name"`
+	jwt.StandardClaims
+}
+
+type jwtManager struct {
+	secret []byte
+	issuer string
+	ttl    time.Duration
+}
+
+type requirement func(*jwtManager)
+
+func Secret(secret string) requirement {
+	return func(j *jwtManager) {
+		j.secret = []byte(secret)
+	}
+}
+
+func Issuer(issuer string) requirement {
+	return func(j *jwtManager) {
+		if issuer != "" {
+			j.issuer = issuer
+		}
+	}
+}
+
+func TTL(ttl time.Duration) requirement {
+	return func(j *jwtManager) {
+		if ttl > 0 {
+			j.ttl = ttl
+		}
+	}
+}
+
+// NewJWT returns a TokenManager that signs HS256 tokens with the shared secret,
+// so every service holding the same secret can verify tokens issued by uranus.
+func NewJWT(reqs ...requirement) uranus.TokenManager {
+	j := &jwtManager{
+		issuer: "uranus",
+		ttl:    time.Hour,
+	}
+	for _, req := range reqs {
+		req(j)
+	}
+
+	return j
+}
+
+func (j *jwtManager) Sign(m *models.UserAccount) (*models.AccessToken, error) {
+	now := time.Now()
+	expiresAt := now.Add(j.ttl)
+	claims := Claims{
+		RoleName: m.Role.RoleName,
+		StandardClaims: jwt.StandardClaims{
+			Subject:   m.ID.Hex(),
+			Issuer:    j.issuer,
+			IssuedAt:  now.Unix(),
+			ExpiresAt: expiresAt.Unix(),
+		},
+	}
+
+	signed, err := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString(j.secret)
+	if err != nil {
+		return nil, err
+	}
+
+	return &models.AccessToken{
+		Token:     signed,
+		TokenType: tokenType,
+		ExpiresAt: expiresAt,
+	}, nil
+}
+
+func (j *jwtManager) Verify(token string) (*models.Principal, error) {
+	claims := new(Claims)
+	parsed, err := jwt.ParseWithClaims(token, claims, func(t *jwt.Token) (interface{}, error) {
+		if _, ok := t.Method.(*jwt.SigningMethodHMAC); !ok {
+			return nil, fmt.Errorf("unexpected signing method %v", t.Header["alg"])
+		}
+
+		return j.secret, nil
+	})
+	if err != nil || !parsed.Valid {
+		return nil, uranus.ErrInvalidToken
+	}
+
+	if claims.Issuer != j.issuer || claims.Subject == "" {
+		return nil, uranus.ErrInvalidToken
+	}
+
+	return &models.Principal{
+		UserID:   claims.Subject,
+		Role:     models.UserRole{RoleName: claims.RoleName},
+		IssuedAt: time.Unix(claims.IssuedAt, 0),
+	}, nil
+}
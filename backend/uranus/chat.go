@@ -0,0 +1,50 @@
+package uranus
+
+import (
+	"context"
+
+	"github.com/fidellr/jastip/backend/uranus/models"
+)
+
+type ChatUsecase interface {
+	OpenConversation(ctx context.Context, m *models.ConversationOpen) (*models.Conversation, error)
+	FetchConversations(ctx context.Context, filter *ConversationFilter) ([]*models.Conversation, Page, error)
+	GetConversationByID(ctx context.Context, conversationID string) (*models.Conversation, error)
+	SendMessage(ctx context.Context, conversationID string, m *models.ChatMessage) error
+	FetchMessages(ctx context.Context, filter *ChatMessageFilter) ([]*models.ChatMessage, Page, error)
+	MarkRead(ctx context.Context, conversationID string, messageID string) error
+	Typing(ctx context.Context, conversationID string) error
+	Listen(ctx context.Context) (<-chan *models.ChatEvent, error)
+}
+
+// ChatHub delivers chat events live to the users they are for, wherever those users are connected.
+// Subscribe's channel gets the user's events until ctx is done, then it's closed.
+// The in-memory hub serves a single node, a broker-backed one can fan out across nodes behind the same interface.
+type ChatHub interface {
+	Publish(ctx context.Context, event *models.ChatEvent, userIDs ...string) error
+	Subscribe(ctx context.Context, userID string) (<-chan *models.ChatEvent, error)
+}
+
+// ConversationSort lists the conversations with the latest activity first.
+const ConversationSort = "-updated_at"
+
+// ConversationFilter narrows conversations down to a participant or a request or order.
+type ConversationFilter struct {
+	Num    int
+	Cursor string
+
+	ParticipantID string
+	Scope         string
+	ScopeID       string
+}
+
+// ChatMessageSort lists the newest messages first, the next page goes further back in the conversation.
+const ChatMessageSort = "-created_at"
+
+// ChatMessageFilter pages through the messages of one conversation.
+type ChatMessageFilter struct {
+	Num    int
+	Cursor string
+
+	ConversationID string
+}
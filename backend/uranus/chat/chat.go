@@ -0,0 +1,392 @@
+package chat
+
+import (
+	"context"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/globalsign/mgo/bson"
+
+	"github.com/fidellr/jastip/backend/uranus"
+	"github.com/fidellr/jastip/backend/uranus/auth"
+	"github.com/fidellr/jastip/backend/uranus/models"
+	"github.com/fidellr/jastip/backend/uranus/repository"
+)
+
+type service struct {
+	repository                repository.ChatRepository
+	purchaseRequestRepository repository.PurchaseRequestRepository
+	orderRepository           repository.OrderRepository
+	userRepository            repository.UserAccountRepository
+	hub                       uranus.ChatHub
+	validator                 uranus.Validate
+	contextTimeout            time.Duration
+}
+
+// OpenConversation returns the conversation about the request or order, starting it the first time.
+// About a request the buyer talks with the traveler they name, a traveler opens their own conversation with the buyer.
+func (s *service) OpenConversation(ctx context.Context, m *models.ConversationOpen) (*models.Conversation, error) {
+	if ctx == nil {
+		err := uranus.ErrContextNil
+		return nil, err
+	}
+
+	if err := s.validator.ValidateStruct(m); err != nil {
+		return nil, err
+	}
+
+	principal, ok := auth.FromContext(ctx)
+	if !ok || !bson.IsObjectIdHex(principal.UserID) {
+		return nil, &uranus.ForbiddenError{Action: string(auth.ActionModerateChats)}
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, s.contextTimeout)
+	defer cancel()
+
+	now := time.Now()
+	conversation := &models.Conversation{
+		ID:        bson.NewObjectId(),
+		CreatedAt: now,
+		UpdatedAt: now,
+		Scope:     m.Scope,
+		ScopeID:   m.ScopeID,
+	}
+
+	switch m.Scope {
+	case models.ConversationRequest:
+		request, err := s.purchaseRequestRepository.GetPurchaseRequestByID(ctx, m.ScopeID.Hex())
+		if err != nil {
+			return nil, err
+		}
+
+		if request.Status != models.PurchaseRequestOpen && request.Status != models.PurchaseRequestMatched {
+			return nil, uranus.ConstraintErrorf("Purchase request is %s, there is nothing to talk about anymore", request.Status)
+		}
+
+		conversation.BuyerID = request.BuyerID
+		if principal.UserID == request.BuyerID.Hex() {
+			if m.TravelerID == "" {
+				return nil, uranus.ConstraintErrorf("Name the traveler to talk with about the request")
+			}
+
+			traveler, err := s.userRepository.GetUserByID(ctx, m.TravelerID.Hex())
+			if err != nil {
+				return nil, err
+			}
+
+			if traveler.Role.RoleName != models.RoleTraveler {
+				return nil, uranus.ConstraintErrorf("%s is a %s, requests are talked over with travelers", traveler.ID.Hex(), traveler.Role.RoleName)
+			}
+
+			conversation.TravelerID = traveler.ID
+		} else if principal.Role.RoleName == models.RoleTraveler {
+			conversation.TravelerID = bson.ObjectIdHex(principal.UserID)
+		} else {
+			return nil, &uranus.ForbiddenError{Action: string(auth.ActionMakeOffer)}
+		}
+	case models.ConversationOrder:
+		order, err := s.orderRepository.GetOrderByID(ctx, m.ScopeID.Hex())
+		if err != nil {
+			return nil, err
+		}
+
+		if order.PartyOf(principal.UserID) == "" {
+			return nil, &uranus.ForbiddenError{Action: string(auth.ActionManageOrders)}
+		}
+
+		conversation.BuyerID = order.BuyerID
+		conversation.TravelerID = order.TravelerID
+	}
+
+	return s.repository.UpsertConversation(ctx, conversation)
+}
+
+// FetchConversations lists the caller's own conversations, only moderators may list those of others.
+func (s *service) FetchConversations(ctx context.Context, filter *uranus.ConversationFilter) ([]*models.Conversation, uranus.Page, error) {
+	if ctx == nil {
+		err := uranus.ErrContextNil
+		return nil, uranus.Page{}, err
+	}
+
+	participantID, err := auth.PartyScope(ctx, auth.ActionModerateChats)
+	if err != nil {
+		return nil, uranus.Page{}, err
+	}
+
+	if participantID != "" {
+		filter.ParticipantID = participantID
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, s.contextTimeout)
+	defer cancel()
+
+	if filter.Num == 0 {
+		filter.Num = int(20)
+	}
+
+	return s.repository.FetchConversations(ctx, filter)
+}
+
+func (s *service) GetConversationByID(ctx context.Context, conversationID string) (*models.Conversation, error) {
+	if ctx == nil {
+		err := uranus.ErrContextNil
+		return nil, err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, s.contextTimeout)
+	defer cancel()
+
+	return s.readableConversation(ctx, conversationID)
+}
+
+// SendMessage stores the message and delivers it live to both participants, the sender's other connections included.
+func (s *service) SendMessage(ctx context.Context, conversationID string, m *models.ChatMessage) (err error) {
+	if ctx == nil {
+		err = uranus.ErrContextNil
+		return err
+	}
+
+	m.Body = strings.TrimSpace(m.Body)
+	if err = s.validator.ValidateStruct(m); err != nil {
+		return err
+	}
+
+	if m.Body == "" && len(m.ImageIDs) == 0 {
+		return uranus.ConstraintErrorf("Message has neither text nor images")
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, s.contextTimeout)
+	defer cancel()
+
+	conversation, userID, err := s.participantConversation(ctx, conversationID)
+	if err != nil {
+		return err
+	}
+
+	m.ID = bson.NewObjectId()
+	m.ConversationID = conversation.ID
+	m.SenderID = bson.ObjectIdHex(userID)
+	m.CreatedAt = time.Now()
+	if err = s.repository.StoreMessage(ctx, m); err != nil {
+		return err
+	}
+
+	s.publish(ctx, conversation, &models.ChatEvent{
+		Type:           models.ChatEventMessage,
+		ConversationID: conversation.ID,
+		UserID:         m.SenderID,
+		Message:        m,
+		At:             m.CreatedAt,
+	}, conversation.Participants()...)
+	return nil
+}
+
+func (s *service) FetchMessages(ctx context.Context, filter *uranus.ChatMessageFilter) ([]*models.ChatMessage, uranus.Page, error) {
+	if ctx == nil {
+		err := uranus.ErrContextNil
+		return nil, uranus.Page{}, err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, s.contextTimeout)
+	defer cancel()
+
+	if _, err := s.readableConversation(ctx, filter.ConversationID); err != nil {
+		return nil, uranus.Page{}, err
+	}
+
+	if filter.Num == 0 {
+		filter.Num = int(50)
+	}
+
+	return s.repository.FetchMessages(ctx, filter)
+}
+
+// MarkRead records that the caller read the conversation up to the message and tells the other participant.
+func (s *service) MarkRead(ctx context.Context, conversationID string, messageID string) (err error) {
+	if ctx == nil {
+		err = uranus.ErrContextNil
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, s.contextTimeout)
+	defer cancel()
+
+	conversation, userID, err := s.participantConversation(ctx, conversationID)
+	if err != nil {
+		return err
+	}
+
+	message, err := s.repository.GetMessageByID(ctx, messageID)
+	if err != nil {
+		return err
+	}
+
+	if message.ConversationID != conversation.ID {
+		return uranus.ErrNotFound
+	}
+
+	read := models.ChatRead{MessageID: message.ID, At: time.Now()}
+	if err = s.repository.MarkRead(ctx, conversation.ID.Hex(), userID, read); err != nil {
+		return err
+	}
+
+	s.publish(ctx, conversation, &models.ChatEvent{
+		Type:           models.ChatEventRead,
+		ConversationID: conversation.ID,
+		UserID:         bson.ObjectIdHex(userID),
+		MessageID:      message.ID,
+		At:             read.At,
+	}, others(conversation, userID)...)
+	return nil
+}
+
+// Typing tells the other participant the caller is typing, nothing is stored.
+func (s *service) Typing(ctx context.Context, conversationID string) (err error) {
+	if ctx == nil {
+		err = uranus.ErrContextNil
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, s.contextTimeout)
+	defer cancel()
+
+	conversation, userID, err := s.participantConversation(ctx, conversationID)
+	if err != nil {
+		return err
+	}
+
+	s.publish(ctx, conversation, &models.ChatEvent{
+		Type:           models.ChatEventTyping,
+		ConversationID: conversation.ID,
+		UserID:         bson.ObjectIdHex(userID),
+		At:             time.Now(),
+	}, others(conversation, userID)...)
+	return nil
+}
+
+// Listen subscribes the caller to the events of all their conversations until ctx is done.
+func (s *service) Listen(ctx context.Context) (<-chan *models.ChatEvent, error) {
+	if ctx == nil {
+		err := uranus.ErrContextNil
+		return nil, err
+	}
+
+	principal, ok := auth.FromContext(ctx)
+	if !ok || !bson.IsObjectIdHex(principal.UserID) {
+		return nil, &uranus.ForbiddenError{Action: string(auth.ActionModerateChats)}
+	}
+
+	return s.hub.Subscribe(ctx, principal.UserID)
+}
+
+// publish hands the event to the hub, the event is already stored or needn't be so a failed delivery is only logged.
+func (s *service) publish(ctx context.Context, conversation *models.Conversation, event *models.ChatEvent, userIDs ...string) {
+	if err := s.hub.Publish(ctx, event, userIDs...); err != nil {
+		log.Printf("Failed to publish %s event of conversation %s : %s", event.Type, conversation.ID.Hex(), err.Error())
+	}
+}
+
+// participantConversation loads a conversation the caller takes part in, along with the caller's ID.
+func (s *service) participantConversation(ctx context.Context, conversationID string) (*models.Conversation, string, error) {
+	principal, ok := auth.FromContext(ctx)
+	if !ok {
+		return nil, "", &uranus.ForbiddenError{Action: string(auth.ActionModerateChats)}
+	}
+
+	conversation, err := s.repository.GetConversationByID(ctx, conversationID)
+	if err != nil {
+		return nil, "", err
+	}
+
+	if !conversation.HasParticipant(principal.UserID) {
+		return nil, "", &uranus.ForbiddenError{Action: string(auth.ActionModerateChats)}
+	}
+
+	return conversation, principal.UserID, nil
+}
+
+// readableConversation loads a conversation the caller takes part in, moderators may read any conversation.
+func (s *service) readableConversation(ctx context.Context, conversationID string) (*models.Conversation, error) {
+	conversation, err := s.repository.GetConversationByID(ctx, conversationID)
+	if err != nil {
+		return nil, err
+	}
+
+	principal, ok := auth.FromContext(ctx)
+	if ok && conversation.HasParticipant(principal.UserID) {
+		return conversation, nil
+	}
+
+	if err = auth.Authorize(ctx, auth.ActionModerateChats); err != nil {
+		return nil, err
+	}
+
+	return conversation, nil
+}
+
+// others are the participants of the conversation besides the user.
+func others(conversation *models.Conversation, userID string) []string {
+	ids := make([]string, 0, 1)
+	for _, participant := range conversation.Participants() {
+		if participant != userID {
+			ids = append(ids, participant)
+		}
+	}
+
+	return ids
+}
+
+type requirement func(*service)
+
+func Repository(repository repository.ChatRepository) requirement {
+	return func(s *service) {
+		s.repository = repository
+	}
+}
+
+func PurchaseRequestRepository(purchaseRequestRepository repository.PurchaseRequestRepository) requirement {
+	return func(s *service) {
+		s.purchaseRequestRepository = purchaseRequestRepository
+	}
+}
+
+func OrderRepository(orderRepository repository.OrderRepository) requirement {
+	return func(s *service) {
+		s.orderRepository = orderRepository
+	}
+}
+
+func UserAccountRepository(userRepository repository.UserAccountRepository) requirement {
+	return func(s *service) {
+		s.userRepository = userRepository
+	}
+}
+
+// Hub sets the ChatHub events are delivered live through.
+func Hub(hub uranus.ChatHub) requirement {
+	return func(s *service) {
+		s.hub = hub
+	}
+}
+
+func Timeout(timeout time.Duration) requirement {
+	return func(s *service) {
+		s.contextTimeout = timeout
+	}
+}
+
+func Validator(validator uranus.Validate) requirement {
+	return func(s *service) {
+		s.validator = validator
+	}
+}
+
+func NewService(reqs ...requirement) uranus.ChatUsecase {
+	s := new(service)
+	for _, option := range reqs {
+		option(s)
+	}
+
+	return s
+}
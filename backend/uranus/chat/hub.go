@@ -0,0 +1,94 @@
+package chat
+
+import (
+	"context"
+	"log"
+	"sync"
+
+	"github.com/fidellr/jastip/backend/uranus"
+	"github.com/fidellr/jastip/backend/uranus/models"
+)
+
+type memoryHub struct {
+	mu          sync.RWMutex
+	buffer      int
+	subscribers map[string]map[chan *models.ChatEvent]struct{}
+}
+
+type hubRequirement func(*memoryHub)
+
+// Buffer sets how many events a connection may fall behind before the hub drops events for it.
+func Buffer(buffer int) hubRequirement {
+	return func(h *memoryHub) {
+		if buffer > 0 {
+			h.buffer = buffer
+		}
+	}
+}
+
+// NewMemoryHub returns a ChatHub that delivers events to the connections of this node only.
+// A user may be connected more than once, e.g. from their phone and a browser, every connection gets the events.
+func NewMemoryHub(reqs ...hubRequirement) uranus.ChatHub {
+	h := &memoryHub{
+		buffer:      64,
+		subscribers: make(map[string]map[chan *models.ChatEvent]struct{}),
+	}
+	for _, req := range reqs {
+		req(h)
+	}
+
+	return h
+}
+
+// Publish never waits on a slow connection, an event that doesn't fit its buffer is dropped
+// and the client catches up from the stored messages.
+func (h *memoryHub) Publish(ctx context.Context, event *models.ChatEvent, userIDs ...string) error {
+	if ctx == nil {
+		return uranus.ErrContextNil
+	}
+
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	for _, userID := range userIDs {
+		for events := range h.subscribers[userID] {
+			select {
+			case events <- event:
+			default:
+				log.Printf("Dropped %s chat event for %s, the connection is behind", event.Type, userID)
+			}
+		}
+	}
+
+	return nil
+}
+
+func (h *memoryHub) Subscribe(ctx context.Context, userID string) (<-chan *models.ChatEvent, error) {
+	if ctx == nil {
+		return nil, uranus.ErrContextNil
+	}
+
+	events := make(chan *models.ChatEvent, h.buffer)
+
+	h.mu.Lock()
+	if h.subscribers[userID] == nil {
+		h.subscribers[userID] = make(map[chan *models.ChatEvent]struct{})
+	}
+	h.subscribers[userID][events] = struct{}{}
+	h.mu.Unlock()
+
+	go func() {
+		<-ctx.Done()
+
+		h.mu.Lock()
+		defer h.mu.Unlock()
+
+		delete(h.subscribers[userID], events)
+		if len(h.subscribers[userID]) == 0 {
+			delete(h.subscribers, userID)
+		}
+		close(events)
+	}()
+
+	return events, nil
+}
@@ -0,0 +1,31 @@
+package cmd
+
+import (
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+
+	_mongoRepository "github.com/fidellr/jastip/backend/uranus/internal/delivery/repository/mongo"
+)
+
+var uranusMigrateMoneyCMD = &cobra.Command{
+	Use:   "migrate-money",
+	Short: "Convert amounts stored as floats to money in minor units of their currency",
+	Long: `Convert the prices, fees, totals, payment amounts and journal lines stored as floats
+in major units before money carried its own currency. Documents already converted are
+left as they are, so it can be run again, e.g. after an interrupted run.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		masterSession, mongoDatabase := initMongoSession()
+		defer masterSession.Close()
+
+		migrated, err := _mongoRepository.MigrateMoney(masterSession, mongoDatabase)
+		if err != nil {
+			logrus.Fatalln(err.Error())
+		}
+
+		logrus.Infof("Migrated %d documents", migrated)
+	},
+}
+
+func init() {
+	RootCMD.AddCommand(uranusMigrateMoneyCMD)
+}
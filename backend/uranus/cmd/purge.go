@@ -0,0 +1,32 @@
+package cmd
+
+import (
+	"context"
+
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+)
+
+var uranusPurgeCMD = &cobra.Command{
+	Use:   "purge",
+	Short: "Anonymize or hard-delete accounts whose retention window has ended",
+	Run: func(cmd *cobra.Command, args []string) {
+		hard, _ := cmd.Flags().GetBool("hard")
+
+		masterSession, mongoDatabase := initMongoSession()
+		defer masterSession.Close()
+
+		uranusService := initUserService(masterSession, mongoDatabase, initTokenManager())
+		purged, err := uranusService.PurgeDeletedAccounts(context.Background(), hard)
+		if err != nil {
+			logrus.Fatalln(err.Error())
+		}
+
+		logrus.Infof("Purged %d deleted accounts", purged)
+	},
+}
+
+func init() {
+	RootCMD.AddCommand(uranusPurgeCMD)
+	uranusPurgeCMD.Flags().Bool("hard", false, "Remove the accounts instead of anonymizing them")
+}
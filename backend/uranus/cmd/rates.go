@@ -0,0 +1,52 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"io/ioutil"
+
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+
+	"github.com/fidellr/jastip/backend/uranus/models"
+)
+
+var uranusRatesCMD = &cobra.Command{
+	Use:   "rates",
+	Short: "Upload exchange rates from a JSON file, e.g. the daily rates",
+	Long: `Upload exchange rates from a JSON file holding a list of rates like
+[{"base": "JPY", "quote": "IDR", "rate": 104.25, "effective_at": "2026-10-18T00:00:00Z"}]
+A rate for a pair and effective date that is already stored gets replaced.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		file, _ := cmd.Flags().GetString("file")
+		if file == "" {
+			logrus.Fatalln("Please provide the rates file with --file")
+		}
+
+		content, err := ioutil.ReadFile(file)
+		if err != nil {
+			logrus.Fatalln(err.Error())
+		}
+
+		rates := make([]*models.ExchangeRate, 0)
+		if err = json.Unmarshal(content, &rates); err != nil {
+			logrus.Fatalln(err.Error())
+		}
+
+		masterSession, mongoDatabase := initMongoSession()
+		defer masterSession.Close()
+
+		exchangeRateService := initExchangeRateService(masterSession, mongoDatabase)
+		stored, err := exchangeRateService.UploadRates(context.Background(), rates)
+		if err != nil {
+			logrus.Fatalln(err.Error())
+		}
+
+		logrus.Infof("Stored %d exchange rates", stored)
+	},
+}
+
+func init() {
+	RootCMD.AddCommand(uranusRatesCMD)
+	uranusRatesCMD.Flags().String("file", "", "JSON file with the exchange rates to upload")
+}
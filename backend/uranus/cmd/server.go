@@ -1,16 +1,38 @@
 package cmd
 
 import (
+	"context"
+	"encoding/json"
 	"errors"
 	"net/http"
 	"strings"
 	"time"
 
 	"github.com/fidellr/jastip/backend/uranus"
+	"github.com/fidellr/jastip/backend/uranus/auth"
+	"github.com/fidellr/jastip/backend/uranus/chat"
+	"github.com/fidellr/jastip/backend/uranus/courier"
+	"github.com/fidellr/jastip/backend/uranus/customs"
+	"github.com/fidellr/jastip/backend/uranus/dispute"
+	"github.com/fidellr/jastip/backend/uranus/fee"
+	"github.com/fidellr/jastip/backend/uranus/gateway"
+	"github.com/fidellr/jastip/backend/uranus/image"
 	"github.com/fidellr/jastip/backend/uranus/internal/delivery"
 	_httpDelivery "github.com/fidellr/jastip/backend/uranus/internal/delivery/http"
 	_mongoRepository "github.com/fidellr/jastip/backend/uranus/internal/delivery/repository/mongo"
+	"github.com/fidellr/jastip/backend/uranus/mailer"
+	"github.com/fidellr/jastip/backend/uranus/models"
+	"github.com/fidellr/jastip/backend/uranus/notification"
+	"github.com/fidellr/jastip/backend/uranus/notifier"
 
+	"github.com/fidellr/jastip/backend/uranus/offer"
+	"github.com/fidellr/jastip/backend/uranus/order"
+	"github.com/fidellr/jastip/backend/uranus/payment"
+	"github.com/fidellr/jastip/backend/uranus/purchase"
+	"github.com/fidellr/jastip/backend/uranus/rate"
+	"github.com/fidellr/jastip/backend/uranus/review"
+	"github.com/fidellr/jastip/backend/uranus/shipment"
+	"github.com/fidellr/jastip/backend/uranus/trip"
 	"github.com/fidellr/jastip/backend/uranus/user"
 	"github.com/globalsign/mgo"
 	"github.com/labstack/echo"
@@ -49,7 +71,7 @@ var uranusServerCMD = &cobra.Command{
 func init() {
 	cobra.OnInitialize(initConfig)
 	RootCMD.AddCommand(uranusServerCMD)
-	uranusServerCMD.PersistentFlags().String("config", "", "Set this flag to use a configuration file")
+	RootCMD.PersistentFlags().String("config", "", "Set this flag to use a configuration file")
 }
 
 func initConfig() {
@@ -59,9 +81,9 @@ func initConfig() {
 	replacer := strings.NewReplacer(".", "_")
 	viper.SetEnvKeyReplacer(replacer)
 
-	if uranusServerCMD.Flags().Lookup("config") != nil {
+	if RootCMD.PersistentFlags().Lookup("config") != nil {
 		configFile = "config.json"
-		viper.BindPFlag("config", uranusServerCMD.Flags().Lookup("config"))
+		viper.BindPFlag("config", RootCMD.PersistentFlags().Lookup("config"))
 		viper.SetConfigType("json")
 	}
 
@@ -84,14 +106,66 @@ func initConfig() {
 }
 
 func initUranusApplication(e *echo.Echo) {
-	// timeout := time.Duration(viper.GetInt("http.timeout")) * time.Second
-	contextTimeout := time.Duration(viper.GetInt("context.timeout")) * time.Second
-	// defaultTransport := &http.Transport{
-	// 	MaxIdleConns:        viper.GetInt("http.max_idle_conns"),
-	// 	MaxIdleConnsPerHost: viper.GetInt("http.max_idle_conns_per_host"),
-	// 	IdleConnTimeout:     time.Duration(viper.GetInt("http.max_idle_conn_timeout")) * time.Second,
-	// }
+	masterSession, mongoDatabase := initMongoSession()
+	tokenManager := initTokenManager()
+	notificationService := initNotificationService(masterSession, mongoDatabase)
+	uranusService := initUserService(masterSession, mongoDatabase, tokenManager)
+	tripService := initTripService(masterSession, mongoDatabase)
+	exchangeRateService := initExchangeRateService(masterSession, mongoDatabase)
+	customsService := initCustomsService(masterSession, mongoDatabase, exchangeRateService)
+	purchaseRequestService := initPurchaseRequestService(masterSession, mongoDatabase, customsService)
+	feeService := initFeeService(masterSession, mongoDatabase)
+	offerService, follower := initOfferService(masterSession, mongoDatabase, exchangeRateService, feeService, customsService)
+	paymentService, escrow := initPaymentService(masterSession, mongoDatabase, follower)
+	orderService := initOrderService(masterSession, mongoDatabase, escrow, follower)
+	shipmentService := initShipmentService(masterSession, mongoDatabase)
+	chatService := initChatService(masterSession, mongoDatabase)
+	reviewService := initReviewService(masterSession, mongoDatabase)
+	disputeService := initDisputeService(masterSession, mongoDatabase, escrow, follower)
+
+	go sweep("lift expired suspensions", interval("suspension.sweep_interval", time.Minute), uranusService.LiftExpiredSuspensions)
+	go sweep("close departed trips", interval("trip.sweep_interval", time.Minute), tripService.CloseDepartedTrips)
+	go sweep("expire overdue purchase requests", interval("purchase_request.sweep_interval", time.Minute), purchaseRequestService.ExpireOverdueRequests)
+	go sweep("settle ended orders", interval("payment.settle_interval", 5*time.Minute), paymentService.SettleEndedOrders)
+	go sweep("escalate overdue disputes", interval("dispute.sweep_interval", 5*time.Minute), disputeService.EscalateOverdueDisputes)
+	go sweep("settle resolved disputes", interval("dispute.sweep_interval", 5*time.Minute), disputeService.SettleResolvedDisputes)
+	go sweep("deliver notifications", interval("notification.interval", 10*time.Second), func(ctx context.Context) (int, error) {
+		// The outboxes are relayed first, so what they held goes out in the same round.
+		if _, err := notificationService.RelayOutbox(ctx); err != nil {
+			logrus.Errorf("Failed to relay the outbox : %s", err.Error())
+		}
+
+		return notificationService.DeliverPending(ctx)
+	})
+
+	e.HTTPErrorHandler = delivery.HandleUncaughtHTTPError
+	e.Use(auth.Middleware(
+		auth.TokenVerifier(tokenManager),
+		auth.RevocationChecker(uranusService),
+		auth.ProtectedGroups(viper.GetStringSlice("auth.protected_groups")...),
+		auth.PublicRoutes(viper.GetStringSlice("auth.public_routes")...),
+		auth.WebSocketRoutes("GET /chat/ws"),
+	))
+	_httpDelivery.NewUserHandler(e, _httpDelivery.UserService(uranusService))
+	_httpDelivery.NewTripHandler(e, _httpDelivery.TripService(tripService))
+	_httpDelivery.NewPurchaseRequestHandler(e, _httpDelivery.PurchaseRequestService(purchaseRequestService))
+	_httpDelivery.NewOfferHandler(e, _httpDelivery.OfferService(offerService))
+	_httpDelivery.NewExchangeRateHandler(e, _httpDelivery.ExchangeRateService(exchangeRateService))
+	_httpDelivery.NewFeeHandler(e, _httpDelivery.FeeService(feeService))
+	_httpDelivery.NewCustomsHandler(e, _httpDelivery.CustomsService(customsService))
+	_httpDelivery.NewOrderHandler(e, _httpDelivery.OrderService(orderService))
+	_httpDelivery.NewPaymentHandler(e, _httpDelivery.PaymentService(paymentService))
+	_httpDelivery.NewShipmentHandler(e, _httpDelivery.ShipmentService(shipmentService))
+	_httpDelivery.NewChatHandler(e,
+		_httpDelivery.ChatService(chatService),
+		_httpDelivery.ChatOrigins(viper.GetStringSlice("chat.allowed_origins")...),
+	)
+	_httpDelivery.NewNotificationHandler(e, _httpDelivery.NotificationService(notificationService))
+	_httpDelivery.NewReviewHandler(e, _httpDelivery.ReviewService(reviewService))
+	_httpDelivery.NewDisputeHandler(e, _httpDelivery.DisputeService(disputeService))
+}
 
+func initMongoSession() (*mgo.Session, string) {
 	mongoDSN := viper.GetString("mongo.dsn")
 
 	masterSession, err := mgo.Dial(mongoDSN)
@@ -106,17 +180,535 @@ func initUranusApplication(e *echo.Echo) {
 		logrus.Fatalln(errors.New("Please provide a mongo database name"))
 	}
 
+	return masterSession, mongoDatabase
+}
+
+func initTokenManager() uranus.TokenManager {
+	authSecret := viper.GetString("auth.secret")
+	if authSecret == "" {
+		logrus.Fatalln(errors.New("Please provide an auth secret to sign access tokens"))
+	}
+
+	return auth.NewJWT(
+		auth.Secret(authSecret),
+		auth.Issuer(viper.GetString("auth.issuer")),
+		auth.TTL(time.Duration(viper.GetInt("auth.access_token_ttl"))*time.Second),
+	)
+}
+
+func initUserService(masterSession *mgo.Session, mongoDatabase string, tokenManager uranus.TokenManager) uranus.UserAccountUsecase {
+	contextTimeout := time.Duration(viper.GetInt("context.timeout")) * time.Second
 	validator := uranus.NewValidator()
 	userRepo := _mongoRepository.NewUserMongo(
 		_mongoRepository.UserSession(masterSession),
 		_mongoRepository.UserDBName(mongoDatabase),
 	)
-	uranusService := user.NewService(
+	userTokenRepo := _mongoRepository.NewUserTokenMongo(
+		_mongoRepository.UserTokenSession(masterSession),
+		_mongoRepository.UserTokenDBName(mongoDatabase),
+	)
+	suspensionRepo := _mongoRepository.NewSuspensionMongo(
+		_mongoRepository.SuspensionSession(masterSession),
+		_mongoRepository.SuspensionDBName(mongoDatabase),
+	)
+
+	return user.NewService(
 		user.Repository(userRepo),
+		user.UserTokenRepository(userTokenRepo),
+		user.SuspensionRepository(suspensionRepo),
+		user.Mailer(initMailer()),
+		user.VerificationTTL(time.Duration(viper.GetInt("mailer.verification_ttl"))*time.Second),
+		user.PasswordResetTTL(time.Duration(viper.GetInt("mailer.password_reset_ttl"))*time.Second),
+		user.RetentionPeriod(time.Duration(viper.GetInt("account.retention_days"))*24*time.Hour),
 		user.Timeout(contextTimeout),
 		user.Validator(validator),
+		user.TokenManager(tokenManager),
 	)
+}
 
-	e.HTTPErrorHandler = delivery.HandleUncaughtHTTPError
-	_httpDelivery.NewUserHandler(e, _httpDelivery.UserService(uranusService))
+func initTripService(masterSession *mgo.Session, mongoDatabase string) uranus.TripUsecase {
+	tripRepo := _mongoRepository.NewTripMongo(
+		_mongoRepository.TripSession(masterSession),
+		_mongoRepository.TripDBName(mongoDatabase),
+	)
+	userRepo := _mongoRepository.NewUserMongo(
+		_mongoRepository.UserSession(masterSession),
+		_mongoRepository.UserDBName(mongoDatabase),
+	)
+
+	return trip.NewService(
+		trip.Repository(tripRepo),
+		trip.UserAccountRepository(userRepo),
+		trip.Timeout(time.Duration(viper.GetInt("context.timeout"))*time.Second),
+		trip.Validator(uranus.NewValidator()),
+	)
+}
+
+func initPurchaseRequestService(masterSession *mgo.Session, mongoDatabase string, customs uranus.CustomsChecker) uranus.PurchaseRequestUsecase {
+	purchaseRequestRepo := _mongoRepository.NewPurchaseRequestMongo(
+		_mongoRepository.PurchaseRequestSession(masterSession),
+		_mongoRepository.PurchaseRequestDBName(mongoDatabase),
+	)
+	userRepo := _mongoRepository.NewUserMongo(
+		_mongoRepository.UserSession(masterSession),
+		_mongoRepository.UserDBName(mongoDatabase),
+	)
+	orderRepo := _mongoRepository.NewOrderMongo(
+		_mongoRepository.OrderSession(masterSession),
+		_mongoRepository.OrderDBName(mongoDatabase),
+	)
+
+	return purchase.NewService(
+		purchase.Repository(purchaseRequestRepo),
+		purchase.UserAccountRepository(userRepo),
+		purchase.OrderRepository(orderRepo),
+		purchase.Customs(customs),
+		purchase.Timeout(time.Duration(viper.GetInt("context.timeout"))*time.Second),
+		purchase.Validator(uranus.NewValidator()),
+	)
+}
+
+// initOfferService builds the offer service along with its follower side the order and dispute services
+// move requests and offers with once an order ended.
+func initOfferService(masterSession *mgo.Session, mongoDatabase string, converter uranus.Converter, feeCalculator uranus.FeeCalculator, customs uranus.CustomsChecker) (uranus.OfferUsecase, uranus.OrderFollower) {
+	offerRepo := _mongoRepository.NewOfferMongo(
+		_mongoRepository.OfferSession(masterSession),
+		_mongoRepository.OfferDBName(mongoDatabase),
+	)
+	purchaseRequestRepo := _mongoRepository.NewPurchaseRequestMongo(
+		_mongoRepository.PurchaseRequestSession(masterSession),
+		_mongoRepository.PurchaseRequestDBName(mongoDatabase),
+	)
+	tripRepo := _mongoRepository.NewTripMongo(
+		_mongoRepository.TripSession(masterSession),
+		_mongoRepository.TripDBName(mongoDatabase),
+	)
+	orderRepo := _mongoRepository.NewOrderMongo(
+		_mongoRepository.OrderSession(masterSession),
+		_mongoRepository.OrderDBName(mongoDatabase),
+	)
+
+	settlementCurrency := viper.GetString("payment.currency")
+	if !models.IsCurrency(settlementCurrency) {
+		logrus.Fatalf("Please provide a payment currency uranus handles, %q is not one", settlementCurrency)
+	}
+
+	contextTimeout := time.Duration(viper.GetInt("context.timeout")) * time.Second
+
+	offerService := offer.NewService(
+		offer.Repository(offerRepo),
+		offer.PurchaseRequestRepository(purchaseRequestRepo),
+		offer.TripRepository(tripRepo),
+		offer.OrderRepository(orderRepo),
+		offer.Converter(converter),
+		offer.FeeCalculator(feeCalculator),
+		offer.Customs(customs),
+		offer.SettlementCurrency(settlementCurrency),
+		offer.Timeout(contextTimeout),
+		offer.Validator(uranus.NewValidator()),
+	)
+	follower := offer.NewFollower(
+		offer.Repository(offerRepo),
+		offer.PurchaseRequestRepository(purchaseRequestRepo),
+		offer.Timeout(contextTimeout),
+	)
+
+	return offerService, follower
+}
+
+func initExchangeRateService(masterSession *mgo.Session, mongoDatabase string) uranus.ExchangeRateUsecase {
+	exchangeRateRepo := _mongoRepository.NewExchangeRateMongo(
+		_mongoRepository.ExchangeRateSession(masterSession),
+		_mongoRepository.ExchangeRateDBName(mongoDatabase),
+	)
+
+	return rate.NewService(
+		rate.Repository(exchangeRateRepo),
+		rate.Timeout(time.Duration(viper.GetInt("context.timeout"))*time.Second),
+		rate.Validator(uranus.NewValidator()),
+	)
+}
+
+// initFeeService reads the fee schedules from the fees.schedules config or, with fees.source mongo, from their collection.
+func initFeeService(masterSession *mgo.Session, mongoDatabase string) uranus.FeeUsecase {
+	contextTimeout := time.Duration(viper.GetInt("context.timeout")) * time.Second
+
+	var feeService uranus.FeeUsecase
+	var err error
+	switch source := viper.GetString("fees.source"); source {
+	case "mongo":
+		feeScheduleRepo := _mongoRepository.NewFeeScheduleMongo(
+			_mongoRepository.FeeScheduleSession(masterSession),
+			_mongoRepository.FeeScheduleDBName(mongoDatabase),
+		)
+
+		feeService, err = fee.NewService(
+			fee.Repository(feeScheduleRepo),
+			fee.Timeout(contextTimeout),
+			fee.Validator(uranus.NewValidator()),
+		)
+	case "", "config":
+		// The rules are nested lists, going through JSON maps them with the same tags the API uses.
+		var content []byte
+		content, err = json.Marshal(viper.Get("fees.schedules"))
+		if err != nil {
+			logrus.Fatalln(err.Error())
+		}
+
+		schedules := make([]*models.FeeSchedule, 0)
+		if err = json.Unmarshal(content, &schedules); err != nil {
+			logrus.Fatalln(err.Error())
+		}
+
+		feeService, err = fee.NewService(
+			fee.Schedules(schedules...),
+			fee.Timeout(contextTimeout),
+			fee.Validator(uranus.NewValidator()),
+		)
+	default:
+		logrus.Fatalf("Unknown fee source %s", source)
+	}
+
+	if err != nil {
+		logrus.Fatalln(err.Error())
+	}
+
+	return feeService
+}
+
+// initCustomsService reads the customs rules from the customs.rules_file JSON, edits to the file apply without a restart.
+func initCustomsService(masterSession *mgo.Session, mongoDatabase string, converter uranus.Converter) uranus.CustomsUsecase {
+	orderRepo := _mongoRepository.NewOrderMongo(
+		_mongoRepository.OrderSession(masterSession),
+		_mongoRepository.OrderDBName(mongoDatabase),
+	)
+	tripRepo := _mongoRepository.NewTripMongo(
+		_mongoRepository.TripSession(masterSession),
+		_mongoRepository.TripDBName(mongoDatabase),
+	)
+	purchaseRequestRepo := _mongoRepository.NewPurchaseRequestMongo(
+		_mongoRepository.PurchaseRequestSession(masterSession),
+		_mongoRepository.PurchaseRequestDBName(mongoDatabase),
+	)
+	userRepo := _mongoRepository.NewUserMongo(
+		_mongoRepository.UserSession(masterSession),
+		_mongoRepository.UserDBName(mongoDatabase),
+	)
+
+	customsService, err := customs.NewService(
+		customs.OrderRepository(orderRepo),
+		customs.TripRepository(tripRepo),
+		customs.PurchaseRequestRepository(purchaseRequestRepo),
+		customs.UserAccountRepository(userRepo),
+		customs.Converter(converter),
+		customs.RulesFile(viper.GetString("customs.rules_file")),
+		customs.Timeout(time.Duration(viper.GetInt("context.timeout"))*time.Second),
+	)
+	if err != nil {
+		logrus.Fatalln(err.Error())
+	}
+
+	return customsService
+}
+
+func initOrderService(masterSession *mgo.Session, mongoDatabase string, escrow uranus.Escrow, follower uranus.OrderFollower) uranus.OrderUsecase {
+	orderRepo := _mongoRepository.NewOrderMongo(
+		_mongoRepository.OrderSession(masterSession),
+		_mongoRepository.OrderDBName(mongoDatabase),
+	)
+
+	return order.NewService(
+		order.Repository(orderRepo),
+		order.Escrow(escrow),
+		order.Follower(follower),
+		order.Timeout(time.Duration(viper.GetInt("context.timeout"))*time.Second),
+		order.Validator(uranus.NewValidator()),
+	)
+}
+
+func initShipmentService(masterSession *mgo.Session, mongoDatabase string) uranus.ShipmentUsecase {
+	shipmentRepo := _mongoRepository.NewShipmentMongo(
+		_mongoRepository.ShipmentSession(masterSession),
+		_mongoRepository.ShipmentDBName(mongoDatabase),
+	)
+	orderRepo := _mongoRepository.NewOrderMongo(
+		_mongoRepository.OrderSession(masterSession),
+		_mongoRepository.OrderDBName(mongoDatabase),
+	)
+
+	return shipment.NewService(
+		shipment.Repository(shipmentRepo),
+		shipment.OrderRepository(orderRepo),
+		shipment.CourierTracker(initCourierTracker()),
+		shipment.Timeout(time.Duration(viper.GetInt("context.timeout"))*time.Second),
+		shipment.Validator(uranus.NewValidator()),
+	)
+}
+
+func initChatService(masterSession *mgo.Session, mongoDatabase string) uranus.ChatUsecase {
+	chatRepo := _mongoRepository.NewChatMongo(
+		_mongoRepository.ChatSession(masterSession),
+		_mongoRepository.ChatDBName(mongoDatabase),
+	)
+	purchaseRequestRepo := _mongoRepository.NewPurchaseRequestMongo(
+		_mongoRepository.PurchaseRequestSession(masterSession),
+		_mongoRepository.PurchaseRequestDBName(mongoDatabase),
+	)
+	orderRepo := _mongoRepository.NewOrderMongo(
+		_mongoRepository.OrderSession(masterSession),
+		_mongoRepository.OrderDBName(mongoDatabase),
+	)
+	userRepo := _mongoRepository.NewUserMongo(
+		_mongoRepository.UserSession(masterSession),
+		_mongoRepository.UserDBName(mongoDatabase),
+	)
+
+	return chat.NewService(
+		chat.Repository(chatRepo),
+		chat.PurchaseRequestRepository(purchaseRequestRepo),
+		chat.OrderRepository(orderRepo),
+		chat.UserAccountRepository(userRepo),
+		chat.Hub(initChatHub()),
+		chat.Timeout(time.Duration(viper.GetInt("context.timeout"))*time.Second),
+		chat.Validator(uranus.NewValidator()),
+	)
+}
+
+func initReviewService(masterSession *mgo.Session, mongoDatabase string) uranus.ReviewUsecase {
+	reviewRepo := _mongoRepository.NewReviewMongo(
+		_mongoRepository.ReviewSession(masterSession),
+		_mongoRepository.ReviewDBName(mongoDatabase),
+	)
+	orderRepo := _mongoRepository.NewOrderMongo(
+		_mongoRepository.OrderSession(masterSession),
+		_mongoRepository.OrderDBName(mongoDatabase),
+	)
+	userRepo := _mongoRepository.NewUserMongo(
+		_mongoRepository.UserSession(masterSession),
+		_mongoRepository.UserDBName(mongoDatabase),
+	)
+
+	return review.NewService(
+		review.Repository(reviewRepo),
+		review.OrderRepository(orderRepo),
+		review.UserAccountRepository(userRepo),
+		review.EditWindow(time.Duration(viper.GetInt("review.edit_window"))*time.Second),
+		review.Timeout(time.Duration(viper.GetInt("context.timeout"))*time.Second),
+		review.Validator(uranus.NewValidator()),
+	)
+}
+
+func initDisputeService(masterSession *mgo.Session, mongoDatabase string, escrow uranus.Escrow, follower uranus.OrderFollower) uranus.DisputeUsecase {
+	disputeRepo := _mongoRepository.NewDisputeMongo(
+		_mongoRepository.DisputeSession(masterSession),
+		_mongoRepository.DisputeDBName(mongoDatabase),
+	)
+	orderRepo := _mongoRepository.NewOrderMongo(
+		_mongoRepository.OrderSession(masterSession),
+		_mongoRepository.OrderDBName(mongoDatabase),
+	)
+	userRepo := _mongoRepository.NewUserMongo(
+		_mongoRepository.UserSession(masterSession),
+		_mongoRepository.UserDBName(mongoDatabase),
+	)
+
+	return dispute.NewService(
+		dispute.Repository(disputeRepo),
+		dispute.OrderRepository(orderRepo),
+		dispute.UserAccountRepository(userRepo),
+		dispute.Images(initImageStore()),
+		dispute.Escrow(escrow),
+		dispute.Follower(follower),
+		dispute.Window(time.Duration(viper.GetInt("dispute.window"))*time.Second),
+		dispute.SLA(time.Duration(viper.GetInt("dispute.sla"))*time.Second),
+		dispute.Timeout(time.Duration(viper.GetInt("context.timeout"))*time.Second),
+		dispute.Validator(uranus.NewValidator()),
+	)
+}
+
+func initImageStore() uranus.ImageStore {
+	switch driver := viper.GetString("image.store"); driver {
+	case "", "fake":
+		return image.NewFake()
+	default:
+		logrus.Fatalf("Unknown image store %s", driver)
+		return nil
+	}
+}
+
+func initChatHub() uranus.ChatHub {
+	switch driver := viper.GetString("chat.hub"); driver {
+	case "", "memory":
+		return chat.NewMemoryHub(chat.Buffer(viper.GetInt("chat.buffer")))
+	default:
+		logrus.Fatalf("Unknown chat hub %s", driver)
+		return nil
+	}
+}
+
+func initCourierTracker() uranus.CourierTracker {
+	switch driver := viper.GetString("shipment.courier"); driver {
+	case "", "fake":
+		return courier.NewFake(
+			courier.Couriers(viper.GetStringSlice("shipment.fake.couriers")...),
+			courier.Step(time.Duration(viper.GetInt("shipment.fake.step"))*time.Second),
+		)
+	default:
+		logrus.Fatalf("Unknown courier tracker %s", driver)
+		return nil
+	}
+}
+
+// initPaymentService builds the payment service along with its escrow side the order service settles orders with.
+func initPaymentService(masterSession *mgo.Session, mongoDatabase string, follower uranus.OrderFollower) (uranus.PaymentUsecase, uranus.Escrow) {
+	paymentRepo := _mongoRepository.NewPaymentMongo(
+		_mongoRepository.PaymentSession(masterSession),
+		_mongoRepository.PaymentDBName(mongoDatabase),
+	)
+	ledgerRepo := _mongoRepository.NewLedgerMongo(
+		_mongoRepository.LedgerSession(masterSession),
+		_mongoRepository.LedgerDBName(mongoDatabase),
+	)
+	orderRepo := _mongoRepository.NewOrderMongo(
+		_mongoRepository.OrderSession(masterSession),
+		_mongoRepository.OrderDBName(mongoDatabase),
+	)
+
+	paymentGateway := initPaymentGateway()
+	contextTimeout := time.Duration(viper.GetInt("context.timeout")) * time.Second
+
+	paymentService := payment.NewService(
+		payment.Repository(paymentRepo),
+		payment.LedgerRepository(ledgerRepo),
+		payment.OrderRepository(orderRepo),
+		payment.Gateway(paymentGateway),
+		payment.Follower(follower),
+		payment.Timeout(contextTimeout),
+	)
+	escrow := payment.NewEscrow(
+		payment.Repository(paymentRepo),
+		payment.LedgerRepository(ledgerRepo),
+		payment.OrderRepository(orderRepo),
+		payment.Gateway(paymentGateway),
+		payment.Timeout(contextTimeout),
+	)
+
+	return paymentService, escrow
+}
+
+func initPaymentGateway() uranus.PaymentGateway {
+	secret := viper.GetString("payment.webhook_secret")
+	if secret == "" {
+		logrus.Fatalln(errors.New("Please provide a payment webhook secret"))
+	}
+
+	switch driver := viper.GetString("payment.gateway"); driver {
+	case "", "fake":
+		return gateway.NewFake(
+			gateway.Secret(secret),
+			gateway.Outcome(viper.GetString("payment.fake.outcome")),
+			gateway.WebhookURL(viper.GetString("payment.fake.webhook_url")),
+			gateway.WebhookDelay(time.Duration(viper.GetInt("payment.fake.webhook_delay"))*time.Second),
+		)
+	default:
+		logrus.Fatalf("Unknown payment gateway %s", driver)
+		return nil
+	}
+}
+
+// initNotificationService builds the notification outbox with a Notifier for every channel,
+// the email channel shares the mailer of the user service.
+func initNotificationService(masterSession *mgo.Session, mongoDatabase string) uranus.NotificationUsecase {
+	notificationRepo := _mongoRepository.NewNotificationMongo(
+		_mongoRepository.NotificationSession(masterSession),
+		_mongoRepository.NotificationDBName(mongoDatabase),
+	)
+	userRepo := _mongoRepository.NewUserMongo(
+		_mongoRepository.UserSession(masterSession),
+		_mongoRepository.UserDBName(mongoDatabase),
+	)
+
+	return notification.NewService(
+		notification.Repository(notificationRepo),
+		notification.UserAccountRepository(userRepo),
+		notification.Notifiers(
+			notifier.NewEmail(initMailer()),
+			notifier.NewInbox(notificationRepo),
+			initPushNotifier(),
+		),
+		notification.MaxAttempts(viper.GetInt("notification.max_attempts")),
+		notification.Backoff(
+			time.Duration(viper.GetInt("notification.backoff"))*time.Second,
+			time.Duration(viper.GetInt("notification.max_backoff"))*time.Second,
+		),
+		notification.Lease(time.Duration(viper.GetInt("notification.lease"))*time.Second),
+		notification.BatchSize(viper.GetInt("notification.batch_size")),
+		notification.Timeout(time.Duration(viper.GetInt("context.timeout"))*time.Second),
+	)
+}
+
+func initPushNotifier() uranus.Notifier {
+	switch driver := viper.GetString("notification.push.driver"); driver {
+	case "", "fake":
+		return notifier.NewFake(models.NotificationPush)
+	case "fcm":
+		return notifier.NewFCM(
+			notifier.ServerKey(viper.GetString("notification.push.fcm.server_key")),
+			notifier.Endpoint(viper.GetString("notification.push.fcm.endpoint")),
+		)
+	default:
+		logrus.Fatalf("Unknown push notifier %s", driver)
+		return nil
+	}
+}
+
+func initMailer() uranus.Mailer {
+	switch driver := viper.GetString("mailer.driver"); driver {
+	case "smtp":
+		return mailer.NewSMTP(
+			mailer.SMTPHost(viper.GetString("mailer.smtp.host")),
+			mailer.SMTPPort(viper.GetInt("mailer.smtp.port")),
+			mailer.SMTPAuth(viper.GetString("mailer.smtp.username"), viper.GetString("mailer.smtp.password")),
+			mailer.From(viper.GetString("mailer.from")),
+		)
+	case "file":
+		fileMailer, err := mailer.NewFile(viper.GetString("mailer.file.path"))
+		if err != nil {
+			logrus.Fatalln(err.Error())
+		}
+
+		return fileMailer
+	case "", "log":
+		return mailer.NewLog()
+	default:
+		logrus.Fatalf("Unknown mailer driver %s", driver)
+		return nil
+	}
+}
+
+// sweep runs fn every so often for as long as uranus runs, for the jobs that follow up on what requests left behind.
+func sweep(name string, every time.Duration, fn func(context.Context) (int, error)) {
+	ticker := time.NewTicker(every)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		swept, err := fn(context.Background())
+		if err != nil {
+			logrus.Errorf("Failed to %s : %s", name, err.Error())
+			continue
+		}
+
+		if swept > 0 {
+			logrus.Infof("Swept %d items to %s", swept, name)
+		}
+	}
+}
+
+// interval reads how often a sweep runs in seconds from the config, fallback is used when it isn't set.
+func interval(key string, fallback time.Duration) time.Duration {
+	if seconds := viper.GetInt(key); seconds > 0 {
+		return time.Duration(seconds) * time.Second
+	}
+
+	return fallback
 }
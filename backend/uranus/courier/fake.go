@@ -0,0 +1,104 @@
+package courier
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/fidellr/jastip/backend/uranus"
+	"github.com/fidellr/jastip/backend/uranus/models"
+)
+
+// fakeSteps are the checkpoints every fake parcel goes through, one step apart.
+var fakeSteps = []models.CourierCheckpoint{
+	{Status: models.CourierPickedUp, Description: "Parcel picked up from the sender"},
+	{Status: models.CourierInTransit, Description: "Parcel arrived at the sorting center"},
+	{Status: models.CourierOutForDelivery, Description: "Parcel is out for delivery"},
+	{Status: models.CourierDelivered, Description: "Parcel delivered to the recipient"},
+}
+
+type fakeTracker struct {
+	mu       sync.Mutex
+	couriers map[string]bool
+	step     time.Duration
+	seen     map[string]time.Time
+}
+
+type fakeRequirement func(*fakeTracker)
+
+// Couriers sets the couriers the fake knows, tracking at any other courier is uranus.ErrNotFound.
+func Couriers(couriers ...string) fakeRequirement {
+	return func(t *fakeTracker) {
+		if len(couriers) == 0 {
+			return
+		}
+
+		t.couriers = make(map[string]bool, len(couriers))
+		for _, courier := range couriers {
+			t.couriers[strings.ToLower(courier)] = true
+		}
+	}
+}
+
+// Step sets how long a fake parcel takes from one checkpoint to the next.
+func Step(step time.Duration) fakeRequirement {
+	return func(t *fakeTracker) {
+		if step > 0 {
+			t.step = step
+		}
+	}
+}
+
+// NewFake returns a CourierTracker that makes parcels up, for local development and tests.
+// A parcel is picked up the first time it's tracked and delivered three steps later.
+func NewFake(reqs ...fakeRequirement) uranus.CourierTracker {
+	t := &fakeTracker{
+		couriers: map[string]bool{"jne": true, "jnt": true, "sicepat": true, "anteraja": true, "pos": true},
+		step:     6 * time.Hour,
+		seen:     make(map[string]time.Time),
+	}
+	for _, req := range reqs {
+		req(t)
+	}
+
+	return t
+}
+
+func (t *fakeTracker) Track(ctx context.Context, courier string, trackingNumber string) (*models.CourierTracking, error) {
+	if ctx == nil {
+		return nil, uranus.ErrContextNil
+	}
+
+	courier = strings.ToLower(courier)
+	if !t.couriers[courier] || trackingNumber == "" {
+		return nil, uranus.ErrNotFound
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	key := courier + "/" + trackingNumber
+	pickedUp, ok := t.seen[key]
+	if !ok {
+		pickedUp = time.Now()
+		t.seen[key] = pickedUp
+	}
+
+	tracking := &models.CourierTracking{
+		Courier:        courier,
+		TrackingNumber: trackingNumber,
+		Checkpoints:    make([]models.CourierCheckpoint, 0, len(fakeSteps)),
+	}
+
+	for i, step := range fakeSteps {
+		step.At = pickedUp.Add(time.Duration(i) * t.step)
+		if step.At.After(time.Now()) {
+			break
+		}
+
+		tracking.Checkpoints = append(tracking.Checkpoints, step)
+	}
+
+	return tracking, nil
+}
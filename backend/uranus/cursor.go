@@ -0,0 +1,134 @@
+package uranus
+
+import (
+	"strings"
+
+	"github.com/globalsign/mgo/bson"
+)
+
+// MaxPageSize caps how many items a single page may hold, whatever the client asks for.
+const MaxPageSize = 100
+
+// PageSize clamps the number of items asked for a page to 1..MaxPageSize.
+func PageSize(num int) int {
+	if num < 1 {
+		return 1
+	}
+
+	if num > MaxPageSize {
+		return MaxPageSize
+	}
+
+	return num
+}
+
+// Page holds the opaque cursors around a fetched page, a cursor is empty when there is nothing more that way.
+// Total counts every item matching the filter, not only the ones on the page.
+type Page struct {
+	Next  string
+	Prev  string
+	Total int
+}
+
+// Cursor is a position in a list sorted on one field and then _id in the same direction.
+// The _id breaks ties between items sharing the sort value, e.g. created in the same millisecond.
+type Cursor struct {
+	Sort     string
+	Value    interface{}
+	ID       bson.ObjectId
+	Backward bool
+}
+
+// EncodeCursor builds the opaque cursor of the item, backward cursors read the items before it.
+func EncodeCursor(sort string, value interface{}, id bson.ObjectId, backward bool) string {
+	cursor, err := CreateCursor(bson.D{
+		{Name: "sort", Value: sort},
+		{Name: "value", Value: value},
+		{Name: "_id", Value: id},
+		{Name: "backward", Value: backward},
+	})
+	if err != nil {
+		return ""
+	}
+
+	return cursor
+}
+
+// DecodeCursor reads back a cursor made by EncodeCursor for the same sort, anything else is ErrInvalidCursor.
+func DecodeCursor(c, sort string) (*Cursor, error) {
+	data, err := ParseCursor(c)
+	if err != nil || len(data) != 4 {
+		return nil, ErrInvalidCursor
+	}
+
+	cursor := new(Cursor)
+	var ok bool
+	if cursor.Sort, ok = data[0].Value.(string); !ok || data[0].Name != "sort" || cursor.Sort != sort {
+		return nil, ErrInvalidCursor
+	}
+
+	if data[1].Name != "value" {
+		return nil, ErrInvalidCursor
+	}
+	cursor.Value = data[1].Value
+
+	if cursor.ID, ok = data[2].Value.(bson.ObjectId); !ok || data[2].Name != "_id" || !cursor.ID.Valid() {
+		return nil, ErrInvalidCursor
+	}
+
+	if cursor.Backward, ok = data[3].Value.(bool); !ok || data[3].Name != "backward" {
+		return nil, ErrInvalidCursor
+	}
+
+	return cursor, nil
+}
+
+// Range matches the items past the cursor in its direction.
+func (c *Cursor) Range() bson.M {
+	field, descending := sortField(c.Sort)
+	op := "$gt"
+	if descending != c.Backward {
+		op = "$lt"
+	}
+
+	return bson.M{"$or": []bson.M{
+		{field: bson.M{op: c.Value}},
+		{field: c.Value, "_id": bson.M{op: c.ID}},
+	}}
+}
+
+// SortOrder is the order to read from the cursor in, backward pages come out reversed and callers flip them.
+func SortOrder(sort string, cursor *Cursor) []string {
+	field, descending := sortField(sort)
+	if cursor != nil && cursor.Backward {
+		descending = !descending
+	}
+
+	if descending {
+		return []string{"-" + field, "-_id"}
+	}
+
+	return []string{field, "_id"}
+}
+
+// NewPage builds the cursors around a page read from cursor, which is nil on the first page.
+// hasMore tells whether the read found items beyond the page in the direction it went.
+func NewPage(cursor *Cursor, sort string, hasMore bool, firstValue interface{}, firstID bson.ObjectId, lastValue interface{}, lastID bson.ObjectId) Page {
+	backward := cursor != nil && cursor.Backward
+	page := Page{}
+
+	// Reading backward always came from a later page, reading forward from a cursor always came from an earlier one.
+	if hasMore || backward {
+		page.Next = EncodeCursor(sort, lastValue, lastID, false)
+	}
+
+	if (hasMore && backward) || (cursor != nil && !backward) {
+		page.Prev = EncodeCursor(sort, firstValue, firstID, true)
+	}
+
+	return page
+}
+
+func sortField(sort string) (field string, descending bool) {
+	return strings.TrimPrefix(sort, "-"), strings.HasPrefix(sort, "-")
+}
@@ -0,0 +1,134 @@
+package uranus
+
+import (
+	"encoding/base64"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/globalsign/mgo/bson"
+)
+
+func TestCursorRoundTrip(t *testing.T) {
+	id := bson.NewObjectId()
+	createdAt := time.Date(2026, 3, 14, 15, 9, 26, 535000000, time.UTC)
+
+	tests := []struct {
+		name     string
+		sort     string
+		value    interface{}
+		backward bool
+	}{
+		{name: "time forward", sort: "-created_at", value: createdAt},
+		{name: "time backward", sort: "-created_at", value: createdAt, backward: true},
+		{name: "string", sort: "email", value: "ana@example.com"},
+		{name: "number", sort: "-rating", value: 4.5, backward: true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			encoded := EncodeCursor(test.sort, test.value, id, test.backward)
+			if encoded == "" {
+				t.Fatalf("EncodeCursor gave an empty cursor")
+			}
+
+			cursor, err := DecodeCursor(encoded, test.sort)
+			if err != nil {
+				t.Fatalf("DecodeCursor: %v", err)
+			}
+
+			if cursor.Sort != test.sort || cursor.ID != id || cursor.Backward != test.backward {
+				t.Errorf("decoded %+v, want sort %s, id %s, backward %v", cursor, test.sort, id.Hex(), test.backward)
+			}
+
+			if want, ok := test.value.(time.Time); ok {
+				if got, ok := cursor.Value.(time.Time); !ok || !got.Equal(want) {
+					t.Errorf("decoded value %v, want %v", cursor.Value, want)
+				}
+			} else if !reflect.DeepEqual(cursor.Value, test.value) {
+				t.Errorf("decoded value %v, want %v", cursor.Value, test.value)
+			}
+		})
+	}
+}
+
+func TestDecodeCursorRejectsTampering(t *testing.T) {
+	id := bson.NewObjectId()
+	valid := EncodeCursor("-created_at", time.Now(), id, false)
+
+	raw, err := base64.RawURLEncoding.DecodeString(valid)
+	if err != nil {
+		t.Fatalf("cursor isn't base64: %v", err)
+	}
+
+	// The first bytes hold the document's length, a cursor that was cut or padded on the way doesn't parse.
+	corrupted := append([]byte(nil), raw...)
+	corrupted[0] ^= 0xff
+
+	invalidID, _ := CreateCursor(bson.D{
+		{Name: "sort", Value: "-created_at"},
+		{Name: "value", Value: time.Now()},
+		{Name: "_id", Value: bson.ObjectId("short")},
+		{Name: "backward", Value: false},
+	})
+
+	reordered, _ := CreateCursor(bson.D{
+		{Name: "value", Value: time.Now()},
+		{Name: "sort", Value: "-created_at"},
+		{Name: "_id", Value: id},
+		{Name: "backward", Value: false},
+	})
+
+	missingField, _ := CreateCursor(bson.D{
+		{Name: "sort", Value: "-created_at"},
+		{Name: "value", Value: time.Now()},
+		{Name: "_id", Value: id},
+	})
+
+	tests := []struct {
+		name   string
+		cursor string
+		sort   string
+	}{
+		{name: "another sort", cursor: valid, sort: "created_at"},
+		{name: "not base64", cursor: "not a cursor!", sort: "-created_at"},
+		{name: "corrupted length", cursor: base64.RawURLEncoding.EncodeToString(corrupted), sort: "-created_at"},
+		{name: "truncated", cursor: valid[:len(valid)/2], sort: "-created_at"},
+		{name: "invalid id", cursor: invalidID, sort: "-created_at"},
+		{name: "fields out of order", cursor: reordered, sort: "-created_at"},
+		{name: "missing field", cursor: missingField, sort: "-created_at"},
+	}
+
+	for _, test := range tests {
+		if _, err := DecodeCursor(test.cursor, test.sort); err != ErrInvalidCursor {
+			t.Errorf("%s: DecodeCursor error = %v, want %v", test.name, err, ErrInvalidCursor)
+		}
+	}
+}
+
+func TestSortOrder(t *testing.T) {
+	tests := []struct {
+		sort   string
+		cursor *Cursor
+		want   []string
+	}{
+		{sort: "-created_at", want: []string{"-created_at", "-_id"}},
+		{sort: "created_at", cursor: &Cursor{}, want: []string{"created_at", "_id"}},
+		{sort: "-created_at", cursor: &Cursor{Backward: true}, want: []string{"created_at", "_id"}},
+		{sort: "email", cursor: &Cursor{Backward: true}, want: []string{"-email", "-_id"}},
+	}
+
+	for _, test := range tests {
+		if got := SortOrder(test.sort, test.cursor); !reflect.DeepEqual(got, test.want) {
+			t.Errorf("SortOrder(%s, %+v) = %v, want %v", test.sort, test.cursor, got, test.want)
+		}
+	}
+}
+
+func TestPageSize(t *testing.T) {
+	for num, want := range map[int]int{-5: 1, 0: 1, 1: 1, 20: 20, MaxPageSize: MaxPageSize, MaxPageSize + 1: MaxPageSize} {
+		if got := PageSize(num); got != want {
+			t.Errorf("PageSize(%d) = %d, want %d", num, got, want)
+		}
+	}
+}
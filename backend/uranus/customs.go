@@ -0,0 +1,21 @@
+package uranus
+
+import (
+	"context"
+
+	"github.com/fidellr/jastip/backend/uranus/models"
+)
+
+type CustomsUsecase interface {
+	CustomsChecker
+	CheckPurchaseRequestByID(ctx context.Context, requestID string) (*models.CustomsCheck, error)
+	CheckOrderByID(ctx context.Context, orderID string) (*models.CustomsCheck, error)
+	GetCustomsRules(ctx context.Context) (*models.CustomsRules, error)
+}
+
+// CustomsChecker checks goods against the import rules of the country they are brought into.
+// A check that finds blocking issues is still returned, CustomsErrorOf tells the caller whether to stop.
+type CustomsChecker interface {
+	CheckPurchaseRequest(ctx context.Context, m *models.PurchaseRequest) (*models.CustomsCheck, error)
+	CheckOrder(ctx context.Context, m *models.Order) (*models.CustomsCheck, error)
+}
@@ -0,0 +1,124 @@
+package customs
+
+import (
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/fidellr/jastip/backend/uranus/models"
+)
+
+// Check checks the goods against the import rules of the country, carried being what the same passenger
+// already brings in. Nil rules only warn that nothing is known, and goods whose value isn't in the rules' currency,
+// e.g. because no exchange rate was in effect, are only checked by category with a warning that duty is unknown.
+func Check(country string, rules *models.CountryCustoms, goods models.CustomsGoods, carried []models.CustomsGoods) (*models.CustomsCheck, error) {
+	check := &models.CustomsCheck{
+		Country:   country,
+		Goods:     goods,
+		Carried:   goods.Value,
+		Warnings:  make([]models.CustomsIssue, 0),
+		Errors:    make([]models.CustomsIssue, 0),
+		CheckedAt: time.Now(),
+	}
+
+	if rules == nil {
+		check.EstimatedDuty = models.Money{Currency: goods.Value.Currency}
+		warn(check, models.CustomsUnknownCountry, "No import rules are known for %s, duty can't be estimated", country)
+		return check, nil
+	}
+
+	quantity := goods.Quantity
+	for _, other := range carried {
+		if other.Category == goods.Category {
+			quantity += other.Quantity
+		}
+	}
+
+	if contains(rules.Prohibited, goods.Category) {
+		block(check, models.CustomsProhibited, "%s goods can't be brought into %s", goods.Category, country)
+	}
+
+	if note, ok := rules.Restricted[goods.Category]; ok {
+		warn(check, models.CustomsRestricted, "%s goods are restricted in %s: %s", goods.Category, country, note)
+	}
+
+	if limit, ok := rules.QuantityCaps[goods.Category]; ok && quantity > limit {
+		block(check, models.CustomsQuantityCap, "A passenger may bring %d %s items into %s, this makes %d", limit, goods.Category, country, quantity)
+	}
+
+	check.DutyFreeLimit = models.Money{Amount: rules.DutyFreeLimit, Currency: rules.Currency}
+	if goods.Value.Currency != rules.Currency {
+		check.EstimatedDuty = models.Money{Currency: rules.Currency}
+		warn(check, models.CustomsUnknownValue, "%s can't be converted to %s yet, duty can't be estimated", goods.Value, rules.Currency)
+		return check, nil
+	}
+
+	var err error
+	before := models.Money{Currency: rules.Currency}
+	for _, other := range carried {
+		if before, err = before.Add(other.Value); err != nil {
+			return nil, err
+		}
+	}
+
+	if check.Carried, err = before.Add(goods.Value); err != nil {
+		return nil, err
+	}
+
+	check.EstimatedDuty = models.Money{Amount: duty(rules, goods.Category, before.Amount, check.Carried.Amount), Currency: rules.Currency}
+
+	switch {
+	case check.Carried.Amount > rules.DutyFreeLimit:
+		warn(check, models.CustomsOverDutyFreeLimit, "%s is over the duty-free limit of %s, expect about %s of duty and taxes", check.Carried, check.DutyFreeLimit, check.EstimatedDuty)
+	case rules.WarnAtPercent > 0 && float64(check.Carried.Amount) >= float64(rules.DutyFreeLimit)*rules.WarnAtPercent/100:
+		warn(check, models.CustomsNearDutyFreeLimit, "%s is close to the duty-free limit of %s", check.Carried, check.DutyFreeLimit)
+	}
+
+	return check, nil
+}
+
+// duty estimates the duty and taxes the goods add when the carried value goes from before to after.
+// Only the part over the duty-free limit the goods are responsible for is charged.
+func duty(rules *models.CountryCustoms, category string, before int64, after int64) int64 {
+	taxable := excess(after, rules.DutyFreeLimit) - excess(before, rules.DutyFreeLimit)
+	if taxable <= 0 {
+		return 0
+	}
+
+	percent := rules.DutyPercent
+	if categoryPercent, ok := rules.CategoryDutyPercents[category]; ok {
+		percent = categoryPercent
+	}
+
+	duty := math.Round(float64(taxable) * percent / 100)
+	tax := math.Round((float64(taxable) + duty) * rules.TaxPercent / 100)
+	return int64(duty + tax)
+}
+
+func excess(value int64, limit int64) int64 {
+	if value <= limit {
+		return 0
+	}
+
+	return value - limit
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+
+	return false
+}
+
+// warn adds an issue that lets the goods through.
+func warn(check *models.CustomsCheck, code string, format string, args ...interface{}) {
+	check.Warnings = append(check.Warnings, models.CustomsIssue{Code: code, Message: fmt.Sprintf(format, args...)})
+}
+
+// block adds an issue that stops the goods.
+func block(check *models.CustomsCheck, code string, format string, args ...interface{}) {
+	check.Errors = append(check.Errors, models.CustomsIssue{Code: code, Message: fmt.Sprintf(format, args...), Blocking: true})
+}
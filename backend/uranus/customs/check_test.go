@@ -0,0 +1,174 @@
+package customs
+
+import (
+	"testing"
+
+	"github.com/fidellr/jastip/backend/uranus/models"
+)
+
+var testRules = map[string]*models.CountryCustoms{
+	"ID": {
+		Currency:             "USD",
+		DutyFreeLimit:        50000,
+		WarnAtPercent:        80,
+		DutyPercent:          10,
+		TaxPercent:           21,
+		CategoryDutyPercents: map[string]float64{"fashion": 25},
+		Restricted:           map[string]string{"health": "needs a permit"},
+		QuantityCaps:         map[string]int{"electronics": 2},
+	},
+	"SG": {
+		Currency:      "SGD",
+		DutyFreeLimit: 50000,
+		WarnAtPercent: 80,
+		TaxPercent:    9,
+		Prohibited:    []string{"tobacco"},
+	},
+}
+
+func TestCheck(t *testing.T) {
+	usd := func(amount int64) models.Money { return models.Money{Amount: amount, Currency: "USD"} }
+	sgd := func(amount int64) models.Money { return models.Money{Amount: amount, Currency: "SGD"} }
+
+	tests := []struct {
+		name     string
+		country  string
+		goods    models.CustomsGoods
+		carried  []models.CustomsGoods
+		warnings []string
+		errors   []string
+		duty     int64
+	}{
+		{
+			name:    "under the duty-free limit",
+			country: "ID",
+			goods:   models.CustomsGoods{Category: "electronics", Quantity: 1, Value: usd(20000)},
+		},
+		{
+			name:     "close to the duty-free limit",
+			country:  "ID",
+			goods:    models.CustomsGoods{Category: "electronics", Quantity: 1, Value: usd(45000)},
+			warnings: []string{models.CustomsNearDutyFreeLimit},
+		},
+		{
+			name:     "over the duty-free limit pays duty and tax on the excess",
+			country:  "ID",
+			goods:    models.CustomsGoods{Category: "electronics", Quantity: 1, Value: usd(60000)},
+			warnings: []string{models.CustomsOverDutyFreeLimit},
+			duty:     3310,
+		},
+		{
+			name:     "category duty replaces the country's",
+			country:  "ID",
+			goods:    models.CustomsGoods{Category: "fashion", Quantity: 1, Value: usd(60000)},
+			warnings: []string{models.CustomsOverDutyFreeLimit},
+			duty:     5125,
+		},
+		{
+			name:     "goods only pay for the excess they add to what's carried",
+			country:  "ID",
+			goods:    models.CustomsGoods{Category: "fashion", Quantity: 1, Value: usd(20000)},
+			carried:  []models.CustomsGoods{{Category: "books", Quantity: 3, Value: usd(40000)}},
+			warnings: []string{models.CustomsOverDutyFreeLimit},
+			duty:     5125,
+		},
+		{
+			name:     "restricted category warns",
+			country:  "ID",
+			goods:    models.CustomsGoods{Category: "health", Quantity: 1, Value: usd(1000)},
+			warnings: []string{models.CustomsRestricted},
+		},
+		{
+			name:    "quantity cap counts what's carried",
+			country: "ID",
+			goods:   models.CustomsGoods{Category: "electronics", Quantity: 1, Value: usd(1000)},
+			carried: []models.CustomsGoods{{Category: "electronics", Quantity: 2, Value: usd(1000)}},
+			errors:  []string{models.CustomsQuantityCap},
+		},
+		{
+			name:    "another country has no quantity cap",
+			country: "SG",
+			goods:   models.CustomsGoods{Category: "electronics", Quantity: 3, Value: sgd(1000)},
+		},
+		{
+			name:     "another country has its own tax",
+			country:  "SG",
+			goods:    models.CustomsGoods{Category: "fashion", Quantity: 1, Value: sgd(60000)},
+			warnings: []string{models.CustomsOverDutyFreeLimit},
+			duty:     900,
+		},
+		{
+			name:    "prohibited category blocks",
+			country: "SG",
+			goods:   models.CustomsGoods{Category: "tobacco", Quantity: 1, Value: sgd(1000)},
+			errors:  []string{models.CustomsProhibited},
+		},
+		{
+			name:     "value that couldn't be converted only warns",
+			country:  "ID",
+			goods:    models.CustomsGoods{Category: "electronics", Quantity: 1, Value: models.Money{Amount: 9000000000, Currency: "IDR"}},
+			warnings: []string{models.CustomsUnknownValue},
+		},
+		{
+			name:     "value that couldn't be converted is still checked by category",
+			country:  "ID",
+			goods:    models.CustomsGoods{Category: "electronics", Quantity: 3, Value: models.Money{Amount: 100000, Currency: "IDR"}},
+			warnings: []string{models.CustomsUnknownValue},
+			errors:   []string{models.CustomsQuantityCap},
+		},
+		{
+			name:     "unknown country",
+			country:  "XX",
+			goods:    models.CustomsGoods{Category: "electronics", Quantity: 1, Value: usd(60000)},
+			warnings: []string{models.CustomsUnknownCountry},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			check, err := Check(test.country, testRules[test.country], test.goods, test.carried)
+			if err != nil {
+				t.Fatalf("Check: %v", err)
+			}
+
+			if got := codes(check.Warnings); !equalStrings(got, test.warnings) {
+				t.Errorf("warnings = %v, want %v", got, test.warnings)
+			}
+
+			if got := codes(check.Errors); !equalStrings(got, test.errors) {
+				t.Errorf("errors = %v, want %v", got, test.errors)
+			}
+
+			if check.EstimatedDuty.Amount != test.duty {
+				t.Errorf("estimated duty = %s, want %d", check.EstimatedDuty, test.duty)
+			}
+
+			if rules := testRules[test.country]; rules != nil && check.EstimatedDuty.Currency != rules.Currency {
+				t.Errorf("estimated duty is in %s, want %s", check.EstimatedDuty.Currency, rules.Currency)
+			}
+		})
+	}
+}
+
+func codes(issues []models.CustomsIssue) []string {
+	codes := make([]string, 0, len(issues))
+	for _, issue := range issues {
+		codes = append(codes, issue.Code)
+	}
+
+	return codes
+}
+
+func equalStrings(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+
+	return true
+}
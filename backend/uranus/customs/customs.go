@@ -0,0 +1,307 @@
+package customs
+
+import (
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"log"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/fidellr/jastip/backend/uranus"
+	"github.com/fidellr/jastip/backend/uranus/auth"
+	"github.com/fidellr/jastip/backend/uranus/models"
+	"github.com/fidellr/jastip/backend/uranus/repository"
+)
+
+type service struct {
+	orderRepository           repository.OrderRepository
+	tripRepository            repository.TripRepository
+	purchaseRequestRepository repository.PurchaseRequestRepository
+	userRepository            repository.UserAccountRepository
+	converter                 uranus.Converter
+	contextTimeout            time.Duration
+
+	// The rules are read from rulesFile again whenever it changes, so they can be edited on a running server.
+	rulesFile string
+	mu        sync.Mutex
+	rules     *models.CustomsRules
+	modTime   time.Time
+}
+
+// CheckPurchaseRequest checks the request's items at the buyer's max price against the customs of the buyer's
+// shipping country, or the default country when the buyer didn't give one.
+func (s *service) CheckPurchaseRequest(ctx context.Context, m *models.PurchaseRequest) (*models.CustomsCheck, error) {
+	if ctx == nil {
+		err := uranus.ErrContextNil
+		return nil, err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, s.contextTimeout)
+	defer cancel()
+
+	rules, err := s.currentRules()
+	if err != nil {
+		return nil, err
+	}
+
+	country := rules.DefaultCountry
+	if m.BuyerID != "" {
+		buyer, err := s.userRepository.GetUserByID(ctx, m.BuyerID.Hex())
+		if err != nil {
+			return nil, err
+		}
+
+		if buyer.BuyerProfile != nil && buyer.BuyerProfile.Shipping.Country != "" {
+			country = strings.ToUpper(buyer.BuyerProfile.Shipping.Country)
+		}
+	}
+
+	// Without an exchange rate the request is still checked by category, the check warns that duty is unknown.
+	countryRules := rules.Countries[country]
+	goods, err := s.goods(ctx, countryRules, m.Category, m.Quantity, m.MaxPrice.Times(m.Quantity), time.Now())
+	if err != nil && err != uranus.ErrNoExchangeRate {
+		return nil, err
+	}
+
+	return Check(country, countryRules, goods, nil)
+}
+
+// CheckOrder checks the order against the customs of the country the trip returns to,
+// together with everything else the traveler carries back on that trip.
+func (s *service) CheckOrder(ctx context.Context, m *models.Order) (*models.CustomsCheck, error) {
+	if ctx == nil {
+		err := uranus.ErrContextNil
+		return nil, err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, s.contextTimeout)
+	defer cancel()
+
+	rules, err := s.currentRules()
+	if err != nil {
+		return nil, err
+	}
+
+	trip, err := s.tripRepository.GetTripByID(ctx, m.TripID.Hex())
+	if err != nil {
+		return nil, err
+	}
+
+	country := trip.Origin.Country
+	countryRules := rules.Countries[country]
+	goods, err := s.goods(ctx, countryRules, m.Category, m.Quantity, m.ItemPrice.Times(m.Quantity), m.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+
+	orders, err := s.orderRepository.FetchOrdersByTrip(ctx, trip.ID.Hex())
+	if err != nil {
+		return nil, err
+	}
+
+	carried := make([]models.CustomsGoods, 0, len(orders))
+	for _, order := range orders {
+		if order.ID == m.ID || order.Status == models.OrderCancelled {
+			continue
+		}
+
+		other, err := s.goods(ctx, countryRules, order.Category, order.Quantity, order.ItemPrice.Times(order.Quantity), order.CreatedAt)
+		if err != nil {
+			return nil, err
+		}
+
+		carried = append(carried, other)
+	}
+
+	return Check(country, countryRules, goods, carried)
+}
+
+func (s *service) CheckPurchaseRequestByID(ctx context.Context, requestID string) (*models.CustomsCheck, error) {
+	if ctx == nil {
+		err := uranus.ErrContextNil
+		return nil, err
+	}
+
+	request, err := s.purchaseRequestRepository.GetPurchaseRequestByID(ctx, requestID)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.CheckPurchaseRequest(ctx, request)
+}
+
+// CheckOrderByID checks an order again under the current rules, for the buyer, the traveler or an admin.
+func (s *service) CheckOrderByID(ctx context.Context, orderID string) (*models.CustomsCheck, error) {
+	if ctx == nil {
+		err := uranus.ErrContextNil
+		return nil, err
+	}
+
+	order, err := s.orderRepository.GetOrderByID(ctx, orderID)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, _, err = auth.AuthorizeParty(ctx, auth.ActionManageOrders, order.PartyOf); err != nil {
+		return nil, err
+	}
+
+	return s.CheckOrder(ctx, order)
+}
+
+func (s *service) GetCustomsRules(ctx context.Context) (*models.CustomsRules, error) {
+	if ctx == nil {
+		err := uranus.ErrContextNil
+		return nil, err
+	}
+
+	return s.currentRules()
+}
+
+// goods converts the value into the currency of the country's rules, unknown countries keep the value as it is.
+func (s *service) goods(ctx context.Context, rules *models.CountryCustoms, category string, quantity int, value models.Money, at time.Time) (models.CustomsGoods, error) {
+	goods := models.CustomsGoods{Category: category, Quantity: quantity, Value: value}
+	if rules == nil {
+		return goods, nil
+	}
+
+	converted, _, err := s.converter.Convert(ctx, value, rules.Currency, at)
+	if err != nil {
+		return goods, err
+	}
+
+	goods.Value = converted
+	return goods, nil
+}
+
+// currentRules reads the rules file again when it changed since the last read.
+// A file that doesn't parse anymore is logged and the rules read before stay in force.
+func (s *service) currentRules() (*models.CustomsRules, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	info, err := os.Stat(s.rulesFile)
+	if err != nil {
+		if s.rules != nil {
+			log.Printf("Failed to stat customs rules %s, keeping the last rules : %s", s.rulesFile, err.Error())
+			return s.rules, nil
+		}
+
+		return nil, err
+	}
+
+	if s.rules != nil && info.ModTime().Equal(s.modTime) {
+		return s.rules, nil
+	}
+
+	rules, err := ReadRules(s.rulesFile)
+	if err != nil {
+		if s.rules != nil {
+			log.Printf("Failed to reload customs rules %s, keeping the last rules : %s", s.rulesFile, err.Error())
+			return s.rules, nil
+		}
+
+		return nil, err
+	}
+
+	s.rules = rules
+	s.modTime = info.ModTime()
+	return s.rules, nil
+}
+
+// ReadRules reads customs rules from a JSON file, country and currency codes are upper-cased.
+func ReadRules(path string) (*models.CustomsRules, error) {
+	content, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	rules := new(models.CustomsRules)
+	if err = json.Unmarshal(content, rules); err != nil {
+		return nil, err
+	}
+
+	rules.DefaultCountry = strings.ToUpper(rules.DefaultCountry)
+	countries := make(map[string]*models.CountryCustoms, len(rules.Countries))
+	for country, countryRules := range rules.Countries {
+		if countryRules == nil {
+			return nil, uranus.ConstraintErrorf("Customs rules of %s are empty, remove the country to leave it unknown", country)
+		}
+
+		countryRules.Currency = strings.ToUpper(countryRules.Currency)
+		if !models.IsCurrency(countryRules.Currency) {
+			return nil, uranus.ConstraintErrorf("Customs rules of %s are in unknown currency %s", country, countryRules.Currency)
+		}
+
+		if countryRules.DutyFreeLimit < 0 || countryRules.DutyPercent < 0 || countryRules.TaxPercent < 0 {
+			return nil, uranus.ConstraintErrorf("Customs rules of %s can't have a negative limit or percentage", country)
+		}
+
+		countries[strings.ToUpper(country)] = countryRules
+	}
+
+	rules.Countries = countries
+	return rules, nil
+}
+
+type requirement func(*service)
+
+func OrderRepository(orderRepository repository.OrderRepository) requirement {
+	return func(s *service) {
+		s.orderRepository = orderRepository
+	}
+}
+
+func TripRepository(tripRepository repository.TripRepository) requirement {
+	return func(s *service) {
+		s.tripRepository = tripRepository
+	}
+}
+
+func PurchaseRequestRepository(purchaseRequestRepository repository.PurchaseRequestRepository) requirement {
+	return func(s *service) {
+		s.purchaseRequestRepository = purchaseRequestRepository
+	}
+}
+
+func UserAccountRepository(userRepository repository.UserAccountRepository) requirement {
+	return func(s *service) {
+		s.userRepository = userRepository
+	}
+}
+
+func Converter(converter uranus.Converter) requirement {
+	return func(s *service) {
+		s.converter = converter
+	}
+}
+
+// RulesFile sets the JSON file the customs rules are read from, edits to it apply without a restart.
+func RulesFile(path string) requirement {
+	return func(s *service) {
+		s.rulesFile = path
+	}
+}
+
+func Timeout(timeout time.Duration) requirement {
+	return func(s *service) {
+		s.contextTimeout = timeout
+	}
+}
+
+// NewService returns the customs usecase, a rules file that can't be read at start is an error.
+func NewService(reqs ...requirement) (uranus.CustomsUsecase, error) {
+	s := new(service)
+	for _, option := range reqs {
+		option(s)
+	}
+
+	if _, err := s.currentRules(); err != nil {
+		return nil, err
+	}
+
+	return s, nil
+}
@@ -0,0 +1,91 @@
+package customs
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/fidellr/jastip/backend/uranus"
+)
+
+func TestReadRules(t *testing.T) {
+	tests := []struct {
+		name           string
+		content        string
+		wantConstraint bool
+		wantErr        bool
+	}{
+		{
+			name:    "country and currency codes are upper-cased",
+			content: `{"default_country": "id", "countries": {"id": {"currency": "usd", "duty_free_limit": 50000, "tax_percent": 11}}}`,
+		},
+		{
+			name:           "empty country rules",
+			content:        `{"countries": {"ID": null}}`,
+			wantConstraint: true,
+		},
+		{
+			name:           "unknown currency",
+			content:        `{"countries": {"ID": {"currency": "XYZ", "duty_free_limit": 50000}}}`,
+			wantConstraint: true,
+		},
+		{
+			name:           "missing currency",
+			content:        `{"countries": {"ID": {"duty_free_limit": 50000}}}`,
+			wantConstraint: true,
+		},
+		{
+			name:           "negative duty-free limit",
+			content:        `{"countries": {"ID": {"currency": "USD", "duty_free_limit": -1}}}`,
+			wantConstraint: true,
+		},
+		{
+			name:           "negative tax",
+			content:        `{"countries": {"ID": {"currency": "USD", "duty_free_limit": 50000, "tax_percent": -11}}}`,
+			wantConstraint: true,
+		},
+		{
+			name:    "malformed file",
+			content: `{"countries": {`,
+			wantErr: true,
+		},
+	}
+
+	dir, err := ioutil.TempDir("", "customs")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	for i, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			path := filepath.Join(dir, string(rune('a'+i))+".json")
+			if err := ioutil.WriteFile(path, []byte(test.content), 0600); err != nil {
+				t.Fatal(err)
+			}
+
+			rules, err := ReadRules(path)
+			if test.wantConstraint {
+				if _, ok := err.(uranus.ConstraintError); !ok {
+					t.Fatalf("ReadRules error = %v, want a ConstraintError", err)
+				}
+
+				return
+			}
+
+			if (err != nil) != test.wantErr {
+				t.Fatalf("ReadRules error = %v, wantErr %v", err, test.wantErr)
+			}
+
+			if test.wantErr {
+				return
+			}
+
+			country, ok := rules.Countries["ID"]
+			if rules.DefaultCountry != "ID" || !ok || country.Currency != "USD" {
+				t.Errorf("rules = %+v, want ID rules in USD", rules)
+			}
+		})
+	}
+}
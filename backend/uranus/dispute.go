@@ -0,0 +1,31 @@
+package uranus
+
+import (
+	"context"
+
+	"github.com/fidellr/jastip/backend/uranus/models"
+)
+
+type DisputeUsecase interface {
+	OpenDispute(ctx context.Context, orderID string, m *models.DisputeOpening) (*models.Dispute, error)
+	FetchDisputes(ctx context.Context, filter *DisputeFilter) ([]*models.Dispute, Page, error)
+	GetDisputeByID(ctx context.Context, disputeID string) (*models.Dispute, error)
+	PostDisputeMessage(ctx context.Context, disputeID string, m *models.DisputeMessage) error
+	FetchDisputeMessages(ctx context.Context, disputeID string) ([]*models.DisputeMessage, error)
+	ResolveDispute(ctx context.Context, disputeID string, m *models.DisputeResolution) (*models.Dispute, error)
+	EscalateOverdueDisputes(ctx context.Context) (int, error)
+	SettleResolvedDisputes(ctx context.Context) (int, error)
+}
+
+// DisputeSort lists the newest disputes first.
+const DisputeSort = "-created_at"
+
+// DisputeFilter narrows disputes down to the ones of a party, an order or a status, like the escalated queue.
+type DisputeFilter struct {
+	Num    int
+	Cursor string
+
+	PartyID string
+	OrderID string
+	Status  string
+}
@@ -0,0 +1,683 @@
+package dispute
+
+import (
+	"context"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/globalsign/mgo/bson"
+
+	"github.com/fidellr/jastip/backend/uranus"
+	"github.com/fidellr/jastip/backend/uranus/auth"
+	"github.com/fidellr/jastip/backend/uranus/models"
+	"github.com/fidellr/jastip/backend/uranus/repository"
+)
+
+// unsettledBatch caps how many resolved disputes one sweep settles.
+const unsettledBatch = 100
+
+type service struct {
+	repository      repository.DisputeRepository
+	orderRepository repository.OrderRepository
+	userRepository  repository.UserAccountRepository
+	images          uranus.ImageStore
+	escrow          uranus.Escrow
+	follower        uranus.OrderFollower
+	window          time.Duration
+	sla             time.Duration
+	validator       uranus.Validate
+	contextTimeout  time.Duration
+}
+
+// OpenDispute has the buyer, or an admin for them, dispute the order. An order that was delivered
+// can only be disputed within the dispute window after its delivery.
+func (s *service) OpenDispute(ctx context.Context, orderID string, m *models.DisputeOpening) (*models.Dispute, error) {
+	if ctx == nil {
+		err := uranus.ErrContextNil
+		return nil, err
+	}
+
+	m.Description = strings.TrimSpace(m.Description)
+	if err := s.validator.ValidateStruct(m); err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, s.contextTimeout)
+	defer cancel()
+
+	order, err := s.orderRepository.GetOrderByID(ctx, orderID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err = auth.AuthorizeOwner(ctx, auth.ActionMediateDisputes, order.BuyerID.Hex()); err != nil {
+		return nil, err
+	}
+
+	principal, _ := auth.FromContext(ctx)
+	party := order.PartyOf(principal.UserID)
+	if party != models.OrderPartyBuyer {
+		party = models.OrderPartyAdmin
+	}
+
+	if !order.CanMove(party, models.OrderDisputed) {
+		return nil, &uranus.IllegalTransitionError{From: order.Status, To: models.OrderDisputed, Party: party}
+	}
+
+	now := time.Now()
+	if deliveredAt := deliveredAt(order); deliveredAt != nil && now.After(deliveredAt.Add(s.window)) {
+		return nil, uranus.ConstraintErrorf("Order was delivered on %s, it could only be disputed until %s",
+			deliveredAt.Format(time.RFC3339), deliveredAt.Add(s.window).Format(time.RFC3339))
+	}
+
+	if err = s.images.CheckImages(ctx, principal.UserID, m.ImageIDs); err != nil {
+		return nil, err
+	}
+
+	transition := models.OrderTransition{
+		From:   order.Status,
+		To:     models.OrderDisputed,
+		Party:  party,
+		Reason: "Disputed as " + strings.Replace(m.Reason, "_", " ", -1),
+		At:     now,
+	}
+
+	if bson.IsObjectIdHex(principal.UserID) {
+		transition.ActorID = bson.ObjectIdHex(principal.UserID)
+	}
+
+	disputeID := bson.NewObjectId()
+	message := models.NewOutboxMessage(order.TravelerID.Hex(), models.NotifyDisputeOpened, map[string]string{
+		"dispute_id": disputeID.Hex(),
+		"order_id":   order.ID.Hex(),
+		"reason":     m.Reason,
+	})
+
+	dispute := &models.Dispute{
+		ID:          disputeID,
+		CreatedAt:   now,
+		UpdatedAt:   now,
+		OrderID:     order.ID,
+		BuyerID:     order.BuyerID,
+		TravelerID:  order.TravelerID,
+		Reason:      m.Reason,
+		Description: m.Description,
+		ImageIDs:    m.ImageIDs,
+		OrderStatus: order.Status,
+		Status:      models.DisputeOpen,
+		EscalateAt:  now.Add(s.sla),
+	}
+
+	// The dispute is stored first, the unique order index keeps a second dispute of the same order from going through.
+	if err = s.repository.StoreDispute(ctx, dispute); err != nil {
+		return nil, err
+	}
+
+	if err = s.orderRepository.AppendOrderTransition(ctx, orderID, transition, message); err != nil {
+		if removeErr := s.repository.RemoveDispute(ctx, disputeID.Hex()); removeErr != nil {
+			log.Printf("Failed to take back dispute %s of order %s : %s", disputeID.Hex(), orderID, removeErr.Error())
+		}
+
+		return nil, err
+	}
+
+	return dispute, nil
+}
+
+// FetchDisputes lists the caller's own disputes, only admins may list the disputes of others.
+func (s *service) FetchDisputes(ctx context.Context, filter *uranus.DisputeFilter) ([]*models.Dispute, uranus.Page, error) {
+	if ctx == nil {
+		err := uranus.ErrContextNil
+		return nil, uranus.Page{}, err
+	}
+
+	partyID, err := auth.PartyScope(ctx, auth.ActionMediateDisputes)
+	if err != nil {
+		return nil, uranus.Page{}, err
+	}
+
+	if partyID != "" {
+		filter.PartyID = partyID
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, s.contextTimeout)
+	defer cancel()
+
+	if filter.Num == 0 {
+		filter.Num = int(20)
+	}
+
+	return s.repository.FetchDisputes(ctx, filter)
+}
+
+func (s *service) GetDisputeByID(ctx context.Context, disputeID string) (*models.Dispute, error) {
+	if ctx == nil {
+		err := uranus.ErrContextNil
+		return nil, err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, s.contextTimeout)
+	defer cancel()
+
+	dispute, _, err := s.partyDispute(ctx, disputeID)
+	if err != nil {
+		return nil, err
+	}
+
+	return dispute, nil
+}
+
+// PostDisputeMessage adds the caller's message to the discussion of a dispute that is still open,
+// the first admin to post in it becomes its mediator.
+func (s *service) PostDisputeMessage(ctx context.Context, disputeID string, m *models.DisputeMessage) error {
+	if ctx == nil {
+		return uranus.ErrContextNil
+	}
+
+	m.Body = strings.TrimSpace(m.Body)
+	if err := s.validator.ValidateStruct(m); err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, s.contextTimeout)
+	defer cancel()
+
+	dispute, party, err := s.partyDispute(ctx, disputeID)
+	if err != nil {
+		return err
+	}
+
+	if !dispute.IsOpen() {
+		return uranus.ConstraintErrorf("Dispute is %s, its discussion is closed", dispute.Status)
+	}
+
+	if m.ReplyToID != "" {
+		if err = s.checkReplyTo(ctx, dispute, m.ReplyToID); err != nil {
+			return err
+		}
+	}
+
+	principal, _ := auth.FromContext(ctx)
+	if err = s.images.CheckImages(ctx, principal.UserID, m.ImageIDs); err != nil {
+		return err
+	}
+
+	m.ID = bson.NewObjectId()
+	m.CreatedAt = time.Now()
+	m.DisputeID = dispute.ID
+	m.Party = party
+	if bson.IsObjectIdHex(principal.UserID) {
+		m.AuthorID = bson.ObjectIdHex(principal.UserID)
+	}
+
+	if party == models.OrderPartyAdmin && dispute.MediatorID == "" && m.AuthorID != "" {
+		if err = s.repository.AssignMediator(ctx, disputeID, principal.UserID); err != nil {
+			return err
+		}
+
+		dispute.MediatorID = m.AuthorID
+	}
+
+	m.Outbox = outbox(participants(dispute), principal.UserID, models.NotifyDisputeMessage, map[string]string{
+		"dispute_id": dispute.ID.Hex(),
+		"order_id":   dispute.OrderID.Hex(),
+		"party":      party,
+		"body":       m.Body,
+	})
+
+	return s.repository.StoreDisputeMessage(ctx, m)
+}
+
+func (s *service) FetchDisputeMessages(ctx context.Context, disputeID string) ([]*models.DisputeMessage, error) {
+	if ctx == nil {
+		err := uranus.ErrContextNil
+		return nil, err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, s.contextTimeout)
+	defer cancel()
+
+	dispute, _, err := s.partyDispute(ctx, disputeID)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.repository.FetchDisputeMessages(ctx, dispute.ID.Hex())
+}
+
+// ResolveDispute settles the dispute with the admin's outcome, the order moves on and its escrow is paid out.
+// Resolving a dispute whose settlement failed halfway tries the settlement of its outcome again,
+// SettleResolvedDisputes does the same for the ones nobody resolves again.
+func (s *service) ResolveDispute(ctx context.Context, disputeID string, m *models.DisputeResolution) (*models.Dispute, error) {
+	if ctx == nil {
+		err := uranus.ErrContextNil
+		return nil, err
+	}
+
+	if err := auth.Authorize(ctx, auth.ActionMediateDisputes); err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, s.contextTimeout)
+	defer cancel()
+
+	dispute, err := s.repository.GetDisputeByID(ctx, disputeID)
+	if err != nil {
+		return nil, err
+	}
+
+	order, err := s.orderRepository.GetOrderByID(ctx, dispute.OrderID.Hex())
+	if err != nil {
+		return nil, err
+	}
+
+	if !dispute.IsOpen() {
+		if order.Status != models.OrderDisputed && order.Status != dispute.Resolution.OrderStatus() {
+			return nil, uranus.ErrStaleStatus
+		}
+
+		return dispute, s.settle(ctx, dispute, order)
+	}
+
+	m.Note = strings.TrimSpace(m.Note)
+	if err = s.validator.ValidateStruct(m); err != nil {
+		return nil, err
+	}
+
+	if err = checkRefund(m, order); err != nil {
+		return nil, err
+	}
+
+	principal, _ := auth.FromContext(ctx)
+	m.ResolvedAt = time.Now()
+	if bson.IsObjectIdHex(principal.UserID) {
+		m.ResolvedBy = bson.ObjectIdHex(principal.UserID)
+	}
+
+	messages := outbox([]string{dispute.BuyerID.Hex(), dispute.TravelerID.Hex()}, "", models.NotifyDisputeResolved, map[string]string{
+		"dispute_id": dispute.ID.Hex(),
+		"order_id":   order.ID.Hex(),
+		"outcome":    m.Outcome,
+		"amount":     models.Money{Amount: m.RefundAmount, Currency: order.Total.Currency}.String(),
+		"note":       m.Note,
+	})
+
+	// Storing the outcome first makes the dispute the lock, a second resolution fails here.
+	if err = s.repository.ResolveDispute(ctx, disputeID, m, messages...); err != nil {
+		return nil, err
+	}
+
+	dispute.Status = models.DisputeResolved
+	dispute.Resolution = m
+	dispute.UpdatedAt = m.ResolvedAt
+
+	if err = s.settle(ctx, dispute, order); err != nil {
+		return nil, err
+	}
+
+	return dispute, nil
+}
+
+// EscalateOverdueDisputes escalates the open disputes no admin resolved within the SLA,
+// the parties and the admins are told and the discussion notes it.
+func (s *service) EscalateOverdueDisputes(ctx context.Context) (int, error) {
+	if ctx == nil {
+		err := uranus.ErrContextNil
+		return 0, err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, s.contextTimeout)
+	defer cancel()
+
+	now := time.Now()
+	disputes, err := s.repository.FetchOverdueDisputes(ctx, now)
+	if err != nil {
+		return 0, err
+	}
+
+	if len(disputes) == 0 {
+		return 0, nil
+	}
+
+	admins, err := s.admins(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	escalated := 0
+	for _, dispute := range disputes {
+		messages := outbox(merge(participants(dispute), admins), "", models.NotifyDisputeEscalated, map[string]string{
+			"dispute_id": dispute.ID.Hex(),
+			"order_id":   dispute.OrderID.Hex(),
+		})
+
+		err = s.repository.EscalateDispute(ctx, dispute.ID.Hex(), now, messages...)
+		if err == uranus.ErrStaleStatus {
+			continue
+		}
+
+		if err != nil {
+			return escalated, err
+		}
+
+		escalated++
+		notice := &models.DisputeMessage{
+			ID:        bson.NewObjectId(),
+			CreatedAt: now,
+			DisputeID: dispute.ID,
+			Party:     models.OrderPartySystem,
+			Body:      "The dispute wasn't resolved within its SLA and was escalated to the admins.",
+		}
+
+		if err = s.repository.StoreDisputeMessage(ctx, notice); err != nil {
+			log.Printf("Failed to note the escalation of dispute %s : %s", dispute.ID.Hex(), err.Error())
+		}
+	}
+
+	return escalated, nil
+}
+
+// SettleResolvedDisputes follows up and settles the resolved disputes whose outcome wasn't paid out,
+// like when the gateway failed right after the dispute was resolved.
+func (s *service) SettleResolvedDisputes(ctx context.Context) (int, error) {
+	if ctx == nil {
+		err := uranus.ErrContextNil
+		return 0, err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, s.contextTimeout)
+	defer cancel()
+
+	// Disputes that were only just resolved are still being settled by the admin who resolved them.
+	disputes, err := s.repository.FetchUnsettledDisputes(ctx, time.Now().Add(-s.contextTimeout), unsettledBatch)
+	if err != nil {
+		return 0, err
+	}
+
+	settled := 0
+	for _, dispute := range disputes {
+		order, err := s.orderRepository.GetOrderByID(ctx, dispute.OrderID.Hex())
+		if err == nil && order.Status != models.OrderDisputed && order.Status != dispute.Resolution.OrderStatus() {
+			err = uranus.ErrStaleStatus
+		}
+
+		if err == nil {
+			err = s.settle(ctx, dispute, order)
+		}
+
+		if err != nil {
+			log.Printf("Failed to settle dispute %s : %s", dispute.ID.Hex(), err.Error())
+			continue
+		}
+
+		settled++
+	}
+
+	return settled, nil
+}
+
+// settle moves the disputed order to the status of the dispute's outcome and pays its escrow out.
+// Each step is skipped or a no-op when it was done before, so a failed settlement can simply run again.
+// The dispute is marked settled last, the sweep stops trying it from then on.
+func (s *service) settle(ctx context.Context, dispute *models.Dispute, order *models.Order) error {
+	resolution := dispute.Resolution
+	if order.Status == models.OrderDisputed {
+		transition := models.OrderTransition{
+			From:    models.OrderDisputed,
+			To:      resolution.OrderStatus(),
+			ActorID: resolution.ResolvedBy,
+			Party:   models.OrderPartyAdmin,
+			Reason:  "Dispute resolved with " + strings.Replace(resolution.Outcome, "_", " ", -1),
+			At:      resolution.ResolvedAt,
+		}
+
+		if err := s.orderRepository.AppendOrderTransition(ctx, order.ID.Hex(), transition); err != nil {
+			return err
+		}
+
+		order.Status = transition.To
+		order.UpdatedAt = transition.At
+		order.History = append(order.History, transition)
+	}
+
+	if err := s.follower.FollowOrder(ctx, order); err != nil {
+		return err
+	}
+
+	if err := s.payOut(ctx, resolution, order); err != nil {
+		return err
+	}
+
+	if dispute.SettledAt != nil {
+		return nil
+	}
+
+	now := time.Now()
+	if err := s.repository.MarkDisputeSettled(ctx, dispute.ID.Hex(), now); err != nil {
+		return err
+	}
+
+	dispute.SettledAt = &now
+	return nil
+}
+
+// payOut settles the escrow of the order the way the resolution says.
+func (s *service) payOut(ctx context.Context, resolution *models.DisputeResolution, order *models.Order) error {
+	switch resolution.Outcome {
+	case models.DisputeFullRefund:
+		return s.escrow.Refund(ctx, order)
+	case models.DisputePartialRefund:
+		if err := s.escrow.RefundPart(ctx, order, resolution.RefundAmount); err != nil {
+			return err
+		}
+	}
+
+	return s.escrow.Release(ctx, order)
+}
+
+// partyDispute loads a dispute the caller takes part in, along with the party they play in it.
+// Admins that aren't a party of the dispute act as the admin party.
+func (s *service) partyDispute(ctx context.Context, disputeID string) (*models.Dispute, string, error) {
+	dispute, err := s.repository.GetDisputeByID(ctx, disputeID)
+	if err != nil {
+		return nil, "", err
+	}
+
+	_, party, err := auth.AuthorizeParty(ctx, auth.ActionMediateDisputes, dispute.PartyOf)
+	if err != nil {
+		return nil, "", err
+	}
+
+	return dispute, party, nil
+}
+
+// admins are the IDs of the admins that aren't banned, they're told about escalated disputes.
+func (s *service) admins(ctx context.Context) ([]string, error) {
+	banned := false
+	filter := &uranus.Filter{RoleName: models.RoleAdmin, IsBanned: &banned, Num: uranus.MaxPageSize}
+
+	var userIDs []string
+	for {
+		users, page, err := s.userRepository.Fetch(ctx, filter)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, user := range users {
+			userIDs = append(userIDs, user.ID.Hex())
+		}
+
+		if page.Next == "" {
+			return userIDs, nil
+		}
+
+		filter.Cursor = page.Next
+	}
+}
+
+// checkReplyTo makes sure a reply is threaded under a message of the same dispute.
+func (s *service) checkReplyTo(ctx context.Context, dispute *models.Dispute, replyToID bson.ObjectId) error {
+	messages, err := s.repository.FetchDisputeMessages(ctx, dispute.ID.Hex())
+	if err != nil {
+		return err
+	}
+
+	for _, message := range messages {
+		if message.ID == replyToID {
+			return nil
+		}
+	}
+
+	return uranus.ConstraintErrorf("Message %s is not part of this dispute", replyToID.Hex())
+}
+
+// checkRefund allows a refund amount only on partial refunds, where it has to leave the traveler something.
+func checkRefund(m *models.DisputeResolution, order *models.Order) error {
+	if m.Outcome != models.DisputePartialRefund {
+		if m.RefundAmount != 0 {
+			return uranus.ConstraintErrorf("Only a partial refund takes a refund amount")
+		}
+
+		return nil
+	}
+
+	if m.RefundAmount <= 0 || m.RefundAmount >= order.Total.Amount {
+		return uranus.ConstraintErrorf("Partial refund has to be above zero and below the order's total of %s", order.Total.String())
+	}
+
+	return nil
+}
+
+// deliveredAt is when the order was delivered, nil for an order that never was.
+func deliveredAt(order *models.Order) *time.Time {
+	for i := len(order.History) - 1; i >= 0; i-- {
+		if order.History[i].To == models.OrderDelivered {
+			return &order.History[i].At
+		}
+	}
+
+	return nil
+}
+
+// participants are the IDs of the buyer, the traveler and the mediator once there is one.
+func participants(dispute *models.Dispute) []string {
+	userIDs := []string{dispute.BuyerID.Hex(), dispute.TravelerID.Hex()}
+	if dispute.MediatorID != "" {
+		userIDs = append(userIDs, dispute.MediatorID.Hex())
+	}
+
+	return userIDs
+}
+
+// merge appends the user IDs of more to those of userIDs that aren't in there yet.
+func merge(userIDs []string, more []string) []string {
+	seen := make(map[string]bool, len(userIDs))
+	for _, userID := range userIDs {
+		seen[userID] = true
+	}
+
+	for _, userID := range more {
+		if !seen[userID] {
+			seen[userID] = true
+			userIDs = append(userIDs, userID)
+		}
+	}
+
+	return userIDs
+}
+
+// outbox tells each of the users but the one who caused it about what happened to the dispute.
+func outbox(userIDs []string, exceptID string, kind string, data map[string]string) []*models.OutboxMessage {
+	messages := make([]*models.OutboxMessage, 0, len(userIDs))
+	for _, userID := range userIDs {
+		if userID != exceptID {
+			messages = append(messages, models.NewOutboxMessage(userID, kind, data))
+		}
+	}
+
+	return messages
+}
+
+type requirement func(*service)
+
+func Repository(repository repository.DisputeRepository) requirement {
+	return func(s *service) {
+		s.repository = repository
+	}
+}
+
+func OrderRepository(orderRepository repository.OrderRepository) requirement {
+	return func(s *service) {
+		s.orderRepository = orderRepository
+	}
+}
+
+// UserAccountRepository looks the admins up, they're told about every escalated dispute.
+func UserAccountRepository(userRepository repository.UserAccountRepository) requirement {
+	return func(s *service) {
+		s.userRepository = userRepository
+	}
+}
+
+// Images checks that the evidence photos of disputes and their messages were uploaded by their author.
+func Images(images uranus.ImageStore) requirement {
+	return func(s *service) {
+		s.images = images
+	}
+}
+
+func Escrow(escrow uranus.Escrow) requirement {
+	return func(s *service) {
+		s.escrow = escrow
+	}
+}
+
+// Follower moves the purchase request and the offer of the disputed order along with the dispute's outcome.
+func Follower(follower uranus.OrderFollower) requirement {
+	return func(s *service) {
+		s.follower = follower
+	}
+}
+
+// Window sets how long after delivery the buyer may still dispute an order.
+func Window(window time.Duration) requirement {
+	return func(s *service) {
+		if window > 0 {
+			s.window = window
+		}
+	}
+}
+
+// SLA sets how long a dispute may stay open before it's escalated.
+func SLA(sla time.Duration) requirement {
+	return func(s *service) {
+		if sla > 0 {
+			s.sla = sla
+		}
+	}
+}
+
+func Timeout(timeout time.Duration) requirement {
+	return func(s *service) {
+		s.contextTimeout = timeout
+	}
+}
+
+func Validator(validator uranus.Validate) requirement {
+	return func(s *service) {
+		s.validator = validator
+	}
+}
+
+func NewService(reqs ...requirement) uranus.DisputeUsecase {
+	s := &service{
+		window: 3 * 24 * time.Hour,
+		sla:    48 * time.Hour,
+	}
+	for _, option := range reqs {
+		option(s)
+	}
+
+	return s
+}
@@ -0,0 +1,411 @@
+package dispute
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/globalsign/mgo/bson"
+
+	"github.com/fidellr/jastip/backend/uranus"
+	"github.com/fidellr/jastip/backend/uranus/auth"
+	"github.com/fidellr/jastip/backend/uranus/image"
+	"github.com/fidellr/jastip/backend/uranus/models"
+	"github.com/fidellr/jastip/backend/uranus/repository"
+)
+
+// memoryDisputes resolves a dispute only while it's still open, like the mongo repository.
+type memoryDisputes struct {
+	repository.DisputeRepository
+	mu       sync.Mutex
+	disputes map[string]models.Dispute
+	messages []*models.DisputeMessage
+}
+
+func (r *memoryDisputes) StoreDispute(ctx context.Context, m *models.Dispute) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.disputes[m.ID.Hex()] = *m
+	return nil
+}
+
+func (r *memoryDisputes) GetDisputeByID(ctx context.Context, disputeID string) (*models.Dispute, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	dispute, ok := r.disputes[disputeID]
+	if !ok {
+		return nil, uranus.ErrNotFound
+	}
+
+	return &dispute, nil
+}
+
+func (r *memoryDisputes) ResolveDispute(ctx context.Context, disputeID string, resolution *models.DisputeResolution, outbox ...*models.OutboxMessage) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	dispute, ok := r.disputes[disputeID]
+	if !ok {
+		return uranus.ErrNotFound
+	}
+
+	if !dispute.IsOpen() {
+		return uranus.ErrStaleStatus
+	}
+
+	dispute.Status = models.DisputeResolved
+	dispute.Resolution = resolution
+	r.disputes[disputeID] = dispute
+	return nil
+}
+
+func (r *memoryDisputes) FetchUnsettledDisputes(ctx context.Context, resolvedBefore time.Time, limit int) ([]*models.Dispute, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	disputes := make([]*models.Dispute, 0)
+	for _, dispute := range r.disputes {
+		dispute := dispute
+		if dispute.Status == models.DisputeResolved && dispute.SettledAt == nil && dispute.Resolution.ResolvedAt.Before(resolvedBefore) {
+			disputes = append(disputes, &dispute)
+		}
+	}
+
+	return disputes, nil
+}
+
+func (r *memoryDisputes) MarkDisputeSettled(ctx context.Context, disputeID string, at time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	dispute := r.disputes[disputeID]
+	dispute.SettledAt = &at
+	r.disputes[disputeID] = dispute
+	return nil
+}
+
+func (r *memoryDisputes) AssignMediator(ctx context.Context, disputeID string, mediatorID string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	dispute := r.disputes[disputeID]
+	dispute.MediatorID = bson.ObjectIdHex(mediatorID)
+	r.disputes[disputeID] = dispute
+	return nil
+}
+
+func (r *memoryDisputes) StoreDisputeMessage(ctx context.Context, m *models.DisputeMessage) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.messages = append(r.messages, m)
+	return nil
+}
+
+// memoryOrders moves an order only while it's still in the transition's from status, like the mongo repository.
+type memoryOrders struct {
+	repository.OrderRepository
+	mu     sync.Mutex
+	orders map[string]models.Order
+}
+
+func (r *memoryOrders) GetOrderByID(ctx context.Context, orderID string) (*models.Order, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	order, ok := r.orders[orderID]
+	if !ok {
+		return nil, uranus.ErrNotFound
+	}
+
+	return &order, nil
+}
+
+func (r *memoryOrders) AppendOrderTransition(ctx context.Context, orderID string, transition models.OrderTransition, outbox ...*models.OutboxMessage) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	order, ok := r.orders[orderID]
+	if !ok {
+		return uranus.ErrNotFound
+	}
+
+	if order.Status != transition.From {
+		return uranus.ErrStaleStatus
+	}
+
+	order.Status = transition.To
+	order.History = append(order.History, transition)
+	r.orders[orderID] = order
+	return nil
+}
+
+// recordingEscrow notes every payout it's asked for, failNext fails the next one like a gateway that's down.
+type recordingEscrow struct {
+	calls    []string
+	failNext bool
+}
+
+func (e *recordingEscrow) call(name string) error {
+	if e.failNext {
+		e.failNext = false
+		return errors.New("gateway is down")
+	}
+
+	e.calls = append(e.calls, name)
+	return nil
+}
+
+func (e *recordingEscrow) Release(ctx context.Context, order *models.Order) error {
+	return e.call("release")
+}
+
+func (e *recordingEscrow) Refund(ctx context.Context, order *models.Order) error {
+	return e.call("refund")
+}
+
+func (e *recordingEscrow) RefundPart(ctx context.Context, order *models.Order, amount int64) error {
+	return e.call("refund-part")
+}
+
+type noopFollower struct{}
+
+func (noopFollower) FollowOrder(ctx context.Context, order *models.Order) error { return nil }
+
+type disputeFixture struct {
+	service  *service
+	disputes *memoryDisputes
+	orders   *memoryOrders
+	escrow   *recordingEscrow
+	order    models.Order
+}
+
+func newDisputeFixture(status string, history ...models.OrderTransition) *disputeFixture {
+	order := models.Order{
+		ID:         bson.NewObjectId(),
+		BuyerID:    bson.NewObjectId(),
+		TravelerID: bson.NewObjectId(),
+		Status:     status,
+		History:    history,
+		Total:      models.Money{Amount: 1000000, Currency: "IDR"},
+	}
+
+	f := &disputeFixture{
+		disputes: &memoryDisputes{disputes: make(map[string]models.Dispute)},
+		orders:   &memoryOrders{orders: map[string]models.Order{order.ID.Hex(): order}},
+		escrow:   &recordingEscrow{},
+		order:    order,
+	}
+
+	f.service = NewService(
+		Repository(f.disputes),
+		OrderRepository(f.orders),
+		Images(image.NewFake()),
+		Escrow(f.escrow),
+		Follower(noopFollower{}),
+		Window(72*time.Hour),
+		Timeout(time.Second),
+		Validator(uranus.NewValidator()),
+	).(*service)
+	return f
+}
+
+// openDispute stores an open dispute of the fixture's order and moves the order to disputed.
+func (f *disputeFixture) openDispute() models.Dispute {
+	dispute := models.Dispute{
+		ID:          bson.NewObjectId(),
+		OrderID:     f.order.ID,
+		BuyerID:     f.order.BuyerID,
+		TravelerID:  f.order.TravelerID,
+		OrderStatus: f.order.Status,
+		Status:      models.DisputeOpen,
+	}
+
+	f.disputes.disputes[dispute.ID.Hex()] = dispute
+	order := f.orders.orders[f.order.ID.Hex()]
+	order.Status = models.OrderDisputed
+	f.orders.orders[f.order.ID.Hex()] = order
+	return dispute
+}
+
+func asUser(userID string, roleName string) context.Context {
+	return auth.NewContext(context.Background(), &models.Principal{UserID: userID, Role: models.UserRole{RoleName: roleName}})
+}
+
+func TestCheckRefund(t *testing.T) {
+	order := &models.Order{Total: models.Money{Amount: 1000, Currency: "IDR"}}
+	tests := []struct {
+		name    string
+		outcome string
+		amount  int64
+		wantErr bool
+	}{
+		{name: "partial refund of nothing", outcome: models.DisputePartialRefund, amount: 0, wantErr: true},
+		{name: "negative partial refund", outcome: models.DisputePartialRefund, amount: -1, wantErr: true},
+		{name: "smallest partial refund", outcome: models.DisputePartialRefund, amount: 1},
+		{name: "largest partial refund", outcome: models.DisputePartialRefund, amount: 999},
+		{name: "partial refund of the whole total", outcome: models.DisputePartialRefund, amount: 1000, wantErr: true},
+		{name: "partial refund above the total", outcome: models.DisputePartialRefund, amount: 1001, wantErr: true},
+		{name: "full refund", outcome: models.DisputeFullRefund},
+		{name: "full refund with an amount", outcome: models.DisputeFullRefund, amount: 500, wantErr: true},
+		{name: "release", outcome: models.DisputeRelease},
+		{name: "release with an amount", outcome: models.DisputeRelease, amount: 500, wantErr: true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			err := checkRefund(&models.DisputeResolution{Outcome: test.outcome, RefundAmount: test.amount}, order)
+			if (err != nil) != test.wantErr {
+				t.Fatalf("checkRefund(%s, %d) error = %v, wantErr %v", test.outcome, test.amount, err, test.wantErr)
+			}
+
+			if _, ok := err.(uranus.ConstraintError); err != nil && !ok {
+				t.Errorf("checkRefund error is a %T, want a ConstraintError", err)
+			}
+		})
+	}
+}
+
+func TestOpenDisputeWithinWindow(t *testing.T) {
+	window := 72 * time.Hour
+	tests := []struct {
+		name      string
+		delivered time.Duration
+		wantErr   bool
+	}{
+		{name: "just delivered", delivered: time.Minute},
+		{name: "right before the window runs out", delivered: window - time.Minute},
+		{name: "right after the window ran out", delivered: window + time.Minute, wantErr: true},
+		{name: "long after delivery", delivered: 30 * 24 * time.Hour, wantErr: true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			delivery := models.OrderTransition{From: models.OrderInTransit, To: models.OrderDelivered, At: time.Now().Add(-test.delivered)}
+			f := newDisputeFixture(models.OrderDelivered, delivery)
+
+			buyer := asUser(f.order.BuyerID.Hex(), models.RoleBuyer)
+			_, err := f.service.OpenDispute(buyer, f.order.ID.Hex(), &models.DisputeOpening{Reason: models.DisputeDamaged, Description: "The box was crushed"})
+			if (err != nil) != test.wantErr {
+				t.Fatalf("OpenDispute %s after delivery error = %v, wantErr %v", test.delivered, err, test.wantErr)
+			}
+
+			stored := f.orders.orders[f.order.ID.Hex()]
+			if test.wantErr {
+				if _, ok := err.(uranus.ConstraintError); !ok {
+					t.Errorf("OpenDispute error is a %T, want a ConstraintError", err)
+				}
+
+				if stored.Status != models.OrderDelivered || len(f.disputes.disputes) != 0 {
+					t.Errorf("refused dispute left the order %s with %d disputes", stored.Status, len(f.disputes.disputes))
+				}
+
+				return
+			}
+
+			if stored.Status != models.OrderDisputed || len(f.disputes.disputes) != 1 {
+				t.Errorf("order is %s with %d disputes, want disputed with 1", stored.Status, len(f.disputes.disputes))
+			}
+		})
+	}
+}
+
+func TestResolveDisputeSettlesOnce(t *testing.T) {
+	f := newDisputeFixture(models.OrderDelivered)
+	dispute := f.openDispute()
+	admin := asUser(bson.NewObjectId().Hex(), models.RoleAdmin)
+
+	// The gateway is down when the admin resolves the dispute, the outcome is stored but not paid out.
+	f.escrow.failNext = true
+	partial := &models.DisputeResolution{Outcome: models.DisputePartialRefund, RefundAmount: 300000, Note: "Half of the items arrived broken"}
+	if _, err := f.service.ResolveDispute(admin, dispute.ID.Hex(), partial); err == nil {
+		t.Fatal("ResolveDispute with the gateway down didn't fail")
+	}
+
+	if stored := f.disputes.disputes[dispute.ID.Hex()]; stored.Status != models.DisputeResolved || stored.SettledAt != nil {
+		t.Fatalf("dispute is %s, settled at %v, want resolved and unsettled", stored.Status, stored.SettledAt)
+	}
+
+	// Resolving it again settles the stored outcome, not the new one, and the order moves only once.
+	refund := &models.DisputeResolution{Outcome: models.DisputeFullRefund, Note: "Refund it all"}
+	for i := 0; i < 2; i++ {
+		if _, err := f.service.ResolveDispute(admin, dispute.ID.Hex(), refund); err != nil {
+			t.Fatalf("ResolveDispute retry %d: %v", i+1, err)
+		}
+	}
+
+	stored := f.disputes.disputes[dispute.ID.Hex()]
+	if stored.SettledAt == nil || stored.Resolution.Outcome != models.DisputePartialRefund {
+		t.Errorf("dispute settled at %v with %s, want settled with a partial refund", stored.SettledAt, stored.Resolution.Outcome)
+	}
+
+	order := f.orders.orders[f.order.ID.Hex()]
+	if order.Status != models.OrderCompleted || len(order.History) != 1 {
+		t.Errorf("order is %s with %d transitions, want completed with 1", order.Status, len(order.History))
+	}
+
+	for _, call := range f.escrow.calls {
+		if call == "refund" {
+			t.Errorf("escrow calls = %v, the retry refunded in full", f.escrow.calls)
+		}
+	}
+}
+
+func TestSettleResolvedDisputes(t *testing.T) {
+	f := newDisputeFixture(models.OrderDelivered)
+	dispute := f.openDispute()
+	admin := asUser(bson.NewObjectId().Hex(), models.RoleAdmin)
+
+	f.escrow.failNext = true
+	resolution := &models.DisputeResolution{Outcome: models.DisputeFullRefund, Note: "Never arrived"}
+	if _, err := f.service.ResolveDispute(admin, dispute.ID.Hex(), resolution); err == nil {
+		t.Fatal("ResolveDispute with the gateway down didn't fail")
+	}
+
+	// The sweep leaves alone what was only just resolved.
+	if settled, err := f.service.SettleResolvedDisputes(context.Background()); err != nil || settled != 0 {
+		t.Fatalf("SettleResolvedDisputes right after the resolution = %d, %v, want 0", settled, err)
+	}
+
+	stored := f.disputes.disputes[dispute.ID.Hex()]
+	stored.Resolution.ResolvedAt = time.Now().Add(-time.Hour)
+	f.disputes.disputes[dispute.ID.Hex()] = stored
+
+	for i, want := range []int{1, 0} {
+		settled, err := f.service.SettleResolvedDisputes(context.Background())
+		if err != nil || settled != want {
+			t.Fatalf("SettleResolvedDisputes run %d = %d, %v, want %d", i+1, settled, err, want)
+		}
+	}
+
+	if order := f.orders.orders[f.order.ID.Hex()]; order.Status != models.OrderCancelled || len(order.History) != 1 {
+		t.Errorf("order is %s with %d transitions, want cancelled with 1", order.Status, len(order.History))
+	}
+
+	if len(f.escrow.calls) != 1 || f.escrow.calls[0] != "refund" {
+		t.Errorf("escrow calls = %v, want a single refund", f.escrow.calls)
+	}
+}
+
+func TestPostDisputeMessageWithoutUserID(t *testing.T) {
+	f := newDisputeFixture(models.OrderDelivered)
+	dispute := f.openDispute()
+
+	// A principal whose user ID isn't an ObjectId, like a service token, posts without an author or taking the dispute on.
+	admin := asUser("support", models.RoleAdmin)
+	if err := f.service.PostDisputeMessage(admin, dispute.ID.Hex(), &models.DisputeMessage{Body: "We're looking into it"}); err != nil {
+		t.Fatalf("PostDisputeMessage: %v", err)
+	}
+
+	if len(f.disputes.messages) != 1 || f.disputes.messages[0].AuthorID != "" {
+		t.Fatalf("stored messages = %v, want one without an author", f.disputes.messages)
+	}
+
+	if mediatorID := f.disputes.disputes[dispute.ID.Hex()].MediatorID; mediatorID != "" {
+		t.Errorf("mediator = %s, want none", mediatorID.Hex())
+	}
+}
@@ -4,6 +4,9 @@ import (
 	"errors"
 	"fmt"
 	"net/http"
+	"strings"
+
+	"github.com/fidellr/jastip/backend/uranus/models"
 )
 
 var (
@@ -15,6 +18,55 @@ var (
 
 	// ErrNotModified is thrown to the client when the cached copy of a particular file is up to date with the server.
 	ErrNotModified = errors.New("")
+
+	// ErrInvalidCursor is thrown if a pagination cursor was not made by this service or got mangled on the way.
+	ErrInvalidCursor = errors.New("Invalid pagination cursor")
+
+	// ErrInvalidSort is thrown if the list is asked to sort on a field that is not sortable.
+	ErrInvalidSort = errors.New("Invalid sort field")
+
+	// ErrProfileRoleMismatch is thrown if a profile is read or written for an account of another role.
+	ErrProfileRoleMismatch = errors.New("This profile does not belong to the account's role")
+
+	// ErrStaleStatus is thrown if the item moved to another status while the change was being made.
+	ErrStaleStatus = errors.New("The item's status was changed by someone else, reload it and try again")
+
+	// ErrVersionConflict is thrown if the document was changed since the version given in If-Match.
+	// uranus, rover and plateu all version their documents and share it.
+	ErrVersionConflict = errors.New("Your copy is outdated, the item was changed by someone else")
+
+	// ErrMissingIfMatch is thrown if an update comes without the If-Match header it needs.
+	ErrMissingIfMatch = errors.New("Missing If-Match header, fetch the item first and send its ETag")
+
+	// ErrAlreadyPosted is thrown if a journal entry with the same key is already in the ledger.
+	ErrAlreadyPosted = errors.New("The journal entry was already posted")
+
+	// ErrAlreadyReviewed is thrown if the reviewer already reviewed the order.
+	ErrAlreadyReviewed = errors.New("You already reviewed this order, edit your review instead")
+
+	// ErrInvalidSignature is thrown if a payment webhook is not signed by the payment gateway.
+	ErrInvalidSignature = errors.New("Invalid webhook signature")
+
+	// ErrNoExchangeRate is thrown if no exchange rate between two currencies was in effect at the time asked for.
+	ErrNoExchangeRate = errors.New("No exchange rate is in effect for these currencies")
+
+	// ErrNoFeeSchedule is thrown if no fee schedule was in effect when an order was placed.
+	ErrNoFeeSchedule = errors.New("No fee schedule is in effect")
+
+	// ErrInvalidCredentials is thrown if the email address or password given on sign in does not match any account.
+	ErrInvalidCredentials = errors.New("Invalid email address or password")
+
+	// ErrAccountSuspended is thrown if a suspended account tries to sign in.
+	ErrAccountSuspended = errors.New("Your account is suspended")
+
+	// ErrEmailTaken is thrown if another account already uses the email address.
+	ErrEmailTaken = errors.New("Email address is already registered")
+
+	// ErrInvalidUserToken is thrown if a one-time token is unknown, already used or expired.
+	ErrInvalidUserToken = errors.New("Token is invalid or has expired")
+
+	// ErrInvalidToken is thrown if the access token is malformed, expired or not signed by uranus.
+	ErrInvalidToken = errors.New("Invalid or expired access token")
 )
 
 // ConstraintError represents a custom error for a contstraint things.
@@ -29,6 +81,60 @@ func ConstraintErrorf(format string, a ...interface{}) ConstraintError {
 	return ConstraintError(fmt.Sprintf(format, a...))
 }
 
+// ForbiddenError is thrown if the caller's role is not allowed to perform the requested action.
+type ForbiddenError struct {
+	RoleName string
+	Action   string
+}
+
+func (e *ForbiddenError) Error() string {
+	if e.RoleName == "" {
+		return fmt.Sprintf("You are not allowed to %s", e.Action)
+	}
+
+	return fmt.Sprintf("Role %s is not allowed to %s", e.RoleName, e.Action)
+}
+
+// IllegalTransitionError is thrown if an order can't go from one status to another,
+// either because the table has no such transition or because the party may not make it.
+type IllegalTransitionError struct {
+	From  string
+	To    string
+	Party string
+}
+
+func (e *IllegalTransitionError) Error() string {
+	if e.Party == "" {
+		return fmt.Sprintf("Order can't go from %s to %s", e.From, e.To)
+	}
+
+	return fmt.Sprintf("The %s can't move an order from %s to %s", e.Party, e.From, e.To)
+}
+
+// CustomsError is thrown if a purchase request or an order breaks the import rules of the country it goes to.
+type CustomsError struct {
+	Country string
+	Reasons []string
+}
+
+func (e *CustomsError) Error() string {
+	return fmt.Sprintf("Blocked by %s customs: %s", e.Country, strings.Join(e.Reasons, ", "))
+}
+
+// CustomsErrorOf turns the blocking findings of a customs check into a CustomsError, nil when nothing blocks.
+func CustomsErrorOf(check *models.CustomsCheck) error {
+	if !check.Blocked() {
+		return nil
+	}
+
+	reasons := make([]string, 0, len(check.Errors))
+	for _, issue := range check.Errors {
+		reasons = append(reasons, issue.Message)
+	}
+
+	return &CustomsError{Country: check.Country, Reasons: reasons}
+}
+
 // ErrorFromResponseStatusCode generates error based on the status code from *http.Response.
 // For example, it will generate fetlar.ErrNotFound when given status code of 404.
 func ErrorFromResponseStatusCode(code int, message string) (err error) {
@@ -39,6 +145,10 @@ func ErrorFromResponseStatusCode(code int, message string) (err error) {
 		err = ConstraintErrorf(message)
 	case http.StatusNotModified:
 		err = ErrNotModified
+	case http.StatusPreconditionFailed:
+		err = ErrVersionConflict
+	case http.StatusForbidden:
+		err = &ForbiddenError{Action: message}
 	default:
 		err = fmt.Errorf(message)
 	}
@@ -0,0 +1,55 @@
+package uranus
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/globalsign/mgo/bson"
+)
+
+// AnyVersion is the version If-Match: * stands for, the update goes through on whatever version is current.
+const AnyVersion = -1
+
+// ETag formats a document version as a strong entity tag.
+func ETag(version int) string {
+	return strconv.Quote(strconv.Itoa(version))
+}
+
+// ParseETag reads the document version back from an If-Match header value, * reads as AnyVersion.
+func ParseETag(etag string) (int, error) {
+	value := strings.TrimPrefix(strings.TrimSpace(etag), "W/")
+	if value == "*" {
+		return AnyVersion, nil
+	}
+
+	version, err := strconv.Atoi(strings.Trim(value, "\""))
+	if err != nil {
+		return 0, ConstraintErrorf("Invalid entity tag %s", etag)
+	}
+
+	return version, nil
+}
+
+// IfMatchVersion reads the version the client last saw from its If-Match header, updates must send one.
+func IfMatchVersion(ifMatch string) (int, error) {
+	if ifMatch == "" {
+		return 0, ErrMissingIfMatch
+	}
+
+	return ParseETag(ifMatch)
+}
+
+// VersionMatches reports whether an update sent with the If-Match version may change the current version.
+func VersionMatches(ifMatch, current int) bool {
+	return ifMatch == AnyVersion || ifMatch == current
+}
+
+// MatchVersion matches documents at the given version in a mongo query, documents written
+// before versioning existed have no version field and count as version 0.
+func MatchVersion(version int) interface{} {
+	if version == 0 {
+		return bson.M{"$in": []interface{}{0, nil}}
+	}
+
+	return version
+}
@@ -0,0 +1,66 @@
+package uranus
+
+import "testing"
+
+func TestIfMatchVersion(t *testing.T) {
+	tests := []struct {
+		ifMatch string
+		version int
+		err     bool
+	}{
+		{ifMatch: `"3"`, version: 3},
+		{ifMatch: ` "0" `, version: 0},
+		{ifMatch: `W/"7"`, version: 7},
+		{ifMatch: "12", version: 12},
+		{ifMatch: "*", version: AnyVersion},
+		{ifMatch: `"three"`, err: true},
+		{ifMatch: `W/`, err: true},
+	}
+
+	for _, test := range tests {
+		version, err := IfMatchVersion(test.ifMatch)
+		if test.err {
+			if _, ok := err.(ConstraintError); !ok {
+				t.Errorf("IfMatchVersion(%q) error = %v, want a ConstraintError", test.ifMatch, err)
+			}
+
+			continue
+		}
+
+		if err != nil || version != test.version {
+			t.Errorf("IfMatchVersion(%q) = %d, %v, want %d", test.ifMatch, version, err, test.version)
+		}
+	}
+
+	if _, err := IfMatchVersion(""); err != ErrMissingIfMatch {
+		t.Errorf("IfMatchVersion without a header error = %v, want %v", err, ErrMissingIfMatch)
+	}
+}
+
+func TestETagRoundTrip(t *testing.T) {
+	for _, version := range []int{0, 1, 42} {
+		if got, err := ParseETag(ETag(version)); err != nil || got != version {
+			t.Errorf("ParseETag(ETag(%d)) = %d, %v", version, got, err)
+		}
+	}
+}
+
+func TestVersionMatches(t *testing.T) {
+	tests := []struct {
+		ifMatch int
+		current int
+		want    bool
+	}{
+		{ifMatch: 3, current: 3, want: true},
+		{ifMatch: 2, current: 3, want: false},
+		{ifMatch: 4, current: 3, want: false},
+		{ifMatch: 0, current: 0, want: true},
+		{ifMatch: AnyVersion, current: 9, want: true},
+	}
+
+	for _, test := range tests {
+		if got := VersionMatches(test.ifMatch, test.current); got != test.want {
+			t.Errorf("VersionMatches(%d, %d) = %v, want %v", test.ifMatch, test.current, got, test.want)
+		}
+	}
+}
@@ -0,0 +1,30 @@
+package uranus
+
+import (
+	"context"
+	"time"
+
+	"github.com/fidellr/jastip/backend/uranus/models"
+)
+
+type ExchangeRateUsecase interface {
+	Converter
+	UploadRates(ctx context.Context, rates []*models.ExchangeRate) (int, error)
+	FetchRates(ctx context.Context, filter *ExchangeRateFilter) ([]*models.ExchangeRate, error)
+}
+
+// Converter converts money into another currency at the rate effective at the given time,
+// along with the rate it used so the caller can record it. Money already in the currency comes back with a nil rate.
+type Converter interface {
+	Convert(ctx context.Context, m models.Money, currency string, at time.Time) (models.Money, *models.AppliedRate, error)
+}
+
+// ExchangeRateFilter narrows exchange rates down to a currency pair and an effective date range, the latest first.
+type ExchangeRateFilter struct {
+	Num int
+
+	Base  string
+	Quote string
+	From  time.Time
+	To    time.Time
+}
@@ -0,0 +1,18 @@
+package uranus
+
+import (
+	"context"
+
+	"github.com/fidellr/jastip/backend/uranus/models"
+)
+
+type FeeUsecase interface {
+	FeeCalculator
+	FetchFeeSchedules(ctx context.Context) ([]*models.FeeSchedule, error)
+	StoreFeeSchedule(ctx context.Context, m *models.FeeSchedule) error
+}
+
+// FeeCalculator works out the fee breakdown of an order under the fee schedule in effect when the order is placed.
+type FeeCalculator interface {
+	CalculateFees(ctx context.Context, quote *models.FeeQuote) (*models.FeeBreakdown, error)
+}
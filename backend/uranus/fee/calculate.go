@@ -0,0 +1,110 @@
+package fee
+
+import (
+	"math"
+	"strings"
+
+	"github.com/fidellr/jastip/backend/uranus"
+	"github.com/fidellr/jastip/backend/uranus/models"
+)
+
+// kinds is the order rules are applied in, each kind may be charged on the kinds before it.
+var kinds = []string{models.FeeTraveler, models.FeePlatform, models.FeeTax}
+
+// Calculate works out the fee breakdown of the quote under the schedule.
+// The fee the traveler quoted comes first, then every matching rule adds a line.
+func Calculate(schedule *models.FeeSchedule, quote *models.FeeQuote) (*models.FeeBreakdown, error) {
+	travelerFee := quote.TravelerFee
+	if travelerFee.Currency == "" {
+		travelerFee.Currency = schedule.Currency
+	}
+
+	if quote.Items.Currency != schedule.Currency || travelerFee.Currency != schedule.Currency {
+		return nil, uranus.ConstraintErrorf("Fee schedule %d is in %s, convert the items and the traveler fee first", schedule.Version, schedule.Currency)
+	}
+
+	breakdown := &models.FeeBreakdown{
+		ScheduleVersion: schedule.Version,
+		Items:           quote.Items,
+		Lines:           make([]models.FeeLine, 0),
+	}
+
+	subtotal := quote.Items
+	if travelerFee.Amount > 0 {
+		breakdown.Lines = append(breakdown.Lines, models.FeeLine{Kind: models.FeeTraveler, Amount: travelerFee})
+		subtotal.Amount += travelerFee.Amount
+	}
+
+	for _, kind := range kinds {
+		// Rules of one kind share their bases, a fee is never charged on another fee of its own kind.
+		bases := map[string]int64{
+			models.FeeBaseItems:       quote.Items.Amount,
+			models.FeeBaseTravelerFee: travelerFee.Amount,
+			models.FeeBaseSubtotal:    subtotal.Amount,
+		}
+
+		for _, rule := range schedule.Rules {
+			if rule.Kind != kind || !matches(&rule, quote) {
+				continue
+			}
+
+			amount := apply(&rule, bases)
+			if amount == 0 {
+				continue
+			}
+
+			line := models.FeeLine{Kind: kind, Rule: rule.Name, Amount: models.Money{Amount: amount, Currency: schedule.Currency}}
+			breakdown.Lines = append(breakdown.Lines, line)
+			subtotal.Amount += amount
+			if kind == models.FeeTraveler {
+				travelerFee.Amount += amount
+			}
+		}
+	}
+
+	breakdown.Total = subtotal
+	return breakdown, nil
+}
+
+// matches reports whether the rule covers the quote's country, category and items price.
+func matches(rule *models.FeeRule, quote *models.FeeQuote) bool {
+	if len(rule.Countries) > 0 && !contains(rule.Countries, quote.Country) {
+		return false
+	}
+
+	if len(rule.Categories) > 0 && !contains(rule.Categories, quote.Category) {
+		return false
+	}
+
+	price := quote.Items.Amount
+	return price >= rule.PriceFrom && (rule.PriceTo == 0 || price < rule.PriceTo)
+}
+
+// apply charges the rule on its base, rounding to the nearest minor unit before the caps.
+func apply(rule *models.FeeRule, bases map[string]int64) int64 {
+	base := rule.Base
+	if base == "" {
+		base = models.FeeBaseItems
+	}
+
+	amount := int64(math.Round(float64(bases[base])*rule.Percent/100)) + rule.Flat
+	if rule.Min > 0 && amount < rule.Min {
+		amount = rule.Min
+	}
+
+	if rule.Max > 0 && amount > rule.Max {
+		amount = rule.Max
+	}
+
+	return amount
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if strings.EqualFold(v, value) {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,142 @@
+package fee
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/fidellr/jastip/backend/uranus/models"
+)
+
+func idr(amount int64) models.Money {
+	return models.Money{Amount: amount, Currency: "IDR"}
+}
+
+func TestCalculate(t *testing.T) {
+	schedule := &models.FeeSchedule{
+		Version:  3,
+		Currency: "IDR",
+		Rules: []models.FeeRule{
+			{Name: "service", Kind: models.FeePlatform, Percent: 5, Min: 1000000, Max: 50000000},
+			{Name: "electronics", Kind: models.FeePlatform, Categories: []string{"electronics"}, Flat: 2500000},
+			{Name: "japan", Kind: models.FeeTraveler, Countries: []string{"JP"}, PriceFrom: 500000000, Percent: 1},
+			{Name: "commission", Kind: models.FeePlatform, Base: models.FeeBaseTravelerFee, Percent: 10},
+			{Name: "vat", Kind: models.FeeTax, Base: models.FeeBaseSubtotal, Percent: 11, Max: 100000000},
+			{Name: "luxury", Kind: models.FeeTax, PriceFrom: 1000000000, PriceTo: 5000000000, Percent: 2},
+		},
+	}
+
+	tests := []struct {
+		name    string
+		quote   models.FeeQuote
+		lines   []models.FeeLine
+		total   int64
+		wantErr bool
+	}{
+		{
+			name:  "minimum service fee and vat on the subtotal",
+			quote: models.FeeQuote{Country: "SG", Category: "books", Items: idr(10000000)},
+			lines: []models.FeeLine{
+				{Kind: models.FeePlatform, Rule: "service", Amount: idr(1000000)},
+				{Kind: models.FeeTax, Rule: "vat", Amount: idr(1210000)},
+			},
+			total: 12210000,
+		},
+		{
+			name:  "quoted traveler fee with commission",
+			quote: models.FeeQuote{Country: "SG", Category: "books", Items: idr(100000000), TravelerFee: idr(20000000)},
+			lines: []models.FeeLine{
+				{Kind: models.FeeTraveler, Amount: idr(20000000)},
+				{Kind: models.FeePlatform, Rule: "service", Amount: idr(5000000)},
+				{Kind: models.FeePlatform, Rule: "commission", Amount: idr(2000000)},
+				{Kind: models.FeeTax, Rule: "vat", Amount: idr(13970000)},
+			},
+			total: 140970000,
+		},
+		{
+			name:  "country, category and price band rules with caps",
+			quote: models.FeeQuote{Country: "jp", Category: "electronics", Items: idr(2000000000)},
+			lines: []models.FeeLine{
+				{Kind: models.FeeTraveler, Rule: "japan", Amount: idr(20000000)},
+				{Kind: models.FeePlatform, Rule: "service", Amount: idr(50000000)},
+				{Kind: models.FeePlatform, Rule: "electronics", Amount: idr(2500000)},
+				{Kind: models.FeePlatform, Rule: "commission", Amount: idr(2000000)},
+				{Kind: models.FeeTax, Rule: "vat", Amount: idr(100000000)},
+				{Kind: models.FeeTax, Rule: "luxury", Amount: idr(40000000)},
+			},
+			total: 2214500000,
+		},
+		{
+			name:  "price band upper bound is exclusive",
+			quote: models.FeeQuote{Country: "SG", Category: "books", Items: idr(5000000000)},
+			lines: []models.FeeLine{
+				{Kind: models.FeePlatform, Rule: "service", Amount: idr(50000000)},
+				{Kind: models.FeeTax, Rule: "vat", Amount: idr(100000000)},
+			},
+			total: 5150000000,
+		},
+		{
+			name:    "items in another currency",
+			quote:   models.FeeQuote{Country: "JP", Category: "books", Items: models.Money{Amount: 1500, Currency: "JPY"}},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			breakdown, err := Calculate(schedule, &tt.quote)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("Calculate() error = nil, want an error")
+				}
+
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("Calculate() error = %v", err)
+			}
+
+			if breakdown.ScheduleVersion != schedule.Version {
+				t.Errorf("ScheduleVersion = %d, want %d", breakdown.ScheduleVersion, schedule.Version)
+			}
+
+			if !reflect.DeepEqual(breakdown.Lines, tt.lines) {
+				t.Errorf("Lines = %+v, want %+v", breakdown.Lines, tt.lines)
+			}
+
+			if breakdown.Total != idr(tt.total) {
+				t.Errorf("Total = %s, want %s", breakdown.Total, idr(tt.total))
+			}
+		})
+	}
+}
+
+func TestApply(t *testing.T) {
+	bases := map[string]int64{
+		models.FeeBaseItems:       100000,
+		models.FeeBaseTravelerFee: 30000,
+		models.FeeBaseSubtotal:    150000,
+	}
+
+	tests := []struct {
+		name string
+		rule models.FeeRule
+		want int64
+	}{
+		{name: "percentage of the items by default", rule: models.FeeRule{Percent: 2.5}, want: 2500},
+		{name: "flat only", rule: models.FeeRule{Flat: 700}, want: 700},
+		{name: "percentage plus flat", rule: models.FeeRule{Percent: 1, Flat: 500}, want: 1500},
+		{name: "rounds half away from zero", rule: models.FeeRule{Base: models.FeeBaseTravelerFee, Percent: 0.005}, want: 2},
+		{name: "raised to the minimum", rule: models.FeeRule{Percent: 1, Min: 5000}, want: 5000},
+		{name: "capped at the maximum", rule: models.FeeRule{Base: models.FeeBaseSubtotal, Percent: 10, Max: 9000}, want: 9000},
+		{name: "minimum applies without a percentage", rule: models.FeeRule{Min: 300}, want: 300},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := apply(&tt.rule, bases); got != tt.want {
+				t.Errorf("apply() = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,202 @@
+package fee
+
+import (
+	"context"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/globalsign/mgo/bson"
+
+	"github.com/fidellr/jastip/backend/uranus"
+	"github.com/fidellr/jastip/backend/uranus/auth"
+	"github.com/fidellr/jastip/backend/uranus/models"
+	"github.com/fidellr/jastip/backend/uranus/repository"
+)
+
+type service struct {
+	repository     repository.FeeScheduleRepository
+	schedules      []*models.FeeSchedule
+	validator      uranus.Validate
+	contextTimeout time.Duration
+}
+
+func (s *service) CalculateFees(ctx context.Context, quote *models.FeeQuote) (*models.FeeBreakdown, error) {
+	if ctx == nil {
+		err := uranus.ErrContextNil
+		return nil, err
+	}
+
+	quote.Country = strings.ToUpper(quote.Country)
+	if err := s.validator.ValidateStruct(quote); err != nil {
+		return nil, err
+	}
+
+	if quote.At.IsZero() {
+		quote.At = time.Now()
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, s.contextTimeout)
+	defer cancel()
+
+	schedule, err := s.effectiveSchedule(ctx, quote.At)
+	if err != nil {
+		return nil, err
+	}
+
+	return Calculate(schedule, quote)
+}
+
+func (s *service) FetchFeeSchedules(ctx context.Context) ([]*models.FeeSchedule, error) {
+	if ctx == nil {
+		err := uranus.ErrContextNil
+		return nil, err
+	}
+
+	if err := auth.Authorize(ctx, auth.ActionManageFees); err != nil {
+		return nil, err
+	}
+
+	if s.repository == nil {
+		return s.schedules, nil
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, s.contextTimeout)
+	defer cancel()
+
+	return s.repository.FetchFeeSchedules(ctx)
+}
+
+// StoreFeeSchedule saves the rules as the next schedule version, past versions are kept for the orders placed under them.
+func (s *service) StoreFeeSchedule(ctx context.Context, m *models.FeeSchedule) error {
+	if ctx == nil {
+		return uranus.ErrContextNil
+	}
+
+	if err := auth.Authorize(ctx, auth.ActionManageFees); err != nil {
+		return err
+	}
+
+	if s.repository == nil {
+		return uranus.ConstraintErrorf("Fee schedules are read from the config file, change them there")
+	}
+
+	if err := s.validateSchedule(m); err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, s.contextTimeout)
+	defer cancel()
+
+	latest, err := s.repository.GetLatestFeeSchedule(ctx)
+	switch err {
+	case nil:
+		m.Version = latest.Version + 1
+	case uranus.ErrNotFound:
+		m.Version = 1
+	default:
+		return err
+	}
+
+	m.ID = bson.NewObjectId()
+	m.CreatedAt = time.Now()
+	return s.repository.StoreFeeSchedule(ctx, m)
+}
+
+func (s *service) effectiveSchedule(ctx context.Context, at time.Time) (*models.FeeSchedule, error) {
+	if s.repository != nil {
+		schedule, err := s.repository.GetEffectiveFeeSchedule(ctx, at)
+		if err == uranus.ErrNotFound {
+			return nil, uranus.ErrNoFeeSchedule
+		}
+
+		return schedule, err
+	}
+
+	// Static schedules are sorted by effective date and version, the last one in effect wins.
+	var effective *models.FeeSchedule
+	for _, schedule := range s.schedules {
+		if schedule.EffectiveAt.After(at) {
+			break
+		}
+
+		effective = schedule
+	}
+
+	if effective == nil {
+		return nil, uranus.ErrNoFeeSchedule
+	}
+
+	return effective, nil
+}
+
+func (s *service) validateSchedule(m *models.FeeSchedule) error {
+	m.Currency = strings.ToUpper(m.Currency)
+	if err := s.validator.ValidateStruct(m); err != nil {
+		return err
+	}
+
+	for _, rule := range m.Rules {
+		if rule.PriceTo > 0 && rule.PriceTo <= rule.PriceFrom {
+			return uranus.ConstraintErrorf("Fee rule %s has an empty price band", rule.Name)
+		}
+
+		if rule.Max > 0 && rule.Max < rule.Min {
+			return uranus.ConstraintErrorf("Fee rule %s caps below its minimum", rule.Name)
+		}
+	}
+
+	return nil
+}
+
+type requirement func(*service)
+
+// Repository keeps the fee schedules in a collection, admins add new versions through the API.
+func Repository(repository repository.FeeScheduleRepository) requirement {
+	return func(s *service) {
+		s.repository = repository
+	}
+}
+
+// Schedules sets fixed fee schedules, e.g. from the config file, for when there is no Repository.
+func Schedules(schedules ...*models.FeeSchedule) requirement {
+	return func(s *service) {
+		s.schedules = append(s.schedules, schedules...)
+	}
+}
+
+func Timeout(timeout time.Duration) requirement {
+	return func(s *service) {
+		s.contextTimeout = timeout
+	}
+}
+
+func Validator(validator uranus.Validate) requirement {
+	return func(s *service) {
+		s.validator = validator
+	}
+}
+
+// NewService returns the fee usecase, static schedules that don't validate are an error.
+func NewService(reqs ...requirement) (uranus.FeeUsecase, error) {
+	s := new(service)
+	for _, option := range reqs {
+		option(s)
+	}
+
+	for _, schedule := range s.schedules {
+		if err := s.validateSchedule(schedule); err != nil {
+			return nil, err
+		}
+	}
+
+	sort.SliceStable(s.schedules, func(i, j int) bool {
+		if s.schedules[i].EffectiveAt.Equal(s.schedules[j].EffectiveAt) {
+			return s.schedules[i].Version < s.schedules[j].Version
+		}
+
+		return s.schedules[i].EffectiveAt.Before(s.schedules[j].EffectiveAt)
+	})
+
+	return s, nil
+}
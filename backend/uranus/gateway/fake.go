@@ -0,0 +1,178 @@
+package gateway
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"log"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/globalsign/mgo/bson"
+
+	"github.com/fidellr/jastip/backend/uranus"
+	"github.com/fidellr/jastip/backend/uranus/models"
+)
+
+// HeaderSignature carries the hex HMAC-SHA256 of a webhook payload, signed with the webhook secret.
+const HeaderSignature = "X-Signature"
+
+type fakeGateway struct {
+	mu           sync.Mutex
+	outcome      string
+	secret       []byte
+	webhookURL   string
+	webhookDelay time.Duration
+	client       *http.Client
+	refunded     map[string]int64
+	refundKeys   map[string]bool
+}
+
+type fakeRequirement func(*fakeGateway)
+
+// Outcome sets how every charge ends, models.PaymentSucceeded or models.PaymentFailed. Charges succeed by default.
+func Outcome(outcome string) fakeRequirement {
+	return func(g *fakeGateway) {
+		if outcome == models.PaymentFailed {
+			g.outcome = outcome
+		}
+	}
+}
+
+// Secret sets the secret webhooks are signed with.
+func Secret(secret string) fakeRequirement {
+	return func(g *fakeGateway) {
+		g.secret = []byte(secret)
+	}
+}
+
+// WebhookURL makes charges end through a webhook posted to the url instead of right away,
+// usually the payment webhook of this very uranus.
+func WebhookURL(url string) fakeRequirement {
+	return func(g *fakeGateway) {
+		g.webhookURL = url
+	}
+}
+
+// WebhookDelay sets how long the fake gateway takes before it posts the webhook of a charge.
+func WebhookDelay(delay time.Duration) fakeRequirement {
+	return func(g *fakeGateway) {
+		g.webhookDelay = delay
+	}
+}
+
+// NewFake returns an in-process PaymentGateway that never moves real money, for local development and tests.
+func NewFake(reqs ...fakeRequirement) uranus.PaymentGateway {
+	g := &fakeGateway{
+		outcome:    models.PaymentSucceeded,
+		client:     &http.Client{Timeout: 10 * time.Second},
+		refunded:   make(map[string]int64),
+		refundKeys: make(map[string]bool),
+	}
+	for _, req := range reqs {
+		req(g)
+	}
+
+	return g
+}
+
+func (g *fakeGateway) Charge(ctx context.Context, m *models.Payment) (*models.PaymentEvent, error) {
+	if ctx == nil {
+		return nil, uranus.ErrContextNil
+	}
+
+	if m.GatewayRef == "" {
+		m.GatewayRef = "fake_" + bson.NewObjectId().Hex()
+	}
+
+	event := &models.PaymentEvent{GatewayRef: m.GatewayRef, Status: g.outcome}
+	if g.outcome == models.PaymentFailed {
+		event.FailureReason = "Card declined by the fake gateway"
+	}
+
+	if g.webhookURL == "" {
+		event.OccurredAt = time.Now()
+		return event, nil
+	}
+
+	go g.deliver(event)
+	return nil, nil
+}
+
+// Refund pays back part or all of a charge, never more than was charged in total. A key that was refunded before is a no-op.
+func (g *fakeGateway) Refund(ctx context.Context, m *models.Payment, amount int64, key string) error {
+	if ctx == nil {
+		return uranus.ErrContextNil
+	}
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	if g.refundKeys[key] {
+		return nil
+	}
+
+	if g.refunded[m.GatewayRef]+amount > m.Amount.Amount {
+		return uranus.ConstraintErrorf("Payment %s has only %d left to refund", m.GatewayRef, m.Amount.Amount-g.refunded[m.GatewayRef])
+	}
+
+	g.refunded[m.GatewayRef] += amount
+	g.refundKeys[key] = true
+	return nil
+}
+
+func (g *fakeGateway) ParseWebhook(payload []byte, signature string) (*models.PaymentEvent, error) {
+	if !hmac.Equal([]byte(Sign(g.secret, payload)), []byte(signature)) {
+		return nil, uranus.ErrInvalidSignature
+	}
+
+	event := new(models.PaymentEvent)
+	if err := json.Unmarshal(payload, event); err != nil {
+		return nil, uranus.ConstraintErrorf("%s", err.Error())
+	}
+
+	return event, nil
+}
+
+// deliver posts the event to the webhook like a real gateway would, failures are only logged.
+func (g *fakeGateway) deliver(event *models.PaymentEvent) {
+	time.Sleep(g.webhookDelay)
+	event.OccurredAt = time.Now()
+
+	payload, err := json.Marshal(event)
+	if err != nil {
+		log.Printf("Failed to encode fake webhook : %s", err.Error())
+		return
+	}
+
+	req, err := http.NewRequest(http.MethodPost, g.webhookURL, bytes.NewReader(payload))
+	if err != nil {
+		log.Printf("Failed to build fake webhook : %s", err.Error())
+		return
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(HeaderSignature, Sign(g.secret, payload))
+
+	res, err := g.client.Do(req)
+	if err != nil {
+		log.Printf("Failed to deliver fake webhook : %s", err.Error())
+		return
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode >= http.StatusMultipleChoices {
+		log.Printf("Fake webhook for %s was answered with %d", event.GatewayRef, res.StatusCode)
+	}
+}
+
+// Sign signs a webhook payload the way the fake gateway does.
+func Sign(secret []byte, payload []byte) string {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write(payload)
+	return hex.EncodeToString(mac.Sum(nil))
+}
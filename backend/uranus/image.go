@@ -0,0 +1,13 @@
+package uranus
+
+import (
+	"context"
+
+	"github.com/globalsign/mgo/bson"
+)
+
+// ImageStore checks the images uploaded to plateu, uranus only keeps their IDs.
+type ImageStore interface {
+	// CheckImages returns a ConstraintError unless every image exists and was uploaded by the user.
+	CheckImages(ctx context.Context, userID string, imageIDs []bson.ObjectId) error
+}
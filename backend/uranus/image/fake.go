@@ -0,0 +1,42 @@
+package image
+
+import (
+	"context"
+	"sync"
+
+	"github.com/globalsign/mgo/bson"
+
+	"github.com/fidellr/jastip/backend/uranus"
+)
+
+type fakeStore struct {
+	mu       sync.Mutex
+	uploader map[bson.ObjectId]string
+}
+
+// NewFake returns an ImageStore that makes uploads up, for local development and tests.
+// Every valid image ID exists and the first user to refer to it is taken as its uploader.
+func NewFake() uranus.ImageStore {
+	return &fakeStore{uploader: make(map[bson.ObjectId]string)}
+}
+
+func (s *fakeStore) CheckImages(ctx context.Context, userID string, imageIDs []bson.ObjectId) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, imageID := range imageIDs {
+		if !imageID.Valid() {
+			return uranus.ConstraintErrorf("Image %q doesn't exist", string(imageID))
+		}
+
+		if uploader, ok := s.uploader[imageID]; ok && uploader != userID {
+			return uranus.ConstraintErrorf("Image %s wasn't uploaded by you", imageID.Hex())
+		}
+	}
+
+	for _, imageID := range imageIDs {
+		s.uploader[imageID] = userID
+	}
+
+	return nil
+}
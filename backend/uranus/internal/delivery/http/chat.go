@@ -0,0 +1,311 @@
+package http
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/globalsign/mgo/bson"
+	"github.com/gorilla/websocket"
+	"github.com/labstack/echo"
+
+	"github.com/fidellr/jastip/backend/uranus"
+	"github.com/fidellr/jastip/backend/uranus/models"
+)
+
+// Timings of the live chat connection, a client that doesn't answer a ping within pongWait is dropped.
+const (
+	chatWriteWait  = 10 * time.Second
+	chatPongWait   = 60 * time.Second
+	chatPingPeriod = chatPongWait * 9 / 10
+	chatReadLimit  = 16 << 10
+)
+
+type chatHandler struct {
+	service  uranus.ChatUsecase
+	upgrader websocket.Upgrader
+}
+
+func (h *chatHandler) OpenConversation(c echo.Context) error {
+	ctx := c.Request().Context()
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	open := new(models.ConversationOpen)
+	if err := c.Bind(open); err != nil {
+		return uranus.ConstraintErrorf("%s", err.Error())
+	}
+
+	conversation, err := h.service.OpenConversation(ctx, open)
+	if err != nil {
+		return responseError(err)
+	}
+
+	return c.JSON(http.StatusOK, conversation)
+}
+
+func (h *chatHandler) FetchConversations(c echo.Context) (err error) {
+	ctx := c.Request().Context()
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	var num int
+	if c.QueryParam("num") != "" {
+		num, err = strconv.Atoi(c.QueryParam("num"))
+		if err != nil {
+			return uranus.ConstraintErrorf("%s", err.Error())
+		}
+	}
+
+	filter := uranus.ConversationFilter{
+		Num:           num,
+		Cursor:        c.QueryParam("cursor"),
+		ParticipantID: c.QueryParam("participant_id"),
+		Scope:         c.QueryParam("scope"),
+		ScopeID:       c.QueryParam("scope_id"),
+	}
+
+	conversations, page, err := h.service.FetchConversations(ctx, &filter)
+	if err != nil {
+		return responseError(err)
+	}
+
+	c.Response().Header().Set("X-Cursor", page.Next)
+	c.Response().Header().Set("X-Prev-Cursor", page.Prev)
+	c.Response().Header().Set("X-Total-Count", strconv.Itoa(page.Total))
+	return c.JSON(http.StatusOK, conversations)
+}
+
+func (h *chatHandler) GetConversationByID(c echo.Context) error {
+	ctx := c.Request().Context()
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	conversation, err := h.service.GetConversationByID(ctx, c.Param("id"))
+	if err != nil {
+		return responseError(err)
+	}
+
+	return c.JSON(http.StatusOK, conversation)
+}
+
+func (h *chatHandler) FetchMessages(c echo.Context) (err error) {
+	ctx := c.Request().Context()
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	var num int
+	if c.QueryParam("num") != "" {
+		num, err = strconv.Atoi(c.QueryParam("num"))
+		if err != nil {
+			return uranus.ConstraintErrorf("%s", err.Error())
+		}
+	}
+
+	filter := uranus.ChatMessageFilter{
+		Num:            num,
+		Cursor:         c.QueryParam("cursor"),
+		ConversationID: c.Param("id"),
+	}
+
+	messages, page, err := h.service.FetchMessages(ctx, &filter)
+	if err != nil {
+		return responseError(err)
+	}
+
+	c.Response().Header().Set("X-Cursor", page.Next)
+	c.Response().Header().Set("X-Prev-Cursor", page.Prev)
+	c.Response().Header().Set("X-Total-Count", strconv.Itoa(page.Total))
+	return c.JSON(http.StatusOK, messages)
+}
+
+func (h *chatHandler) SendMessage(c echo.Context) error {
+	ctx := c.Request().Context()
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	message := new(models.ChatMessage)
+	if err := c.Bind(message); err != nil {
+		return uranus.ConstraintErrorf("%s", err.Error())
+	}
+
+	if err := h.service.SendMessage(ctx, c.Param("id"), message); err != nil {
+		return responseError(err)
+	}
+
+	return c.JSON(http.StatusCreated, message)
+}
+
+func (h *chatHandler) MarkRead(c echo.Context) error {
+	ctx := c.Request().Context()
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	read := new(models.ChatRead)
+	if err := c.Bind(read); err != nil {
+		return uranus.ConstraintErrorf("%s", err.Error())
+	}
+
+	if err := h.service.MarkRead(ctx, c.Param("id"), read.MessageID.Hex()); err != nil {
+		return responseError(err)
+	}
+
+	return c.NoContent(http.StatusNoContent)
+}
+
+// Connect upgrades to a WebSocket that delivers the events of all the caller's conversations
+// and takes their messages, read receipts and typing notices as ChatCommands.
+func (h *chatHandler) Connect(c echo.Context) error {
+	ctx := c.Request().Context()
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	events, err := h.service.Listen(ctx)
+	if err != nil {
+		return responseError(err)
+	}
+
+	ws, err := h.upgrader.Upgrade(c.Response(), c.Request(), nil)
+	if err != nil {
+		return err
+	}
+	defer ws.Close()
+
+	replies := make(chan *models.ChatEvent, 8)
+	go h.read(ctx, cancel, ws, replies)
+	h.write(ctx, ws, events, replies)
+	return nil
+}
+
+// read runs the client's commands until the connection breaks, failed commands are answered with an error event.
+func (h *chatHandler) read(ctx context.Context, cancel context.CancelFunc, ws *websocket.Conn, replies chan<- *models.ChatEvent) {
+	defer cancel()
+
+	ws.SetReadLimit(chatReadLimit)
+	ws.SetReadDeadline(time.Now().Add(chatPongWait))
+	ws.SetPongHandler(func(string) error {
+		return ws.SetReadDeadline(time.Now().Add(chatPongWait))
+	})
+
+	for {
+		command := new(models.ChatCommand)
+		if err := ws.ReadJSON(command); err != nil {
+			return
+		}
+
+		var err error
+		switch command.Type {
+		case models.ChatEventMessage:
+			err = h.service.SendMessage(ctx, command.ConversationID, &models.ChatMessage{Body: command.Body, ImageIDs: command.ImageIDs})
+		case models.ChatEventRead:
+			err = h.service.MarkRead(ctx, command.ConversationID, command.MessageID)
+		case models.ChatEventTyping:
+			err = h.service.Typing(ctx, command.ConversationID)
+		default:
+			err = uranus.ConstraintErrorf("Unknown chat command %s", command.Type)
+		}
+
+		if err == nil {
+			continue
+		}
+
+		reply := &models.ChatEvent{Type: models.ChatEventError, Error: err.Error(), At: time.Now()}
+		if bson.IsObjectIdHex(command.ConversationID) {
+			reply.ConversationID = bson.ObjectIdHex(command.ConversationID)
+		}
+
+		select {
+		case replies <- reply:
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// write is the only writer of the connection, it sends the events and keeps the connection alive with pings.
+func (h *chatHandler) write(ctx context.Context, ws *websocket.Conn, events <-chan *models.ChatEvent, replies <-chan *models.ChatEvent) {
+	ticker := time.NewTicker(chatPingPeriod)
+	defer ticker.Stop()
+
+	for {
+		var event *models.ChatEvent
+		select {
+		case <-ctx.Done():
+			ws.SetWriteDeadline(time.Now().Add(chatWriteWait))
+			ws.WriteMessage(websocket.CloseMessage, websocket.FormatCloseMessage(websocket.CloseNormalClosure, ""))
+			return
+		case <-ticker.C:
+			ws.SetWriteDeadline(time.Now().Add(chatWriteWait))
+			if err := ws.WriteMessage(websocket.PingMessage, nil); err != nil {
+				return
+			}
+			continue
+		case reply := <-replies:
+			event = reply
+		case next, ok := <-events:
+			if !ok {
+				return
+			}
+			event = next
+		}
+
+		ws.SetWriteDeadline(time.Now().Add(chatWriteWait))
+		if err := ws.WriteJSON(event); err != nil {
+			return
+		}
+	}
+}
+
+type chatRequirements func(d *chatHandler)
+
+func ChatService(service uranus.ChatUsecase) chatRequirements {
+	return func(d *chatHandler) {
+		d.service = service
+	}
+}
+
+// ChatOrigins sets the origins browsers may open the chat WebSocket from, by default only the API's own host.
+// Clients that send no Origin, like the mobile apps, are always let in.
+func ChatOrigins(origins ...string) chatRequirements {
+	return func(d *chatHandler) {
+		if len(origins) == 0 {
+			return
+		}
+
+		allowed := make(map[string]bool, len(origins))
+		for _, origin := range origins {
+			allowed[origin] = true
+		}
+
+		d.upgrader.CheckOrigin = func(r *http.Request) bool {
+			origin := r.Header.Get("Origin")
+			return origin == "" || allowed[origin]
+		}
+	}
+}
+
+func NewChatHandler(e *echo.Echo, reqs ...chatRequirements) {
+	handler := new(chatHandler)
+	for _, req := range reqs {
+		req(handler)
+	}
+
+	e.POST("/chat/conversations", handler.OpenConversation)
+	e.GET("/chat/conversations", handler.FetchConversations)
+	e.GET("/chat/conversation/:id", handler.GetConversationByID)
+	e.GET("/chat/messages/:id", handler.FetchMessages)
+	e.POST("/chat/message/:id", handler.SendMessage)
+	e.POST("/chat/read/:id", handler.MarkRead)
+	e.GET("/chat/ws", handler.Connect)
+}
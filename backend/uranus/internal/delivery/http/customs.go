@@ -0,0 +1,75 @@
+package http
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/labstack/echo"
+
+	"github.com/fidellr/jastip/backend/uranus"
+)
+
+type customsHandler struct {
+	service uranus.CustomsUsecase
+}
+
+func (h *customsHandler) CheckPurchaseRequest(c echo.Context) error {
+	ctx := c.Request().Context()
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	check, err := h.service.CheckPurchaseRequestByID(ctx, c.Param("id"))
+	if err != nil {
+		return responseError(err)
+	}
+
+	return c.JSON(http.StatusOK, check)
+}
+
+func (h *customsHandler) CheckOrder(c echo.Context) error {
+	ctx := c.Request().Context()
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	check, err := h.service.CheckOrderByID(ctx, c.Param("id"))
+	if err != nil {
+		return responseError(err)
+	}
+
+	return c.JSON(http.StatusOK, check)
+}
+
+func (h *customsHandler) GetCustomsRules(c echo.Context) error {
+	ctx := c.Request().Context()
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	rules, err := h.service.GetCustomsRules(ctx)
+	if err != nil {
+		return responseError(err)
+	}
+
+	return c.JSON(http.StatusOK, rules)
+}
+
+type customsRequirements func(d *customsHandler)
+
+func CustomsService(service uranus.CustomsUsecase) customsRequirements {
+	return func(d *customsHandler) {
+		d.service = service
+	}
+}
+
+func NewCustomsHandler(e *echo.Echo, reqs ...customsRequirements) {
+	handler := new(customsHandler)
+	for _, req := range reqs {
+		req(handler)
+	}
+
+	e.GET("/request/customs/:id", handler.CheckPurchaseRequest)
+	e.GET("/order/customs/:id", handler.CheckOrder)
+	e.GET("/customs/rules", handler.GetCustomsRules)
+}
@@ -0,0 +1,154 @@
+package http
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+
+	"github.com/labstack/echo"
+
+	"github.com/fidellr/jastip/backend/uranus"
+	"github.com/fidellr/jastip/backend/uranus/models"
+)
+
+type disputeHandler struct {
+	service uranus.DisputeUsecase
+}
+
+func (h *disputeHandler) OpenDispute(c echo.Context) error {
+	ctx := c.Request().Context()
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	opening := new(models.DisputeOpening)
+	if err := c.Bind(opening); err != nil {
+		return uranus.ConstraintErrorf("%s", err.Error())
+	}
+
+	dispute, err := h.service.OpenDispute(ctx, c.Param("id"), opening)
+	if err != nil {
+		return responseError(err)
+	}
+
+	return c.JSON(http.StatusCreated, dispute)
+}
+
+func (h *disputeHandler) FetchDisputes(c echo.Context) (err error) {
+	ctx := c.Request().Context()
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	var num int
+	if c.QueryParam("num") != "" {
+		num, err = strconv.Atoi(c.QueryParam("num"))
+		if err != nil {
+			return uranus.ConstraintErrorf("%s", err.Error())
+		}
+	}
+
+	filter := uranus.DisputeFilter{
+		Num:     num,
+		Cursor:  c.QueryParam("cursor"),
+		OrderID: c.QueryParam("order_id"),
+		Status:  c.QueryParam("status"),
+	}
+
+	disputes, page, err := h.service.FetchDisputes(ctx, &filter)
+	if err != nil {
+		return responseError(err)
+	}
+
+	c.Response().Header().Set("X-Cursor", page.Next)
+	c.Response().Header().Set("X-Prev-Cursor", page.Prev)
+	c.Response().Header().Set("X-Total-Count", strconv.Itoa(page.Total))
+	return c.JSON(http.StatusOK, disputes)
+}
+
+func (h *disputeHandler) GetDisputeByID(c echo.Context) error {
+	ctx := c.Request().Context()
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	dispute, err := h.service.GetDisputeByID(ctx, c.Param("id"))
+	if err != nil {
+		return responseError(err)
+	}
+
+	return c.JSON(http.StatusOK, dispute)
+}
+
+func (h *disputeHandler) PostDisputeMessage(c echo.Context) error {
+	ctx := c.Request().Context()
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	message := new(models.DisputeMessage)
+	if err := c.Bind(message); err != nil {
+		return uranus.ConstraintErrorf("%s", err.Error())
+	}
+
+	if err := h.service.PostDisputeMessage(ctx, c.Param("id"), message); err != nil {
+		return responseError(err)
+	}
+
+	return c.JSON(http.StatusCreated, message)
+}
+
+func (h *disputeHandler) FetchDisputeMessages(c echo.Context) error {
+	ctx := c.Request().Context()
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	messages, err := h.service.FetchDisputeMessages(ctx, c.Param("id"))
+	if err != nil {
+		return responseError(err)
+	}
+
+	return c.JSON(http.StatusOK, messages)
+}
+
+func (h *disputeHandler) ResolveDispute(c echo.Context) error {
+	ctx := c.Request().Context()
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	resolution := new(models.DisputeResolution)
+	if err := c.Bind(resolution); err != nil {
+		return uranus.ConstraintErrorf("%s", err.Error())
+	}
+
+	dispute, err := h.service.ResolveDispute(ctx, c.Param("id"), resolution)
+	if err != nil {
+		return responseError(err)
+	}
+
+	return c.JSON(http.StatusOK, dispute)
+}
+
+type disputeRequirements func(d *disputeHandler)
+
+func DisputeService(service uranus.DisputeUsecase) disputeRequirements {
+	return func(d *disputeHandler) {
+		d.service = service
+	}
+}
+
+func NewDisputeHandler(e *echo.Echo, reqs ...disputeRequirements) {
+	handler := new(disputeHandler)
+	for _, req := range reqs {
+		req(handler)
+	}
+
+	e.POST("/order/dispute/:id", handler.OpenDispute)
+	e.GET("/disputes", handler.FetchDisputes)
+	e.GET("/dispute/:id", handler.GetDisputeByID)
+	e.POST("/dispute/message/:id", handler.PostDisputeMessage)
+	e.GET("/dispute/messages/:id", handler.FetchDisputeMessages)
+	e.POST("/dispute/resolve/:id", handler.ResolveDispute)
+}
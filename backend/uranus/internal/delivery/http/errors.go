@@ -0,0 +1,42 @@
+package http
+
+import (
+	"net/http"
+
+	"github.com/labstack/echo"
+
+	"github.com/fidellr/jastip/backend/uranus"
+	"github.com/fidellr/jastip/backend/uranus/models"
+)
+
+// responseError gives the errors of the usecases their HTTP status,
+// anything unknown is left to the uncaught error handler.
+func responseError(err error) error {
+	switch err {
+	case uranus.ErrNotFound:
+		return echo.NewHTTPError(http.StatusNotFound, err.Error())
+	case uranus.ErrInvalidCursor, uranus.ErrInvalidSort, uranus.ErrInvalidSignature:
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	case uranus.ErrProfileRoleMismatch, uranus.ErrNoExchangeRate, uranus.ErrNoFeeSchedule:
+		return echo.NewHTTPError(http.StatusUnprocessableEntity, err.Error())
+	case uranus.ErrVersionConflict:
+		return echo.NewHTTPError(http.StatusPreconditionFailed, err.Error())
+	case uranus.ErrMissingIfMatch:
+		return echo.NewHTTPError(http.StatusPreconditionRequired, err.Error())
+	case uranus.ErrStaleStatus, uranus.ErrAlreadyReviewed:
+		return echo.NewHTTPError(http.StatusConflict, err.Error())
+	}
+
+	switch err.(type) {
+	case *uranus.ForbiddenError:
+		return err
+	case *uranus.CustomsError, *models.CurrencyMismatchError, *models.UnknownCurrencyError:
+		return echo.NewHTTPError(http.StatusUnprocessableEntity, err.Error())
+	case *uranus.IllegalTransitionError:
+		return echo.NewHTTPError(http.StatusConflict, err.Error())
+	case *uranus.ErrValidation, uranus.ConstraintError:
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+
+	return err
+}
@@ -0,0 +1,89 @@
+package http
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+
+	"github.com/labstack/echo"
+
+	"github.com/fidellr/jastip/backend/uranus"
+	"github.com/fidellr/jastip/backend/uranus/models"
+)
+
+type exchangeRateHandler struct {
+	service uranus.ExchangeRateUsecase
+}
+
+func (h *exchangeRateHandler) UploadRates(c echo.Context) error {
+	ctx := c.Request().Context()
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	rates := make([]*models.ExchangeRate, 0)
+	if err := c.Bind(&rates); err != nil {
+		return uranus.ConstraintErrorf("%s", err.Error())
+	}
+
+	stored, err := h.service.UploadRates(ctx, rates)
+	if err != nil {
+		return responseError(err)
+	}
+
+	return c.JSON(http.StatusCreated, echo.Map{"stored": stored})
+}
+
+func (h *exchangeRateHandler) FetchRates(c echo.Context) (err error) {
+	ctx := c.Request().Context()
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	var num int
+	if c.QueryParam("num") != "" {
+		num, err = strconv.Atoi(c.QueryParam("num"))
+		if err != nil {
+			return uranus.ConstraintErrorf("%s", err.Error())
+		}
+	}
+
+	filter := uranus.ExchangeRateFilter{
+		Num:   num,
+		Base:  c.QueryParam("base"),
+		Quote: c.QueryParam("quote"),
+	}
+
+	if filter.From, err = timeQueryParam(c, "from"); err != nil {
+		return err
+	}
+
+	if filter.To, err = timeQueryParam(c, "to"); err != nil {
+		return err
+	}
+
+	rates, err := h.service.FetchRates(ctx, &filter)
+	if err != nil {
+		return responseError(err)
+	}
+
+	return c.JSON(http.StatusOK, rates)
+}
+
+type exchangeRateRequirements func(d *exchangeRateHandler)
+
+func ExchangeRateService(service uranus.ExchangeRateUsecase) exchangeRateRequirements {
+	return func(d *exchangeRateHandler) {
+		d.service = service
+	}
+}
+
+func NewExchangeRateHandler(e *echo.Echo, reqs ...exchangeRateRequirements) {
+	handler := new(exchangeRateHandler)
+	for _, req := range reqs {
+		req(handler)
+	}
+
+	e.POST("/rates/upload", handler.UploadRates)
+	e.GET("/rates", handler.FetchRates)
+}
@@ -0,0 +1,85 @@
+package http
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/labstack/echo"
+
+	"github.com/fidellr/jastip/backend/uranus"
+	"github.com/fidellr/jastip/backend/uranus/models"
+)
+
+type feeHandler struct {
+	service uranus.FeeUsecase
+}
+
+func (h *feeHandler) CalculateFees(c echo.Context) error {
+	ctx := c.Request().Context()
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	quote := new(models.FeeQuote)
+	if err := c.Bind(quote); err != nil {
+		return uranus.ConstraintErrorf("%s", err.Error())
+	}
+
+	breakdown, err := h.service.CalculateFees(ctx, quote)
+	if err != nil {
+		return responseError(err)
+	}
+
+	return c.JSON(http.StatusOK, breakdown)
+}
+
+func (h *feeHandler) FetchFeeSchedules(c echo.Context) error {
+	ctx := c.Request().Context()
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	schedules, err := h.service.FetchFeeSchedules(ctx)
+	if err != nil {
+		return responseError(err)
+	}
+
+	return c.JSON(http.StatusOK, schedules)
+}
+
+func (h *feeHandler) StoreFeeSchedule(c echo.Context) error {
+	ctx := c.Request().Context()
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	schedule := new(models.FeeSchedule)
+	if err := c.Bind(schedule); err != nil {
+		return uranus.ConstraintErrorf("%s", err.Error())
+	}
+
+	if err := h.service.StoreFeeSchedule(ctx, schedule); err != nil {
+		return responseError(err)
+	}
+
+	return c.JSON(http.StatusCreated, schedule)
+}
+
+type feeRequirements func(d *feeHandler)
+
+func FeeService(service uranus.FeeUsecase) feeRequirements {
+	return func(d *feeHandler) {
+		d.service = service
+	}
+}
+
+func NewFeeHandler(e *echo.Echo, reqs ...feeRequirements) {
+	handler := new(feeHandler)
+	for _, req := range reqs {
+		req(handler)
+	}
+
+	e.POST("/fees/quote", handler.CalculateFees)
+	e.GET("/fees/schedules", handler.FetchFeeSchedules)
+	e.POST("/fees/schedules", handler.StoreFeeSchedule)
+}
@@ -0,0 +1,86 @@
+package http
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+
+	"github.com/labstack/echo"
+
+	"github.com/fidellr/jastip/backend/uranus"
+)
+
+type notificationHandler struct {
+	service uranus.NotificationUsecase
+}
+
+// FetchInbox lists the caller's in-app notifications, newest first, ?unread=true leaves out the read ones.
+func (h *notificationHandler) FetchInbox(c echo.Context) (err error) {
+	ctx := c.Request().Context()
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	var num int
+	if c.QueryParam("num") != "" {
+		num, err = strconv.Atoi(c.QueryParam("num"))
+		if err != nil {
+			return uranus.ConstraintErrorf("%s", err.Error())
+		}
+	}
+
+	var unread bool
+	if c.QueryParam("unread") != "" {
+		unread, err = strconv.ParseBool(c.QueryParam("unread"))
+		if err != nil {
+			return uranus.ConstraintErrorf("%s", err.Error())
+		}
+	}
+
+	filter := uranus.InboxFilter{
+		Num:    num,
+		Cursor: c.QueryParam("cursor"),
+		Unread: unread,
+	}
+
+	items, page, err := h.service.FetchInbox(ctx, &filter)
+	if err != nil {
+		return responseError(err)
+	}
+
+	c.Response().Header().Set("X-Cursor", page.Next)
+	c.Response().Header().Set("X-Prev-Cursor", page.Prev)
+	c.Response().Header().Set("X-Total-Count", strconv.Itoa(page.Total))
+	return c.JSON(http.StatusOK, items)
+}
+
+func (h *notificationHandler) MarkInboxRead(c echo.Context) error {
+	ctx := c.Request().Context()
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	if err := h.service.MarkInboxRead(ctx, c.Param("id")); err != nil {
+		return responseError(err)
+	}
+
+	return c.NoContent(http.StatusNoContent)
+}
+
+type notificationRequirements func(d *notificationHandler)
+
+func NotificationService(service uranus.NotificationUsecase) notificationRequirements {
+	return func(d *notificationHandler) {
+		d.service = service
+	}
+}
+
+func NewNotificationHandler(e *echo.Echo, reqs ...notificationRequirements) {
+	handler := new(notificationHandler)
+	for _, req := range reqs {
+		req(handler)
+	}
+
+	e.GET("/notifications", handler.FetchInbox)
+	e.POST("/notification/read/:id", handler.MarkInboxRead)
+}
@@ -0,0 +1,174 @@
+package http
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+
+	"github.com/labstack/echo"
+
+	"github.com/fidellr/jastip/backend/uranus"
+	"github.com/fidellr/jastip/backend/uranus/models"
+)
+
+type offerHandler struct {
+	service uranus.OfferUsecase
+}
+
+func (h *offerHandler) CreateOffer(c echo.Context) error {
+	ctx := c.Request().Context()
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	offer := new(models.Offer)
+	if err := c.Bind(offer); err != nil {
+		return uranus.ConstraintErrorf("%s", err.Error())
+	}
+
+	if err := h.service.CreateOffer(ctx, offer); err != nil {
+		return responseError(err)
+	}
+
+	return c.JSON(http.StatusCreated, offer)
+}
+
+func (h *offerHandler) FetchOffers(c echo.Context) (err error) {
+	ctx := c.Request().Context()
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	var num int
+	if c.QueryParam("num") != "" {
+		num, err = strconv.Atoi(c.QueryParam("num"))
+		if err != nil {
+			return uranus.ConstraintErrorf("%s", err.Error())
+		}
+	}
+
+	filter := uranus.OfferFilter{
+		Num:        num,
+		Cursor:     c.QueryParam("cursor"),
+		RequestID:  c.QueryParam("request_id"),
+		TripID:     c.QueryParam("trip_id"),
+		TravelerID: c.QueryParam("traveler_id"),
+		Status:     c.QueryParam("status"),
+	}
+
+	offers, page, err := h.service.FetchOffers(ctx, &filter)
+	if err != nil {
+		return responseError(err)
+	}
+
+	c.Response().Header().Set("X-Cursor", page.Next)
+	c.Response().Header().Set("X-Prev-Cursor", page.Prev)
+	c.Response().Header().Set("X-Total-Count", strconv.Itoa(page.Total))
+	return c.JSON(http.StatusOK, offers)
+}
+
+func (h *offerHandler) GetOfferByID(c echo.Context) error {
+	ctx := c.Request().Context()
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	offer, err := h.service.GetOfferByID(ctx, c.Param("id"))
+	if err != nil {
+		return responseError(err)
+	}
+
+	return c.JSON(http.StatusOK, offer)
+}
+
+func (h *offerHandler) AcceptOffer(c echo.Context) error {
+	ctx := c.Request().Context()
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	order, err := h.service.AcceptOffer(ctx, c.Param("id"))
+	if err != nil {
+		return responseError(err)
+	}
+
+	return c.JSON(http.StatusCreated, order)
+}
+
+func (h *offerHandler) DeclineOffer(c echo.Context) error {
+	ctx := c.Request().Context()
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	offer, err := h.service.DeclineOffer(ctx, c.Param("id"))
+	if err != nil {
+		return responseError(err)
+	}
+
+	return c.JSON(http.StatusOK, offer)
+}
+
+func (h *offerHandler) WithdrawOffer(c echo.Context) error {
+	ctx := c.Request().Context()
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	offer, err := h.service.WithdrawOffer(ctx, c.Param("id"))
+	if err != nil {
+		return responseError(err)
+	}
+
+	return c.JSON(http.StatusOK, offer)
+}
+
+func (h *offerHandler) FetchMatches(c echo.Context) (err error) {
+	ctx := c.Request().Context()
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	tripID := c.QueryParam("trip_id")
+	if tripID == "" {
+		return echo.NewHTTPError(http.StatusBadRequest, "Missing trip_id")
+	}
+
+	var num int
+	if c.QueryParam("num") != "" {
+		num, err = strconv.Atoi(c.QueryParam("num"))
+		if err != nil {
+			return uranus.ConstraintErrorf("%s", err.Error())
+		}
+	}
+
+	matches, err := h.service.FetchMatches(ctx, tripID, num)
+	if err != nil {
+		return responseError(err)
+	}
+
+	return c.JSON(http.StatusOK, matches)
+}
+
+type offerRequirements func(d *offerHandler)
+
+func OfferService(service uranus.OfferUsecase) offerRequirements {
+	return func(d *offerHandler) {
+		d.service = service
+	}
+}
+
+func NewOfferHandler(e *echo.Echo, reqs ...offerRequirements) {
+	handler := new(offerHandler)
+	for _, req := range reqs {
+		req(handler)
+	}
+
+	e.POST("/offer/create", handler.CreateOffer)
+	e.GET("/offers", handler.FetchOffers)
+	e.GET("/offer/:id", handler.GetOfferByID)
+	e.POST("/offer/accept/:id", handler.AcceptOffer)
+	e.POST("/offer/decline/:id", handler.DeclineOffer)
+	e.POST("/offer/withdraw/:id", handler.WithdrawOffer)
+	e.GET("/matches", handler.FetchMatches)
+}
@@ -0,0 +1,101 @@
+package http
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+
+	"github.com/labstack/echo"
+
+	"github.com/fidellr/jastip/backend/uranus"
+	"github.com/fidellr/jastip/backend/uranus/models"
+)
+
+type orderHandler struct {
+	service uranus.OrderUsecase
+}
+
+func (h *orderHandler) FetchOrders(c echo.Context) (err error) {
+	ctx := c.Request().Context()
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	var num int
+	if c.QueryParam("num") != "" {
+		num, err = strconv.Atoi(c.QueryParam("num"))
+		if err != nil {
+			return uranus.ConstraintErrorf("%s", err.Error())
+		}
+	}
+
+	filter := uranus.OrderFilter{
+		Num:        num,
+		Cursor:     c.QueryParam("cursor"),
+		BuyerID:    c.QueryParam("buyer_id"),
+		TravelerID: c.QueryParam("traveler_id"),
+		Status:     c.QueryParam("status"),
+	}
+
+	orders, page, err := h.service.FetchOrders(ctx, &filter)
+	if err != nil {
+		return responseError(err)
+	}
+
+	c.Response().Header().Set("X-Cursor", page.Next)
+	c.Response().Header().Set("X-Prev-Cursor", page.Prev)
+	c.Response().Header().Set("X-Total-Count", strconv.Itoa(page.Total))
+	return c.JSON(http.StatusOK, orders)
+}
+
+func (h *orderHandler) GetOrderByID(c echo.Context) error {
+	ctx := c.Request().Context()
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	order, err := h.service.GetOrderByID(ctx, c.Param("id"))
+	if err != nil {
+		return responseError(err)
+	}
+
+	return c.JSON(http.StatusOK, order)
+}
+
+func (h *orderHandler) MoveOrder(c echo.Context) error {
+	ctx := c.Request().Context()
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	move := new(models.OrderMove)
+	if err := c.Bind(move); err != nil {
+		return uranus.ConstraintErrorf("%s", err.Error())
+	}
+
+	order, err := h.service.MoveOrder(ctx, c.Param("id"), move)
+	if err != nil {
+		return responseError(err)
+	}
+
+	return c.JSON(http.StatusOK, order)
+}
+
+type orderRequirements func(d *orderHandler)
+
+func OrderService(service uranus.OrderUsecase) orderRequirements {
+	return func(d *orderHandler) {
+		d.service = service
+	}
+}
+
+func NewOrderHandler(e *echo.Echo, reqs ...orderRequirements) {
+	handler := new(orderHandler)
+	for _, req := range reqs {
+		req(handler)
+	}
+
+	e.GET("/orders", handler.FetchOrders)
+	e.GET("/order/:id", handler.GetOrderByID)
+	e.POST("/order/move/:id", handler.MoveOrder)
+}
@@ -0,0 +1,115 @@
+package http
+
+import (
+	"context"
+	"io/ioutil"
+	"net/http"
+
+	"github.com/labstack/echo"
+
+	"github.com/fidellr/jastip/backend/uranus"
+	"github.com/fidellr/jastip/backend/uranus/gateway"
+)
+
+type paymentHandler struct {
+	service uranus.PaymentUsecase
+}
+
+func (h *paymentHandler) PayOrder(c echo.Context) error {
+	ctx := c.Request().Context()
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	payment, err := h.service.PayOrder(ctx, c.Param("id"))
+	if err != nil {
+		return responseError(err)
+	}
+
+	return c.JSON(http.StatusCreated, payment)
+}
+
+func (h *paymentHandler) HandleWebhook(c echo.Context) error {
+	ctx := c.Request().Context()
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	payload, err := ioutil.ReadAll(c.Request().Body)
+	if err != nil {
+		return uranus.ConstraintErrorf("%s", err.Error())
+	}
+
+	if err = h.service.HandleWebhook(ctx, payload, c.Request().Header.Get(gateway.HeaderSignature)); err != nil {
+		return responseError(err)
+	}
+
+	return c.NoContent(http.StatusNoContent)
+}
+
+func (h *paymentHandler) SettleOrder(c echo.Context) error {
+	ctx := c.Request().Context()
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	if err := h.service.SettleOrder(ctx, c.Param("id")); err != nil {
+		return responseError(err)
+	}
+
+	return c.NoContent(http.StatusNoContent)
+}
+
+func (h *paymentHandler) FetchJournalEntries(c echo.Context) error {
+	ctx := c.Request().Context()
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	entries, err := h.service.FetchJournalEntries(ctx, c.Param("id"))
+	if err != nil {
+		return responseError(err)
+	}
+
+	return c.JSON(http.StatusOK, entries)
+}
+
+func (h *paymentHandler) GetBalance(c echo.Context) error {
+	ctx := c.Request().Context()
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	account := c.QueryParam("account")
+	if account == "" {
+		return echo.NewHTTPError(http.StatusBadRequest, "Missing account")
+	}
+
+	balance, err := h.service.GetBalance(ctx, account)
+	if err != nil {
+		return responseError(err)
+	}
+
+	return c.JSON(http.StatusOK, balance)
+}
+
+type paymentRequirements func(d *paymentHandler)
+
+func PaymentService(service uranus.PaymentUsecase) paymentRequirements {
+	return func(d *paymentHandler) {
+		d.service = service
+	}
+}
+
+func NewPaymentHandler(e *echo.Echo, reqs ...paymentRequirements) {
+	handler := new(paymentHandler)
+	for _, req := range reqs {
+		req(handler)
+	}
+
+	e.POST("/order/pay/:id", handler.PayOrder)
+	e.POST("/order/settle/:id", handler.SettleOrder)
+	e.GET("/order/ledger/:id", handler.FetchJournalEntries)
+	e.GET("/ledger/balance", handler.GetBalance)
+	e.POST("/payment/webhook", handler.HandleWebhook)
+}
@@ -0,0 +1,107 @@
+package http
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/labstack/echo"
+
+	"github.com/fidellr/jastip/backend/uranus"
+	"github.com/fidellr/jastip/backend/uranus/models"
+)
+
+func (h *userHandler) GetTravelerProfile(c echo.Context) error {
+	ctx := c.Request().Context()
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	profile, err := h.service.GetTravelerProfile(ctx, c.Param("id"))
+	if err != nil {
+		return responseError(err)
+	}
+
+	return c.JSON(http.StatusOK, profile)
+}
+
+func (h *userHandler) UpdateTravelerProfile(c echo.Context) error {
+	ctx := c.Request().Context()
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	profile := new(models.TravelerProfile)
+	if err := c.Bind(profile); err != nil {
+		return uranus.ConstraintErrorf("%s", err.Error())
+	}
+
+	if err := h.service.UpdateTravelerProfile(ctx, c.Param("id"), profile); err != nil {
+		return responseError(err)
+	}
+
+	return c.JSON(http.StatusOK, profile)
+}
+
+func (h *userHandler) GetBuyerProfile(c echo.Context) error {
+	ctx := c.Request().Context()
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	profile, err := h.service.GetBuyerProfile(ctx, c.Param("id"))
+	if err != nil {
+		return responseError(err)
+	}
+
+	return c.JSON(http.StatusOK, profile)
+}
+
+func (h *userHandler) UpdateBuyerProfile(c echo.Context) error {
+	ctx := c.Request().Context()
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	profile := new(models.BuyerProfile)
+	if err := c.Bind(profile); err != nil {
+		return uranus.ConstraintErrorf("%s", err.Error())
+	}
+
+	if err := h.service.UpdateBuyerProfile(ctx, c.Param("id"), profile); err != nil {
+		return responseError(err)
+	}
+
+	return c.JSON(http.StatusOK, profile)
+}
+
+func (h *userHandler) GetNotificationPreferences(c echo.Context) error {
+	ctx := c.Request().Context()
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	prefs, err := h.service.GetNotificationPreferences(ctx, c.Param("id"))
+	if err != nil {
+		return responseError(err)
+	}
+
+	return c.JSON(http.StatusOK, prefs)
+}
+
+func (h *userHandler) UpdateNotificationPreferences(c echo.Context) error {
+	ctx := c.Request().Context()
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	prefs := new(models.NotificationPreferences)
+	if err := c.Bind(prefs); err != nil {
+		return uranus.ConstraintErrorf("%s", err.Error())
+	}
+
+	if err := h.service.UpdateNotificationPreferences(ctx, c.Param("id"), prefs); err != nil {
+		return responseError(err)
+	}
+
+	return c.JSON(http.StatusOK, prefs)
+}
@@ -0,0 +1,134 @@
+package http
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+
+	"github.com/labstack/echo"
+
+	"github.com/fidellr/jastip/backend/uranus"
+	"github.com/fidellr/jastip/backend/uranus/models"
+)
+
+type purchaseRequestHandler struct {
+	service uranus.PurchaseRequestUsecase
+}
+
+func (h *purchaseRequestHandler) CreatePurchaseRequest(c echo.Context) error {
+	ctx := c.Request().Context()
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	request := new(models.PurchaseRequest)
+	if err := c.Bind(request); err != nil {
+		return uranus.ConstraintErrorf("%s", err.Error())
+	}
+
+	if err := h.service.CreatePurchaseRequest(ctx, request); err != nil {
+		return responseError(err)
+	}
+
+	return c.JSON(http.StatusCreated, request)
+}
+
+func (h *purchaseRequestHandler) FetchPurchaseRequests(c echo.Context) (err error) {
+	ctx := c.Request().Context()
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	var num int
+	if c.QueryParam("num") != "" {
+		num, err = strconv.Atoi(c.QueryParam("num"))
+		if err != nil {
+			return uranus.ConstraintErrorf("%s", err.Error())
+		}
+	}
+
+	filter := uranus.PurchaseRequestFilter{
+		Num:                num,
+		Cursor:             c.QueryParam("cursor"),
+		BuyerID:            c.QueryParam("buyer_id"),
+		DestinationCountry: c.QueryParam("destination_country"),
+		Category:           c.QueryParam("category"),
+		Status:             c.QueryParam("status"),
+	}
+
+	requests, page, err := h.service.FetchPurchaseRequests(ctx, &filter)
+	if err != nil {
+		return responseError(err)
+	}
+
+	c.Response().Header().Set("X-Cursor", page.Next)
+	c.Response().Header().Set("X-Prev-Cursor", page.Prev)
+	c.Response().Header().Set("X-Total-Count", strconv.Itoa(page.Total))
+	return c.JSON(http.StatusOK, requests)
+}
+
+func (h *purchaseRequestHandler) GetPurchaseRequestByID(c echo.Context) error {
+	ctx := c.Request().Context()
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	request, err := h.service.GetPurchaseRequestByID(ctx, c.Param("id"))
+	if err != nil {
+		return responseError(err)
+	}
+
+	return c.JSON(http.StatusOK, request)
+}
+
+func (h *purchaseRequestHandler) UpdatePurchaseRequestByID(c echo.Context) error {
+	ctx := c.Request().Context()
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	request := new(models.PurchaseRequest)
+	if err := c.Bind(request); err != nil {
+		return uranus.ConstraintErrorf("%s", err.Error())
+	}
+
+	if err := h.service.UpdatePurchaseRequestByID(ctx, c.Param("id"), request); err != nil {
+		return responseError(err)
+	}
+
+	return c.JSON(http.StatusOK, request)
+}
+
+func (h *purchaseRequestHandler) CancelPurchaseRequest(c echo.Context) error {
+	ctx := c.Request().Context()
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	if err := h.service.CancelPurchaseRequest(ctx, c.Param("id")); err != nil {
+		return responseError(err)
+	}
+
+	return c.JSON(http.StatusOK, true)
+}
+
+type purchaseRequestRequirements func(d *purchaseRequestHandler)
+
+func PurchaseRequestService(service uranus.PurchaseRequestUsecase) purchaseRequestRequirements {
+	return func(d *purchaseRequestHandler) {
+		d.service = service
+	}
+}
+
+func NewPurchaseRequestHandler(e *echo.Echo, reqs ...purchaseRequestRequirements) {
+	handler := new(purchaseRequestHandler)
+	for _, req := range reqs {
+		req(handler)
+	}
+
+	e.POST("/request/create", handler.CreatePurchaseRequest)
+	e.GET("/requests", handler.FetchPurchaseRequests)
+	e.GET("/request/:id", handler.GetPurchaseRequestByID)
+	e.PUT("/request/:id", handler.UpdatePurchaseRequestByID)
+	e.POST("/request/cancel/:id", handler.CancelPurchaseRequest)
+}
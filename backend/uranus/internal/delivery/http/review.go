@@ -0,0 +1,153 @@
+package http
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+
+	"github.com/labstack/echo"
+
+	"github.com/fidellr/jastip/backend/uranus"
+	"github.com/fidellr/jastip/backend/uranus/models"
+)
+
+type reviewHandler struct {
+	service uranus.ReviewUsecase
+}
+
+func (h *reviewHandler) CreateReview(c echo.Context) error {
+	ctx := c.Request().Context()
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	review := new(models.Review)
+	if err := c.Bind(review); err != nil {
+		return uranus.ConstraintErrorf("%s", err.Error())
+	}
+
+	if err := h.service.CreateReview(ctx, c.Param("id"), review); err != nil {
+		return responseError(err)
+	}
+
+	return c.JSON(http.StatusCreated, review)
+}
+
+func (h *reviewHandler) UpdateReview(c echo.Context) error {
+	ctx := c.Request().Context()
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	edit := new(models.Review)
+	if err := c.Bind(edit); err != nil {
+		return uranus.ConstraintErrorf("%s", err.Error())
+	}
+
+	review, err := h.service.UpdateReview(ctx, c.Param("id"), edit)
+	if err != nil {
+		return responseError(err)
+	}
+
+	return c.JSON(http.StatusOK, review)
+}
+
+func (h *reviewHandler) GetReviewByID(c echo.Context) error {
+	ctx := c.Request().Context()
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	review, err := h.service.GetReviewByID(ctx, c.Param("id"))
+	if err != nil {
+		return responseError(err)
+	}
+
+	return c.JSON(http.StatusOK, review)
+}
+
+func (h *reviewHandler) FetchReviews(c echo.Context) error {
+	return h.fetchReviews(c, c.QueryParam("reviewee_id"))
+}
+
+// FetchUserReviews lists the reviews the user got, the reputation shown on their profile.
+func (h *reviewHandler) FetchUserReviews(c echo.Context) error {
+	return h.fetchReviews(c, c.Param("id"))
+}
+
+func (h *reviewHandler) fetchReviews(c echo.Context, revieweeID string) (err error) {
+	ctx := c.Request().Context()
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	var num, rating int
+	if c.QueryParam("num") != "" {
+		num, err = strconv.Atoi(c.QueryParam("num"))
+		if err != nil {
+			return uranus.ConstraintErrorf("%s", err.Error())
+		}
+	}
+
+	if c.QueryParam("rating") != "" {
+		rating, err = strconv.Atoi(c.QueryParam("rating"))
+		if err != nil {
+			return uranus.ConstraintErrorf("%s", err.Error())
+		}
+	}
+
+	filter := uranus.ReviewFilter{
+		Num:        num,
+		Cursor:     c.QueryParam("cursor"),
+		RevieweeID: revieweeID,
+		ReviewerID: c.QueryParam("reviewer_id"),
+		OrderID:    c.QueryParam("order_id"),
+		Rating:     rating,
+	}
+
+	reviews, page, err := h.service.FetchReviews(ctx, &filter)
+	if err != nil {
+		return responseError(err)
+	}
+
+	c.Response().Header().Set("X-Cursor", page.Next)
+	c.Response().Header().Set("X-Prev-Cursor", page.Prev)
+	c.Response().Header().Set("X-Total-Count", strconv.Itoa(page.Total))
+	return c.JSON(http.StatusOK, reviews)
+}
+
+func (h *reviewHandler) GetRatingSummary(c echo.Context) error {
+	ctx := c.Request().Context()
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	summary, err := h.service.GetRatingSummary(ctx, c.Param("id"))
+	if err != nil {
+		return responseError(err)
+	}
+
+	return c.JSON(http.StatusOK, summary)
+}
+
+type reviewRequirements func(d *reviewHandler)
+
+func ReviewService(service uranus.ReviewUsecase) reviewRequirements {
+	return func(d *reviewHandler) {
+		d.service = service
+	}
+}
+
+func NewReviewHandler(e *echo.Echo, reqs ...reviewRequirements) {
+	handler := new(reviewHandler)
+	for _, req := range reqs {
+		req(handler)
+	}
+
+	e.POST("/order/review/:id", handler.CreateReview)
+	e.PUT("/review/:id", handler.UpdateReview)
+	e.GET("/review/:id", handler.GetReviewByID)
+	e.GET("/reviews", handler.FetchReviews)
+	e.GET("/user/:id/reviews", handler.FetchUserReviews)
+	e.GET("/user/:id/rating", handler.GetRatingSummary)
+}
@@ -0,0 +1,80 @@
+package http
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/labstack/echo"
+
+	"github.com/fidellr/jastip/backend/uranus"
+	"github.com/fidellr/jastip/backend/uranus/models"
+)
+
+type shipmentHandler struct {
+	service uranus.ShipmentUsecase
+}
+
+func (h *shipmentHandler) AppendShipmentEvent(c echo.Context) error {
+	ctx := c.Request().Context()
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	event := new(models.ShipmentEvent)
+	if err := c.Bind(event); err != nil {
+		return uranus.ConstraintErrorf("%s", err.Error())
+	}
+
+	if err := h.service.AppendShipmentEvent(ctx, c.Param("id"), event); err != nil {
+		return responseError(err)
+	}
+
+	return c.JSON(http.StatusCreated, event)
+}
+
+func (h *shipmentHandler) FetchShipmentEvents(c echo.Context) error {
+	ctx := c.Request().Context()
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	events, err := h.service.FetchShipmentEvents(ctx, c.Param("id"))
+	if err != nil {
+		return responseError(err)
+	}
+
+	return c.JSON(http.StatusOK, events)
+}
+
+func (h *shipmentHandler) GetShipmentTimeline(c echo.Context) error {
+	ctx := c.Request().Context()
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	timeline, err := h.service.GetShipmentTimeline(ctx, c.Param("id"))
+	if err != nil {
+		return responseError(err)
+	}
+
+	return c.JSON(http.StatusOK, timeline)
+}
+
+type shipmentRequirements func(d *shipmentHandler)
+
+func ShipmentService(service uranus.ShipmentUsecase) shipmentRequirements {
+	return func(d *shipmentHandler) {
+		d.service = service
+	}
+}
+
+func NewShipmentHandler(e *echo.Echo, reqs ...shipmentRequirements) {
+	handler := new(shipmentHandler)
+	for _, req := range reqs {
+		req(handler)
+	}
+
+	e.POST("/order/shipment/:id", handler.AppendShipmentEvent)
+	e.GET("/order/shipment/:id", handler.FetchShipmentEvents)
+	e.GET("/order/timeline/:id", handler.GetShipmentTimeline)
+}
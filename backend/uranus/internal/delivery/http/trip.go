@@ -0,0 +1,142 @@
+package http
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+
+	"github.com/labstack/echo"
+
+	"github.com/fidellr/jastip/backend/uranus"
+	"github.com/fidellr/jastip/backend/uranus/models"
+)
+
+type tripHandler struct {
+	service uranus.TripUsecase
+}
+
+func (h *tripHandler) CreateTrip(c echo.Context) error {
+	ctx := c.Request().Context()
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	trip := new(models.Trip)
+	if err := c.Bind(trip); err != nil {
+		return uranus.ConstraintErrorf("%s", err.Error())
+	}
+
+	if err := h.service.CreateTrip(ctx, trip); err != nil {
+		return responseError(err)
+	}
+
+	return c.JSON(http.StatusCreated, trip)
+}
+
+func (h *tripHandler) FetchTrips(c echo.Context) (err error) {
+	ctx := c.Request().Context()
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	var num int
+	if c.QueryParam("num") != "" {
+		num, err = strconv.Atoi(c.QueryParam("num"))
+		if err != nil {
+			return uranus.ConstraintErrorf("%s", err.Error())
+		}
+	}
+
+	filter := uranus.TripFilter{
+		Num:                num,
+		Cursor:             c.QueryParam("cursor"),
+		TravelerID:         c.QueryParam("traveler_id"),
+		DestinationCountry: c.QueryParam("destination_country"),
+		DestinationCity:    c.QueryParam("destination_city"),
+		Status:             c.QueryParam("status"),
+	}
+
+	if filter.DepartureFrom, err = timeQueryParam(c, "departure_from"); err != nil {
+		return err
+	}
+
+	if filter.DepartureTo, err = timeQueryParam(c, "departure_to"); err != nil {
+		return err
+	}
+
+	trips, page, err := h.service.FetchTrips(ctx, &filter)
+	if err != nil {
+		return responseError(err)
+	}
+
+	c.Response().Header().Set("X-Cursor", page.Next)
+	c.Response().Header().Set("X-Prev-Cursor", page.Prev)
+	c.Response().Header().Set("X-Total-Count", strconv.Itoa(page.Total))
+	return c.JSON(http.StatusOK, trips)
+}
+
+func (h *tripHandler) GetTripByID(c echo.Context) error {
+	ctx := c.Request().Context()
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	trip, err := h.service.GetTripByID(ctx, c.Param("id"))
+	if err != nil {
+		return responseError(err)
+	}
+
+	return c.JSON(http.StatusOK, trip)
+}
+
+func (h *tripHandler) UpdateTripByID(c echo.Context) error {
+	ctx := c.Request().Context()
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	trip := new(models.Trip)
+	if err := c.Bind(trip); err != nil {
+		return uranus.ConstraintErrorf("%s", err.Error())
+	}
+
+	if err := h.service.UpdateTripByID(ctx, c.Param("id"), trip); err != nil {
+		return responseError(err)
+	}
+
+	return c.JSON(http.StatusOK, trip)
+}
+
+func (h *tripHandler) RemoveTripByID(c echo.Context) error {
+	ctx := c.Request().Context()
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	if err := h.service.RemoveTripByID(ctx, c.Param("id")); err != nil {
+		return responseError(err)
+	}
+
+	return c.NoContent(http.StatusOK)
+}
+
+type tripRequirements func(d *tripHandler)
+
+func TripService(service uranus.TripUsecase) tripRequirements {
+	return func(d *tripHandler) {
+		d.service = service
+	}
+}
+
+func NewTripHandler(e *echo.Echo, reqs ...tripRequirements) {
+	handler := new(tripHandler)
+	for _, req := range reqs {
+		req(handler)
+	}
+
+	e.POST("/trip/create", handler.CreateTrip)
+	e.GET("/trips", handler.FetchTrips)
+	e.GET("/trip/:id", handler.GetTripByID)
+	e.PUT("/trip/:id", handler.UpdateTripByID)
+	e.DELETE("/trip/:id", handler.RemoveTripByID)
+}
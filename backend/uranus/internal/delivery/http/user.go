@@ -2,8 +2,11 @@ package http
 
 import (
 	"context"
+	"fmt"
+	"io/ioutil"
 	"net/http"
 	"strconv"
+	"time"
 
 	"github.com/labstack/echo"
 
@@ -11,6 +14,11 @@ import (
 	"github.com/fidellr/jastip/backend/uranus/models"
 )
 
+const (
+	headerETag    = "ETag"
+	headerIfMatch = "If-Match"
+)
+
 type userHandler struct {
 	service uranus.UserAccountUsecase
 }
@@ -28,13 +36,13 @@ func (h *userHandler) CreateAccount(c echo.Context) error {
 
 	err := h.service.CreateUserAccount(ctx, u)
 	if err != nil {
-		return uranus.ConstraintErrorf("%s", err.Error())
+		return responseError(err)
 	}
 
 	return c.JSON(http.StatusCreated, u)
 }
 
-func (h *userHandler) Fetch(c echo.Context) error {
+func (h *userHandler) Fetch(c echo.Context) (err error) {
 	ctx := c.Request().Context()
 	if ctx == nil {
 		ctx = context.Background()
@@ -42,7 +50,6 @@ func (h *userHandler) Fetch(c echo.Context) error {
 
 	var num int
 	if c.QueryParam("num") != "" {
-		var err error
 		num, err = strconv.Atoi(c.QueryParam("num"))
 		if err != nil {
 			return uranus.ConstraintErrorf("%s", err.Error())
@@ -53,14 +60,34 @@ func (h *userHandler) Fetch(c echo.Context) error {
 		Cursor:   c.QueryParam("cursor"),
 		Num:      num,
 		RoleName: c.QueryParam("role"),
+		Query:    c.QueryParam("q"),
+		Sort:     c.QueryParam("sort"),
+	}
+
+	if filter.IsBanned, err = boolQueryParam(c, "banned"); err != nil {
+		return err
 	}
 
-	users, nextCursors, err := h.service.Fetch(ctx, &filter)
+	if filter.IsVerified, err = boolQueryParam(c, "verified"); err != nil {
+		return err
+	}
+
+	if filter.CreatedFrom, err = timeQueryParam(c, "created_from"); err != nil {
+		return err
+	}
+
+	if filter.CreatedTo, err = timeQueryParam(c, "created_to"); err != nil {
+		return err
+	}
+
+	users, page, err := h.service.Fetch(ctx, &filter)
 	if err != nil {
-		return uranus.ConstraintErrorf("%s", err.Error())
+		return responseError(err)
 	}
 
-	c.Response().Header().Set("X-Cursor", nextCursors)
+	c.Response().Header().Set("X-Cursor", page.Next)
+	c.Response().Header().Set("X-Prev-Cursor", page.Prev)
+	c.Response().Header().Set("X-Total-Count", strconv.Itoa(page.Total))
 	return c.JSON(http.StatusOK, users)
 }
 
@@ -73,9 +100,10 @@ func (h *userHandler) GetUserByID(c echo.Context) error {
 	id := c.Param("id")
 	user, err := h.service.GetUserByID(ctx, id)
 	if err != nil {
-		return uranus.ConstraintErrorf("%s", err.Error())
+		return responseError(err)
 	}
 
+	c.Response().Header().Set(headerETag, uranus.ETag(user.Version))
 	return c.JSON(http.StatusOK, user)
 }
 
@@ -85,13 +113,60 @@ func (h *userHandler) SuspendAccount(c echo.Context) error {
 		ctx = context.Background()
 	}
 
+	suspension := new(models.Suspension)
+	if err := c.Bind(suspension); err != nil {
+		return uranus.ConstraintErrorf("%s", err.Error())
+	}
+
+	uuid := c.Param("id")
+	isSuspended, err := h.service.SuspendAccount(ctx, uuid, suspension)
+	if err != nil {
+		return responseError(err)
+	}
+
+	if !isSuspended {
+		return uranus.ConstraintErrorf("failed to suspend account")
+	}
+
+	return c.JSON(http.StatusOK, suspension)
+}
+
+func (h *userHandler) ReinstateAccount(c echo.Context) error {
+	ctx := c.Request().Context()
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	reinstatement := new(models.Reinstatement)
+	if err := c.Bind(reinstatement); err != nil {
+		return uranus.ConstraintErrorf("%s", err.Error())
+	}
+
 	uuid := c.Param("id")
-	isSuspended, err := h.service.SuspendAccount(ctx, uuid)
-	if !isSuspended || err != nil {
-		return uranus.ConstraintErrorf("failed to suspend account %t: %s", isSuspended, err.Error())
+	isReinstated, err := h.service.ReinstateAccount(ctx, uuid, reinstatement)
+	if err != nil {
+		return responseError(err)
+	}
+
+	if !isReinstated {
+		return uranus.ConstraintErrorf("failed to reinstate account")
+	}
+
+	return c.JSON(http.StatusOK, isReinstated)
+}
+
+func (h *userHandler) FetchSuspensions(c echo.Context) error {
+	ctx := c.Request().Context()
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	suspensions, err := h.service.FetchSuspensions(ctx, c.Param("id"))
+	if err != nil {
+		return responseError(err)
 	}
 
-	return c.JSON(http.StatusOK, isSuspended)
+	return c.JSON(http.StatusOK, suspensions)
 }
 
 func (h *userHandler) RemoveAccount(c echo.Context) error {
@@ -102,13 +177,36 @@ func (h *userHandler) RemoveAccount(c echo.Context) error {
 
 	uuid := c.Param("id")
 	isDeleted, err := h.service.RemoveAccount(ctx, uuid)
-	if !isDeleted || err != nil {
-		return uranus.ConstraintErrorf("failed to remove account %t: %s", isDeleted, err.Error())
+	if err != nil {
+		return responseError(err)
+	}
+
+	if !isDeleted {
+		return uranus.ConstraintErrorf("failed to remove account")
 	}
 
 	return c.JSON(http.StatusOK, isDeleted)
 }
 
+func (h *userHandler) RestoreAccount(c echo.Context) error {
+	ctx := c.Request().Context()
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	uuid := c.Param("id")
+	isRestored, err := h.service.RestoreAccount(ctx, uuid)
+	if err != nil {
+		return responseError(err)
+	}
+
+	if !isRestored {
+		return uranus.ConstraintErrorf("failed to restore account")
+	}
+
+	return c.JSON(http.StatusOK, isRestored)
+}
+
 func (h *userHandler) UpdateUserByID(c echo.Context) (err error) {
 	ctx := c.Request().Context()
 	if ctx == nil {
@@ -121,14 +219,153 @@ func (h *userHandler) UpdateUserByID(c echo.Context) (err error) {
 		return uranus.ConstraintErrorf("%s", err.Error())
 	}
 
+	u.Version, err = uranus.IfMatchVersion(c.Request().Header.Get(headerIfMatch))
+	if err != nil {
+		return responseError(err)
+	}
+
 	err = h.service.UpdateUserByID(ctx, id, u)
 	if err != nil {
+		return responseError(err)
+	}
+
+	c.Response().Header().Set(headerETag, uranus.ETag(u.Version))
+	return c.JSON(http.StatusOK, true)
+}
+
+func (h *userHandler) PatchUserByID(c echo.Context) error {
+	ctx := c.Request().Context()
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	version, err := uranus.IfMatchVersion(c.Request().Header.Get(headerIfMatch))
+	if err != nil {
+		return responseError(err)
+	}
+
+	patch, err := ioutil.ReadAll(c.Request().Body)
+	if err != nil {
+		return uranus.ConstraintErrorf("%s", err.Error())
+	}
+
+	user, err := h.service.PatchUserByID(ctx, c.Param("id"), version, patch)
+	if err != nil {
+		return responseError(err)
+	}
+
+	c.Response().Header().Set(headerETag, uranus.ETag(user.Version))
+	return c.JSON(http.StatusOK, user)
+}
+
+func (h *userHandler) Login(c echo.Context) error {
+	ctx := c.Request().Context()
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	credential := new(models.Credential)
+	if err := c.Bind(credential); err != nil {
+		return uranus.ConstraintErrorf("%s", err.Error())
+	}
+
+	token, err := h.service.Login(ctx, credential)
+	if err != nil {
+		switch err {
+		case uranus.ErrInvalidCredentials:
+			return echo.NewHTTPError(http.StatusUnauthorized, err.Error())
+		case uranus.ErrAccountSuspended:
+			return echo.NewHTTPError(http.StatusForbidden, err.Error())
+		}
+
+		return uranus.ConstraintErrorf("%s", err.Error())
+	}
+
+	return c.JSON(http.StatusOK, token)
+}
+
+func (h *userHandler) VerifyEmail(c echo.Context) error {
+	ctx := c.Request().Context()
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	verification := new(models.EmailVerification)
+	if err := c.Bind(verification); err != nil {
+		return uranus.ConstraintErrorf("%s", err.Error())
+	}
+
+	if err := h.service.VerifyEmail(ctx, verification.Token); err != nil {
+		return uranus.ConstraintErrorf("%s", err.Error())
+	}
+
+	return c.JSON(http.StatusOK, true)
+}
+
+func (h *userHandler) ForgotPassword(c echo.Context) error {
+	ctx := c.Request().Context()
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	forgot := new(models.ForgotPassword)
+	if err := c.Bind(forgot); err != nil {
+		return uranus.ConstraintErrorf("%s", err.Error())
+	}
+
+	if err := h.service.ForgotPassword(ctx, forgot.EmailAddress); err != nil {
+		return uranus.ConstraintErrorf("%s", err.Error())
+	}
+
+	return c.JSON(http.StatusAccepted, true)
+}
+
+func (h *userHandler) ResetPassword(c echo.Context) error {
+	ctx := c.Request().Context()
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	reset := new(models.PasswordReset)
+	if err := c.Bind(reset); err != nil {
+		return uranus.ConstraintErrorf("%s", err.Error())
+	}
+
+	if err := h.service.ResetPassword(ctx, reset); err != nil {
 		return uranus.ConstraintErrorf("%s", err.Error())
 	}
 
 	return c.JSON(http.StatusOK, true)
 }
 
+// boolQueryParam reads an optional true/false query param, nil means the param was not given.
+func boolQueryParam(c echo.Context, name string) (*bool, error) {
+	if c.QueryParam(name) == "" {
+		return nil, nil
+	}
+
+	value, err := strconv.ParseBool(c.QueryParam(name))
+	if err != nil {
+		return nil, echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Invalid %s, expected true or false", name))
+	}
+
+	return &value, nil
+}
+
+// timeQueryParam reads an optional RFC 3339 time query param, the zero time means the param was not given.
+func timeQueryParam(c echo.Context, name string) (time.Time, error) {
+	if c.QueryParam(name) == "" {
+		return time.Time{}, nil
+	}
+
+	value, err := time.Parse(time.RFC3339, c.QueryParam(name))
+	if err != nil {
+		return time.Time{}, echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Invalid %s, expected an RFC 3339 time", name))
+	}
+
+	return value, nil
+}
+
 type userRequirements func(d *userHandler)
 
 func UserService(service uranus.UserAccountUsecase) userRequirements {
@@ -148,6 +385,20 @@ func NewUserHandler(e *echo.Echo, reqs ...userRequirements) {
 	e.GET("/user", handler.Fetch)
 	e.GET("/user/:id", handler.GetUserByID)
 	e.POST("/user/suspend/:id", handler.SuspendAccount)
+	e.POST("/user/reinstate/:id", handler.ReinstateAccount)
+	e.GET("/user/:id/suspensions", handler.FetchSuspensions)
 	e.DELETE("/user/:id", handler.RemoveAccount)
+	e.POST("/user/restore/:id", handler.RestoreAccount)
 	e.PUT("/user/:id", handler.UpdateUserByID)
+	e.PATCH("/user/:id", handler.PatchUserByID)
+	e.GET("/user/:id/profile/traveler", handler.GetTravelerProfile)
+	e.PUT("/user/:id/profile/traveler", handler.UpdateTravelerProfile)
+	e.GET("/user/:id/profile/buyer", handler.GetBuyerProfile)
+	e.PUT("/user/:id/profile/buyer", handler.UpdateBuyerProfile)
+	e.GET("/user/:id/notifications", handler.GetNotificationPreferences)
+	e.PUT("/user/:id/notifications", handler.UpdateNotificationPreferences)
+	e.POST("/user/verify", handler.VerifyEmail)
+	e.POST("/auth/login", handler.Login)
+	e.POST("/auth/password/forgot", handler.ForgotPassword)
+	e.POST("/auth/password/reset", handler.ResetPassword)
 }
@@ -0,0 +1,234 @@
+package mongo
+
+import (
+	"context"
+	"log"
+
+	"github.com/globalsign/mgo"
+	"github.com/globalsign/mgo/bson"
+
+	"github.com/fidellr/jastip/backend/uranus"
+	"github.com/fidellr/jastip/backend/uranus/models"
+	"github.com/fidellr/jastip/backend/uranus/repository"
+)
+
+var (
+	conversationCollectionName = "conversations"
+	chatMessageCollectionName  = "chat_messages"
+)
+
+type chatMongoRepository struct {
+	Session *mgo.Session
+	DBName  string
+}
+
+type chatRequirement func(*chatMongoRepository)
+
+func ChatSession(session *mgo.Session) chatRequirement {
+	return func(r *chatMongoRepository) {
+		r.Session = session
+	}
+}
+
+func ChatDBName(dbName string) chatRequirement {
+	return func(r *chatMongoRepository) {
+		r.DBName = dbName
+	}
+}
+
+func NewChatMongo(reqs ...chatRequirement) repository.ChatRepository {
+	repo := new(chatMongoRepository)
+	for _, req := range reqs {
+		req(repo)
+	}
+
+	repo.ensureIndexes()
+	return repo
+}
+
+func (r *chatMongoRepository) ensureIndexes() {
+	session := r.Session.Clone()
+	defer session.Close()
+
+	conversationIndexes := []mgo.Index{
+		{Key: []string{"scope", "scope_id", "traveler_id"}, Unique: true},
+		{Key: []string{"buyer_id", "-updated_at"}},
+		{Key: []string{"traveler_id", "-updated_at"}},
+	}
+
+	for _, index := range conversationIndexes {
+		if err := session.DB(r.DBName).C(conversationCollectionName).EnsureIndex(index); err != nil {
+			log.Printf("Failed to ensure conversation indexes : %s", err.Error())
+		}
+	}
+
+	index := mgo.Index{Key: []string{"conversation_id", "-created_at"}}
+	if err := session.DB(r.DBName).C(chatMessageCollectionName).EnsureIndex(index); err != nil {
+		log.Printf("Failed to ensure chat message indexes : %s", err.Error())
+	}
+}
+
+// UpsertConversation stores the conversation unless there already is one about the same request or order
+// with the same traveler, either way the stored conversation comes back.
+func (r *chatMongoRepository) UpsertConversation(ctx context.Context, m *models.Conversation) (*models.Conversation, error) {
+	session := r.Session.Clone()
+	defer session.Close()
+
+	query := bson.M{"scope": m.Scope, "scope_id": m.ScopeID, "traveler_id": m.TravelerID}
+	change := mgo.Change{Update: bson.M{"$setOnInsert": m}, Upsert: true, ReturnNew: true}
+
+	stored := new(models.Conversation)
+	if _, err := session.DB(r.DBName).C(conversationCollectionName).Find(query).Apply(change, stored); err != nil {
+		log.Printf("Failed to upsert conversation : %s", err.Error())
+		return nil, err
+	}
+
+	return stored, nil
+}
+
+func (r *chatMongoRepository) FetchConversations(ctx context.Context, filter *uranus.ConversationFilter) ([]*models.Conversation, uranus.Page, error) {
+	session := r.Session.Clone()
+	defer session.Close()
+
+	query := bson.M{}
+	if filter.ParticipantID != "" {
+		if !bson.IsObjectIdHex(filter.ParticipantID) {
+			return make([]*models.Conversation, 0), uranus.Page{}, nil
+		}
+
+		participantID := bson.ObjectIdHex(filter.ParticipantID)
+		query["$or"] = []bson.M{{"buyer_id": participantID}, {"traveler_id": participantID}}
+	}
+
+	if filter.Scope != "" {
+		query["scope"] = filter.Scope
+	}
+
+	if filter.ScopeID != "" {
+		if !bson.IsObjectIdHex(filter.ScopeID) {
+			return make([]*models.Conversation, 0), uranus.Page{}, nil
+		}
+
+		query["scope_id"] = bson.ObjectIdHex(filter.ScopeID)
+	}
+
+	m := make([]*models.Conversation, 0)
+	page, err := paginate(session.DB(r.DBName).C(conversationCollectionName), query, uranus.ConversationSort, filter.Cursor, filter.Num, &m, func(i int) (interface{}, bson.ObjectId) {
+		return m[i].UpdatedAt, m[i].ID
+	})
+	if err != nil {
+		log.Printf("Failed to fetch conversations : %s", err.Error())
+		return nil, uranus.Page{}, err
+	}
+
+	return m, page, nil
+}
+
+func (r *chatMongoRepository) GetConversationByID(ctx context.Context, conversationID string) (*models.Conversation, error) {
+	session := r.Session.Clone()
+	defer session.Close()
+
+	if !bson.IsObjectIdHex(conversationID) {
+		return nil, uranus.ErrNotFound
+	}
+
+	m := new(models.Conversation)
+	if err := session.DB(r.DBName).C(conversationCollectionName).FindId(bson.ObjectIdHex(conversationID)).One(m); err != nil {
+		if err == mgo.ErrNotFound {
+			return nil, uranus.ErrNotFound
+		}
+
+		log.Printf("Failed to get conversation : %s", err.Error())
+		return nil, err
+	}
+
+	return m, nil
+}
+
+// StoreMessage stores the message and bumps its conversation to the top of the participants' lists.
+func (r *chatMongoRepository) StoreMessage(ctx context.Context, m *models.ChatMessage) error {
+	session := r.Session.Clone()
+	defer session.Close()
+
+	if err := session.DB(r.DBName).C(chatMessageCollectionName).Insert(m); err != nil {
+		log.Printf("Failed to store chat message : %s", err.Error())
+		return err
+	}
+
+	update := bson.M{"$set": bson.M{"updated_at": m.CreatedAt, "last_message_at": m.CreatedAt}}
+	if err := session.DB(r.DBName).C(conversationCollectionName).UpdateId(m.ConversationID, update); err != nil {
+		log.Printf("Failed to touch conversation : %s", err.Error())
+		return err
+	}
+
+	return nil
+}
+
+func (r *chatMongoRepository) FetchMessages(ctx context.Context, filter *uranus.ChatMessageFilter) ([]*models.ChatMessage, uranus.Page, error) {
+	session := r.Session.Clone()
+	defer session.Close()
+
+	if !bson.IsObjectIdHex(filter.ConversationID) {
+		return make([]*models.ChatMessage, 0), uranus.Page{}, nil
+	}
+
+	query := bson.M{"conversation_id": bson.ObjectIdHex(filter.ConversationID)}
+	m := make([]*models.ChatMessage, 0)
+	page, err := paginate(session.DB(r.DBName).C(chatMessageCollectionName), query, uranus.ChatMessageSort, filter.Cursor, filter.Num, &m, func(i int) (interface{}, bson.ObjectId) {
+		return m[i].CreatedAt, m[i].ID
+	})
+	if err != nil {
+		log.Printf("Failed to fetch chat messages : %s", err.Error())
+		return nil, uranus.Page{}, err
+	}
+
+	return m, page, nil
+}
+
+func (r *chatMongoRepository) GetMessageByID(ctx context.Context, messageID string) (*models.ChatMessage, error) {
+	session := r.Session.Clone()
+	defer session.Close()
+
+	if !bson.IsObjectIdHex(messageID) {
+		return nil, uranus.ErrNotFound
+	}
+
+	m := new(models.ChatMessage)
+	if err := session.DB(r.DBName).C(chatMessageCollectionName).FindId(bson.ObjectIdHex(messageID)).One(m); err != nil {
+		if err == mgo.ErrNotFound {
+			return nil, uranus.ErrNotFound
+		}
+
+		log.Printf("Failed to get chat message : %s", err.Error())
+		return nil, err
+	}
+
+	return m, nil
+}
+
+// MarkRead moves the participant's read receipt forward, a receipt for an older message than the last one read is ignored.
+func (r *chatMongoRepository) MarkRead(ctx context.Context, conversationID string, userID string, read models.ChatRead) error {
+	session := r.Session.Clone()
+	defer session.Close()
+
+	if !bson.IsObjectIdHex(conversationID) {
+		return uranus.ErrNotFound
+	}
+
+	field := "reads." + userID
+	query := bson.M{
+		"_id": bson.ObjectIdHex(conversationID),
+		"$or": []bson.M{
+			{field: bson.M{"$exists": false}},
+			{field + ".message_id": bson.M{"$lt": read.MessageID}},
+		},
+	}
+
+	err := session.DB(r.DBName).C(conversationCollectionName).Update(query, bson.M{"$set": bson.M{field: read}})
+	if err != nil && err != mgo.ErrNotFound {
+		log.Printf("Failed to mark conversation read : %s", err.Error())
+		return err
+	}
+
+	return nil
+}
@@ -0,0 +1,332 @@
+package mongo
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/globalsign/mgo"
+	"github.com/globalsign/mgo/bson"
+
+	"github.com/fidellr/jastip/backend/uranus"
+	"github.com/fidellr/jastip/backend/uranus/models"
+	"github.com/fidellr/jastip/backend/uranus/repository"
+)
+
+var (
+	disputeCollectionName        = "disputes"
+	disputeMessageCollectionName = "dispute_messages"
+)
+
+type disputeMongoRepository struct {
+	Session *mgo.Session
+	DBName  string
+}
+
+type disputeRequirement func(*disputeMongoRepository)
+
+func DisputeSession(session *mgo.Session) disputeRequirement {
+	return func(r *disputeMongoRepository) {
+		r.Session = session
+	}
+}
+
+func DisputeDBName(dbName string) disputeRequirement {
+	return func(r *disputeMongoRepository) {
+		r.DBName = dbName
+	}
+}
+
+func NewDisputeMongo(reqs ...disputeRequirement) repository.DisputeRepository {
+	repo := new(disputeMongoRepository)
+	for _, req := range reqs {
+		req(repo)
+	}
+
+	repo.ensureIndexes()
+	return repo
+}
+
+// ensureIndexes keeps an order to one dispute and the SLA sweep on an index.
+func (r *disputeMongoRepository) ensureIndexes() {
+	session := r.Session.Clone()
+	defer session.Close()
+
+	indexes := []mgo.Index{
+		{Key: []string{"order_id"}, Unique: true},
+		{Key: []string{"status", "escalate_at"}},
+		{Key: []string{"status", "resolution.resolved_at"}},
+		{Key: []string{"buyer_id", "-created_at"}},
+		{Key: []string{"traveler_id", "-created_at"}},
+	}
+
+	for _, index := range indexes {
+		if err := session.DB(r.DBName).C(disputeCollectionName).EnsureIndex(index); err != nil {
+			log.Printf("Failed to ensure dispute indexes : %s", err.Error())
+		}
+	}
+
+	index := mgo.Index{Key: []string{"dispute_id", "created_at"}}
+	if err := session.DB(r.DBName).C(disputeMessageCollectionName).EnsureIndex(index); err != nil {
+		log.Printf("Failed to ensure dispute message indexes : %s", err.Error())
+	}
+}
+
+func (r *disputeMongoRepository) StoreDispute(ctx context.Context, m *models.Dispute) error {
+	session := r.Session.Clone()
+	defer session.Close()
+
+	if err := session.DB(r.DBName).C(disputeCollectionName).Insert(m); err != nil {
+		if mgo.IsDup(err) {
+			return uranus.ErrStaleStatus
+		}
+
+		log.Printf("Failed to store dispute : %s", err.Error())
+		return err
+	}
+
+	return nil
+}
+
+func (r *disputeMongoRepository) RemoveDispute(ctx context.Context, disputeID string) error {
+	session := r.Session.Clone()
+	defer session.Close()
+
+	if !bson.IsObjectIdHex(disputeID) {
+		return uranus.ErrNotFound
+	}
+
+	if err := session.DB(r.DBName).C(disputeCollectionName).RemoveId(bson.ObjectIdHex(disputeID)); err != nil {
+		if err == mgo.ErrNotFound {
+			return uranus.ErrNotFound
+		}
+
+		log.Printf("Failed to remove dispute : %s", err.Error())
+		return err
+	}
+
+	return nil
+}
+
+func (r *disputeMongoRepository) FetchDisputes(ctx context.Context, filter *uranus.DisputeFilter) ([]*models.Dispute, uranus.Page, error) {
+	session := r.Session.Clone()
+	defer session.Close()
+
+	query := bson.M{}
+	if filter.OrderID != "" {
+		if !bson.IsObjectIdHex(filter.OrderID) {
+			return make([]*models.Dispute, 0), uranus.Page{}, nil
+		}
+
+		query["order_id"] = bson.ObjectIdHex(filter.OrderID)
+	}
+
+	if filter.PartyID != "" {
+		if !bson.IsObjectIdHex(filter.PartyID) {
+			return make([]*models.Dispute, 0), uranus.Page{}, nil
+		}
+
+		partyID := bson.ObjectIdHex(filter.PartyID)
+		query["$or"] = []bson.M{{"buyer_id": partyID}, {"traveler_id": partyID}}
+	}
+
+	if filter.Status != "" {
+		query["status"] = filter.Status
+	}
+
+	m := make([]*models.Dispute, 0)
+	page, err := paginate(session.DB(r.DBName).C(disputeCollectionName), query, uranus.DisputeSort, filter.Cursor, filter.Num, &m, func(i int) (interface{}, bson.ObjectId) {
+		return m[i].CreatedAt, m[i].ID
+	})
+	if err != nil {
+		log.Printf("Failed to fetch disputes : %s", err.Error())
+		return nil, uranus.Page{}, err
+	}
+
+	return m, page, nil
+}
+
+func (r *disputeMongoRepository) GetDisputeByID(ctx context.Context, disputeID string) (*models.Dispute, error) {
+	session := r.Session.Clone()
+	defer session.Close()
+
+	if !bson.IsObjectIdHex(disputeID) {
+		return nil, uranus.ErrNotFound
+	}
+
+	m := new(models.Dispute)
+	if err := session.DB(r.DBName).C(disputeCollectionName).FindId(bson.ObjectIdHex(disputeID)).One(m); err != nil {
+		if err == mgo.ErrNotFound {
+			return nil, uranus.ErrNotFound
+		}
+
+		log.Printf("Failed to get dispute : %s", err.Error())
+		return nil, err
+	}
+
+	return m, nil
+}
+
+// FetchOverdueDisputes lists the open disputes whose SLA ran out by the given time.
+func (r *disputeMongoRepository) FetchOverdueDisputes(ctx context.Context, at time.Time) ([]*models.Dispute, error) {
+	session := r.Session.Clone()
+	defer session.Close()
+
+	var m []*models.Dispute
+	query := bson.M{"status": models.DisputeOpen, "escalate_at": bson.M{"$lte": at}}
+	if err := session.DB(r.DBName).C(disputeCollectionName).Find(query).Sort("escalate_at").All(&m); err != nil {
+		log.Printf("Failed to fetch overdue disputes : %s", err.Error())
+		return nil, err
+	}
+
+	return m, nil
+}
+
+// EscalateDispute escalates the dispute if it's still open, one resolved or escalated meanwhile is uranus.ErrStaleStatus.
+// The outbox messages are written with the escalation.
+func (r *disputeMongoRepository) EscalateDispute(ctx context.Context, disputeID string, at time.Time, outbox ...*models.OutboxMessage) error {
+	session := r.Session.Clone()
+	defer session.Close()
+
+	if !bson.IsObjectIdHex(disputeID) {
+		return uranus.ErrNotFound
+	}
+
+	query := bson.M{"_id": bson.ObjectIdHex(disputeID), "status": models.DisputeOpen}
+	update := bson.M{"$set": bson.M{"status": models.DisputeEscalated, "escalated_at": at, "updated_at": at}}
+	if err := session.DB(r.DBName).C(disputeCollectionName).Update(query, pushOutbox(update, outbox)); err != nil {
+		if err == mgo.ErrNotFound {
+			return uranus.ErrStaleStatus
+		}
+
+		log.Printf("Failed to escalate dispute : %s", err.Error())
+		return err
+	}
+
+	return nil
+}
+
+// FetchUnsettledDisputes lists the disputes resolved before the given time whose outcome wasn't paid out yet.
+func (r *disputeMongoRepository) FetchUnsettledDisputes(ctx context.Context, resolvedBefore time.Time, limit int) ([]*models.Dispute, error) {
+	session := r.Session.Clone()
+	defer session.Close()
+
+	query := bson.M{
+		"status":                 models.DisputeResolved,
+		"settled_at":             bson.M{"$exists": false},
+		"resolution.resolved_at": bson.M{"$lt": resolvedBefore},
+	}
+
+	m := make([]*models.Dispute, 0)
+	if err := session.DB(r.DBName).C(disputeCollectionName).Find(query).Sort("resolution.resolved_at").Limit(limit).All(&m); err != nil {
+		log.Printf("Failed to fetch unsettled disputes : %s", err.Error())
+		return nil, err
+	}
+
+	return m, nil
+}
+
+func (r *disputeMongoRepository) MarkDisputeSettled(ctx context.Context, disputeID string, at time.Time) error {
+	session := r.Session.Clone()
+	defer session.Close()
+
+	if !bson.IsObjectIdHex(disputeID) {
+		return uranus.ErrNotFound
+	}
+
+	err := session.DB(r.DBName).C(disputeCollectionName).UpdateId(bson.ObjectIdHex(disputeID), bson.M{
+		"$set": bson.M{"settled_at": at},
+	})
+	if err != nil {
+		if err == mgo.ErrNotFound {
+			return uranus.ErrNotFound
+		}
+
+		log.Printf("Failed to mark dispute settled : %s", err.Error())
+		return err
+	}
+
+	return nil
+}
+
+// AssignMediator makes the admin the dispute's mediator unless another admin took it on first.
+func (r *disputeMongoRepository) AssignMediator(ctx context.Context, disputeID string, mediatorID string) error {
+	session := r.Session.Clone()
+	defer session.Close()
+
+	if !bson.IsObjectIdHex(disputeID) || !bson.IsObjectIdHex(mediatorID) {
+		return uranus.ErrNotFound
+	}
+
+	query := bson.M{"_id": bson.ObjectIdHex(disputeID), "mediator_id": bson.M{"$exists": false}}
+	update := bson.M{"$set": bson.M{"mediator_id": bson.ObjectIdHex(mediatorID), "updated_at": time.Now()}}
+	if err := session.DB(r.DBName).C(disputeCollectionName).Update(query, update); err != nil && err != mgo.ErrNotFound {
+		log.Printf("Failed to assign dispute mediator : %s", err.Error())
+		return err
+	}
+
+	return nil
+}
+
+// ResolveDispute stores the outcome of a dispute that is still open or escalated along with the outbox messages,
+// one resolved meanwhile is uranus.ErrStaleStatus.
+func (r *disputeMongoRepository) ResolveDispute(ctx context.Context, disputeID string, resolution *models.DisputeResolution, outbox ...*models.OutboxMessage) error {
+	session := r.Session.Clone()
+	defer session.Close()
+
+	if !bson.IsObjectIdHex(disputeID) {
+		return uranus.ErrNotFound
+	}
+
+	query := bson.M{
+		"_id":    bson.ObjectIdHex(disputeID),
+		"status": bson.M{"$in": []string{models.DisputeOpen, models.DisputeEscalated}},
+	}
+	update := bson.M{"$set": bson.M{
+		"status":     models.DisputeResolved,
+		"resolution": resolution,
+		"updated_at": resolution.ResolvedAt,
+	}}
+
+	if err := session.DB(r.DBName).C(disputeCollectionName).Update(query, pushOutbox(update, outbox)); err != nil {
+		if err == mgo.ErrNotFound {
+			return uranus.ErrStaleStatus
+		}
+
+		log.Printf("Failed to resolve dispute : %s", err.Error())
+		return err
+	}
+
+	return nil
+}
+
+func (r *disputeMongoRepository) StoreDisputeMessage(ctx context.Context, m *models.DisputeMessage) error {
+	session := r.Session.Clone()
+	defer session.Close()
+
+	if err := session.DB(r.DBName).C(disputeMessageCollectionName).Insert(m); err != nil {
+		log.Printf("Failed to store dispute message : %s", err.Error())
+		return err
+	}
+
+	return nil
+}
+
+// FetchDisputeMessages lists the whole discussion of a dispute, oldest first.
+func (r *disputeMongoRepository) FetchDisputeMessages(ctx context.Context, disputeID string) ([]*models.DisputeMessage, error) {
+	session := r.Session.Clone()
+	defer session.Close()
+
+	m := make([]*models.DisputeMessage, 0)
+	if !bson.IsObjectIdHex(disputeID) {
+		return m, nil
+	}
+
+	query := bson.M{"dispute_id": bson.ObjectIdHex(disputeID)}
+	if err := session.DB(r.DBName).C(disputeMessageCollectionName).Find(query).Sort("created_at").All(&m); err != nil {
+		log.Printf("Failed to fetch dispute messages : %s", err.Error())
+		return nil, err
+	}
+
+	return m, nil
+}
@@ -0,0 +1,140 @@
+package mongo
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/globalsign/mgo"
+	"github.com/globalsign/mgo/bson"
+
+	"github.com/fidellr/jastip/backend/uranus"
+	"github.com/fidellr/jastip/backend/uranus/models"
+	"github.com/fidellr/jastip/backend/uranus/repository"
+)
+
+var (
+	exchangeRateCollectionName = "exchange_rates"
+)
+
+type exchangeRateMongoRepository struct {
+	Session *mgo.Session
+	DBName  string
+}
+
+type exchangeRateRequirement func(*exchangeRateMongoRepository)
+
+func ExchangeRateSession(session *mgo.Session) exchangeRateRequirement {
+	return func(r *exchangeRateMongoRepository) {
+		r.Session = session
+	}
+}
+
+func ExchangeRateDBName(dbName string) exchangeRateRequirement {
+	return func(r *exchangeRateMongoRepository) {
+		r.DBName = dbName
+	}
+}
+
+func NewExchangeRateMongo(reqs ...exchangeRateRequirement) repository.ExchangeRateRepository {
+	repo := new(exchangeRateMongoRepository)
+	for _, req := range reqs {
+		req(repo)
+	}
+
+	repo.ensureIndexes()
+	return repo
+}
+
+func (r *exchangeRateMongoRepository) ensureIndexes() {
+	session := r.Session.Clone()
+	defer session.Close()
+
+	index := mgo.Index{Key: []string{"base", "quote", "-effective_at"}, Unique: true}
+	if err := session.DB(r.DBName).C(exchangeRateCollectionName).EnsureIndex(index); err != nil {
+		log.Printf("Failed to ensure exchange rate indexes : %s", err.Error())
+	}
+}
+
+// StoreRates saves the rates, a rate for a pair and effective date that already exists is replaced.
+func (r *exchangeRateMongoRepository) StoreRates(ctx context.Context, rates []*models.ExchangeRate) (int, error) {
+	session := r.Session.Clone()
+	defer session.Close()
+
+	stored := 0
+	for _, rate := range rates {
+		query := bson.M{"base": rate.Base, "quote": rate.Quote, "effective_at": rate.EffectiveAt}
+		set := bson.M{"rate": rate.Rate, "created_at": rate.CreatedAt}
+		if rate.UploadedBy != "" {
+			set["uploaded_by"] = rate.UploadedBy
+		}
+
+		_, err := session.DB(r.DBName).C(exchangeRateCollectionName).Upsert(query, bson.M{
+			"$set":         set,
+			"$setOnInsert": bson.M{"_id": rate.ID},
+		})
+		if err != nil {
+			log.Printf("Failed to store exchange rate : %s", err.Error())
+			return stored, err
+		}
+
+		stored++
+	}
+
+	return stored, nil
+}
+
+func (r *exchangeRateMongoRepository) FetchRates(ctx context.Context, filter *uranus.ExchangeRateFilter) ([]*models.ExchangeRate, error) {
+	session := r.Session.Clone()
+	defer session.Close()
+
+	query := bson.M{}
+	if filter.Base != "" {
+		query["base"] = filter.Base
+	}
+
+	if filter.Quote != "" {
+		query["quote"] = filter.Quote
+	}
+
+	effectiveAt := bson.M{}
+	if !filter.From.IsZero() {
+		effectiveAt["$gte"] = filter.From
+	}
+
+	if !filter.To.IsZero() {
+		effectiveAt["$lte"] = filter.To
+	}
+
+	if len(effectiveAt) > 0 {
+		query["effective_at"] = effectiveAt
+	}
+
+	m := make([]*models.ExchangeRate, 0)
+	err := session.DB(r.DBName).C(exchangeRateCollectionName).Find(query).Sort("-effective_at", "base", "quote").Limit(filter.Num).All(&m)
+	if err != nil {
+		log.Printf("Failed to fetch exchange rates : %s", err.Error())
+		return nil, err
+	}
+
+	return m, nil
+}
+
+// GetEffectiveRate gets the latest rate of the pair that took effect at or before at.
+func (r *exchangeRateMongoRepository) GetEffectiveRate(ctx context.Context, base, quote string, at time.Time) (*models.ExchangeRate, error) {
+	session := r.Session.Clone()
+	defer session.Close()
+
+	var m *models.ExchangeRate
+	query := bson.M{"base": base, "quote": quote, "effective_at": bson.M{"$lte": at}}
+	if err := session.DB(r.DBName).C(exchangeRateCollectionName).Find(query).Sort("-effective_at").One(&m); err != nil {
+		if err == mgo.ErrNotFound {
+			return nil, uranus.ErrNotFound
+		}
+
+		log.Printf("Failed to get effective exchange rate : %s", err.Error())
+		return nil, err
+	}
+
+	return m, nil
+}
@@ -0,0 +1,119 @@
+package mongo
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/globalsign/mgo"
+	"github.com/globalsign/mgo/bson"
+
+	"github.com/fidellr/jastip/backend/uranus"
+	"github.com/fidellr/jastip/backend/uranus/models"
+	"github.com/fidellr/jastip/backend/uranus/repository"
+)
+
+var (
+	feeScheduleCollectionName = "fee_schedules"
+)
+
+type feeScheduleMongoRepository struct {
+	Session *mgo.Session
+	DBName  string
+}
+
+type feeScheduleRequirement func(*feeScheduleMongoRepository)
+
+func FeeScheduleSession(session *mgo.Session) feeScheduleRequirement {
+	return func(r *feeScheduleMongoRepository) {
+		r.Session = session
+	}
+}
+
+func FeeScheduleDBName(dbName string) feeScheduleRequirement {
+	return func(r *feeScheduleMongoRepository) {
+		r.DBName = dbName
+	}
+}
+
+func NewFeeScheduleMongo(reqs ...feeScheduleRequirement) repository.FeeScheduleRepository {
+	repo := new(feeScheduleMongoRepository)
+	for _, req := range reqs {
+		req(repo)
+	}
+
+	repo.ensureIndexes()
+	return repo
+}
+
+func (r *feeScheduleMongoRepository) ensureIndexes() {
+	session := r.Session.Clone()
+	defer session.Close()
+
+	indexes := []mgo.Index{
+		{Key: []string{"version"}, Unique: true},
+		{Key: []string{"-effective_at", "-version"}},
+	}
+
+	for _, index := range indexes {
+		if err := session.DB(r.DBName).C(feeScheduleCollectionName).EnsureIndex(index); err != nil {
+			log.Printf("Failed to ensure fee schedule indexes : %s", err.Error())
+		}
+	}
+}
+
+// StoreFeeSchedule saves a new schedule version, it returns uranus.ErrVersionConflict when the version is taken.
+func (r *feeScheduleMongoRepository) StoreFeeSchedule(ctx context.Context, m *models.FeeSchedule) error {
+	session := r.Session.Clone()
+	defer session.Close()
+
+	if err := session.DB(r.DBName).C(feeScheduleCollectionName).Insert(m); err != nil {
+		if mgo.IsDup(err) {
+			return uranus.ErrVersionConflict
+		}
+
+		log.Printf("Failed to store fee schedule : %s", err.Error())
+		return err
+	}
+
+	return nil
+}
+
+func (r *feeScheduleMongoRepository) FetchFeeSchedules(ctx context.Context) ([]*models.FeeSchedule, error) {
+	session := r.Session.Clone()
+	defer session.Close()
+
+	m := make([]*models.FeeSchedule, 0)
+	if err := session.DB(r.DBName).C(feeScheduleCollectionName).Find(bson.M{}).Sort("-version").All(&m); err != nil {
+		log.Printf("Failed to fetch fee schedules : %s", err.Error())
+		return nil, err
+	}
+
+	return m, nil
+}
+
+func (r *feeScheduleMongoRepository) GetLatestFeeSchedule(ctx context.Context) (*models.FeeSchedule, error) {
+	return r.getFeeSchedule(bson.M{}, "-version")
+}
+
+// GetEffectiveFeeSchedule gets the schedule that took effect last at or before at, the higher version on a tie.
+func (r *feeScheduleMongoRepository) GetEffectiveFeeSchedule(ctx context.Context, at time.Time) (*models.FeeSchedule, error) {
+	return r.getFeeSchedule(bson.M{"effective_at": bson.M{"$lte": at}}, "-effective_at", "-version")
+}
+
+func (r *feeScheduleMongoRepository) getFeeSchedule(query bson.M, sort ...string) (*models.FeeSchedule, error) {
+	session := r.Session.Clone()
+	defer session.Close()
+
+	var m *models.FeeSchedule
+	if err := session.DB(r.DBName).C(feeScheduleCollectionName).Find(query).Sort(sort...).One(&m); err != nil {
+		if err == mgo.ErrNotFound {
+			return nil, uranus.ErrNotFound
+		}
+
+		log.Printf("Failed to get fee schedule : %s", err.Error())
+		return nil, err
+	}
+
+	return m, nil
+}
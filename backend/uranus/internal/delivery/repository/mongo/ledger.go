@@ -0,0 +1,155 @@
+package mongo
+
+import (
+	"context"
+	"log"
+
+	"github.com/globalsign/mgo"
+	"github.com/globalsign/mgo/bson"
+
+	"github.com/fidellr/jastip/backend/uranus"
+	"github.com/fidellr/jastip/backend/uranus/models"
+	"github.com/fidellr/jastip/backend/uranus/repository"
+)
+
+var (
+	ledgerAccountCollectionName = "ledger_accounts"
+	journalEntryCollectionName  = "journal_entries"
+)
+
+type ledgerMongoRepository struct {
+	Session *mgo.Session
+	DBName  string
+}
+
+type ledgerRequirement func(*ledgerMongoRepository)
+
+func LedgerSession(session *mgo.Session) ledgerRequirement {
+	return func(r *ledgerMongoRepository) {
+		r.Session = session
+	}
+}
+
+func LedgerDBName(dbName string) ledgerRequirement {
+	return func(r *ledgerMongoRepository) {
+		r.DBName = dbName
+	}
+}
+
+func NewLedgerMongo(reqs ...ledgerRequirement) repository.LedgerRepository {
+	repo := new(ledgerMongoRepository)
+	for _, req := range reqs {
+		req(repo)
+	}
+
+	repo.ensureIndexes()
+	return repo
+}
+
+func (r *ledgerMongoRepository) ensureIndexes() {
+	session := r.Session.Clone()
+	defer session.Close()
+
+	indexes := []mgo.Index{
+		{Key: []string{"key"}, Unique: true},
+		{Key: []string{"order_id", "created_at"}},
+		{Key: []string{"lines.account"}},
+	}
+
+	for _, index := range indexes {
+		if err := session.DB(r.DBName).C(journalEntryCollectionName).EnsureIndex(index); err != nil {
+			log.Printf("Failed to ensure journal entry indexes : %s", err.Error())
+		}
+	}
+}
+
+// EnsureAccount opens the account unless it already exists, an existing account is left as it is.
+func (r *ledgerMongoRepository) EnsureAccount(ctx context.Context, m *models.LedgerAccount) error {
+	session := r.Session.Clone()
+	defer session.Close()
+
+	_, err := session.DB(r.DBName).C(ledgerAccountCollectionName).UpsertId(m.Code, bson.M{"$setOnInsert": m})
+	if err != nil {
+		log.Printf("Failed to ensure ledger account : %s", err.Error())
+		return err
+	}
+
+	return nil
+}
+
+func (r *ledgerMongoRepository) GetAccount(ctx context.Context, code string) (*models.LedgerAccount, error) {
+	session := r.Session.Clone()
+	defer session.Close()
+
+	var m *models.LedgerAccount
+	if err := session.DB(r.DBName).C(ledgerAccountCollectionName).FindId(code).One(&m); err != nil {
+		if err == mgo.ErrNotFound {
+			return nil, uranus.ErrNotFound
+		}
+
+		log.Printf("Failed to get ledger account : %s", err.Error())
+		return nil, err
+	}
+
+	return m, nil
+}
+
+// StoreJournalEntry posts the entry, it returns uranus.ErrAlreadyPosted when an entry with the same key exists.
+func (r *ledgerMongoRepository) StoreJournalEntry(ctx context.Context, m *models.JournalEntry) error {
+	session := r.Session.Clone()
+	defer session.Close()
+
+	if err := session.DB(r.DBName).C(journalEntryCollectionName).Insert(m); err != nil {
+		if mgo.IsDup(err) {
+			return uranus.ErrAlreadyPosted
+		}
+
+		log.Printf("Failed to store journal entry : %s", err.Error())
+		return err
+	}
+
+	return nil
+}
+
+func (r *ledgerMongoRepository) FetchJournalEntries(ctx context.Context, orderID string) ([]*models.JournalEntry, error) {
+	session := r.Session.Clone()
+	defer session.Close()
+
+	m := make([]*models.JournalEntry, 0)
+	if !bson.IsObjectIdHex(orderID) {
+		return m, nil
+	}
+
+	query := bson.M{"order_id": bson.ObjectIdHex(orderID)}
+	if err := session.DB(r.DBName).C(journalEntryCollectionName).Find(query).Sort("created_at").All(&m); err != nil {
+		log.Printf("Failed to fetch journal entries : %s", err.Error())
+		return nil, err
+	}
+
+	return m, nil
+}
+
+// GetBalance sums every line posted to the account, an account nothing was posted to has zero sums.
+func (r *ledgerMongoRepository) GetBalance(ctx context.Context, code string) (*models.LedgerBalance, error) {
+	session := r.Session.Clone()
+	defer session.Close()
+
+	pipeline := []bson.M{
+		{"$match": bson.M{"lines.account": code}},
+		{"$unwind": "$lines"},
+		{"$match": bson.M{"lines.account": code}},
+		{"$group": bson.M{
+			"_id":     "$lines.account",
+			"debits":  bson.M{"$sum": "$lines.debit"},
+			"credits": bson.M{"$sum": "$lines.credit"},
+		}},
+	}
+
+	m := &models.LedgerBalance{Account: code}
+	if err := session.DB(r.DBName).C(journalEntryCollectionName).Pipe(pipeline).One(m); err != nil && err != mgo.ErrNotFound {
+		log.Printf("Failed to get ledger balance : %s", err.Error())
+		return nil, err
+	}
+
+	return m, nil
+}
@@ -0,0 +1,171 @@
+package mongo
+
+import (
+	"log"
+	"time"
+
+	"github.com/globalsign/mgo"
+	"github.com/globalsign/mgo/bson"
+
+	"github.com/fidellr/jastip/backend/uranus/models"
+)
+
+// legacyClearingAccount is the one gateway clearing account used before there was one per currency.
+const legacyClearingAccount = "gateway:clearing"
+
+// legacyMoney lists the fields that were stored as floats in major units of the document's currency field.
+var legacyMoney = []struct {
+	collection string
+	fields     []string
+}{
+	{purchaseRequestCollectionName, []string{"max_price"}},
+	{offerCollectionName, []string{"quoted_price", "fee"}},
+	{orderCollectionName, []string{"item_price", "fee", "total"}},
+	{paymentCollectionName, []string{"amount"}},
+}
+
+// MigrateMoney rewrites the amounts stored before money carried its own currency. The float fields become money
+// in minor units, journal lines become minor units of their entry's currency and post to the clearing account of
+// that currency. Documents already in the current format are left as they are, so it is safe to run again.
+func MigrateMoney(session *mgo.Session, dbName string) (int, error) {
+	session = session.Clone()
+	defer session.Close()
+
+	db := session.DB(dbName)
+	migrated := 0
+	for _, legacy := range legacyMoney {
+		n, err := migrateMoneyFields(db.C(legacy.collection), legacy.fields)
+		migrated += n
+		if err != nil {
+			return migrated, err
+		}
+	}
+
+	n, err := migrateJournalEntries(db)
+	migrated += n
+	if err != nil {
+		return migrated, err
+	}
+
+	err = db.C(ledgerAccountCollectionName).RemoveId(legacyClearingAccount)
+	if err != nil && err != mgo.ErrNotFound {
+		log.Printf("Failed to remove the legacy clearing account : %s", err.Error())
+		return migrated, err
+	}
+
+	return migrated, nil
+}
+
+func migrateMoneyFields(c *mgo.Collection, fields []string) (int, error) {
+	legacy := make([]bson.M, 0, len(fields))
+	for _, field := range fields {
+		legacy = append(legacy, bson.M{field: bson.M{"$type": "number"}})
+	}
+
+	migrated := 0
+	iter := c.Find(bson.M{"$or": legacy}).Iter()
+	for {
+		doc := bson.M{}
+		if !iter.Next(&doc) {
+			break
+		}
+
+		currency, _ := doc["currency"].(string)
+		if !models.IsCurrency(currency) {
+			log.Printf("Skipped %s %v, %q is not a currency uranus handles", c.Name, doc["_id"], currency)
+			continue
+		}
+
+		set := bson.M{}
+		for _, field := range fields {
+			if major, ok := number(doc[field]); ok {
+				set[field], _ = models.MoneyFromMajor(major, currency)
+			}
+		}
+
+		if err := c.UpdateId(doc["_id"], bson.M{"$set": set, "$unset": bson.M{"currency": ""}}); err != nil {
+			log.Printf("Failed to migrate %s %v : %s", c.Name, doc["_id"], err.Error())
+			iter.Close()
+			return migrated, err
+		}
+
+		migrated++
+	}
+
+	return migrated, iter.Close()
+}
+
+type legacyJournalEntry struct {
+	ID       bson.ObjectId `bson:"_id"`
+	Currency string        `bson:"currency"`
+	Lines    []struct {
+		Account string  `bson:"account"`
+		Debit   float64 `bson:"debit"`
+		Credit  float64 `bson:"credit"`
+	} `bson:"lines"`
+}
+
+func migrateJournalEntries(db *mgo.Database) (int, error) {
+	c := db.C(journalEntryCollectionName)
+	legacy := bson.M{"lines": bson.M{"$elemMatch": bson.M{"$or": []bson.M{
+		{"debit": bson.M{"$type": "double"}},
+		{"credit": bson.M{"$type": "double"}},
+	}}}}
+
+	migrated := 0
+	iter := c.Find(legacy).Iter()
+	for {
+		entry := new(legacyJournalEntry)
+		if !iter.Next(entry) {
+			break
+		}
+
+		if !models.IsCurrency(entry.Currency) {
+			log.Printf("Skipped journal entry %s, %q is not a currency uranus handles", entry.ID.Hex(), entry.Currency)
+			continue
+		}
+
+		lines := make([]models.JournalLine, 0, len(entry.Lines))
+		for _, line := range entry.Lines {
+			account := line.Account
+			if account == legacyClearingAccount {
+				account = models.GatewayClearingAccount(entry.Currency)
+				clearing := &models.LedgerAccount{Code: account, Type: models.LedgerAsset, Currency: entry.Currency, CreatedAt: time.Now()}
+				if _, err := db.C(ledgerAccountCollectionName).UpsertId(account, bson.M{"$setOnInsert": clearing}); err != nil {
+					log.Printf("Failed to open clearing account %s : %s", account, err.Error())
+					iter.Close()
+					return migrated, err
+				}
+			}
+
+			// The currency was checked above, neither amount can fail to convert.
+			debit, _ := models.MoneyFromMajor(line.Debit, entry.Currency)
+			credit, _ := models.MoneyFromMajor(line.Credit, entry.Currency)
+			lines = append(lines, models.JournalLine{Account: account, Debit: debit.Amount, Credit: credit.Amount})
+		}
+
+		if err := c.UpdateId(entry.ID, bson.M{"$set": bson.M{"lines": lines}}); err != nil {
+			log.Printf("Failed to migrate journal entry %s : %s", entry.ID.Hex(), err.Error())
+			iter.Close()
+			return migrated, err
+		}
+
+		migrated++
+	}
+
+	return migrated, iter.Close()
+}
+
+// number reads a stored number whatever type it was stored as.
+func number(value interface{}) (float64, bool) {
+	switch v := value.(type) {
+	case float64:
+		return v, true
+	case int:
+		return float64(v), true
+	case int64:
+		return float64(v), true
+	}
+
+	return 0, false
+}
@@ -3,6 +3,8 @@ package mongo
 import (
 	"context"
 	"log"
+	"strings"
+	"time"
 
 	"github.com/fidellr/jastip/backend/uranus/repository"
 
@@ -15,6 +17,12 @@ import (
 
 var (
 	userAccountCollectionName = "user_account"
+
+	// notDeleted hides soft-deleted accounts from every lookup.
+	notDeleted = bson.M{"$exists": false}
+
+	// bumpVersion moves the account version on every write so outstanding ETags go stale.
+	bumpVersion = bson.M{"version": 1}
 )
 
 type userMongoRepository struct {
@@ -42,15 +50,40 @@ func NewUserMongo(reqs ...userRequirement) repository.UserAccountRepository {
 		req(repo)
 	}
 
+	repo.ensureIndexes()
 	return repo
 }
 
+func (u *userMongoRepository) ensureIndexes() {
+	session := u.Session.Clone()
+	defer session.Close()
+
+	indexes := []mgo.Index{
+		{Key: []string{"email_address"}, Unique: true},
+		{Key: []string{"$text:first_name", "$text:last_name", "$text:email_address"}, Name: "user_search"},
+		{Key: []string{"-created_at", "-_id"}},
+		{Key: []string{"first_name", "_id"}},
+		{Key: []string{"last_name", "_id"}},
+	}
+
+	for _, index := range indexes {
+		err := session.DB(u.DBName).C(userAccountCollectionName).EnsureIndex(index)
+		if err != nil {
+			log.Printf("Failed to ensure user account indexes : %s", err.Error())
+		}
+	}
+}
+
 func (u *userMongoRepository) CreateUserAccount(ctx context.Context, m *models.UserAccount) error {
 	session := u.Session.Clone()
 	defer session.Close()
 
 	err := session.DB(u.DBName).C(userAccountCollectionName).Insert(m)
 	if err != nil {
+		if mgo.IsDup(err) {
+			return uranus.ErrEmailTaken
+		}
+
 		log.Println(err.Error())
 		return err
 	}
@@ -58,38 +91,71 @@ func (u *userMongoRepository) CreateUserAccount(ctx context.Context, m *models.U
 	return nil
 }
 
-func (u *userMongoRepository) Fetch(ctx context.Context, filter *uranus.Filter) ([]*models.UserAccount, string, error) {
+func (u *userMongoRepository) Fetch(ctx context.Context, filter *uranus.Filter) ([]*models.UserAccount, uranus.Page, error) {
 	session := u.Session.Clone()
 	defer session.Close()
 
-	var m []*models.UserAccount
-	query := make(bson.M)
-	if filter.Cursor != "" {
-		createdAt, err := uranus.DecodeTime(filter.Cursor)
-		if err == nil {
-			query["created_at"] = bson.M{"$lt": createdAt}
-		} else {
-			log.Fatalf("Failed to pass cursor %s : %s \n", filter.Cursor, err.Error())
-		}
+	query := filterQuery(filter)
+	m := make([]*models.UserAccount, 0)
+	page, err := paginate(session.DB(u.DBName).C(userAccountCollectionName), query, filter.Sort, filter.Cursor, filter.Num, &m, func(i int) (interface{}, bson.ObjectId) {
+		return sortValue(m[i], filter.Sort), m[i].ID
+	})
+	if err != nil {
+		log.Println(err.Error())
+		return make([]*models.UserAccount, 0), uranus.Page{}, err
+	}
+
+	return m, page, nil
+}
+
+// filterQuery turns the account filter into a query, leaving out the cursor so it can also count the matches.
+func filterQuery(filter *uranus.Filter) bson.M {
+	query := bson.M{"deleted_at": notDeleted}
+	if filter.Query != "" {
+		query["$text"] = bson.M{"$search": filter.Query}
 	}
 
 	if filter.RoleName != "" {
-		query["role"] = bson.M{"role_name": filter.RoleName}
+		query["role.role_name"] = filter.RoleName
 	}
 
-	err := session.DB(u.DBName).C(userAccountCollectionName).Find(query).Limit(filter.Num).Sort("-created_at").All(&m)
-	if err != nil {
-		log.Println(err.Error())
-		return make([]*models.UserAccount, 0), "", err
+	if filter.IsBanned != nil {
+		// Accounts stored before is_banned existed are not banned.
+		query["is_banned"] = bson.M{"$ne": !*filter.IsBanned}
+	}
+
+	if filter.IsVerified != nil {
+		query["verified_at"] = bson.M{"$exists": *filter.IsVerified}
+	}
+
+	createdAt := bson.M{}
+	if !filter.CreatedFrom.IsZero() {
+		createdAt["$gte"] = filter.CreatedFrom
 	}
 
-	if len(m) == 0 {
-		return make([]*models.UserAccount, 0), "", err
+	if !filter.CreatedTo.IsZero() {
+		createdAt["$lte"] = filter.CreatedTo
 	}
 
-	lastUsers := m[len(m)-1]
-	nextCursors := uranus.EncodeTime(lastUsers.CreatedAt)
-	return m, nextCursors, nil
+	if len(createdAt) > 0 {
+		query["created_at"] = createdAt
+	}
+
+	return query
+}
+
+// sortValue is the value of the sorted field a cursor keeps to resume from the account.
+func sortValue(m *models.UserAccount, sort string) interface{} {
+	switch strings.TrimPrefix(sort, "-") {
+	case "first_name":
+		return m.FirstName
+	case "last_name":
+		return m.LastName
+	case "email_address":
+		return m.EmailAddress
+	default:
+		return m.CreatedAt
+	}
 }
 
 func (u *userMongoRepository) GetUserByID(ctx context.Context, uuid string) (*models.UserAccount, error) {
@@ -97,9 +163,17 @@ func (u *userMongoRepository) GetUserByID(ctx context.Context, uuid string) (*mo
 	defer session.Close()
 
 	var m *models.UserAccount
+	if !bson.IsObjectIdHex(uuid) {
+		return nil, uranus.ErrNotFound
+	}
+
 	uuidB := bson.ObjectIdHex(uuid)
-	err := session.DB(u.DBName).C(userAccountCollectionName).Find(bson.M{"_id": uuidB}).One(&m)
+	err := session.DB(u.DBName).C(userAccountCollectionName).Find(bson.M{"_id": uuidB, "deleted_at": notDeleted}).One(&m)
 	if err != nil {
+		if err == mgo.ErrNotFound {
+			return nil, uranus.ErrNotFound
+		}
+
 		log.Println(err.Error())
 		return nil, err
 	}
@@ -107,22 +181,125 @@ func (u *userMongoRepository) GetUserByID(ctx context.Context, uuid string) (*mo
 	return m, nil
 }
 
-func (u *userMongoRepository) SuspendAccount(ctx context.Context, uuid string) (bool, error) {
+func (u *userMongoRepository) GetUserByEmail(ctx context.Context, email string) (*models.UserAccount, error) {
 	session := u.Session.Clone()
 	defer session.Close()
 
 	var m *models.UserAccount
+	err := session.DB(u.DBName).C(userAccountCollectionName).Find(bson.M{"email_address": email, "deleted_at": notDeleted}).One(&m)
+	if err != nil {
+		if err == mgo.ErrNotFound {
+			return nil, uranus.ErrNotFound
+		}
+
+		log.Println(err.Error())
+		return nil, err
+	}
+
+	return m, nil
+}
+
+func (u *userMongoRepository) SuspendAccount(ctx context.Context, uuid string, until *time.Time, outbox ...*models.OutboxMessage) (bool, error) {
+	session := u.Session.Clone()
+	defer session.Close()
+
+	set := bson.M{"is_banned": true, "updated_at": time.Now()}
+	update := bson.M{"$set": set, "$inc": bumpVersion}
+	if until != nil {
+		set["suspended_until"] = until
+	} else {
+		update["$unset"] = bson.M{"suspended_until": ""}
+	}
+
+	if !bson.IsObjectIdHex(uuid) {
+		return false, uranus.ErrNotFound
+	}
+
+	uuidB := bson.ObjectIdHex(uuid)
+	err := session.DB(u.DBName).C(userAccountCollectionName).Update(bson.M{"_id": uuidB}, pushOutbox(update, outbox))
+	if err != nil {
+		log.Println(err.Error())
+		return false, err
+	}
+
+	return true, nil
+}
+
+func (u *userMongoRepository) ReinstateAccount(ctx context.Context, uuid string) (bool, error) {
+	session := u.Session.Clone()
+	defer session.Close()
+
+	if !bson.IsObjectIdHex(uuid) {
+		return false, uranus.ErrNotFound
+	}
+
 	uuidB := bson.ObjectIdHex(uuid)
-	c := session.DB(u.DBName).C(userAccountCollectionName)
-	err := c.Find(bson.M{"_id": uuidB}).One(&m)
+	err := session.DB(u.DBName).C(userAccountCollectionName).Update(bson.M{"_id": uuidB}, bson.M{
+		"$inc":   bumpVersion,
+		"$set":   bson.M{"is_banned": false, "updated_at": time.Now()},
+		"$unset": bson.M{"suspended_until": ""},
+	})
 	if err != nil {
 		log.Println(err.Error())
 		return false, err
 	}
 
-	m.IsBanned = true
-	err = c.Update(bson.M{"_id": uuidB}, m)
+	return true, nil
+}
+
+func (u *userMongoRepository) FetchExpiredSuspensions(ctx context.Context, at time.Time) ([]*models.UserAccount, error) {
+	session := u.Session.Clone()
+	defer session.Close()
+
+	var m []*models.UserAccount
+	query := bson.M{"is_banned": true, "suspended_until": bson.M{"$lte": at}}
+	if err := session.DB(u.DBName).C(userAccountCollectionName).Find(query).All(&m); err != nil {
+		log.Println(err.Error())
+		return nil, err
+	}
+
+	return m, nil
+}
+
+// RemoveAccount soft-deletes the account. Its email address moves aside so it can be registered again,
+// and the access tokens issued so far stay revoked even if the account is restored.
+func (u *userMongoRepository) RemoveAccount(ctx context.Context, uuid string, deletedAt time.Time) (bool, error) {
+	session := u.Session.Clone()
+	defer session.Close()
+
+	if !bson.IsObjectIdHex(uuid) {
+		return false, uranus.ErrNotFound
+	}
+
+	uuidB := bson.ObjectIdHex(uuid)
+	query := bson.M{"_id": uuidB, "deleted_at": notDeleted}
+
+	var m *models.UserAccount
+	if err := session.DB(u.DBName).C(userAccountCollectionName).Find(query).One(&m); err != nil {
+		if err == mgo.ErrNotFound {
+			return false, uranus.ErrNotFound
+		}
+
+		log.Println(err.Error())
+		return false, err
+	}
+
+	query["email_address"] = m.EmailAddress
+	err := session.DB(u.DBName).C(userAccountCollectionName).Update(query, bson.M{
+		"$inc": bumpVersion,
+		"$set": bson.M{
+			"email_address":         deletedEmailAddress(uuid),
+			"deleted_email_address": m.EmailAddress,
+			"deleted_at":            deletedAt,
+			"tokens_revoked_at":     deletedAt,
+			"updated_at":            deletedAt,
+		},
+	})
 	if err != nil {
+		if err == mgo.ErrNotFound {
+			return false, uranus.ErrNotFound
+		}
+
 		log.Println(err.Error())
 		return false, err
 	}
@@ -130,13 +307,53 @@ func (u *userMongoRepository) SuspendAccount(ctx context.Context, uuid string) (
 	return true, nil
 }
 
-func (u *userMongoRepository) RemoveAccount(ctx context.Context, uuid string) (bool, error) {
+// RestoreAccount undeletes the account and gives it its email address back,
+// uranus.ErrEmailTaken when another account registered the address meanwhile.
+func (u *userMongoRepository) RestoreAccount(ctx context.Context, uuid string, deletedAfter time.Time) (bool, error) {
 	session := u.Session.Clone()
 	defer session.Close()
 
+	if !bson.IsObjectIdHex(uuid) {
+		return false, uranus.ErrNotFound
+	}
+
 	uuidB := bson.ObjectIdHex(uuid)
-	err := session.DB(u.DBName).C(userAccountCollectionName).Remove(bson.M{"_id": uuidB})
+	query := bson.M{
+		"_id":        uuidB,
+		"deleted_at": bson.M{"$gte": deletedAfter},
+		"purged_at":  bson.M{"$exists": false},
+	}
+
+	var m *models.UserAccount
+	if err := session.DB(u.DBName).C(userAccountCollectionName).Find(query).One(&m); err != nil {
+		if err == mgo.ErrNotFound {
+			return false, uranus.ErrNotFound
+		}
+
+		log.Println(err.Error())
+		return false, err
+	}
+
+	set := bson.M{"updated_at": time.Now()}
+	if m.DeletedEmailAddress != "" {
+		// Accounts deleted before the address was moved aside kept it all along.
+		set["email_address"] = m.DeletedEmailAddress
+	}
+
+	err := session.DB(u.DBName).C(userAccountCollectionName).Update(query, bson.M{
+		"$inc":   bumpVersion,
+		"$set":   set,
+		"$unset": bson.M{"deleted_at": "", "deleted_email_address": ""},
+	})
 	if err != nil {
+		if mgo.IsDup(err) {
+			return false, uranus.ErrEmailTaken
+		}
+
+		if err == mgo.ErrNotFound {
+			return false, uranus.ErrNotFound
+		}
+
 		log.Println(err.Error())
 		return false, err
 	}
@@ -144,12 +361,142 @@ func (u *userMongoRepository) RemoveAccount(ctx context.Context, uuid string) (b
 	return true, nil
 }
 
-func (u *userMongoRepository) UpdateUserByID(ctx context.Context, uuid string, m *models.UserAccount) error {
+func (u *userMongoRepository) FetchDeletedBefore(ctx context.Context, deletedBefore time.Time) ([]*models.UserAccount, error) {
+	session := u.Session.Clone()
+	defer session.Close()
+
+	var m []*models.UserAccount
+	query := bson.M{
+		"deleted_at": bson.M{"$lt": deletedBefore},
+		"purged_at":  bson.M{"$exists": false},
+	}
+	if err := session.DB(u.DBName).C(userAccountCollectionName).Find(query).All(&m); err != nil {
+		log.Println(err.Error())
+		return nil, err
+	}
+
+	return m, nil
+}
+
+func (u *userMongoRepository) AnonymizeAccount(ctx context.Context, uuid string, purgedAt time.Time) error {
+	session := u.Session.Clone()
+	defer session.Close()
+
+	if !bson.IsObjectIdHex(uuid) {
+		return uranus.ErrNotFound
+	}
+
+	uuidB := bson.ObjectIdHex(uuid)
+	err := session.DB(u.DBName).C(userAccountCollectionName).Update(bson.M{"_id": uuidB}, bson.M{
+		"$inc": bumpVersion,
+		"$set": bson.M{
+			"first_name":    "Deleted",
+			"last_name":     "User",
+			"email_address": deletedEmailAddress(uuid),
+			"purged_at":     purgedAt,
+			"updated_at":    purgedAt,
+		},
+		"$unset": bson.M{
+			"password":              "",
+			"traveler_profile":      "",
+			"buyer_profile":         "",
+			"verified_at":           "",
+			"deleted_email_address": "",
+		},
+	})
+	if err != nil {
+		log.Println(err.Error())
+		return err
+	}
+
+	return nil
+}
+
+func (u *userMongoRepository) PurgeAccount(ctx context.Context, uuid string) error {
+	session := u.Session.Clone()
+	defer session.Close()
+
+	if !bson.IsObjectIdHex(uuid) {
+		return uranus.ErrNotFound
+	}
+
+	uuidB := bson.ObjectIdHex(uuid)
+	err := session.DB(u.DBName).C(userAccountCollectionName).Remove(bson.M{"_id": uuidB})
+	if err != nil {
+		log.Println(err.Error())
+		return err
+	}
+
+	return nil
+}
+
+func (u *userMongoRepository) UpdateUserByID(ctx context.Context, uuid string, version int, m *models.UserAccount) error {
+	session := u.Session.Clone()
+	defer session.Close()
+
+	if !bson.IsObjectIdHex(uuid) {
+		return uranus.ErrNotFound
+	}
+
+	uuidB := bson.ObjectIdHex(uuid)
+	err := session.DB(u.DBName).C(userAccountCollectionName).Update(bson.M{"_id": uuidB, "version": uranus.MatchVersion(version)}, m)
+	if err != nil {
+		if mgo.IsDup(err) {
+			return uranus.ErrEmailTaken
+		}
+
+		if err == mgo.ErrNotFound {
+			return uranus.ErrVersionConflict
+		}
+
+		log.Println(err.Error())
+		return err
+	}
+
+	return nil
+}
+
+// deletedEmailAddress is the placeholder address a deleted account holds, unique like the account's ID.
+func deletedEmailAddress(uuid string) string {
+	return "deleted-" + uuid + "@users.jastip.invalid"
+}
+
+func (u *userMongoRepository) MarkEmailVerified(ctx context.Context, uuid string, verifiedAt time.Time) error {
+	session := u.Session.Clone()
+	defer session.Close()
+
+	if !bson.IsObjectIdHex(uuid) {
+		return uranus.ErrNotFound
+	}
+
+	uuidB := bson.ObjectIdHex(uuid)
+	err := session.DB(u.DBName).C(userAccountCollectionName).Update(bson.M{"_id": uuidB}, bson.M{
+		"$inc": bumpVersion,
+		"$set": bson.M{"verified_at": verifiedAt, "updated_at": verifiedAt},
+	})
+	if err != nil {
+		log.Println(err.Error())
+		return err
+	}
+
+	return nil
+}
+
+// UpdatePassword stores the new password hash and revokes the access tokens issued with the old password.
+func (u *userMongoRepository) UpdatePassword(ctx context.Context, uuid string, passwordHash string) error {
 	session := u.Session.Clone()
 	defer session.Close()
 
+	now := time.Now()
+	if !bson.IsObjectIdHex(uuid) {
+		return uranus.ErrNotFound
+	}
+
 	uuidB := bson.ObjectIdHex(uuid)
-	err := session.DB(u.DBName).C(userAccountCollectionName).Update(bson.M{"_id": uuidB}, m)
+	err := session.DB(u.DBName).C(userAccountCollectionName).Update(bson.M{"_id": uuidB}, bson.M{
+		"$inc": bumpVersion,
+		"$set": bson.M{"password": passwordHash, "tokens_revoked_at": now, "updated_at": now},
+	})
 	if err != nil {
 		log.Println(err.Error())
 		return err
@@ -157,3 +504,44 @@ func (u *userMongoRepository) UpdateUserByID(ctx context.Context, uuid string, m
 
 	return nil
 }
+
+func (u *userMongoRepository) UpdateTravelerProfile(ctx context.Context, uuid string, profile *models.TravelerProfile) error {
+	return u.updateProfile(uuid, "traveler_profile", profile)
+}
+
+func (u *userMongoRepository) UpdateBuyerProfile(ctx context.Context, uuid string, profile *models.BuyerProfile) error {
+	return u.updateProfile(uuid, "buyer_profile", profile)
+}
+
+func (u *userMongoRepository) UpdateNotificationPreferences(ctx context.Context, uuid string, prefs *models.NotificationPreferences) error {
+	return u.updateProfile(uuid, "notifications", prefs)
+}
+
+func (u *userMongoRepository) UpdateRatingSummary(ctx context.Context, uuid string, summary *models.RatingSummary) error {
+	return u.updateProfile(uuid, "rating", summary)
+}
+
+func (u *userMongoRepository) updateProfile(uuid string, field string, profile interface{}) error {
+	session := u.Session.Clone()
+	defer session.Close()
+
+	if !bson.IsObjectIdHex(uuid) {
+		return uranus.ErrNotFound
+	}
+
+	uuidB := bson.ObjectIdHex(uuid)
+	err := session.DB(u.DBName).C(userAccountCollectionName).Update(bson.M{"_id": uuidB, "deleted_at": notDeleted}, bson.M{
+		"$inc": bumpVersion,
+		"$set": bson.M{field: profile, "updated_at": time.Now()},
+	})
+	if err != nil {
+		if err == mgo.ErrNotFound {
+			return uranus.ErrNotFound
+		}
+
+		log.Println(err.Error())
+		return err
+	}
+
+	return nil
+}
@@ -0,0 +1,306 @@
+package mongo
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/globalsign/mgo"
+	"github.com/globalsign/mgo/bson"
+
+	"github.com/fidellr/jastip/backend/uranus"
+	"github.com/fidellr/jastip/backend/uranus/models"
+	"github.com/fidellr/jastip/backend/uranus/repository"
+)
+
+var (
+	notificationCollectionName = "notifications"
+	inboxCollectionName        = "notification_inbox"
+)
+
+// outboxCollections hold documents that keep the notifications about their changes in an outbox field.
+var outboxCollections = []string{
+	userAccountCollectionName,
+	offerCollectionName,
+	orderCollectionName,
+	reviewCollectionName,
+	disputeCollectionName,
+	disputeMessageCollectionName,
+}
+
+type notificationMongoRepository struct {
+	Session *mgo.Session
+	DBName  string
+}
+
+type notificationRequirement func(*notificationMongoRepository)
+
+func NotificationSession(session *mgo.Session) notificationRequirement {
+	return func(r *notificationMongoRepository) {
+		r.Session = session
+	}
+}
+
+func NotificationDBName(dbName string) notificationRequirement {
+	return func(r *notificationMongoRepository) {
+		r.DBName = dbName
+	}
+}
+
+func NewNotificationMongo(reqs ...notificationRequirement) repository.NotificationRepository {
+	repo := new(notificationMongoRepository)
+	for _, req := range reqs {
+		req(repo)
+	}
+
+	repo.ensureIndexes()
+	return repo
+}
+
+func (r *notificationMongoRepository) ensureIndexes() {
+	session := r.Session.Clone()
+	defer session.Close()
+
+	indexes := []mgo.Index{
+		{Key: []string{"status", "next_attempt_at"}},
+		{Key: []string{"key"}, Unique: true, Sparse: true},
+	}
+
+	for _, index := range indexes {
+		if err := session.DB(r.DBName).C(notificationCollectionName).EnsureIndex(index); err != nil {
+			log.Printf("Failed to ensure notification indexes : %s", err.Error())
+		}
+	}
+
+	// Only documents with messages waiting are in the outbox indexes, the relay finds them without a scan.
+	for _, name := range outboxCollections {
+		index := mgo.Index{Key: []string{"outbox._id"}, Sparse: true}
+		if err := session.DB(r.DBName).C(name).EnsureIndex(index); err != nil {
+			log.Printf("Failed to ensure outbox index of %s : %s", name, err.Error())
+		}
+	}
+
+	inboxIndexes := []mgo.Index{
+		{Key: []string{"user_id", "-created_at"}},
+		{Key: []string{"notification_id"}, Unique: true},
+	}
+
+	for _, index := range inboxIndexes {
+		if err := session.DB(r.DBName).C(inboxCollectionName).EnsureIndex(index); err != nil {
+			log.Printf("Failed to ensure inbox indexes : %s", err.Error())
+		}
+	}
+}
+
+// pushOutbox adds the messages to the update, so they are written to the document's outbox along with the change.
+func pushOutbox(update bson.M, outbox []*models.OutboxMessage) bson.M {
+	if len(outbox) == 0 {
+		return update
+	}
+
+	push, ok := update["$push"].(bson.M)
+	if !ok {
+		push = bson.M{}
+		update["$push"] = push
+	}
+
+	push["outbox"] = bson.M{"$each": outbox}
+	return update
+}
+
+// FetchOutbox lists the messages waiting in up to limit documents of every outbox, each with the document it waits in.
+// Every collection gets its own limit so a busy outbox doesn't keep the others waiting.
+func (r *notificationMongoRepository) FetchOutbox(ctx context.Context, limit int) ([]*models.OutboxMessage, error) {
+	session := r.Session.Clone()
+	defer session.Close()
+
+	m := make([]*models.OutboxMessage, 0)
+	for _, name := range outboxCollections {
+		docs := make([]struct {
+			ID     bson.ObjectId           `bson:"_id"`
+			Outbox []*models.OutboxMessage `bson:"outbox"`
+		}, 0)
+
+		query := bson.M{"outbox._id": bson.M{"$exists": true}}
+		if err := session.DB(r.DBName).C(name).Find(query).Select(bson.M{"outbox": 1}).Limit(limit).All(&docs); err != nil {
+			log.Printf("Failed to fetch outbox of %s : %s", name, err.Error())
+			return nil, err
+		}
+
+		for _, doc := range docs {
+			for _, message := range doc.Outbox {
+				message.Source = name
+				message.SourceID = doc.ID
+				m = append(m, message)
+			}
+		}
+	}
+
+	return m, nil
+}
+
+// ClearOutboxMessage takes the relayed message out of its document, a document that is gone has nothing to clear.
+func (r *notificationMongoRepository) ClearOutboxMessage(ctx context.Context, m *models.OutboxMessage) error {
+	session := r.Session.Clone()
+	defer session.Close()
+
+	err := session.DB(r.DBName).C(m.Source).UpdateId(m.SourceID, bson.M{"$pull": bson.M{"outbox": bson.M{"_id": m.ID}}})
+	if err != nil && err != mgo.ErrNotFound {
+		log.Printf("Failed to clear outbox message : %s", err.Error())
+		return err
+	}
+
+	return nil
+}
+
+// StoreNotifications stores the notifications one by one, a notification whose key is already stored was queued
+// by an earlier relay of the same message and is skipped.
+func (r *notificationMongoRepository) StoreNotifications(ctx context.Context, m []*models.Notification) error {
+	session := r.Session.Clone()
+	defer session.Close()
+
+	for _, notification := range m {
+		if err := session.DB(r.DBName).C(notificationCollectionName).Insert(notification); err != nil {
+			if mgo.IsDup(err) {
+				continue
+			}
+
+			log.Printf("Failed to store notifications : %s", err.Error())
+			return err
+		}
+	}
+
+	return nil
+}
+
+// ClaimNotification takes the next notification due at the given time for one worker, it's leased to that
+// worker as sending until the lease runs out. Every claim gets a new lease ID. Nothing due is uranus.ErrNotFound.
+func (r *notificationMongoRepository) ClaimNotification(ctx context.Context, at time.Time, lease time.Duration) (*models.Notification, error) {
+	session := r.Session.Clone()
+	defer session.Close()
+
+	query := bson.M{
+		"status":          bson.M{"$in": []string{models.NotificationPending, models.NotificationSending}},
+		"next_attempt_at": bson.M{"$lte": at},
+	}
+	change := mgo.Change{
+		Update: bson.M{"$set": bson.M{
+			"status":          models.NotificationSending,
+			"next_attempt_at": at.Add(lease),
+			"updated_at":      at,
+			"lease_id":        bson.NewObjectId(),
+		}},
+		ReturnNew: true,
+	}
+
+	m := new(models.Notification)
+	if _, err := session.DB(r.DBName).C(notificationCollectionName).Find(query).Sort("next_attempt_at").Apply(change, m); err != nil {
+		if err == mgo.ErrNotFound {
+			return nil, uranus.ErrNotFound
+		}
+
+		log.Printf("Failed to claim notification : %s", err.Error())
+		return nil, err
+	}
+
+	return m, nil
+}
+
+// UpdateNotification stores how the delivery of a claimed notification went. It returns uranus.ErrStaleStatus
+// when the claim's lease ran out and another worker claimed the notification meanwhile.
+func (r *notificationMongoRepository) UpdateNotification(ctx context.Context, m *models.Notification) error {
+	session := r.Session.Clone()
+	defer session.Close()
+
+	update := bson.M{"$set": bson.M{
+		"status":          m.Status,
+		"attempts":        m.Attempts,
+		"next_attempt_at": m.NextAttemptAt,
+		"last_error":      m.LastError,
+		"sent_at":         m.SentAt,
+		"updated_at":      m.UpdatedAt,
+	}}
+
+	query := bson.M{"_id": m.ID, "lease_id": m.LeaseID}
+	if err := session.DB(r.DBName).C(notificationCollectionName).Update(query, update); err != nil {
+		if err == mgo.ErrNotFound {
+			return uranus.ErrStaleStatus
+		}
+
+		log.Printf("Failed to update notification : %s", err.Error())
+		return err
+	}
+
+	return nil
+}
+
+// StoreInboxItem puts the item into the inbox once, delivering the same notification again is a no-op.
+func (r *notificationMongoRepository) StoreInboxItem(ctx context.Context, m *models.InboxItem) error {
+	session := r.Session.Clone()
+	defer session.Close()
+
+	_, err := session.DB(r.DBName).C(inboxCollectionName).Upsert(bson.M{"notification_id": m.NotificationID}, bson.M{"$setOnInsert": m})
+	if err != nil {
+		log.Printf("Failed to store inbox item : %s", err.Error())
+		return err
+	}
+
+	return nil
+}
+
+func (r *notificationMongoRepository) FetchInbox(ctx context.Context, filter *uranus.InboxFilter) ([]*models.InboxItem, uranus.Page, error) {
+	session := r.Session.Clone()
+	defer session.Close()
+
+	if !bson.IsObjectIdHex(filter.UserID) {
+		return make([]*models.InboxItem, 0), uranus.Page{}, nil
+	}
+
+	query := bson.M{"user_id": bson.ObjectIdHex(filter.UserID)}
+	if filter.Unread {
+		query["read_at"] = bson.M{"$exists": false}
+	}
+
+	m := make([]*models.InboxItem, 0)
+	page, err := paginate(session.DB(r.DBName).C(inboxCollectionName), query, uranus.InboxSort, filter.Cursor, filter.Num, &m, func(i int) (interface{}, bson.ObjectId) {
+		return m[i].CreatedAt, m[i].ID
+	})
+	if err != nil {
+		log.Printf("Failed to fetch inbox items : %s", err.Error())
+		return nil, uranus.Page{}, err
+	}
+
+	return m, page, nil
+}
+
+// MarkInboxRead marks the user's inbox item read, an item read before keeps its first read time.
+func (r *notificationMongoRepository) MarkInboxRead(ctx context.Context, itemID string, userID string, at time.Time) error {
+	session := r.Session.Clone()
+	defer session.Close()
+
+	if !bson.IsObjectIdHex(itemID) || !bson.IsObjectIdHex(userID) {
+		return uranus.ErrNotFound
+	}
+
+	query := bson.M{"_id": bson.ObjectIdHex(itemID), "user_id": bson.ObjectIdHex(userID)}
+	item := new(models.InboxItem)
+	if err := session.DB(r.DBName).C(inboxCollectionName).Find(query).One(item); err != nil {
+		if err == mgo.ErrNotFound {
+			return uranus.ErrNotFound
+		}
+
+		log.Printf("Failed to get inbox item : %s", err.Error())
+		return err
+	}
+
+	if item.ReadAt != nil {
+		return nil
+	}
+
+	if err := session.DB(r.DBName).C(inboxCollectionName).UpdateId(item.ID, bson.M{"$set": bson.M{"read_at": at}}); err != nil {
+		log.Printf("Failed to mark inbox item read : %s", err.Error())
+		return err
+	}
+
+	return nil
+}
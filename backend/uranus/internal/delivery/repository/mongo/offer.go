@@ -0,0 +1,225 @@
+package mongo
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/globalsign/mgo"
+	"github.com/globalsign/mgo/bson"
+
+	"github.com/fidellr/jastip/backend/uranus"
+	"github.com/fidellr/jastip/backend/uranus/models"
+	"github.com/fidellr/jastip/backend/uranus/repository"
+)
+
+var (
+	offerCollectionName = "offers"
+)
+
+type offerMongoRepository struct {
+	Session *mgo.Session
+	DBName  string
+}
+
+type offerRequirement func(*offerMongoRepository)
+
+func OfferSession(session *mgo.Session) offerRequirement {
+	return func(r *offerMongoRepository) {
+		r.Session = session
+	}
+}
+
+func OfferDBName(dbName string) offerRequirement {
+	return func(r *offerMongoRepository) {
+		r.DBName = dbName
+	}
+}
+
+func NewOfferMongo(reqs ...offerRequirement) repository.OfferRepository {
+	repo := new(offerMongoRepository)
+	for _, req := range reqs {
+		req(repo)
+	}
+
+	repo.ensureIndexes()
+	return repo
+}
+
+func (r *offerMongoRepository) ensureIndexes() {
+	session := r.Session.Clone()
+	defer session.Close()
+
+	indexes := []mgo.Index{
+		{Key: []string{"request_id", "status"}},
+		{Key: []string{"trip_id", "status"}},
+		{Key: []string{"traveler_id", "-created_at", "-_id"}},
+	}
+
+	for _, index := range indexes {
+		if err := session.DB(r.DBName).C(offerCollectionName).EnsureIndex(index); err != nil {
+			log.Printf("Failed to ensure offer indexes : %s", err.Error())
+		}
+	}
+}
+
+func (r *offerMongoRepository) StoreOffer(ctx context.Context, m *models.Offer) error {
+	session := r.Session.Clone()
+	defer session.Close()
+
+	if err := session.DB(r.DBName).C(offerCollectionName).Insert(m); err != nil {
+		log.Printf("Failed to store offer : %s", err.Error())
+		return err
+	}
+
+	return nil
+}
+
+func (r *offerMongoRepository) FetchOffers(ctx context.Context, filter *uranus.OfferFilter) ([]*models.Offer, uranus.Page, error) {
+	session := r.Session.Clone()
+	defer session.Close()
+
+	query := bson.M{}
+	for field, id := range map[string]string{"request_id": filter.RequestID, "trip_id": filter.TripID, "traveler_id": filter.TravelerID} {
+		if id == "" {
+			continue
+		}
+
+		if !bson.IsObjectIdHex(id) {
+			return make([]*models.Offer, 0), uranus.Page{}, nil
+		}
+
+		query[field] = bson.ObjectIdHex(id)
+	}
+
+	if filter.PartyID != "" {
+		if !bson.IsObjectIdHex(filter.PartyID) {
+			return make([]*models.Offer, 0), uranus.Page{}, nil
+		}
+
+		partyID := bson.ObjectIdHex(filter.PartyID)
+		query["$or"] = []bson.M{{"buyer_id": partyID}, {"traveler_id": partyID}}
+	}
+
+	if filter.Status != "" {
+		query["status"] = filter.Status
+	}
+
+	m := make([]*models.Offer, 0)
+	page, err := paginate(session.DB(r.DBName).C(offerCollectionName), query, uranus.OfferSort, filter.Cursor, filter.Num, &m, func(i int) (interface{}, bson.ObjectId) {
+		return m[i].CreatedAt, m[i].ID
+	})
+	if err != nil {
+		log.Printf("Failed to fetch offers : %s", err.Error())
+		return nil, uranus.Page{}, err
+	}
+
+	return m, page, nil
+}
+
+func (r *offerMongoRepository) GetOfferByID(ctx context.Context, offerID string) (*models.Offer, error) {
+	session := r.Session.Clone()
+	defer session.Close()
+
+	if !bson.IsObjectIdHex(offerID) {
+		return nil, uranus.ErrNotFound
+	}
+
+	var m *models.Offer
+	if err := session.DB(r.DBName).C(offerCollectionName).FindId(bson.ObjectIdHex(offerID)).One(&m); err != nil {
+		if err == mgo.ErrNotFound {
+			return nil, uranus.ErrNotFound
+		}
+
+		log.Printf("Failed to get offer : %s", err.Error())
+		return nil, err
+	}
+
+	return m, nil
+}
+
+func (r *offerMongoRepository) FetchOffersByTrip(ctx context.Context, tripID string, status string) ([]*models.Offer, error) {
+	session := r.Session.Clone()
+	defer session.Close()
+
+	m := make([]*models.Offer, 0)
+	if !bson.IsObjectIdHex(tripID) {
+		return m, nil
+	}
+
+	query := bson.M{"trip_id": bson.ObjectIdHex(tripID), "status": status}
+	if err := session.DB(r.DBName).C(offerCollectionName).Find(query).All(&m); err != nil {
+		log.Printf("Failed to fetch offers by trip : %s", err.Error())
+		return nil, err
+	}
+
+	return m, nil
+}
+
+func (r *offerMongoRepository) HasPendingOffer(ctx context.Context, requestID string, travelerID string) (bool, error) {
+	session := r.Session.Clone()
+	defer session.Close()
+
+	if !bson.IsObjectIdHex(requestID) || !bson.IsObjectIdHex(travelerID) {
+		return false, nil
+	}
+
+	query := bson.M{
+		"request_id":  bson.ObjectIdHex(requestID),
+		"traveler_id": bson.ObjectIdHex(travelerID),
+		"status":      models.OfferPending,
+	}
+	n, err := session.DB(r.DBName).C(offerCollectionName).Find(query).Count()
+	if err != nil {
+		log.Printf("Failed to count pending offers : %s", err.Error())
+		return false, err
+	}
+
+	return n > 0, nil
+}
+
+// UpdateOfferStatus moves an offer from one status to another,
+// it returns uranus.ErrStaleStatus when the offer is no longer in the from status.
+func (r *offerMongoRepository) UpdateOfferStatus(ctx context.Context, offerID string, from, to string) error {
+	session := r.Session.Clone()
+	defer session.Close()
+
+	if !bson.IsObjectIdHex(offerID) {
+		return uranus.ErrNotFound
+	}
+
+	query := bson.M{"_id": bson.ObjectIdHex(offerID), "status": from}
+	err := session.DB(r.DBName).C(offerCollectionName).Update(query, bson.M{
+		"$set": bson.M{"status": to, "updated_at": time.Now()},
+	})
+	if err != nil {
+		if err == mgo.ErrNotFound {
+			return uranus.ErrStaleStatus
+		}
+
+		log.Printf("Failed to update offer status : %s", err.Error())
+		return err
+	}
+
+	return nil
+}
+
+func (r *offerMongoRepository) DeclinePendingOffers(ctx context.Context, requestID string) (int, error) {
+	session := r.Session.Clone()
+	defer session.Close()
+
+	if !bson.IsObjectIdHex(requestID) {
+		return 0, nil
+	}
+
+	query := bson.M{"request_id": bson.ObjectIdHex(requestID), "status": models.OfferPending}
+	info, err := session.DB(r.DBName).C(offerCollectionName).UpdateAll(query, bson.M{
+		"$set": bson.M{"status": models.OfferDeclined, "updated_at": time.Now()},
+	})
+	if err != nil {
+		log.Printf("Failed to decline pending offers : %s", err.Error())
+		return 0, err
+	}
+
+	return info.Updated, nil
+}
@@ -0,0 +1,250 @@
+package mongo
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/globalsign/mgo"
+	"github.com/globalsign/mgo/bson"
+
+	"github.com/fidellr/jastip/backend/uranus"
+	"github.com/fidellr/jastip/backend/uranus/models"
+	"github.com/fidellr/jastip/backend/uranus/repository"
+)
+
+var (
+	orderCollectionName = "orders"
+)
+
+type orderMongoRepository struct {
+	Session *mgo.Session
+	DBName  string
+}
+
+type orderRequirement func(*orderMongoRepository)
+
+func OrderSession(session *mgo.Session) orderRequirement {
+	return func(r *orderMongoRepository) {
+		r.Session = session
+	}
+}
+
+func OrderDBName(dbName string) orderRequirement {
+	return func(r *orderMongoRepository) {
+		r.DBName = dbName
+	}
+}
+
+func NewOrderMongo(reqs ...orderRequirement) repository.OrderRepository {
+	repo := new(orderMongoRepository)
+	for _, req := range reqs {
+		req(repo)
+	}
+
+	repo.ensureIndexes()
+	return repo
+}
+
+func (r *orderMongoRepository) ensureIndexes() {
+	session := r.Session.Clone()
+	defer session.Close()
+
+	indexes := []mgo.Index{
+		{Key: []string{"buyer_id", "-created_at", "-_id"}},
+		{Key: []string{"traveler_id", "-created_at", "-_id"}},
+		{Key: []string{"status", "-created_at", "-_id"}},
+		{Key: []string{"offer_id"}, Unique: true},
+		{Key: []string{"trip_id"}},
+		{Key: []string{"request_id"}},
+		{Key: []string{"status", "settled_at", "updated_at"}},
+	}
+
+	for _, index := range indexes {
+		if err := session.DB(r.DBName).C(orderCollectionName).EnsureIndex(index); err != nil {
+			log.Printf("Failed to ensure order indexes : %s", err.Error())
+		}
+	}
+}
+
+func (r *orderMongoRepository) StoreOrder(ctx context.Context, m *models.Order) error {
+	session := r.Session.Clone()
+	defer session.Close()
+
+	if err := session.DB(r.DBName).C(orderCollectionName).Insert(m); err != nil {
+		log.Printf("Failed to store order : %s", err.Error())
+		return err
+	}
+
+	return nil
+}
+
+func (r *orderMongoRepository) FetchOrders(ctx context.Context, filter *uranus.OrderFilter) ([]*models.Order, uranus.Page, error) {
+	session := r.Session.Clone()
+	defer session.Close()
+
+	query := bson.M{}
+	for field, id := range map[string]string{"buyer_id": filter.BuyerID, "traveler_id": filter.TravelerID} {
+		if id == "" {
+			continue
+		}
+
+		if !bson.IsObjectIdHex(id) {
+			return make([]*models.Order, 0), uranus.Page{}, nil
+		}
+
+		query[field] = bson.ObjectIdHex(id)
+	}
+
+	if filter.PartyID != "" {
+		if !bson.IsObjectIdHex(filter.PartyID) {
+			return make([]*models.Order, 0), uranus.Page{}, nil
+		}
+
+		partyID := bson.ObjectIdHex(filter.PartyID)
+		query["$or"] = []bson.M{{"buyer_id": partyID}, {"traveler_id": partyID}}
+	}
+
+	if filter.Status != "" {
+		query["status"] = filter.Status
+	}
+
+	m := make([]*models.Order, 0)
+	page, err := paginate(session.DB(r.DBName).C(orderCollectionName), query, uranus.OrderSort, filter.Cursor, filter.Num, &m, func(i int) (interface{}, bson.ObjectId) {
+		return m[i].CreatedAt, m[i].ID
+	})
+	if err != nil {
+		log.Printf("Failed to fetch orders : %s", err.Error())
+		return nil, uranus.Page{}, err
+	}
+
+	return m, page, nil
+}
+
+func (r *orderMongoRepository) GetOrderByID(ctx context.Context, orderID string) (*models.Order, error) {
+	session := r.Session.Clone()
+	defer session.Close()
+
+	if !bson.IsObjectIdHex(orderID) {
+		return nil, uranus.ErrNotFound
+	}
+
+	var m *models.Order
+	if err := session.DB(r.DBName).C(orderCollectionName).FindId(bson.ObjectIdHex(orderID)).One(&m); err != nil {
+		if err == mgo.ErrNotFound {
+			return nil, uranus.ErrNotFound
+		}
+
+		log.Printf("Failed to get order : %s", err.Error())
+		return nil, err
+	}
+
+	return m, nil
+}
+
+func (r *orderMongoRepository) FetchOrdersByTrip(ctx context.Context, tripID string) ([]*models.Order, error) {
+	session := r.Session.Clone()
+	defer session.Close()
+
+	m := make([]*models.Order, 0)
+	if !bson.IsObjectIdHex(tripID) {
+		return m, nil
+	}
+
+	if err := session.DB(r.DBName).C(orderCollectionName).Find(bson.M{"trip_id": bson.ObjectIdHex(tripID)}).All(&m); err != nil {
+		log.Printf("Failed to fetch orders by trip : %s", err.Error())
+		return nil, err
+	}
+
+	return m, nil
+}
+
+func (r *orderMongoRepository) FetchOrdersByRequest(ctx context.Context, requestID string) ([]*models.Order, error) {
+	session := r.Session.Clone()
+	defer session.Close()
+
+	m := make([]*models.Order, 0)
+	if !bson.IsObjectIdHex(requestID) {
+		return m, nil
+	}
+
+	if err := session.DB(r.DBName).C(orderCollectionName).Find(bson.M{"request_id": bson.ObjectIdHex(requestID)}).All(&m); err != nil {
+		log.Printf("Failed to fetch orders by request : %s", err.Error())
+		return nil, err
+	}
+
+	return m, nil
+}
+
+// AppendOrderTransition moves the order to the transition's status and pushes the transition onto its history,
+// it returns uranus.ErrStaleStatus when the order is no longer in the transition's from status.
+// The outbox messages are written with the transition.
+func (r *orderMongoRepository) AppendOrderTransition(ctx context.Context, orderID string, transition models.OrderTransition, outbox ...*models.OutboxMessage) error {
+	session := r.Session.Clone()
+	defer session.Close()
+
+	if !bson.IsObjectIdHex(orderID) {
+		return uranus.ErrNotFound
+	}
+
+	query := bson.M{"_id": bson.ObjectIdHex(orderID), "status": transition.From}
+	err := session.DB(r.DBName).C(orderCollectionName).Update(query, pushOutbox(bson.M{
+		"$set":  bson.M{"status": transition.To, "updated_at": transition.At},
+		"$push": bson.M{"history": transition},
+	}, outbox))
+	if err != nil {
+		if err == mgo.ErrNotFound {
+			return uranus.ErrStaleStatus
+		}
+
+		log.Printf("Failed to append order transition : %s", err.Error())
+		return err
+	}
+
+	return nil
+}
+
+// FetchUnsettledOrders lists orders that ended before the given time without their escrow being settled,
+// orders that ended out of a dispute are left to the dispute's settlement.
+func (r *orderMongoRepository) FetchUnsettledOrders(ctx context.Context, endedBefore time.Time, limit int) ([]*models.Order, error) {
+	session := r.Session.Clone()
+	defer session.Close()
+
+	query := bson.M{
+		"status":       bson.M{"$in": []string{models.OrderCompleted, models.OrderCancelled}},
+		"settled_at":   bson.M{"$exists": false},
+		"updated_at":   bson.M{"$lt": endedBefore},
+		"history.from": bson.M{"$ne": models.OrderDisputed},
+	}
+
+	m := make([]*models.Order, 0)
+	if err := session.DB(r.DBName).C(orderCollectionName).Find(query).Sort("updated_at").Limit(limit).All(&m); err != nil {
+		log.Printf("Failed to fetch unsettled orders : %s", err.Error())
+		return nil, err
+	}
+
+	return m, nil
+}
+
+func (r *orderMongoRepository) MarkOrderSettled(ctx context.Context, orderID string, at time.Time) error {
+	session := r.Session.Clone()
+	defer session.Close()
+
+	if !bson.IsObjectIdHex(orderID) {
+		return uranus.ErrNotFound
+	}
+
+	err := session.DB(r.DBName).C(orderCollectionName).UpdateId(bson.ObjectIdHex(orderID), bson.M{
+		"$set": bson.M{"settled_at": at},
+	})
+	if err != nil {
+		if err == mgo.ErrNotFound {
+			return uranus.ErrNotFound
+		}
+
+		log.Printf("Failed to mark order settled : %s", err.Error())
+		return err
+	}
+
+	return nil
+}
@@ -0,0 +1,68 @@
+package mongo
+
+import (
+	"reflect"
+
+	"github.com/globalsign/mgo"
+	"github.com/globalsign/mgo/bson"
+
+	"github.com/fidellr/jastip/backend/uranus"
+)
+
+// paginate reads the page of query after the encoded cursor into result, a pointer to a slice, in the order of sort.
+// key gives the sort value and _id of the i-th item on the page, the cursors around the page are built from them.
+func paginate(c *mgo.Collection, query bson.M, sort, encoded string, num int, result interface{}, key func(i int) (interface{}, bson.ObjectId)) (uranus.Page, error) {
+	var cursor *uranus.Cursor
+	if encoded != "" {
+		var err error
+		cursor, err = uranus.DecodeCursor(encoded, sort)
+		if err != nil {
+			return uranus.Page{}, err
+		}
+	}
+
+	total, err := c.Find(query).Count()
+	if err != nil {
+		return uranus.Page{}, err
+	}
+
+	ranged := query
+	if cursor != nil {
+		ranged = bson.M{"$and": []bson.M{query, cursor.Range()}}
+	}
+
+	// One extra item tells whether there is another page.
+	num = uranus.PageSize(num)
+	if err := c.Find(ranged).Limit(num + 1).Sort(uranus.SortOrder(sort, cursor)...).All(result); err != nil {
+		return uranus.Page{}, err
+	}
+
+	items := reflect.ValueOf(result).Elem()
+	if items.Len() == 0 && cursor != nil && cursor.Backward {
+		// Nothing is left before the cursor, e.g. the items there got deleted, so the client goes on from the first page.
+		return paginate(c, query, sort, "", num, result, key)
+	}
+
+	if items.Len() == 0 {
+		return uranus.Page{Total: total}, nil
+	}
+
+	hasMore := items.Len() > num
+	if hasMore {
+		items.Set(items.Slice(0, num))
+	}
+
+	// Backward pages are read in reverse, flip them back into the list order.
+	if cursor != nil && cursor.Backward {
+		swap := reflect.Swapper(items.Interface())
+		for i, j := 0, items.Len()-1; i < j; i, j = i+1, j-1 {
+			swap(i, j)
+		}
+	}
+
+	firstValue, firstID := key(0)
+	lastValue, lastID := key(items.Len() - 1)
+	page := uranus.NewPage(cursor, sort, hasMore, firstValue, firstID, lastValue, lastID)
+	page.Total = total
+	return page, nil
+}
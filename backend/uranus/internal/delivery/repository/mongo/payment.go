@@ -0,0 +1,133 @@
+package mongo
+
+import (
+	"context"
+	"log"
+
+	"github.com/globalsign/mgo"
+	"github.com/globalsign/mgo/bson"
+
+	"github.com/fidellr/jastip/backend/uranus"
+	"github.com/fidellr/jastip/backend/uranus/models"
+	"github.com/fidellr/jastip/backend/uranus/repository"
+)
+
+var (
+	paymentCollectionName = "payments"
+)
+
+type paymentMongoRepository struct {
+	Session *mgo.Session
+	DBName  string
+}
+
+type paymentRequirement func(*paymentMongoRepository)
+
+func PaymentSession(session *mgo.Session) paymentRequirement {
+	return func(r *paymentMongoRepository) {
+		r.Session = session
+	}
+}
+
+func PaymentDBName(dbName string) paymentRequirement {
+	return func(r *paymentMongoRepository) {
+		r.DBName = dbName
+	}
+}
+
+func NewPaymentMongo(reqs ...paymentRequirement) repository.PaymentRepository {
+	repo := new(paymentMongoRepository)
+	for _, req := range reqs {
+		req(repo)
+	}
+
+	repo.ensureIndexes()
+	return repo
+}
+
+func (r *paymentMongoRepository) ensureIndexes() {
+	session := r.Session.Clone()
+	defer session.Close()
+
+	indexes := []mgo.Index{
+		{Key: []string{"gateway_ref"}, Unique: true, Sparse: true},
+		{Key: []string{"order_id", "status"}},
+	}
+
+	for _, index := range indexes {
+		if err := session.DB(r.DBName).C(paymentCollectionName).EnsureIndex(index); err != nil {
+			log.Printf("Failed to ensure payment indexes : %s", err.Error())
+		}
+	}
+}
+
+func (r *paymentMongoRepository) StorePayment(ctx context.Context, m *models.Payment) error {
+	session := r.Session.Clone()
+	defer session.Close()
+
+	if err := session.DB(r.DBName).C(paymentCollectionName).Insert(m); err != nil {
+		log.Printf("Failed to store payment : %s", err.Error())
+		return err
+	}
+
+	return nil
+}
+
+func (r *paymentMongoRepository) GetPaymentByGatewayRef(ctx context.Context, gatewayRef string) (*models.Payment, error) {
+	session := r.Session.Clone()
+	defer session.Close()
+
+	var m *models.Payment
+	if err := session.DB(r.DBName).C(paymentCollectionName).Find(bson.M{"gateway_ref": gatewayRef}).One(&m); err != nil {
+		if err == mgo.ErrNotFound {
+			return nil, uranus.ErrNotFound
+		}
+
+		log.Printf("Failed to get payment by gateway ref : %s", err.Error())
+		return nil, err
+	}
+
+	return m, nil
+}
+
+// GetPaymentByOrder gets the latest payment of the order in the given status.
+func (r *paymentMongoRepository) GetPaymentByOrder(ctx context.Context, orderID string, status string) (*models.Payment, error) {
+	session := r.Session.Clone()
+	defer session.Close()
+
+	if !bson.IsObjectIdHex(orderID) {
+		return nil, uranus.ErrNotFound
+	}
+
+	var m *models.Payment
+	query := bson.M{"order_id": bson.ObjectIdHex(orderID), "status": status}
+	if err := session.DB(r.DBName).C(paymentCollectionName).Find(query).Sort("-created_at").One(&m); err != nil {
+		if err == mgo.ErrNotFound {
+			return nil, uranus.ErrNotFound
+		}
+
+		log.Printf("Failed to get payment by order : %s", err.Error())
+		return nil, err
+	}
+
+	return m, nil
+}
+
+// UpdatePayment replaces the payment only while it is still in the from status,
+// a payment some other webhook settled meanwhile returns uranus.ErrStaleStatus.
+func (r *paymentMongoRepository) UpdatePayment(ctx context.Context, m *models.Payment, from string) error {
+	session := r.Session.Clone()
+	defer session.Close()
+
+	query := bson.M{"_id": m.ID, "status": from}
+	if err := session.DB(r.DBName).C(paymentCollectionName).Update(query, m); err != nil {
+		if err == mgo.ErrNotFound {
+			return uranus.ErrStaleStatus
+		}
+
+		log.Printf("Failed to update payment : %s", err.Error())
+		return err
+	}
+
+	return nil
+}
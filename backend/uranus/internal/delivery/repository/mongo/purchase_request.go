@@ -0,0 +1,221 @@
+package mongo
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/globalsign/mgo"
+	"github.com/globalsign/mgo/bson"
+
+	"github.com/fidellr/jastip/backend/uranus"
+	"github.com/fidellr/jastip/backend/uranus/models"
+	"github.com/fidellr/jastip/backend/uranus/repository"
+)
+
+var (
+	purchaseRequestCollectionName = "purchase_requests"
+)
+
+type purchaseRequestMongoRepository struct {
+	Session *mgo.Session
+	DBName  string
+}
+
+type purchaseRequestRequirement func(*purchaseRequestMongoRepository)
+
+func PurchaseRequestSession(session *mgo.Session) purchaseRequestRequirement {
+	return func(r *purchaseRequestMongoRepository) {
+		r.Session = session
+	}
+}
+
+func PurchaseRequestDBName(dbName string) purchaseRequestRequirement {
+	return func(r *purchaseRequestMongoRepository) {
+		r.DBName = dbName
+	}
+}
+
+func NewPurchaseRequestMongo(reqs ...purchaseRequestRequirement) repository.PurchaseRequestRepository {
+	repo := new(purchaseRequestMongoRepository)
+	for _, req := range reqs {
+		req(repo)
+	}
+
+	repo.ensureIndexes()
+	return repo
+}
+
+func (r *purchaseRequestMongoRepository) ensureIndexes() {
+	session := r.Session.Clone()
+	defer session.Close()
+
+	indexes := []mgo.Index{
+		{Key: []string{"status", "destination_country", "-created_at", "-_id"}},
+		{Key: []string{"buyer_id", "-created_at", "-_id"}},
+		{Key: []string{"status", "deadline"}},
+	}
+
+	for _, index := range indexes {
+		if err := session.DB(r.DBName).C(purchaseRequestCollectionName).EnsureIndex(index); err != nil {
+			log.Printf("Failed to ensure purchase request indexes : %s", err.Error())
+		}
+	}
+}
+
+func (r *purchaseRequestMongoRepository) StorePurchaseRequest(ctx context.Context, m *models.PurchaseRequest) error {
+	session := r.Session.Clone()
+	defer session.Close()
+
+	if err := session.DB(r.DBName).C(purchaseRequestCollectionName).Insert(m); err != nil {
+		log.Printf("Failed to store purchase request : %s", err.Error())
+		return err
+	}
+
+	return nil
+}
+
+func (r *purchaseRequestMongoRepository) FetchPurchaseRequests(ctx context.Context, filter *uranus.PurchaseRequestFilter) ([]*models.PurchaseRequest, uranus.Page, error) {
+	session := r.Session.Clone()
+	defer session.Close()
+
+	query := bson.M{}
+	if filter.BuyerID != "" {
+		if !bson.IsObjectIdHex(filter.BuyerID) {
+			return make([]*models.PurchaseRequest, 0), uranus.Page{}, nil
+		}
+
+		query["buyer_id"] = bson.ObjectIdHex(filter.BuyerID)
+	}
+
+	if filter.DestinationCountry != "" {
+		query["destination_country"] = filter.DestinationCountry
+	}
+
+	if filter.Category != "" {
+		query["category"] = filter.Category
+	}
+
+	if filter.Status != "" {
+		query["status"] = filter.Status
+	}
+
+	m := make([]*models.PurchaseRequest, 0)
+	page, err := paginate(session.DB(r.DBName).C(purchaseRequestCollectionName), query, uranus.PurchaseRequestSort, filter.Cursor, filter.Num, &m, func(i int) (interface{}, bson.ObjectId) {
+		return m[i].CreatedAt, m[i].ID
+	})
+	if err != nil {
+		log.Printf("Failed to fetch purchase requests : %s", err.Error())
+		return nil, uranus.Page{}, err
+	}
+
+	return m, page, nil
+}
+
+func (r *purchaseRequestMongoRepository) GetPurchaseRequestByID(ctx context.Context, requestID string) (*models.PurchaseRequest, error) {
+	session := r.Session.Clone()
+	defer session.Close()
+
+	if !bson.IsObjectIdHex(requestID) {
+		return nil, uranus.ErrNotFound
+	}
+
+	var m *models.PurchaseRequest
+	if err := session.DB(r.DBName).C(purchaseRequestCollectionName).FindId(bson.ObjectIdHex(requestID)).One(&m); err != nil {
+		if err == mgo.ErrNotFound {
+			return nil, uranus.ErrNotFound
+		}
+
+		log.Printf("Failed to get purchase request : %s", err.Error())
+		return nil, err
+	}
+
+	return m, nil
+}
+
+// UpdatePurchaseRequestByID replaces a request only while it is still open,
+// a request that got matched meanwhile returns uranus.ErrStaleStatus.
+func (r *purchaseRequestMongoRepository) UpdatePurchaseRequestByID(ctx context.Context, requestID string, m *models.PurchaseRequest) error {
+	session := r.Session.Clone()
+	defer session.Close()
+
+	if !bson.IsObjectIdHex(requestID) {
+		return uranus.ErrNotFound
+	}
+
+	query := bson.M{"_id": bson.ObjectIdHex(requestID), "status": models.PurchaseRequestOpen}
+	if err := session.DB(r.DBName).C(purchaseRequestCollectionName).Update(query, m); err != nil {
+		if err == mgo.ErrNotFound {
+			return uranus.ErrStaleStatus
+		}
+
+		log.Printf("Failed to update purchase request : %s", err.Error())
+		return err
+	}
+
+	return nil
+}
+
+// UpdatePurchaseRequestStatus moves a request from one status to another,
+// it returns uranus.ErrStaleStatus when the request is no longer in the from status.
+func (r *purchaseRequestMongoRepository) UpdatePurchaseRequestStatus(ctx context.Context, requestID string, from, to string) error {
+	session := r.Session.Clone()
+	defer session.Close()
+
+	if !bson.IsObjectIdHex(requestID) {
+		return uranus.ErrNotFound
+	}
+
+	query := bson.M{"_id": bson.ObjectIdHex(requestID), "status": from}
+	err := session.DB(r.DBName).C(purchaseRequestCollectionName).Update(query, bson.M{
+		"$set": bson.M{"status": to, "updated_at": time.Now()},
+	})
+	if err != nil {
+		if err == mgo.ErrNotFound {
+			return uranus.ErrStaleStatus
+		}
+
+		log.Printf("Failed to update purchase request status : %s", err.Error())
+		return err
+	}
+
+	return nil
+}
+
+func (r *purchaseRequestMongoRepository) ExpireOverdueRequests(ctx context.Context, at time.Time) (int, error) {
+	session := r.Session.Clone()
+	defer session.Close()
+
+	query := bson.M{"status": models.PurchaseRequestOpen, "deadline": bson.M{"$lt": at}}
+	info, err := session.DB(r.DBName).C(purchaseRequestCollectionName).UpdateAll(query, bson.M{
+		"$set": bson.M{"status": models.PurchaseRequestExpired, "updated_at": at},
+	})
+	if err != nil {
+		log.Printf("Failed to expire overdue purchase requests : %s", err.Error())
+		return 0, err
+	}
+
+	return info.Updated, nil
+}
+
+// FetchMatchCandidates loads open requests a trip could take, the soonest deadline first.
+func (r *purchaseRequestMongoRepository) FetchMatchCandidates(ctx context.Context, country string, categories []string, deadlineAfter time.Time, limit int) ([]*models.PurchaseRequest, error) {
+	session := r.Session.Clone()
+	defer session.Close()
+
+	query := bson.M{
+		"status":              models.PurchaseRequestOpen,
+		"destination_country": country,
+		"category":            bson.M{"$in": categories},
+		"deadline":            bson.M{"$gte": deadlineAfter},
+	}
+
+	m := make([]*models.PurchaseRequest, 0)
+	err := session.DB(r.DBName).C(purchaseRequestCollectionName).Find(query).Sort("deadline").Limit(limit).All(&m)
+	if err != nil {
+		log.Printf("Failed to fetch match candidates : %s", err.Error())
+		return nil, err
+	}
+
+	return m, nil
+}
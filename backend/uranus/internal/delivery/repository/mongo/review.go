@@ -0,0 +1,200 @@
+package mongo
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/globalsign/mgo"
+	"github.com/globalsign/mgo/bson"
+
+	"github.com/fidellr/jastip/backend/uranus"
+	"github.com/fidellr/jastip/backend/uranus/models"
+	"github.com/fidellr/jastip/backend/uranus/repository"
+)
+
+var (
+	reviewCollectionName = "reviews"
+)
+
+type reviewMongoRepository struct {
+	Session *mgo.Session
+	DBName  string
+}
+
+type reviewRequirement func(*reviewMongoRepository)
+
+func ReviewSession(session *mgo.Session) reviewRequirement {
+	return func(r *reviewMongoRepository) {
+		r.Session = session
+	}
+}
+
+func ReviewDBName(dbName string) reviewRequirement {
+	return func(r *reviewMongoRepository) {
+		r.DBName = dbName
+	}
+}
+
+func NewReviewMongo(reqs ...reviewRequirement) repository.ReviewRepository {
+	repo := new(reviewMongoRepository)
+	for _, req := range reqs {
+		req(repo)
+	}
+
+	repo.ensureIndexes()
+	return repo
+}
+
+// ensureIndexes makes a reviewer's second review of the same order fail to store.
+func (r *reviewMongoRepository) ensureIndexes() {
+	session := r.Session.Clone()
+	defer session.Close()
+
+	indexes := []mgo.Index{
+		{Key: []string{"order_id", "reviewer_id"}, Unique: true},
+		{Key: []string{"reviewee_id", "-created_at"}},
+		{Key: []string{"reviewer_id", "-created_at"}},
+	}
+
+	for _, index := range indexes {
+		if err := session.DB(r.DBName).C(reviewCollectionName).EnsureIndex(index); err != nil {
+			log.Printf("Failed to ensure review indexes : %s", err.Error())
+		}
+	}
+}
+
+func (r *reviewMongoRepository) StoreReview(ctx context.Context, m *models.Review) error {
+	session := r.Session.Clone()
+	defer session.Close()
+
+	if err := session.DB(r.DBName).C(reviewCollectionName).Insert(m); err != nil {
+		if mgo.IsDup(err) {
+			return uranus.ErrAlreadyReviewed
+		}
+
+		log.Printf("Failed to store review : %s", err.Error())
+		return err
+	}
+
+	return nil
+}
+
+// UpdateReview stores the reviewer's edit as long as the review is still editable at the given time,
+// a review past its edit window is uranus.ErrNotFound.
+func (r *reviewMongoRepository) UpdateReview(ctx context.Context, m *models.Review, at time.Time) error {
+	session := r.Session.Clone()
+	defer session.Close()
+
+	query := bson.M{
+		"_id":            m.ID,
+		"reviewer_id":    m.ReviewerID,
+		"editable_until": bson.M{"$gt": at},
+	}
+	update := bson.M{"$set": bson.M{
+		"rating":     m.Rating,
+		"comment":    m.Comment,
+		"image_ids":  m.ImageIDs,
+		"updated_at": m.UpdatedAt,
+	}}
+
+	if err := session.DB(r.DBName).C(reviewCollectionName).Update(query, update); err != nil {
+		if err == mgo.ErrNotFound {
+			return uranus.ErrNotFound
+		}
+
+		log.Printf("Failed to update review : %s", err.Error())
+		return err
+	}
+
+	return nil
+}
+
+func (r *reviewMongoRepository) GetReviewByID(ctx context.Context, reviewID string) (*models.Review, error) {
+	session := r.Session.Clone()
+	defer session.Close()
+
+	if !bson.IsObjectIdHex(reviewID) {
+		return nil, uranus.ErrNotFound
+	}
+
+	m := new(models.Review)
+	if err := session.DB(r.DBName).C(reviewCollectionName).FindId(bson.ObjectIdHex(reviewID)).One(m); err != nil {
+		if err == mgo.ErrNotFound {
+			return nil, uranus.ErrNotFound
+		}
+
+		log.Printf("Failed to get review : %s", err.Error())
+		return nil, err
+	}
+
+	return m, nil
+}
+
+func (r *reviewMongoRepository) FetchReviews(ctx context.Context, filter *uranus.ReviewFilter) ([]*models.Review, uranus.Page, error) {
+	session := r.Session.Clone()
+	defer session.Close()
+
+	query := bson.M{}
+	for field, id := range map[string]string{
+		"reviewee_id": filter.RevieweeID,
+		"reviewer_id": filter.ReviewerID,
+		"order_id":    filter.OrderID,
+	} {
+		if id == "" {
+			continue
+		}
+
+		if !bson.IsObjectIdHex(id) {
+			return make([]*models.Review, 0), uranus.Page{}, nil
+		}
+
+		query[field] = bson.ObjectIdHex(id)
+	}
+
+	if filter.Rating != 0 {
+		query["rating"] = filter.Rating
+	}
+
+	m := make([]*models.Review, 0)
+	page, err := paginate(session.DB(r.DBName).C(reviewCollectionName), query, uranus.ReviewSort, filter.Cursor, filter.Num, &m, func(i int) (interface{}, bson.ObjectId) {
+		return m[i].CreatedAt, m[i].ID
+	})
+	if err != nil {
+		log.Printf("Failed to fetch reviews : %s", err.Error())
+		return nil, uranus.Page{}, err
+	}
+
+	return m, page, nil
+}
+
+// CountRatings counts the reviews the user got by rating.
+func (r *reviewMongoRepository) CountRatings(ctx context.Context, revieweeID string) (map[int]int, error) {
+	session := r.Session.Clone()
+	defer session.Close()
+
+	counts := make(map[int]int)
+	if !bson.IsObjectIdHex(revieweeID) {
+		return counts, nil
+	}
+
+	pipeline := []bson.M{
+		{"$match": bson.M{"reviewee_id": bson.ObjectIdHex(revieweeID)}},
+		{"$group": bson.M{"_id": "$rating", "count": bson.M{"$sum": 1}}},
+	}
+
+	var groups []struct {
+		Rating int `bson:"_id"`
+		Count  int `bson:"count"`
+	}
+	if err := session.DB(r.DBName).C(reviewCollectionName).Pipe(pipeline).All(&groups); err != nil {
+		log.Printf("Failed to count ratings : %s", err.Error())
+		return nil, err
+	}
+
+	for _, group := range groups {
+		counts[group.Rating] = group.Count
+	}
+
+	return counts, nil
+}
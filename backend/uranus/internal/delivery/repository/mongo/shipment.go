@@ -0,0 +1,111 @@
+package mongo
+
+import (
+	"context"
+	"log"
+
+	"github.com/globalsign/mgo"
+	"github.com/globalsign/mgo/bson"
+
+	"github.com/fidellr/jastip/backend/uranus"
+	"github.com/fidellr/jastip/backend/uranus/models"
+	"github.com/fidellr/jastip/backend/uranus/repository"
+)
+
+var (
+	shipmentEventCollectionName = "shipment_events"
+)
+
+type shipmentMongoRepository struct {
+	Session *mgo.Session
+	DBName  string
+}
+
+type shipmentRequirement func(*shipmentMongoRepository)
+
+func ShipmentSession(session *mgo.Session) shipmentRequirement {
+	return func(r *shipmentMongoRepository) {
+		r.Session = session
+	}
+}
+
+func ShipmentDBName(dbName string) shipmentRequirement {
+	return func(r *shipmentMongoRepository) {
+		r.DBName = dbName
+	}
+}
+
+func NewShipmentMongo(reqs ...shipmentRequirement) repository.ShipmentRepository {
+	repo := new(shipmentMongoRepository)
+	for _, req := range reqs {
+		req(repo)
+	}
+
+	repo.ensureIndexes()
+	return repo
+}
+
+// ensureIndexes allows one event of each type per order, two travelers' clicks can't both post it.
+func (r *shipmentMongoRepository) ensureIndexes() {
+	session := r.Session.Clone()
+	defer session.Close()
+
+	index := mgo.Index{Key: []string{"order_id", "type"}, Unique: true}
+	if err := session.DB(r.DBName).C(shipmentEventCollectionName).EnsureIndex(index); err != nil {
+		log.Printf("Failed to ensure shipment event indexes : %s", err.Error())
+	}
+}
+
+func (r *shipmentMongoRepository) StoreShipmentEvent(ctx context.Context, m *models.ShipmentEvent) error {
+	session := r.Session.Clone()
+	defer session.Close()
+
+	if err := session.DB(r.DBName).C(shipmentEventCollectionName).Insert(m); err != nil {
+		if mgo.IsDup(err) {
+			return uranus.ErrStaleStatus
+		}
+
+		log.Printf("Failed to store shipment event : %s", err.Error())
+		return err
+	}
+
+	return nil
+}
+
+func (r *shipmentMongoRepository) FetchShipmentEvents(ctx context.Context, orderID string) ([]*models.ShipmentEvent, error) {
+	session := r.Session.Clone()
+	defer session.Close()
+
+	m := make([]*models.ShipmentEvent, 0)
+	if !bson.IsObjectIdHex(orderID) {
+		return m, nil
+	}
+
+	query := bson.M{"order_id": bson.ObjectIdHex(orderID)}
+	if err := session.DB(r.DBName).C(shipmentEventCollectionName).Find(query).Sort("created_at").All(&m); err != nil {
+		log.Printf("Failed to fetch shipment events : %s", err.Error())
+		return nil, err
+	}
+
+	return m, nil
+}
+
+func (r *shipmentMongoRepository) RemoveShipmentEvent(ctx context.Context, id string) error {
+	session := r.Session.Clone()
+	defer session.Close()
+
+	if !bson.IsObjectIdHex(id) {
+		return uranus.ErrNotFound
+	}
+
+	if err := session.DB(r.DBName).C(shipmentEventCollectionName).RemoveId(bson.ObjectIdHex(id)); err != nil {
+		if err == mgo.ErrNotFound {
+			return uranus.ErrNotFound
+		}
+
+		log.Printf("Failed to remove shipment event : %s", err.Error())
+		return err
+	}
+
+	return nil
+}
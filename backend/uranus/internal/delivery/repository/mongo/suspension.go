@@ -0,0 +1,89 @@
+package mongo
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/globalsign/mgo"
+	"github.com/globalsign/mgo/bson"
+
+	"github.com/fidellr/jastip/backend/uranus/models"
+	"github.com/fidellr/jastip/backend/uranus/repository"
+)
+
+var (
+	suspensionCollectionName = "user_suspensions"
+)
+
+type suspensionMongoRepository struct {
+	Session *mgo.Session
+	DBName  string
+}
+
+type suspensionRequirement func(*suspensionMongoRepository)
+
+func SuspensionSession(session *mgo.Session) suspensionRequirement {
+	return func(r *suspensionMongoRepository) {
+		r.Session = session
+	}
+}
+
+func SuspensionDBName(dbName string) suspensionRequirement {
+	return func(r *suspensionMongoRepository) {
+		r.DBName = dbName
+	}
+}
+
+func NewSuspensionMongo(reqs ...suspensionRequirement) repository.SuspensionRepository {
+	repo := new(suspensionMongoRepository)
+	for _, req := range reqs {
+		req(repo)
+	}
+
+	return repo
+}
+
+func (r *suspensionMongoRepository) StoreSuspension(ctx context.Context, m *models.Suspension) error {
+	session := r.Session.Clone()
+	defer session.Close()
+
+	if err := session.DB(r.DBName).C(suspensionCollectionName).Insert(m); err != nil {
+		log.Printf("Failed to store suspension : %s", err.Error())
+		return err
+	}
+
+	return nil
+}
+
+func (r *suspensionMongoRepository) FetchSuspensionsByUser(ctx context.Context, userID string) ([]*models.Suspension, error) {
+	session := r.Session.Clone()
+	defer session.Close()
+
+	m := make([]*models.Suspension, 0)
+	query := bson.M{"user_id": bson.ObjectIdHex(userID)}
+	if err := session.DB(r.DBName).C(suspensionCollectionName).Find(query).Sort("-started_at").All(&m); err != nil {
+		log.Printf("Failed to fetch suspensions : %s", err.Error())
+		return nil, err
+	}
+
+	return m, nil
+}
+
+func (r *suspensionMongoRepository) LiftSuspensions(ctx context.Context, userID, liftedBy, note string, liftedAt time.Time) error {
+	session := r.Session.Clone()
+	defer session.Close()
+
+	query := bson.M{"user_id": bson.ObjectIdHex(userID), "lifted_at": bson.M{"$exists": false}}
+	set := bson.M{"lifted_at": liftedAt, "lifted_by": liftedBy}
+	if note != "" {
+		set["lift_note"] = note
+	}
+
+	if _, err := session.DB(r.DBName).C(suspensionCollectionName).UpdateAll(query, bson.M{"$set": set}); err != nil {
+		log.Printf("Failed to lift suspensions : %s", err.Error())
+		return err
+	}
+
+	return nil
+}
@@ -0,0 +1,206 @@
+package mongo
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/globalsign/mgo"
+	"github.com/globalsign/mgo/bson"
+
+	"github.com/fidellr/jastip/backend/uranus"
+	"github.com/fidellr/jastip/backend/uranus/models"
+	"github.com/fidellr/jastip/backend/uranus/repository"
+)
+
+var (
+	tripCollectionName = "trips"
+)
+
+type tripMongoRepository struct {
+	Session *mgo.Session
+	DBName  string
+}
+
+type tripRequirement func(*tripMongoRepository)
+
+func TripSession(session *mgo.Session) tripRequirement {
+	return func(r *tripMongoRepository) {
+		r.Session = session
+	}
+}
+
+func TripDBName(dbName string) tripRequirement {
+	return func(r *tripMongoRepository) {
+		r.DBName = dbName
+	}
+}
+
+func NewTripMongo(reqs ...tripRequirement) repository.TripRepository {
+	repo := new(tripMongoRepository)
+	for _, req := range reqs {
+		req(repo)
+	}
+
+	repo.ensureIndexes()
+	return repo
+}
+
+func (r *tripMongoRepository) ensureIndexes() {
+	session := r.Session.Clone()
+	defer session.Close()
+
+	indexes := []mgo.Index{
+		{Key: []string{"destination.country", "destination.city", "departure_at", "_id"}},
+		{Key: []string{"traveler_id", "departure_at"}},
+		{Key: []string{"status", "departure_at"}},
+	}
+
+	for _, index := range indexes {
+		if err := session.DB(r.DBName).C(tripCollectionName).EnsureIndex(index); err != nil {
+			log.Printf("Failed to ensure trip indexes : %s", err.Error())
+		}
+	}
+}
+
+func (r *tripMongoRepository) StoreTrip(ctx context.Context, m *models.Trip) error {
+	session := r.Session.Clone()
+	defer session.Close()
+
+	if err := session.DB(r.DBName).C(tripCollectionName).Insert(m); err != nil {
+		log.Printf("Failed to store trip : %s", err.Error())
+		return err
+	}
+
+	return nil
+}
+
+func (r *tripMongoRepository) FetchTrips(ctx context.Context, filter *uranus.TripFilter) ([]*models.Trip, uranus.Page, error) {
+	session := r.Session.Clone()
+	defer session.Close()
+
+	query := bson.M{}
+	if filter.TravelerID != "" {
+		if !bson.IsObjectIdHex(filter.TravelerID) {
+			return make([]*models.Trip, 0), uranus.Page{}, nil
+		}
+
+		query["traveler_id"] = bson.ObjectIdHex(filter.TravelerID)
+	}
+
+	if filter.DestinationCountry != "" {
+		query["destination.country"] = filter.DestinationCountry
+	}
+
+	if filter.DestinationCity != "" {
+		query["destination.city"] = filter.DestinationCity
+	}
+
+	if filter.Status != "" {
+		query["status"] = filter.Status
+	}
+
+	departureAt := bson.M{}
+	if !filter.DepartureFrom.IsZero() {
+		departureAt["$gte"] = filter.DepartureFrom
+	}
+
+	if !filter.DepartureTo.IsZero() {
+		departureAt["$lte"] = filter.DepartureTo
+	}
+
+	if len(departureAt) > 0 {
+		query["departure_at"] = departureAt
+	}
+
+	m := make([]*models.Trip, 0)
+	page, err := paginate(session.DB(r.DBName).C(tripCollectionName), query, uranus.TripSort, filter.Cursor, filter.Num, &m, func(i int) (interface{}, bson.ObjectId) {
+		return m[i].DepartureAt, m[i].ID
+	})
+	if err != nil {
+		log.Printf("Failed to fetch trips : %s", err.Error())
+		return nil, uranus.Page{}, err
+	}
+
+	return m, page, nil
+}
+
+func (r *tripMongoRepository) GetTripByID(ctx context.Context, tripID string) (*models.Trip, error) {
+	session := r.Session.Clone()
+	defer session.Close()
+
+	if !bson.IsObjectIdHex(tripID) {
+		return nil, uranus.ErrNotFound
+	}
+
+	var m *models.Trip
+	if err := session.DB(r.DBName).C(tripCollectionName).FindId(bson.ObjectIdHex(tripID)).One(&m); err != nil {
+		if err == mgo.ErrNotFound {
+			return nil, uranus.ErrNotFound
+		}
+
+		log.Printf("Failed to get trip : %s", err.Error())
+		return nil, err
+	}
+
+	return m, nil
+}
+
+func (r *tripMongoRepository) UpdateTripByID(ctx context.Context, tripID string, m *models.Trip) error {
+	session := r.Session.Clone()
+	defer session.Close()
+
+	if !bson.IsObjectIdHex(tripID) {
+		return uranus.ErrNotFound
+	}
+
+	if err := session.DB(r.DBName).C(tripCollectionName).UpdateId(bson.ObjectIdHex(tripID), m); err != nil {
+		if err == mgo.ErrNotFound {
+			return uranus.ErrNotFound
+		}
+
+		log.Printf("Failed to update trip : %s", err.Error())
+		return err
+	}
+
+	return nil
+}
+
+func (r *tripMongoRepository) RemoveTripByID(ctx context.Context, tripID string) error {
+	session := r.Session.Clone()
+	defer session.Close()
+
+	if !bson.IsObjectIdHex(tripID) {
+		return uranus.ErrNotFound
+	}
+
+	if err := session.DB(r.DBName).C(tripCollectionName).RemoveId(bson.ObjectIdHex(tripID)); err != nil {
+		if err == mgo.ErrNotFound {
+			return uranus.ErrNotFound
+		}
+
+		log.Printf("Failed to remove trip : %s", err.Error())
+		return err
+	}
+
+	return nil
+}
+
+func (r *tripMongoRepository) CloseDepartedTrips(ctx context.Context, departedBefore time.Time) (int, error) {
+	session := r.Session.Clone()
+	defer session.Close()
+
+	query := bson.M{
+		"status":       bson.M{"$in": []string{models.TripPlanned, models.TripOpen}},
+		"departure_at": bson.M{"$lt": departedBefore},
+	}
+	info, err := session.DB(r.DBName).C(tripCollectionName).UpdateAll(query, bson.M{
+		"$set": bson.M{"status": models.TripClosed, "closed_at": departedBefore, "updated_at": departedBefore},
+	})
+	if err != nil {
+		log.Printf("Failed to close departed trips : %s", err.Error())
+		return 0, err
+	}
+
+	return info.Updated, nil
+}
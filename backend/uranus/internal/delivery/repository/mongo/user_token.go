@@ -0,0 +1,113 @@
+package mongo
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/globalsign/mgo"
+	"github.com/globalsign/mgo/bson"
+
+	"github.com/fidellr/jastip/backend/uranus"
+	"github.com/fidellr/jastip/backend/uranus/models"
+	"github.com/fidellr/jastip/backend/uranus/repository"
+)
+
+var (
+	userTokenCollectionName = "user_tokens"
+)
+
+type userTokenMongoRepository struct {
+	Session *mgo.Session
+	DBName  string
+}
+
+type userTokenRequirement func(*userTokenMongoRepository)
+
+func UserTokenSession(session *mgo.Session) userTokenRequirement {
+	return func(r *userTokenMongoRepository) {
+		r.Session = session
+	}
+}
+
+func UserTokenDBName(dbName string) userTokenRequirement {
+	return func(r *userTokenMongoRepository) {
+		r.DBName = dbName
+	}
+}
+
+func NewUserTokenMongo(reqs ...userTokenRequirement) repository.UserTokenRepository {
+	repo := new(userTokenMongoRepository)
+	for _, req := range reqs {
+		req(repo)
+	}
+
+	repo.ensureIndexes()
+	return repo
+}
+
+func (r *userTokenMongoRepository) ensureIndexes() {
+	session := r.Session.Clone()
+	defer session.Close()
+
+	c := session.DB(r.DBName).C(userTokenCollectionName)
+	indexes := []mgo.Index{
+		// Mongo drops the token by itself once expires_at has passed.
+		{Key: []string{"expires_at"}, ExpireAfter: time.Second},
+		{Key: []string{"purpose", "token_hash"}, Unique: true},
+		{Key: []string{"user_id", "purpose"}},
+	}
+	for _, index := range indexes {
+		if err := c.EnsureIndex(index); err != nil {
+			log.Printf("Failed to ensure user token indexes : %s", err.Error())
+		}
+	}
+}
+
+func (r *userTokenMongoRepository) StoreToken(ctx context.Context, m *models.UserToken) error {
+	session := r.Session.Clone()
+	defer session.Close()
+
+	if err := session.DB(r.DBName).C(userTokenCollectionName).Insert(m); err != nil {
+		log.Printf("Failed to store user token : %s", err.Error())
+		return err
+	}
+
+	return nil
+}
+
+func (r *userTokenMongoRepository) ConsumeToken(ctx context.Context, purpose, tokenHash string) (*models.UserToken, error) {
+	session := r.Session.Clone()
+	defer session.Close()
+
+	var m *models.UserToken
+	query := bson.M{
+		"purpose":    purpose,
+		"token_hash": tokenHash,
+		"expires_at": bson.M{"$gt": time.Now()},
+	}
+	_, err := session.DB(r.DBName).C(userTokenCollectionName).Find(query).Apply(mgo.Change{Remove: true}, &m)
+	if err != nil {
+		if err == mgo.ErrNotFound {
+			return nil, uranus.ErrInvalidUserToken
+		}
+
+		log.Printf("Failed to consume user token : %s", err.Error())
+		return nil, err
+	}
+
+	return m, nil
+}
+
+func (r *userTokenMongoRepository) RemoveTokensByUser(ctx context.Context, userID, purpose string) error {
+	session := r.Session.Clone()
+	defer session.Close()
+
+	query := bson.M{"user_id": bson.ObjectIdHex(userID), "purpose": purpose}
+	if _, err := session.DB(r.DBName).C(userTokenCollectionName).RemoveAll(query); err != nil {
+		log.Printf("Failed to remove user tokens : %s", err.Error())
+		return err
+	}
+
+	return nil
+}
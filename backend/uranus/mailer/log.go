@@ -0,0 +1,60 @@
+package mailer
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/fidellr/jastip/backend/uranus"
+	"github.com/fidellr/jastip/backend/uranus/models"
+)
+
+type logMailer struct {
+	mu  sync.Mutex
+	out io.Writer
+}
+
+type logRequirement func(*logMailer)
+
+// Output sets where the log mailer writes the mails, it defaults to stdout.
+func Output(out io.Writer) logRequirement {
+	return func(m *logMailer) {
+		m.out = out
+	}
+}
+
+// NewLog returns a Mailer that only writes the mails out, for local development and tests.
+func NewLog(reqs ...logRequirement) uranus.Mailer {
+	m := &logMailer{out: os.Stdout}
+	for _, req := range reqs {
+		req(m)
+	}
+
+	return m
+}
+
+// NewFile returns a log Mailer appending every mail to the file at path.
+func NewFile(path string) (uranus.Mailer, error) {
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0644)
+	if err != nil {
+		return nil, err
+	}
+
+	return NewLog(Output(file)), nil
+}
+
+func (m *logMailer) Send(ctx context.Context, mail *models.Mail) error {
+	if ctx == nil {
+		return uranus.ErrContextNil
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	_, err := fmt.Fprintf(m.out, "--- %s\nTo: %s\nSubject: %s\n\n%s\n\n",
+		time.Now().Format(time.RFC3339), mail.To, mail.Subject, mail.Body)
+	return err
+}
@@ -0,0 +1,94 @@
+package mailer
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net"
+	"net/smtp"
+	"strconv"
+
+	"github.com/fidellr/jastip/backend/uranus"
+	"github.com/fidellr/jastip/backend/uranus/models"
+)
+
+type smtpMailer struct {
+	host     string
+	port     int
+	username string
+	password string
+	from     string
+}
+
+type smtpRequirement func(*smtpMailer)
+
+func SMTPHost(host string) smtpRequirement {
+	return func(m *smtpMailer) {
+		m.host = host
+	}
+}
+
+func SMTPPort(port int) smtpRequirement {
+	return func(m *smtpMailer) {
+		m.port = port
+	}
+}
+
+func SMTPAuth(username, password string) smtpRequirement {
+	return func(m *smtpMailer) {
+		m.username = username
+		m.password = password
+	}
+}
+
+func From(from string) smtpRequirement {
+	return func(m *smtpMailer) {
+		m.from = from
+	}
+}
+
+// NewSMTP returns a Mailer that delivers through an SMTP relay.
+func NewSMTP(reqs ...smtpRequirement) uranus.Mailer {
+	m := &smtpMailer{port: 587}
+	for _, req := range reqs {
+		req(m)
+	}
+
+	return m
+}
+
+func (m *smtpMailer) Send(ctx context.Context, mail *models.Mail) error {
+	if ctx == nil {
+		return uranus.ErrContextNil
+	}
+
+	var auth smtp.Auth
+	if m.username != "" {
+		auth = smtp.PlainAuth("", m.username, m.password, m.host)
+	}
+
+	address := net.JoinHostPort(m.host, strconv.Itoa(m.port))
+	done := make(chan error, 1)
+	go func() {
+		done <- smtp.SendMail(address, auth, m.from, []string{mail.To}, m.message(mail))
+	}()
+
+	select {
+	case err := <-done:
+		return err
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (m *smtpMailer) message(mail *models.Mail) []byte {
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "From: %s\r\n", m.from)
+	fmt.Fprintf(&buf, "To: %s\r\n", mail.To)
+	fmt.Fprintf(&buf, "Subject: %s\r\n", mail.Subject)
+	buf.WriteString("MIME-Version: 1.0\r\n")
+	buf.WriteString("Content-Type: text/plain; charset=\"utf-8\"\r\n\r\n")
+	buf.WriteString(mail.Body)
+
+	return buf.Bytes()
+}
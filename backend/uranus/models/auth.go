@@ -0,0 +1,23 @@
+package models
+
+import "time"
+
+// Credential is the payload sent by the client to sign in.
+type Credential struct {
+	EmailAddress string `json:"email_address" validate:"required,email"`
+	Password     string `json:"password" validate:"required"`
+}
+
+// AccessToken is the signed token handed to the client after a successful sign in.
+type AccessToken struct {
+	Token     string    `json:"access_token"`
+	TokenType string    `json:"token_type"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+// Principal is the identity carried by a verified access token.
+type Principal struct {
+	UserID   string    `json:"user_id"`
+	Role     UserRole  `json:"role"`
+	IssuedAt time.Time `json:"issued_at"`
+}
@@ -0,0 +1,90 @@
+package models
+
+import (
+	"time"
+
+	"github.com/globalsign/mgo/bson"
+)
+
+// Conversation scopes, a buyer talks with each traveler about a request and with the traveler of an order about it.
+const (
+	ConversationRequest = "request"
+	ConversationOrder   = "order"
+)
+
+// Conversation is the chat between a buyer and a traveler about one purchase request or order.
+// Reads holds the last message each participant read, keyed by the participant's ID.
+type Conversation struct {
+	ID            bson.ObjectId       `json:"id,omitempty" bson:"_id,omitempty"`
+	CreatedAt     time.Time           `json:"created_at" bson:"created_at"`
+	UpdatedAt     time.Time           `json:"updated_at" bson:"updated_at"`
+	Scope         string              `json:"scope" bson:"scope"`
+	ScopeID       bson.ObjectId       `json:"scope_id" bson:"scope_id"`
+	BuyerID       bson.ObjectId       `json:"buyer_id" bson:"buyer_id"`
+	TravelerID    bson.ObjectId       `json:"traveler_id" bson:"traveler_id"`
+	LastMessageAt *time.Time          `json:"last_message_at,omitempty" bson:"last_message_at,omitempty"`
+	Reads         map[string]ChatRead `json:"reads,omitempty" bson:"reads,omitempty"`
+}
+
+// Participants are the IDs of the users in the conversation.
+func (m *Conversation) Participants() []string {
+	return []string{m.BuyerID.Hex(), m.TravelerID.Hex()}
+}
+
+// HasParticipant reports whether the user takes part in the conversation.
+func (m *Conversation) HasParticipant(userID string) bool {
+	return userID == m.BuyerID.Hex() || userID == m.TravelerID.Hex()
+}
+
+// ChatRead is how far a participant read a conversation.
+type ChatRead struct {
+	MessageID bson.ObjectId `json:"message_id" bson:"message_id"`
+	At        time.Time     `json:"at" bson:"at"`
+}
+
+// ConversationOpen asks for the conversation about a request or an order, a request's conversation is with one
+// traveler so the buyer names the traveler, a traveler always opens their own.
+type ConversationOpen struct {
+	Scope      string        `json:"scope" validate:"required,oneof=request order"`
+	ScopeID    bson.ObjectId `json:"scope_id" validate:"required"`
+	TravelerID bson.ObjectId `json:"traveler_id,omitempty"`
+}
+
+// ChatMessage is one message of a conversation, ImageIDs refer to images uploaded to plateu.
+type ChatMessage struct {
+	ID             bson.ObjectId   `json:"id,omitempty" bson:"_id,omitempty"`
+	CreatedAt      time.Time       `json:"created_at" bson:"created_at"`
+	ConversationID bson.ObjectId   `json:"conversation_id" bson:"conversation_id"`
+	SenderID       bson.ObjectId   `json:"sender_id" bson:"sender_id"`
+	Body           string          `json:"body,omitempty" bson:"body,omitempty" validate:"max=4000"`
+	ImageIDs       []bson.ObjectId `json:"image_ids,omitempty" bson:"image_ids,omitempty" validate:"max=5"`
+}
+
+// Chat event types, sent to the participants of a conversation over their live connection.
+const (
+	ChatEventMessage = "message"
+	ChatEventRead    = "read"
+	ChatEventTyping  = "typing"
+	ChatEventError   = "error"
+)
+
+// ChatEvent is something that happened in a conversation, Message is set on message events
+// and MessageID on read events. Error events only go back to the connection that caused them.
+type ChatEvent struct {
+	Type           string        `json:"type"`
+	ConversationID bson.ObjectId `json:"conversation_id,omitempty"`
+	UserID         bson.ObjectId `json:"user_id,omitempty"`
+	Message        *ChatMessage  `json:"message,omitempty"`
+	MessageID      bson.ObjectId `json:"message_id,omitempty"`
+	Error          string        `json:"error,omitempty"`
+	At             time.Time     `json:"at"`
+}
+
+// ChatCommand is what a client sends over its live connection, a message, a read receipt or a typing notice.
+type ChatCommand struct {
+	Type           string          `json:"type" validate:"required,oneof=message read typing"`
+	ConversationID string          `json:"conversation_id" validate:"required"`
+	Body           string          `json:"body,omitempty"`
+	ImageIDs       []bson.ObjectId `json:"image_ids,omitempty"`
+	MessageID      string          `json:"message_id,omitempty"`
+}
@@ -0,0 +1,71 @@
+package models
+
+import "time"
+
+// CustomsRules are the import rules of every country uranus knows, keyed by ISO 3166-1 alpha-2 code.
+// DefaultCountry is where purchase requests are imported to when the buyer didn't give a shipping country.
+type CustomsRules struct {
+	DefaultCountry string                     `json:"default_country"`
+	Countries      map[string]*CountryCustoms `json:"countries"`
+}
+
+// CountryCustoms are the import rules of one country for goods a passenger brings in.
+// DutyFreeLimit is per passenger in minor units of Currency, the value over it pays DutyPercent, or the percentage
+// of its category, and TaxPercent is levied on that value plus the duty. WarnAtPercent of the limit warns the buyer early.
+// Restricted categories are let in with a warning, the note tells the buyer what to take care of.
+// QuantityCaps are the units of a category one passenger may carry in.
+type CountryCustoms struct {
+	Currency             string             `json:"currency"`
+	DutyFreeLimit        int64              `json:"duty_free_limit"`
+	WarnAtPercent        float64            `json:"warn_at_percent,omitempty"`
+	DutyPercent          float64            `json:"duty_percent"`
+	TaxPercent           float64            `json:"tax_percent"`
+	CategoryDutyPercents map[string]float64 `json:"category_duty_percents,omitempty"`
+	Prohibited           []string           `json:"prohibited_categories,omitempty"`
+	Restricted           map[string]string  `json:"restricted_categories,omitempty"`
+	QuantityCaps         map[string]int     `json:"quantity_caps,omitempty"`
+}
+
+// CustomsGoods is what a passenger carries in for one request or order, Value in the country's customs currency.
+type CustomsGoods struct {
+	Category string `json:"category" bson:"category"`
+	Quantity int    `json:"quantity" bson:"quantity"`
+	Value    Money  `json:"value" bson:"value"`
+}
+
+// Customs issue codes, prohibited goods and goods over a quantity cap block, the others only warn.
+const (
+	CustomsProhibited        = "prohibited_category"
+	CustomsRestricted        = "restricted_category"
+	CustomsQuantityCap       = "quantity_cap"
+	CustomsNearDutyFreeLimit = "near_duty_free_limit"
+	CustomsOverDutyFreeLimit = "over_duty_free_limit"
+	CustomsUnknownCountry    = "unknown_country"
+	CustomsUnknownValue      = "unknown_value"
+)
+
+// CustomsIssue is one finding of a customs check, a blocking one stops the request or order.
+type CustomsIssue struct {
+	Code     string `json:"code" bson:"code"`
+	Message  string `json:"message" bson:"message"`
+	Blocking bool   `json:"blocking" bson:"blocking"`
+}
+
+// CustomsCheck is the outcome of checking goods against a country's import rules.
+// Carried is the value the passenger brings in with these goods included, EstimatedDuty the duty and tax
+// these goods would add on arrival.
+type CustomsCheck struct {
+	Country       string         `json:"country" bson:"country"`
+	Goods         CustomsGoods   `json:"goods" bson:"goods"`
+	Carried       Money          `json:"carried" bson:"carried"`
+	DutyFreeLimit Money          `json:"duty_free_limit" bson:"duty_free_limit"`
+	EstimatedDuty Money          `json:"estimated_duty" bson:"estimated_duty"`
+	Warnings      []CustomsIssue `json:"warnings" bson:"warnings"`
+	Errors        []CustomsIssue `json:"errors" bson:"errors"`
+	CheckedAt     time.Time      `json:"checked_at" bson:"checked_at"`
+}
+
+// Blocked reports whether the check found anything that stops the goods.
+func (m *CustomsCheck) Blocked() bool {
+	return len(m.Errors) > 0
+}
@@ -0,0 +1,113 @@
+package models
+
+import (
+	"time"
+
+	"github.com/globalsign/mgo/bson"
+)
+
+// Dispute statuses, an open dispute no admin settled within its SLA is escalated.
+const (
+	DisputeOpen      = "open"
+	DisputeEscalated = "escalated"
+	DisputeResolved  = "resolved"
+)
+
+// Reasons a buyer disputes an order for.
+const (
+	DisputeDamaged     = "damaged"
+	DisputeCounterfeit = "counterfeit"
+	DisputeWrongItem   = "wrong_item"
+	DisputeNotReceived = "not_received"
+	DisputeOther       = "other"
+)
+
+// Dispute outcomes, each settles the order's escrow its own way.
+// A full refund cancels the order and pays the buyer back, a release completes it and pays the traveler,
+// a partial refund pays the buyer part of it back and the traveler the rest.
+const (
+	DisputeFullRefund    = "full_refund"
+	DisputePartialRefund = "partial_refund"
+	DisputeRelease       = "release"
+)
+
+// DisputeOpening is how a buyer opens a dispute on an order, ImageIDs are the evidence photos uploaded to plateu.
+type DisputeOpening struct {
+	Reason      string          `json:"reason" validate:"required,oneof=damaged counterfeit wrong_item not_received other"`
+	Description string          `json:"description" validate:"required,max=4000"`
+	ImageIDs    []bson.ObjectId `json:"image_ids,omitempty" validate:"max=10"`
+}
+
+// Dispute is a buyer's formal complaint about an order, it holds the order in disputed until an admin resolves it.
+// MediatorID is the admin who took the dispute on, EscalateAt is when the SLA runs out.
+// SettledAt is when the outcome of a resolved dispute was paid out.
+type Dispute struct {
+	ID          bson.ObjectId      `json:"id,omitempty" bson:"_id,omitempty"`
+	CreatedAt   time.Time          `json:"created_at" bson:"created_at"`
+	UpdatedAt   time.Time          `json:"updated_at" bson:"updated_at"`
+	OrderID     bson.ObjectId      `json:"order_id" bson:"order_id"`
+	BuyerID     bson.ObjectId      `json:"buyer_id" bson:"buyer_id"`
+	TravelerID  bson.ObjectId      `json:"traveler_id" bson:"traveler_id"`
+	MediatorID  bson.ObjectId      `json:"mediator_id,omitempty" bson:"mediator_id,omitempty"`
+	Reason      string             `json:"reason" bson:"reason"`
+	Description string             `json:"description" bson:"description"`
+	ImageIDs    []bson.ObjectId    `json:"image_ids,omitempty" bson:"image_ids,omitempty"`
+	OrderStatus string             `json:"order_status" bson:"order_status"`
+	Status      string             `json:"status" bson:"status"`
+	EscalateAt  time.Time          `json:"escalate_at" bson:"escalate_at"`
+	EscalatedAt *time.Time         `json:"escalated_at,omitempty" bson:"escalated_at,omitempty"`
+	Resolution  *DisputeResolution `json:"resolution,omitempty" bson:"resolution,omitempty"`
+	SettledAt   *time.Time         `json:"settled_at,omitempty" bson:"settled_at,omitempty"`
+	Outbox      []*OutboxMessage   `json:"-" bson:"outbox,omitempty"`
+}
+
+// PartyOf tells which side of the dispute the user is on, empty when the user is neither the buyer nor the traveler.
+func (m *Dispute) PartyOf(userID string) string {
+	switch userID {
+	case m.BuyerID.Hex():
+		return OrderPartyBuyer
+	case m.TravelerID.Hex():
+		return OrderPartyTraveler
+	}
+
+	return ""
+}
+
+// IsOpen reports whether the dispute still waits for its outcome.
+func (m *Dispute) IsOpen() bool {
+	return m.Status == DisputeOpen || m.Status == DisputeEscalated
+}
+
+// DisputeResolution is the outcome an admin settled the dispute with, RefundAmount is what a partial refund
+// pays back in the currency of the order's total.
+type DisputeResolution struct {
+	Outcome      string        `json:"outcome" bson:"outcome" validate:"required,oneof=full_refund partial_refund release"`
+	RefundAmount int64         `json:"refund_amount,omitempty" bson:"refund_amount,omitempty" validate:"gte=0"`
+	Note         string        `json:"note" bson:"note" validate:"required,max=2000"`
+	ResolvedBy   bson.ObjectId `json:"resolved_by,omitempty" bson:"resolved_by,omitempty"`
+	ResolvedAt   time.Time     `json:"resolved_at" bson:"resolved_at"`
+}
+
+// OrderStatus is the status the outcome moves the disputed order to.
+func (m *DisputeResolution) OrderStatus() string {
+	if m.Outcome == DisputeFullRefund {
+		return OrderCancelled
+	}
+
+	return OrderCompleted
+}
+
+// DisputeMessage is one post of the discussion between the buyer, the traveler and the mediating admin.
+// ReplyToID threads it under an earlier message, ImageIDs are more evidence photos uploaded to plateu.
+// Messages posted by uranus itself, like the escalation notice, have the system party and no author.
+type DisputeMessage struct {
+	ID        bson.ObjectId    `json:"id,omitempty" bson:"_id,omitempty"`
+	CreatedAt time.Time        `json:"created_at" bson:"created_at"`
+	DisputeID bson.ObjectId    `json:"dispute_id" bson:"dispute_id"`
+	AuthorID  bson.ObjectId    `json:"author_id,omitempty" bson:"author_id,omitempty"`
+	Party     string           `json:"party" bson:"party"`
+	ReplyToID bson.ObjectId    `json:"reply_to_id,omitempty" bson:"reply_to_id,omitempty"`
+	Body      string           `json:"body" bson:"body" validate:"required,max=4000"`
+	ImageIDs  []bson.ObjectId  `json:"image_ids,omitempty" bson:"image_ids,omitempty" validate:"max=5"`
+	Outbox    []*OutboxMessage `json:"-" bson:"outbox,omitempty"`
+}
@@ -0,0 +1,91 @@
+package models
+
+import (
+	"time"
+
+	"github.com/globalsign/mgo/bson"
+)
+
+// Fee kinds, traveler fees go to the traveler, platform fees to jastip and taxes to the tax office.
+// Rules are applied kind by kind in this order, so a tax can be levied on the fees before it.
+const (
+	FeeTraveler = "traveler"
+	FeePlatform = "platform"
+	FeeTax      = "tax"
+)
+
+// Fee bases, what a rule's percentage is taken of.
+const (
+	FeeBaseItems       = "items"
+	FeeBaseTravelerFee = "traveler_fee"
+	FeeBaseSubtotal    = "subtotal"
+)
+
+// FeeRule charges a percentage of its base plus a flat amount, capped by Min and Max when they are set.
+// A rule only applies to the countries, categories and items price band it lists, an empty list matches anything.
+// Flat, Min, Max and the price band are minor units of the schedule's currency, PriceTo is exclusive and 0 leaves it open.
+type FeeRule struct {
+	Name       string   `json:"name" bson:"name" validate:"required"`
+	Kind       string   `json:"kind" bson:"kind" validate:"required,oneof=traveler platform tax"`
+	Base       string   `json:"base,omitempty" bson:"base,omitempty" validate:"omitempty,oneof=items traveler_fee subtotal"`
+	Countries  []string `json:"countries,omitempty" bson:"countries,omitempty"`
+	Categories []string `json:"categories,omitempty" bson:"categories,omitempty"`
+	PriceFrom  int64    `json:"price_from,omitempty" bson:"price_from,omitempty" validate:"gte=0"`
+	PriceTo    int64    `json:"price_to,omitempty" bson:"price_to,omitempty" validate:"gte=0"`
+	Percent    float64  `json:"percent,omitempty" bson:"percent,omitempty" validate:"gte=0,lte=100"`
+	Flat       int64    `json:"flat,omitempty" bson:"flat,omitempty" validate:"gte=0"`
+	Min        int64    `json:"min,omitempty" bson:"min,omitempty" validate:"gte=0"`
+	Max        int64    `json:"max,omitempty" bson:"max,omitempty" validate:"gte=0"`
+}
+
+// FeeSchedule is one version of the fee rules, it applies to orders placed from EffectiveAt on
+// until a later schedule takes over. Schedules are never changed, a change is a new version.
+type FeeSchedule struct {
+	ID          bson.ObjectId `json:"id,omitempty" bson:"_id,omitempty"`
+	CreatedAt   time.Time     `json:"created_at" bson:"created_at"`
+	Version     int           `json:"version" bson:"version"`
+	Currency    string        `json:"currency" bson:"currency" validate:"required,currency"`
+	EffectiveAt time.Time     `json:"effective_at" bson:"effective_at" validate:"required"`
+	Rules       []FeeRule     `json:"rules" bson:"rules" validate:"dive"`
+}
+
+// FeeQuote is what the fees of an order are worked out from, Items and TravelerFee in the schedule's currency.
+type FeeQuote struct {
+	Country     string    `json:"country" validate:"required,len=2,alpha"`
+	Category    string    `json:"category" validate:"required"`
+	Items       Money     `json:"items"`
+	TravelerFee Money     `json:"traveler_fee"`
+	At          time.Time `json:"at"`
+}
+
+// FeeLine is one charge of a fee breakdown, Rule is empty for the fee the traveler quoted.
+type FeeLine struct {
+	Kind   string `json:"kind" bson:"kind"`
+	Rule   string `json:"rule,omitempty" bson:"rule,omitempty"`
+	Amount Money  `json:"amount" bson:"amount"`
+}
+
+// FeeBreakdown itemizes what a buyer pays on top of the items, under the schedule version that applied.
+type FeeBreakdown struct {
+	ScheduleVersion int       `json:"schedule_version" bson:"schedule_version"`
+	Items           Money     `json:"items" bson:"items"`
+	Lines           []FeeLine `json:"lines" bson:"lines"`
+	Total           Money     `json:"total" bson:"total"`
+}
+
+// Sum adds up the lines of the given kind.
+func (m *FeeBreakdown) Sum(kind string) (Money, error) {
+	sum := Money{Currency: m.Total.Currency}
+	for _, line := range m.Lines {
+		if line.Kind != kind {
+			continue
+		}
+
+		var err error
+		if sum, err = sum.Add(line.Amount); err != nil {
+			return Money{}, err
+		}
+	}
+
+	return sum, nil
+}
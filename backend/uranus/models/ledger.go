@@ -0,0 +1,87 @@
+package models
+
+import (
+	"time"
+
+	"github.com/globalsign/mgo/bson"
+)
+
+// Ledger account types, they tell on which side an account's balance grows.
+// Asset accounts grow with debits, liability and revenue accounts grow with credits.
+const (
+	LedgerAsset     = "asset"
+	LedgerLiability = "liability"
+	LedgerRevenue   = "revenue"
+)
+
+// GatewayClearingAccount holds what the payment gateway collected for uranus in the currency and didn't pay back yet.
+func GatewayClearingAccount(currency string) string {
+	return "gateway:clearing:" + currency
+}
+
+// LedgerAccount is an account journal entries post to, Code is unique like escrow:<order id>.
+type LedgerAccount struct {
+	Code      string    `json:"code" bson:"_id"`
+	Type      string    `json:"type" bson:"type"`
+	Currency  string    `json:"currency" bson:"currency"`
+	CreatedAt time.Time `json:"created_at" bson:"created_at"`
+}
+
+// PlatformRevenueAccount is what jastip earned in platform fees in the currency.
+func PlatformRevenueAccount(currency string) string {
+	return "platform:revenue:" + currency
+}
+
+// TaxPayableAccount is the tax collected on orders in the currency and owed to the tax office.
+func TaxPayableAccount(currency string) string {
+	return "tax:payable:" + currency
+}
+
+// EscrowAccount is where a buyer's payment is held until the order completes or gets cancelled.
+func EscrowAccount(orderID bson.ObjectId) string {
+	return "escrow:" + orderID.Hex()
+}
+
+// TravelerAccount is what uranus owes a traveler for the orders they delivered.
+func TravelerAccount(travelerID bson.ObjectId) string {
+	return "traveler:" + travelerID.Hex()
+}
+
+// JournalLine debits or credits one account in minor units of the entry's currency, only one of the two is set.
+type JournalLine struct {
+	Account string `json:"account" bson:"account"`
+	Debit   int64  `json:"debit,omitempty" bson:"debit"`
+	Credit  int64  `json:"credit,omitempty" bson:"credit"`
+}
+
+// JournalEntry is one balanced movement of money between ledger accounts.
+// Key makes posting idempotent, the same movement for the same order is only ever posted once.
+type JournalEntry struct {
+	ID          bson.ObjectId `json:"id,omitempty" bson:"_id,omitempty"`
+	CreatedAt   time.Time     `json:"created_at" bson:"created_at"`
+	Key         string        `json:"key" bson:"key"`
+	OrderID     bson.ObjectId `json:"order_id,omitempty" bson:"order_id,omitempty"`
+	Description string        `json:"description" bson:"description"`
+	Currency    string        `json:"currency" bson:"currency"`
+	Lines       []JournalLine `json:"lines" bson:"lines"`
+}
+
+// IsBalanced reports whether the entry debits as much as it credits, and moves anything at all.
+func (m *JournalEntry) IsBalanced() bool {
+	var debits, credits int64
+	for _, line := range m.Lines {
+		debits += line.Debit
+		credits += line.Credit
+	}
+
+	return debits > 0 && debits == credits
+}
+
+// LedgerBalance is the sum of every line posted to an account, in minor units of the account's currency.
+type LedgerBalance struct {
+	Account  string `json:"account" bson:"_id"`
+	Currency string `json:"currency" bson:"-"`
+	Debits   int64  `json:"debits" bson:"debits"`
+	Credits  int64  `json:"credits" bson:"credits"`
+	Balance  int64  `json:"balance" bson:"-"`
+}
@@ -0,0 +1,8 @@
+package models
+
+// Mail is an email message sent through a Mailer.
+type Mail struct {
+	To      string
+	Subject string
+	Body    string
+}
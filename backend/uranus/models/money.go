@@ -0,0 +1,127 @@
+package models
+
+import (
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/globalsign/mgo/bson"
+)
+
+// currencyExponents lists the ISO 4217 currencies uranus handles with the number of digits of their minor unit.
+var currencyExponents = map[string]int{
+	"AUD": 2,
+	"CNY": 2,
+	"EUR": 2,
+	"GBP": 2,
+	"HKD": 2,
+	"IDR": 2,
+	"JPY": 0,
+	"KRW": 0,
+	"MYR": 2,
+	"SGD": 2,
+	"THB": 2,
+	"TWD": 2,
+	"USD": 2,
+}
+
+// IsCurrency reports whether uranus handles the ISO 4217 currency code.
+func IsCurrency(code string) bool {
+	_, ok := currencyExponents[code]
+	return ok
+}
+
+// UnknownCurrencyError tells that an amount came in a currency uranus doesn't handle.
+type UnknownCurrencyError struct {
+	Currency string
+}
+
+func (e *UnknownCurrencyError) Error() string {
+	return fmt.Sprintf("%q is not a currency uranus handles", e.Currency)
+}
+
+// exponent is the number of digits of the currency's minor unit. Money is checked with IsCurrency before it gets here,
+// through the currency validation or by hand, so an unknown currency is a programming error.
+func exponent(currency string) int {
+	exponent, ok := currencyExponents[currency]
+	if !ok {
+		panic(&UnknownCurrencyError{Currency: currency})
+	}
+
+	return exponent
+}
+
+// Money is an amount in the minor unit of its currency, e.g. cents for USD and yen for JPY.
+type Money struct {
+	Amount   int64  `json:"amount" bson:"amount"`
+	Currency string `json:"currency" bson:"currency" validate:"required,currency"`
+}
+
+// MoneyFromMajor turns an amount in the major unit of the currency into money, rounding to the nearest minor unit.
+func MoneyFromMajor(major float64, currency string) (Money, error) {
+	if !IsCurrency(currency) {
+		return Money{}, &UnknownCurrencyError{Currency: currency}
+	}
+
+	return Money{Amount: int64(math.Round(major * math.Pow10(exponent(currency)))), Currency: currency}, nil
+}
+
+// Times is the amount n times over, like the price of n items.
+func (m Money) Times(n int) Money {
+	return Money{Amount: m.Amount * int64(n), Currency: m.Currency}
+}
+
+// CurrencyMismatchError tells that two amounts of different currencies were added up.
+type CurrencyMismatchError struct {
+	Currency string
+	Other    string
+}
+
+func (e *CurrencyMismatchError) Error() string {
+	return fmt.Sprintf("Can't add %s to %s, convert it first", e.Other, e.Currency)
+}
+
+// Add sums two amounts of the same currency, amounts of other currencies have to be converted first.
+func (m Money) Add(o Money) (Money, error) {
+	if m.Currency != o.Currency {
+		return Money{}, &CurrencyMismatchError{Currency: m.Currency, Other: o.Currency}
+	}
+
+	return Money{Amount: m.Amount + o.Amount, Currency: m.Currency}, nil
+}
+
+// String formats the amount in its major unit, e.g. USD 12.50 or JPY 1500. It panics on a currency uranus doesn't handle.
+func (m Money) String() string {
+	exponent := exponent(m.Currency)
+	return fmt.Sprintf("%s %.*f", m.Currency, exponent, float64(m.Amount)/math.Pow10(exponent))
+}
+
+// ExchangeRate is what one major unit of Base is worth in Quote from EffectiveAt on, until a later rate takes over.
+type ExchangeRate struct {
+	ID          bson.ObjectId `json:"id,omitempty" bson:"_id,omitempty"`
+	CreatedAt   time.Time     `json:"created_at" bson:"created_at"`
+	Base        string        `json:"base" bson:"base" validate:"required,currency"`
+	Quote       string        `json:"quote" bson:"quote" validate:"required,currency,nefield=Base"`
+	Rate        float64       `json:"rate" bson:"rate" validate:"gt=0"`
+	EffectiveAt time.Time     `json:"effective_at" bson:"effective_at" validate:"required"`
+	UploadedBy  bson.ObjectId `json:"uploaded_by,omitempty" bson:"uploaded_by,omitempty"`
+}
+
+// AppliedRate records the rate a conversion used, Rate converts From into To even when it came from the inverse pair.
+type AppliedRate struct {
+	RateID      bson.ObjectId `json:"rate_id" bson:"rate_id"`
+	From        string        `json:"from" bson:"from"`
+	To          string        `json:"to" bson:"to"`
+	Rate        float64       `json:"rate" bson:"rate"`
+	EffectiveAt time.Time     `json:"effective_at" bson:"effective_at"`
+}
+
+// Convert converts the money with the applied rate, rounding to the nearest minor unit of the target currency.
+// Rates are only ever uploaded between currencies uranus handles.
+func (r *AppliedRate) Convert(m Money) Money {
+	major := float64(m.Amount) / math.Pow10(exponent(r.From)) * r.Rate
+	return Money{
+		Amount:   int64(math.Round(major * math.Pow10(exponent(r.To)))),
+		Currency: r.To,
+	}
+}
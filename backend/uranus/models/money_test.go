@@ -0,0 +1,124 @@
+package models
+
+import "testing"
+
+func TestMoneyFromMajor(t *testing.T) {
+	tests := []struct {
+		major    float64
+		currency string
+		amount   int64
+		wantErr  bool
+	}{
+		{major: 12.5, currency: "USD", amount: 1250},
+		{major: 0.125, currency: "USD", amount: 13},
+		{major: -0.125, currency: "USD", amount: -13},
+		{major: 15000.5, currency: "IDR", amount: 1500050},
+		{major: 1500, currency: "JPY", amount: 1500},
+		{major: 1500.4, currency: "JPY", amount: 1500},
+		{major: 1500.5, currency: "JPY", amount: 1501},
+		{major: 999.5, currency: "KRW", amount: 1000},
+		{major: 10, currency: "XXX", wantErr: true},
+		{major: 10, currency: "", wantErr: true},
+	}
+
+	for _, test := range tests {
+		money, err := MoneyFromMajor(test.major, test.currency)
+		if test.wantErr {
+			if _, ok := err.(*UnknownCurrencyError); !ok {
+				t.Errorf("MoneyFromMajor(%v, %q) error = %v, want an UnknownCurrencyError", test.major, test.currency, err)
+			}
+
+			continue
+		}
+
+		if err != nil || money != (Money{Amount: test.amount, Currency: test.currency}) {
+			t.Errorf("MoneyFromMajor(%v, %q) = %v, %v, want %d", test.major, test.currency, money, err, test.amount)
+		}
+	}
+}
+
+func TestMoneyString(t *testing.T) {
+	tests := []struct {
+		money Money
+		want  string
+	}{
+		{Money{Amount: 1250, Currency: "USD"}, "USD 12.50"},
+		{Money{Amount: 5, Currency: "USD"}, "USD 0.05"},
+		{Money{Amount: 1500, Currency: "JPY"}, "JPY 1500"},
+		{Money{Amount: 1500000, Currency: "IDR"}, "IDR 15000.00"},
+		{Money{Amount: -250, Currency: "SGD"}, "SGD -2.50"},
+	}
+
+	for _, test := range tests {
+		if got := test.money.String(); got != test.want {
+			t.Errorf("String() = %q, want %q", got, test.want)
+		}
+	}
+}
+
+func TestMoneyStringPanicsOnUnknownCurrency(t *testing.T) {
+	defer func() {
+		if _, ok := recover().(*UnknownCurrencyError); !ok {
+			t.Errorf("String() of an unknown currency didn't panic with an UnknownCurrencyError")
+		}
+	}()
+
+	_ = Money{Amount: 100, Currency: "XXX"}.String()
+}
+
+func TestMoneyAdd(t *testing.T) {
+	sum, err := Money{Amount: 100, Currency: "IDR"}.Add(Money{Amount: 250, Currency: "IDR"})
+	if err != nil || sum != (Money{Amount: 350, Currency: "IDR"}) {
+		t.Errorf("Add = %v, %v, want IDR 350", sum, err)
+	}
+
+	if _, err = (Money{Amount: 100, Currency: "IDR"}).Add(Money{Amount: 100, Currency: "JPY"}); err == nil {
+		t.Errorf("Add of IDR and JPY didn't fail")
+	}
+}
+
+func TestAppliedRateConvert(t *testing.T) {
+	tests := []struct {
+		name string
+		rate AppliedRate
+		from Money
+		want Money
+	}{
+		{
+			name: "two digits to none",
+			rate: AppliedRate{From: "USD", To: "JPY", Rate: 150},
+			from: Money{Amount: 1250, Currency: "USD"},
+			want: Money{Amount: 1875, Currency: "JPY"},
+		},
+		{
+			name: "none to two digits",
+			rate: AppliedRate{From: "JPY", To: "IDR", Rate: 105.3},
+			from: Money{Amount: 100, Currency: "JPY"},
+			want: Money{Amount: 1053000, Currency: "IDR"},
+		},
+		{
+			name: "rounds half a yen up",
+			rate: AppliedRate{From: "USD", To: "JPY", Rate: 150},
+			from: Money{Amount: 1, Currency: "USD"},
+			want: Money{Amount: 2, Currency: "JPY"},
+		},
+		{
+			name: "inverse rate back to two digits",
+			rate: AppliedRate{From: "JPY", To: "USD", Rate: 1.0 / 150},
+			from: Money{Amount: 1875, Currency: "JPY"},
+			want: Money{Amount: 1250, Currency: "USD"},
+		},
+		{
+			name: "inverse rate rounds to the cent",
+			rate: AppliedRate{From: "IDR", To: "SGD", Rate: 1.0 / 11500},
+			from: Money{Amount: 10000000, Currency: "IDR"},
+			want: Money{Amount: 870, Currency: "SGD"},
+		},
+	}
+
+	for _, test := range tests {
+		if got := test.rate.Convert(test.from); got != test.want {
+			t.Errorf("%s: Convert(%v) = %v, want %v", test.name, test.from, got, test.want)
+		}
+	}
+}
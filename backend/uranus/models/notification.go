@@ -0,0 +1,133 @@
+package models
+
+import (
+	"time"
+
+	"github.com/globalsign/mgo/bson"
+)
+
+// Notification channels, each is delivered by its own Notifier.
+const (
+	NotificationEmail = "email"
+	NotificationPush  = "push"
+	NotificationInApp = "inapp"
+)
+
+// Notification kinds, what happened that the user is told about.
+const (
+	NotifyOfferReceived      = "offer_received"
+	NotifyOfferAccepted      = "offer_accepted"
+	NotifyOrderStatusChanged = "order_status_changed"
+	NotifyAccountSuspended   = "account_suspended"
+	NotifyReviewReceived     = "review_received"
+	NotifyDisputeOpened      = "dispute_opened"
+	NotifyDisputeMessage     = "dispute_message"
+	NotifyDisputeEscalated   = "dispute_escalated"
+	NotifyDisputeResolved    = "dispute_resolved"
+)
+
+// Notification statuses in the outbox, a sending notification whose lease ran out is picked up again.
+const (
+	NotificationPending = "pending"
+	NotificationSending = "sending"
+	NotificationSent    = "sent"
+	NotificationFailed  = "failed"
+)
+
+// Languages notifications are written in, Indonesian unless the user picked English.
+const (
+	LanguageIndonesian = "id"
+	LanguageEnglish    = "en"
+)
+
+// defaultNotificationChannels are used for users who never set their preferences.
+var defaultNotificationChannels = []string{NotificationEmail, NotificationInApp}
+
+// NotificationPreferences are how a user wants to be notified. Channels apply to every kind
+// unless Kinds lists other channels for it, an empty list there mutes the kind.
+// PushTokens are the device tokens of the user's phones.
+type NotificationPreferences struct {
+	Language   string              `json:"language" bson:"language" validate:"omitempty,oneof=id en"`
+	Channels   []string            `json:"channels" bson:"channels" validate:"dive,oneof=email push inapp"`
+	Kinds      map[string][]string `json:"kinds,omitempty" bson:"kinds,omitempty" validate:"dive,dive,oneof=email push inapp"`
+	PushTokens []string            `json:"push_tokens,omitempty" bson:"push_tokens,omitempty" validate:"max=10"`
+}
+
+// ChannelsFor lists the channels the user wants to hear about the kind on, the defaults when they never said.
+func (m *NotificationPreferences) ChannelsFor(kind string) []string {
+	if m == nil {
+		return defaultNotificationChannels
+	}
+
+	if channels, ok := m.Kinds[kind]; ok {
+		return channels
+	}
+
+	return m.Channels
+}
+
+// Lang is the language the user reads notifications in.
+func (m *NotificationPreferences) Lang() string {
+	if m == nil || m.Language == "" {
+		return LanguageIndonesian
+	}
+
+	return m.Language
+}
+
+// Notification is one message to one user on one channel, kept in the outbox until it's delivered or given up on.
+// Subject and Body are rendered when the notification is queued, so a retry sends the very same message.
+type Notification struct {
+	ID            bson.ObjectId     `json:"id,omitempty" bson:"_id,omitempty"`
+	CreatedAt     time.Time         `json:"created_at" bson:"created_at"`
+	UpdatedAt     time.Time         `json:"updated_at" bson:"updated_at"`
+	UserID        bson.ObjectId     `json:"user_id" bson:"user_id"`
+	Kind          string            `json:"kind" bson:"kind"`
+	Channel       string            `json:"channel" bson:"channel"`
+	Language      string            `json:"language" bson:"language"`
+	Subject       string            `json:"subject" bson:"subject"`
+	Body          string            `json:"body" bson:"body"`
+	Data          map[string]string `json:"data,omitempty" bson:"data,omitempty"`
+	Status        string            `json:"status" bson:"status"`
+	Attempts      int               `json:"attempts" bson:"attempts"`
+	NextAttemptAt time.Time         `json:"next_attempt_at" bson:"next_attempt_at"`
+	LastError     string            `json:"last_error,omitempty" bson:"last_error,omitempty"`
+	SentAt        *time.Time        `json:"sent_at,omitempty" bson:"sent_at,omitempty"`
+
+	// Key is unique for the outbox message and channel the notification was queued from, so relaying
+	// the same message again queues nothing twice. LeaseID is the claim of the worker delivering it.
+	Key     string        `json:"-" bson:"key,omitempty"`
+	LeaseID bson.ObjectId `json:"-" bson:"lease_id,omitempty"`
+}
+
+// OutboxMessage is a notification kept in the document whose change it tells about, written by the same update
+// as the change, so it exists exactly when the change went through. The relay queues it and takes it out again.
+type OutboxMessage struct {
+	ID        bson.ObjectId     `json:"-" bson:"_id"`
+	CreatedAt time.Time         `json:"-" bson:"created_at"`
+	UserID    string            `json:"-" bson:"user_id"`
+	Kind      string            `json:"-" bson:"kind"`
+	Data      map[string]string `json:"-" bson:"data,omitempty"`
+
+	// Source and SourceID tell the collection and the document the message waits in.
+	Source   string        `json:"-" bson:"-"`
+	SourceID bson.ObjectId `json:"-" bson:"-"`
+}
+
+// NewOutboxMessage tells the user about what happened, data fills in the kind's template.
+func NewOutboxMessage(userID string, kind string, data map[string]string) *OutboxMessage {
+	return &OutboxMessage{ID: bson.NewObjectId(), CreatedAt: time.Now(), UserID: userID, Kind: kind, Data: data}
+}
+
+// InboxItem is a notification delivered to the user's in-app inbox.
+type InboxItem struct {
+	ID             bson.ObjectId     `json:"id,omitempty" bson:"_id,omitempty"`
+	CreatedAt      time.Time         `json:"created_at" bson:"created_at"`
+	NotificationID bson.ObjectId     `json:"notification_id" bson:"notification_id"`
+	UserID         bson.ObjectId     `json:"user_id" bson:"user_id"`
+	Kind           string            `json:"kind" bson:"kind"`
+	Subject        string            `json:"subject" bson:"subject"`
+	Body           string            `json:"body" bson:"body"`
+	Data           map[string]string `json:"data,omitempty" bson:"data,omitempty"`
+	ReadAt         *time.Time        `json:"read_at,omitempty" bson:"read_at,omitempty"`
+}
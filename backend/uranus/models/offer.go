@@ -0,0 +1,75 @@
+package models
+
+import (
+	"time"
+
+	"github.com/globalsign/mgo/bson"
+)
+
+// Offer statuses, a buyer accepts at most one offer per purchase request.
+// An accepted offer is cancelled along with its order, it no longer takes up the trip's luggage then.
+const (
+	OfferPending   = "pending"
+	OfferAccepted  = "accepted"
+	OfferDeclined  = "declined"
+	OfferWithdrawn = "withdrawn"
+	OfferCancelled = "cancelled"
+)
+
+// offerTransitions lists the statuses an offer may move to from each status.
+var offerTransitions = map[string][]string{
+	OfferPending:   {OfferAccepted, OfferDeclined, OfferWithdrawn},
+	OfferAccepted:  {OfferCancelled},
+	OfferDeclined:  {},
+	OfferWithdrawn: {},
+	OfferCancelled: {},
+}
+
+// Offer is a traveler's quote to buy a purchase request on one of their trips.
+// QuotedPrice is the price of one item and Fee what the traveler charges on top of the whole request,
+// each in its own currency.
+type Offer struct {
+	ID                 bson.ObjectId    `json:"id,omitempty" bson:"_id,omitempty"`
+	CreatedAt          time.Time        `json:"created_at" bson:"created_at"`
+	UpdatedAt          time.Time        `json:"updated_at" bson:"updated_at"`
+	RequestID          bson.ObjectId    `json:"request_id" bson:"request_id" validate:"required"`
+	TripID             bson.ObjectId    `json:"trip_id" bson:"trip_id" validate:"required"`
+	TravelerID         bson.ObjectId    `json:"traveler_id" bson:"traveler_id"`
+	BuyerID            bson.ObjectId    `json:"buyer_id" bson:"buyer_id"`
+	QuotedPrice        Money            `json:"quoted_price" bson:"quoted_price"`
+	Fee                Money            `json:"fee" bson:"fee"`
+	WeightKG           float64          `json:"weight_kg" bson:"weight_kg"`
+	ExpectedDeliveryAt time.Time        `json:"expected_delivery_at" bson:"expected_delivery_at" validate:"required"`
+	Note               string           `json:"note,omitempty" bson:"note,omitempty" validate:"max=1000"`
+	Status             string           `json:"status" bson:"status"`
+	Outbox             []*OutboxMessage `json:"-" bson:"outbox,omitempty"`
+}
+
+// CanMoveTo reports whether the offer may go from its current status to the given one.
+func (m *Offer) CanMoveTo(status string) bool {
+	for _, allowed := range offerTransitions[m.Status] {
+		if allowed == status {
+			return true
+		}
+	}
+
+	return false
+}
+
+// PartyOf tells whether the user is the buyer or the traveler of the offer, empty when they are neither.
+func (m *Offer) PartyOf(userID string) string {
+	switch userID {
+	case m.BuyerID.Hex():
+		return OrderPartyBuyer
+	case m.TravelerID.Hex():
+		return OrderPartyTraveler
+	}
+
+	return ""
+}
+
+// Match is an open purchase request ranked for a trip, a higher score is a better fit.
+type Match struct {
+	Request *PurchaseRequest `json:"request"`
+	Score   float64          `json:"score"`
+}
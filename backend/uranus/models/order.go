@@ -0,0 +1,158 @@
+package models
+
+import (
+	"time"
+
+	"github.com/globalsign/mgo/bson"
+)
+
+// Order statuses, an order is placed awaiting payment once the buyer accepts an offer.
+const (
+	OrderAwaitingPayment = "awaiting_payment"
+	OrderPaid            = "paid"
+	OrderPurchased       = "purchased"
+	OrderInTransit       = "in_transit"
+	OrderDelivered       = "delivered"
+	OrderCompleted       = "completed"
+	OrderCancelled       = "cancelled"
+	OrderDisputed        = "disputed"
+)
+
+// Order parties, the role a caller plays on one order rather than the role of their account.
+// OrderPartySystem moves orders on behalf of uranus itself, like a payment callback or a sweeper.
+const (
+	OrderPartyBuyer    = "buyer"
+	OrderPartyTraveler = "traveler"
+	OrderPartyAdmin    = "admin"
+	OrderPartySystem   = "system"
+)
+
+// orderTransitions lists, for each status, the statuses an order may move to and the parties allowed to move it there.
+var orderTransitions = map[string]map[string][]string{
+	OrderAwaitingPayment: {
+		OrderPaid:      {OrderPartySystem, OrderPartyAdmin},
+		OrderCancelled: {OrderPartyBuyer, OrderPartySystem, OrderPartyAdmin},
+	},
+	OrderPaid: {
+		OrderPurchased: {OrderPartyTraveler, OrderPartyAdmin},
+		OrderCancelled: {OrderPartyTraveler, OrderPartyAdmin},
+		OrderDisputed:  {OrderPartyBuyer, OrderPartyAdmin},
+	},
+	OrderPurchased: {
+		OrderInTransit: {OrderPartyTraveler, OrderPartyAdmin},
+		OrderDisputed:  {OrderPartyBuyer, OrderPartyAdmin},
+	},
+	OrderInTransit: {
+		OrderDelivered: {OrderPartyTraveler, OrderPartyAdmin},
+		OrderDisputed:  {OrderPartyBuyer, OrderPartyAdmin},
+	},
+	OrderDelivered: {
+		OrderCompleted: {OrderPartyBuyer, OrderPartySystem, OrderPartyAdmin},
+		OrderDisputed:  {OrderPartyBuyer, OrderPartyAdmin},
+	},
+	OrderDisputed: {
+		OrderCompleted: {OrderPartyAdmin},
+		OrderCancelled: {OrderPartyAdmin},
+	},
+	OrderCompleted: {},
+	OrderCancelled: {},
+}
+
+// OrderTransition is one entry of an order's history, entries are only ever appended.
+type OrderTransition struct {
+	From    string        `json:"from" bson:"from"`
+	To      string        `json:"to" bson:"to"`
+	ActorID bson.ObjectId `json:"actor_id,omitempty" bson:"actor_id,omitempty"`
+	Party   string        `json:"party" bson:"party"`
+	Reason  string        `json:"reason,omitempty" bson:"reason,omitempty"`
+	At      time.Time     `json:"at" bson:"at"`
+}
+
+// Order is the deal between a buyer and a traveler made from an accepted offer.
+// ItemPrice is the price of one item and Fee the traveler's fee as quoted in the offer,
+// Total is what the buyer pays in their currency, Fees itemizes it and Rates are the exchange rates it took.
+// Customs is the import check of the order when it was placed, with the duty the buyer should expect.
+// SettledAt is when the escrow of the ended order was paid out, to the traveler or back to the buyer.
+type Order struct {
+	ID         bson.ObjectId     `json:"id,omitempty" bson:"_id,omitempty"`
+	CreatedAt  time.Time         `json:"created_at" bson:"created_at"`
+	UpdatedAt  time.Time         `json:"updated_at" bson:"updated_at"`
+	RequestID  bson.ObjectId     `json:"request_id" bson:"request_id"`
+	OfferID    bson.ObjectId     `json:"offer_id" bson:"offer_id"`
+	TripID     bson.ObjectId     `json:"trip_id" bson:"trip_id"`
+	BuyerID    bson.ObjectId     `json:"buyer_id" bson:"buyer_id"`
+	TravelerID bson.ObjectId     `json:"traveler_id" bson:"traveler_id"`
+	Category   string            `json:"category" bson:"category"`
+	ItemPrice  Money             `json:"item_price" bson:"item_price"`
+	Quantity   int               `json:"quantity" bson:"quantity"`
+	Fee        Money             `json:"fee" bson:"fee"`
+	Total      Money             `json:"total" bson:"total"`
+	Fees       *FeeBreakdown     `json:"fees,omitempty" bson:"fees,omitempty"`
+	Rates      []AppliedRate     `json:"rates,omitempty" bson:"rates,omitempty"`
+	Customs    *CustomsCheck     `json:"customs,omitempty" bson:"customs,omitempty"`
+	Status     string            `json:"status" bson:"status"`
+	History    []OrderTransition `json:"history" bson:"history"`
+	SettledAt  *time.Time        `json:"settled_at,omitempty" bson:"settled_at,omitempty"`
+	Outbox     []*OutboxMessage  `json:"-" bson:"outbox,omitempty"`
+}
+
+// NewOrder places an order for the accepted offer on the request, awaiting the buyer's payment.
+// The total still has to be worked out in the buyer's currency.
+func NewOrder(request *PurchaseRequest, offer *Offer, at time.Time) *Order {
+	return &Order{
+		ID:         bson.NewObjectId(),
+		CreatedAt:  at,
+		UpdatedAt:  at,
+		RequestID:  request.ID,
+		OfferID:    offer.ID,
+		TripID:     offer.TripID,
+		BuyerID:    request.BuyerID,
+		TravelerID: offer.TravelerID,
+		Category:   request.Category,
+		ItemPrice:  offer.QuotedPrice,
+		Quantity:   request.Quantity,
+		Fee:        offer.Fee,
+		Status:     OrderAwaitingPayment,
+		History:    []OrderTransition{},
+	}
+}
+
+// CanMoveTo reports whether any party may move the order from its current status to the given one.
+func (m *Order) CanMoveTo(status string) bool {
+	_, ok := orderTransitions[m.Status][status]
+	return ok
+}
+
+// CanMove reports whether the party may move the order from its current status to the given one.
+func (m *Order) CanMove(party string, status string) bool {
+	for _, allowed := range orderTransitions[m.Status][status] {
+		if allowed == party {
+			return true
+		}
+	}
+
+	return false
+}
+
+// HasEnded reports whether the order completed or got cancelled, nothing moves it anymore then.
+func (m *Order) HasEnded() bool {
+	return len(orderTransitions[m.Status]) == 0
+}
+
+// PartyOf tells which party of the order the user is, empty when the user is neither the buyer nor the traveler.
+func (m *Order) PartyOf(userID string) string {
+	switch userID {
+	case m.BuyerID.Hex():
+		return OrderPartyBuyer
+	case m.TravelerID.Hex():
+		return OrderPartyTraveler
+	}
+
+	return ""
+}
+
+// OrderMove asks for an order to go to another status, Reason ends up in the order's history.
+type OrderMove struct {
+	Status string `json:"status" validate:"required,oneof=awaiting_payment paid purchased in_transit delivered completed cancelled disputed"`
+	Reason string `json:"reason,omitempty" validate:"max=500"`
+}
@@ -0,0 +1,61 @@
+package models
+
+import "testing"
+
+func TestOrderTransitions(t *testing.T) {
+	tests := []struct {
+		from   string
+		to     string
+		party  string
+		moveTo bool
+		move   bool
+	}{
+		{OrderAwaitingPayment, OrderPaid, OrderPartySystem, true, true},
+		{OrderAwaitingPayment, OrderPaid, OrderPartyBuyer, true, false},
+		{OrderAwaitingPayment, OrderCancelled, OrderPartyBuyer, true, true},
+		{OrderAwaitingPayment, OrderCancelled, OrderPartyTraveler, true, false},
+		{OrderAwaitingPayment, OrderPurchased, OrderPartyAdmin, false, false},
+		{OrderPaid, OrderPurchased, OrderPartyTraveler, true, true},
+		{OrderPaid, OrderPurchased, OrderPartyBuyer, true, false},
+		{OrderPaid, OrderCancelled, OrderPartyTraveler, true, true},
+		{OrderPaid, OrderCancelled, OrderPartyBuyer, true, false},
+		{OrderPaid, OrderDisputed, OrderPartyBuyer, true, true},
+		{OrderPaid, OrderDisputed, OrderPartyTraveler, true, false},
+		{OrderPurchased, OrderInTransit, OrderPartyTraveler, true, true},
+		{OrderPurchased, OrderCancelled, OrderPartyAdmin, false, false},
+		{OrderInTransit, OrderDelivered, OrderPartyTraveler, true, true},
+		{OrderInTransit, OrderDelivered, OrderPartySystem, true, false},
+		{OrderDelivered, OrderCompleted, OrderPartyBuyer, true, true},
+		{OrderDelivered, OrderCompleted, OrderPartySystem, true, true},
+		{OrderDelivered, OrderCompleted, OrderPartyTraveler, true, false},
+		{OrderDelivered, OrderDisputed, OrderPartyAdmin, true, true},
+		{OrderDisputed, OrderCompleted, OrderPartyAdmin, true, true},
+		{OrderDisputed, OrderCompleted, OrderPartyBuyer, true, false},
+		{OrderDisputed, OrderCancelled, OrderPartyAdmin, true, true},
+		{OrderDisputed, OrderDelivered, OrderPartyAdmin, false, false},
+		{OrderCompleted, OrderDisputed, OrderPartyBuyer, false, false},
+		{OrderCompleted, OrderCancelled, OrderPartyAdmin, false, false},
+		{OrderCancelled, OrderPaid, OrderPartySystem, false, false},
+	}
+
+	for _, test := range tests {
+		order := &Order{Status: test.from}
+		if got := order.CanMoveTo(test.to); got != test.moveTo {
+			t.Errorf("%s -> %s: CanMoveTo = %v, want %v", test.from, test.to, got, test.moveTo)
+		}
+
+		if got := order.CanMove(test.party, test.to); got != test.move {
+			t.Errorf("%s -> %s by %s: CanMove = %v, want %v", test.from, test.to, test.party, got, test.move)
+		}
+	}
+}
+
+func TestOrderHasEnded(t *testing.T) {
+	ended := map[string]bool{OrderCompleted: true, OrderCancelled: true}
+	for status := range orderTransitions {
+		order := &Order{Status: status}
+		if got := order.HasEnded(); got != ended[status] {
+			t.Errorf("%s: HasEnded = %v, want %v", status, got, ended[status])
+		}
+	}
+}
@@ -0,0 +1,40 @@
+package models
+
+import (
+	"time"
+
+	"github.com/globalsign/mgo/bson"
+)
+
+// Payment statuses, a payment stays pending until the gateway tells whether the buyer paid.
+// A partially refunded payment gave part of the money back after a dispute, the rest went to the traveler.
+const (
+	PaymentPending           = "pending"
+	PaymentSucceeded         = "succeeded"
+	PaymentFailed            = "failed"
+	PaymentRefunded          = "refunded"
+	PaymentPartiallyRefunded = "partially_refunded"
+)
+
+// Payment is a buyer's charge for an order, GatewayRef is how the payment gateway knows it.
+// Refunded is how much of the amount went back to the buyer.
+type Payment struct {
+	ID            bson.ObjectId `json:"id,omitempty" bson:"_id,omitempty"`
+	CreatedAt     time.Time     `json:"created_at" bson:"created_at"`
+	UpdatedAt     time.Time     `json:"updated_at" bson:"updated_at"`
+	OrderID       bson.ObjectId `json:"order_id" bson:"order_id"`
+	BuyerID       bson.ObjectId `json:"buyer_id" bson:"buyer_id"`
+	Amount        Money         `json:"amount" bson:"amount"`
+	GatewayRef    string        `json:"gateway_ref,omitempty" bson:"gateway_ref,omitempty"`
+	Status        string        `json:"status" bson:"status"`
+	Refunded      int64         `json:"refunded,omitempty" bson:"refunded,omitempty"`
+	FailureReason string        `json:"failure_reason,omitempty" bson:"failure_reason,omitempty"`
+}
+
+// PaymentEvent is what a payment gateway reports back about a charge, through its webhook.
+type PaymentEvent struct {
+	GatewayRef    string    `json:"gateway_ref"`
+	Status        string    `json:"status"`
+	FailureReason string    `json:"failure_reason,omitempty"`
+	OccurredAt    time.Time `json:"occurred_at"`
+}
@@ -0,0 +1,42 @@
+package models
+
+// TravelerProfile is what a traveler tells us about the trips they make, matching and payouts read it.
+type TravelerProfile struct {
+	HomeCity          string      `json:"home_city" bson:"home_city" validate:"required"`
+	FrequentRoutes    []Route     `json:"frequent_routes" bson:"frequent_routes" validate:"max=10,dive"`
+	LuggageCapacityKG float64     `json:"luggage_capacity_kg" bson:"luggage_capacity_kg" validate:"gt=0,lte=100"`
+	Payout            *BankPayout `json:"payout,omitempty" bson:"payout,omitempty"`
+}
+
+// Route is a trip a traveler makes often, countries are ISO 3166-1 alpha-2 codes.
+type Route struct {
+	OriginCity         string `json:"origin_city" bson:"origin_city" validate:"required"`
+	OriginCountry      string `json:"origin_country" bson:"origin_country" validate:"required,len=2,alpha"`
+	DestinationCity    string `json:"destination_city" bson:"destination_city" validate:"required"`
+	DestinationCountry string `json:"destination_country" bson:"destination_country" validate:"required,len=2,alpha"`
+}
+
+// BankPayout is the bank account a traveler gets paid out to.
+type BankPayout struct {
+	BankName      string `json:"bank_name" bson:"bank_name" validate:"required"`
+	AccountName   string `json:"account_name" bson:"account_name" validate:"required"`
+	AccountNumber string `json:"account_number" bson:"account_number" validate:"required,numeric,min=6,max=20"`
+}
+
+// BuyerProfile is what a buyer tells us about how they want their items delivered.
+type BuyerProfile struct {
+	Shipping ShippingPreference `json:"shipping" bson:"shipping"`
+}
+
+// ShippingPreference is where and how a buyer receives items, the country is an ISO 3166-1 alpha-2 code.
+type ShippingPreference struct {
+	RecipientName      string `json:"recipient_name" bson:"recipient_name" validate:"required"`
+	PhoneNumber        string `json:"phone_number" bson:"phone_number" validate:"required,e164"`
+	AddressLine        string `json:"address_line" bson:"address_line" validate:"required"`
+	City               string `json:"city" bson:"city" validate:"required"`
+	PostalCode         string `json:"postal_code" bson:"postal_code" validate:"required,numeric"`
+	Country            string `json:"country" bson:"country" validate:"required,len=2,alpha"`
+	PreferredCourier   string `json:"preferred_courier,omitempty" bson:"preferred_courier,omitempty"`
+	MeetUpAllowed      bool   `json:"meet_up_allowed" bson:"meet_up_allowed"`
+	ConsolidateParcels bool   `json:"consolidate_parcels" bson:"consolidate_parcels"`
+}
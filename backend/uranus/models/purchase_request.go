@@ -0,0 +1,65 @@
+package models
+
+import (
+	"time"
+
+	"github.com/globalsign/mgo/bson"
+)
+
+// Purchase request statuses, only open requests take offers from travelers.
+const (
+	PurchaseRequestOpen      = "open"
+	PurchaseRequestMatched   = "matched"
+	PurchaseRequestFulfilled = "fulfilled"
+	PurchaseRequestCancelled = "cancelled"
+	PurchaseRequestExpired   = "expired"
+)
+
+// purchaseRequestTransitions lists the statuses a purchase request may move to from each status.
+var purchaseRequestTransitions = map[string][]string{
+	PurchaseRequestOpen:      {PurchaseRequestMatched, PurchaseRequestCancelled, PurchaseRequestExpired},
+	PurchaseRequestMatched:   {PurchaseRequestOpen, PurchaseRequestFulfilled, PurchaseRequestCancelled},
+	PurchaseRequestFulfilled: {},
+	PurchaseRequestCancelled: {},
+	PurchaseRequestExpired:   {},
+}
+
+// PurchaseRequest is an item a buyer wants a traveler to buy abroad and bring back, a "titip" request.
+// MaxPrice is what the buyer pays at most for one item, usually in the currency of the shop abroad.
+// DestinationCountry is where the item is bought, so it's where a matching trip has to go,
+// an optional DestinationCity ranks trips to that city higher.
+// Customs is the import check of the request's last change, it warns the buyer of limits and duty before any offer.
+type PurchaseRequest struct {
+	ID                 bson.ObjectId   `json:"id,omitempty" bson:"_id,omitempty"`
+	CreatedAt          time.Time       `json:"created_at" bson:"created_at"`
+	UpdatedAt          time.Time       `json:"updated_at" bson:"updated_at"`
+	BuyerID            bson.ObjectId   `json:"buyer_id" bson:"buyer_id"`
+	ItemDescription    string          `json:"item_description" bson:"item_description" validate:"required,max=2000"`
+	ReferenceURL       string          `json:"reference_url,omitempty" bson:"reference_url,omitempty" validate:"omitempty,url"`
+	Category           string          `json:"category" bson:"category" validate:"required,oneof=electronics fashion cosmetics food health books toys other"`
+	Quantity           int             `json:"quantity" bson:"quantity" validate:"gte=1,lte=100"`
+	EstimatedWeightKG  float64         `json:"estimated_weight_kg,omitempty" bson:"estimated_weight_kg,omitempty" validate:"gte=0,lte=100"`
+	MaxPrice           Money           `json:"max_price" bson:"max_price"`
+	DestinationCountry string          `json:"destination_country" bson:"destination_country" validate:"required,len=2,alpha"`
+	DestinationCity    string          `json:"destination_city,omitempty" bson:"destination_city,omitempty"`
+	Deadline           time.Time       `json:"deadline" bson:"deadline" validate:"required"`
+	ImageIDs           []bson.ObjectId `json:"image_ids,omitempty" bson:"image_ids,omitempty" validate:"max=10"`
+	Status             string          `json:"status" bson:"status"`
+	Customs            *CustomsCheck   `json:"customs,omitempty" bson:"customs,omitempty"`
+}
+
+// CanMoveTo reports whether the request may go from its current status to the given one.
+func (m *PurchaseRequest) CanMoveTo(status string) bool {
+	for _, allowed := range purchaseRequestTransitions[m.Status] {
+		if allowed == status {
+			return true
+		}
+	}
+
+	return false
+}
+
+// TotalWeightKG is the luggage weight all items of the request take, 0 when the buyer didn't estimate it.
+func (m *PurchaseRequest) TotalWeightKG() float64 {
+	return m.EstimatedWeightKG * float64(m.Quantity)
+}
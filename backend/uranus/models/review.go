@@ -0,0 +1,56 @@
+package models
+
+import (
+	"math"
+	"time"
+
+	"github.com/globalsign/mgo/bson"
+)
+
+// Review is what one party of a completed order thinks of the other, RevieweeID is that other party
+// and Party the side the reviewer was on. ImageIDs refer to images uploaded to plateu.
+// The reviewer may change the rating, comment and photos until EditableUntil.
+type Review struct {
+	ID            bson.ObjectId    `json:"id,omitempty" bson:"_id,omitempty"`
+	CreatedAt     time.Time        `json:"created_at" bson:"created_at"`
+	UpdatedAt     time.Time        `json:"updated_at" bson:"updated_at"`
+	OrderID       bson.ObjectId    `json:"order_id" bson:"order_id"`
+	ReviewerID    bson.ObjectId    `json:"reviewer_id" bson:"reviewer_id"`
+	RevieweeID    bson.ObjectId    `json:"reviewee_id" bson:"reviewee_id"`
+	Party         string           `json:"party" bson:"party"`
+	Rating        int              `json:"rating" bson:"rating" validate:"required,min=1,max=5"`
+	Comment       string           `json:"comment,omitempty" bson:"comment,omitempty" validate:"max=2000"`
+	ImageIDs      []bson.ObjectId  `json:"image_ids,omitempty" bson:"image_ids,omitempty" validate:"max=5"`
+	EditableUntil time.Time        `json:"editable_until" bson:"editable_until"`
+	Outbox        []*OutboxMessage `json:"-" bson:"outbox,omitempty"`
+}
+
+// RatingSummary sums up the reviews a user got, Stars[i] counts the reviews rating them i+1.
+type RatingSummary struct {
+	Count     int       `json:"count" bson:"count"`
+	Average   float64   `json:"average" bson:"average"`
+	Stars     []int     `json:"stars" bson:"stars"`
+	UpdatedAt time.Time `json:"updated_at" bson:"updated_at"`
+}
+
+// NewRatingSummary sums up the number of reviews given each rating, the average is rounded to two decimals.
+func NewRatingSummary(counts map[int]int, at time.Time) *RatingSummary {
+	m := &RatingSummary{Stars: make([]int, 5), UpdatedAt: at}
+
+	total := 0
+	for rating, count := range counts {
+		if rating < 1 || rating > 5 {
+			continue
+		}
+
+		m.Stars[rating-1] = count
+		m.Count += count
+		total += rating * count
+	}
+
+	if m.Count > 0 {
+		m.Average = math.Round(float64(total)/float64(m.Count)*100) / 100
+	}
+
+	return m
+}
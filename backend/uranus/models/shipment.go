@@ -0,0 +1,114 @@
+package models
+
+import (
+	"time"
+
+	"github.com/globalsign/mgo/bson"
+)
+
+// Shipment event types in the order they happen, packed, landed and handed to courier may be skipped
+// when the traveler hands the item over in person.
+const (
+	ShipmentPurchased       = "purchased"
+	ShipmentPacked          = "packed"
+	ShipmentDeparted        = "departed"
+	ShipmentLanded          = "landed"
+	ShipmentHandedToCourier = "handed_to_courier"
+	ShipmentDelivered       = "delivered"
+)
+
+// shipmentSteps ranks the event types, an order's events only ever go forward.
+var shipmentSteps = map[string]int{
+	ShipmentPurchased:       1,
+	ShipmentPacked:          2,
+	ShipmentDeparted:        3,
+	ShipmentLanded:          4,
+	ShipmentHandedToCourier: 5,
+	ShipmentDelivered:       6,
+}
+
+// shipmentOrderStatus is the order status each event type may be posted in.
+var shipmentOrderStatus = map[string]string{
+	ShipmentPurchased:       OrderPaid,
+	ShipmentPacked:          OrderPurchased,
+	ShipmentDeparted:        OrderPurchased,
+	ShipmentLanded:          OrderInTransit,
+	ShipmentHandedToCourier: OrderInTransit,
+	ShipmentDelivered:       OrderInTransit,
+}
+
+// shipmentMoves lists the event types that move the order on, to the status they move it to.
+var shipmentMoves = map[string]string{
+	ShipmentPurchased: OrderPurchased,
+	ShipmentDeparted:  OrderInTransit,
+	ShipmentDelivered: OrderDelivered,
+}
+
+// ShipmentEvent is one step of getting an order's item to the buyer, events are only ever appended.
+// ImageIDs refer to plateu images, the receipt of a purchase or the proof of a delivery.
+// Courier and TrackingNumber are set when the item is handed to a domestic courier.
+type ShipmentEvent struct {
+	ID             bson.ObjectId   `json:"id,omitempty" bson:"_id,omitempty"`
+	CreatedAt      time.Time       `json:"created_at" bson:"created_at"`
+	OrderID        bson.ObjectId   `json:"order_id" bson:"order_id"`
+	ActorID        bson.ObjectId   `json:"actor_id,omitempty" bson:"actor_id,omitempty"`
+	Type           string          `json:"type" bson:"type" validate:"required,oneof=purchased packed departed landed handed_to_courier delivered"`
+	OccurredAt     time.Time       `json:"occurred_at" bson:"occurred_at"`
+	Location       *Place          `json:"location,omitempty" bson:"location,omitempty"`
+	Note           string          `json:"note,omitempty" bson:"note,omitempty" validate:"max=500"`
+	ImageIDs       []bson.ObjectId `json:"image_ids,omitempty" bson:"image_ids,omitempty" validate:"max=5"`
+	Courier        string          `json:"courier,omitempty" bson:"courier,omitempty"`
+	TrackingNumber string          `json:"tracking_number,omitempty" bson:"tracking_number,omitempty"`
+}
+
+// After reports whether the event may follow the other one.
+func (m *ShipmentEvent) After(other *ShipmentEvent) bool {
+	return shipmentSteps[m.Type] > shipmentSteps[other.Type]
+}
+
+// OrderStatus is the status the order has to be in for the event to be posted.
+func (m *ShipmentEvent) OrderStatus() string {
+	return shipmentOrderStatus[m.Type]
+}
+
+// Moves tells the status the event moves the order to, empty when the order stays as it is.
+func (m *ShipmentEvent) Moves() string {
+	return shipmentMoves[m.Type]
+}
+
+// NeedsPhoto reports whether the event has to come with a photo, a receipt or a proof of delivery.
+func (m *ShipmentEvent) NeedsPhoto() bool {
+	return m.Type == ShipmentPurchased || m.Type == ShipmentDelivered
+}
+
+// CourierCheckpoint is one scan of a parcel by a domestic courier.
+type CourierCheckpoint struct {
+	Status      string    `json:"status"`
+	Description string    `json:"description,omitempty"`
+	Location    string    `json:"location,omitempty"`
+	At          time.Time `json:"at"`
+}
+
+// Courier checkpoint statuses.
+const (
+	CourierPickedUp       = "picked_up"
+	CourierInTransit      = "in_transit"
+	CourierOutForDelivery = "out_for_delivery"
+	CourierDelivered      = "delivered"
+)
+
+// CourierTracking is what a courier knows about a parcel, the latest checkpoint last.
+type CourierTracking struct {
+	Courier        string              `json:"courier"`
+	TrackingNumber string              `json:"tracking_number"`
+	Checkpoints    []CourierCheckpoint `json:"checkpoints"`
+}
+
+// ShipmentTimeline is everything known about where an order's item is, for the buyer.
+// Courier is filled in once the item was handed to a courier and the courier could be reached.
+type ShipmentTimeline struct {
+	OrderID bson.ObjectId    `json:"order_id"`
+	Status  string           `json:"status"`
+	Events  []*ShipmentEvent `json:"events"`
+	Courier *CourierTracking `json:"courier,omitempty"`
+}
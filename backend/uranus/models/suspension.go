@@ -0,0 +1,35 @@
+package models
+
+import (
+	"time"
+
+	"github.com/globalsign/mgo/bson"
+)
+
+// Reason codes a user can be suspended for.
+const (
+	SuspensionLateDelivery    = "late_delivery"
+	SuspensionFraud           = "fraud"
+	SuspensionAbusiveBehavior = "abusive_behavior"
+	SuspensionPolicyViolation = "policy_violation"
+	SuspensionOther           = "other"
+)
+
+// Suspension is one entry of a user's suspension history, EndsAt is nil for a permanent ban.
+type Suspension struct {
+	ID          bson.ObjectId `json:"id,omitempty" bson:"_id,omitempty"`
+	UserID      bson.ObjectId `json:"user_id" bson:"user_id"`
+	ReasonCode  string        `json:"reason_code" bson:"reason_code" validate:"required,oneof=late_delivery fraud abusive_behavior policy_violation other"`
+	Note        string        `json:"note,omitempty" bson:"note,omitempty"`
+	SuspendedBy string        `json:"suspended_by" bson:"suspended_by"`
+	StartedAt   time.Time     `json:"started_at" bson:"started_at"`
+	EndsAt      *time.Time    `json:"ends_at,omitempty" bson:"ends_at,omitempty"`
+	LiftedAt    *time.Time    `json:"lifted_at,omitempty" bson:"lifted_at,omitempty"`
+	LiftedBy    string        `json:"lifted_by,omitempty" bson:"lifted_by,omitempty"`
+	LiftNote    string        `json:"lift_note,omitempty" bson:"lift_note,omitempty"`
+}
+
+// Reinstatement is the payload sent by an admin to lift a suspension early.
+type Reinstatement struct {
+	Note string `json:"note"`
+}
@@ -0,0 +1,83 @@
+package models
+
+import (
+	"time"
+
+	"github.com/globalsign/mgo/bson"
+)
+
+// Trip statuses, buyers can only send requests to open trips.
+const (
+	TripPlanned   = "planned"
+	TripOpen      = "open"
+	TripClosed    = "closed"
+	TripCompleted = "completed"
+)
+
+// Item categories travelers accept and buyers request.
+const (
+	CategoryElectronics = "electronics"
+	CategoryFashion     = "fashion"
+	CategoryCosmetics   = "cosmetics"
+	CategoryFood        = "food"
+	CategoryHealth      = "health"
+	CategoryBooks       = "books"
+	CategoryToys        = "toys"
+	CategoryOther       = "other"
+)
+
+// tripTransitions lists the statuses a trip may move to from each status.
+var tripTransitions = map[string][]string{
+	TripPlanned:   {TripOpen, TripClosed},
+	TripOpen:      {TripPlanned, TripClosed},
+	TripClosed:    {TripOpen, TripCompleted},
+	TripCompleted: {},
+}
+
+// Trip is a journey a traveler announces to carry items back for buyers.
+type Trip struct {
+	ID                bson.ObjectId `json:"id,omitempty" bson:"_id,omitempty"`
+	CreatedAt         time.Time     `json:"created_at" bson:"created_at"`
+	UpdatedAt         time.Time     `json:"updated_at" bson:"updated_at"`
+	TravelerID        bson.ObjectId `json:"traveler_id" bson:"traveler_id"`
+	Origin            Place         `json:"origin" bson:"origin"`
+	Destination       Place         `json:"destination" bson:"destination"`
+	DepartureAt       time.Time     `json:"departure_at" bson:"departure_at" validate:"required"`
+	ReturnAt          *time.Time    `json:"return_at,omitempty" bson:"return_at,omitempty"`
+	LuggageCapacityKG float64       `json:"luggage_capacity_kg" bson:"luggage_capacity_kg" validate:"gt=0,lte=100"`
+	Categories        []string      `json:"categories" bson:"categories" validate:"required,min=1,dive,oneof=electronics fashion cosmetics food health books toys other"`
+	Status            string        `json:"status" bson:"status" validate:"required,oneof=planned open closed completed"`
+	ClosedAt          *time.Time    `json:"closed_at,omitempty" bson:"closed_at,omitempty"`
+}
+
+// Place is a city in a country, the country is an ISO 3166-1 alpha-2 code.
+type Place struct {
+	City    string `json:"city,omitempty" bson:"city,omitempty"`
+	Country string `json:"country" bson:"country" validate:"required,len=2,alpha"`
+}
+
+// CanMoveTo reports whether the trip may go from its current status to the given one.
+func (m *Trip) CanMoveTo(status string) bool {
+	if m.Status == status {
+		return true
+	}
+
+	for _, allowed := range tripTransitions[m.Status] {
+		if allowed == status {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Accepts reports whether the traveler takes items of the category on this trip.
+func (m *Trip) Accepts(category string) bool {
+	for _, accepted := range m.Categories {
+		if accepted == category {
+			return true
+		}
+	}
+
+	return false
+}
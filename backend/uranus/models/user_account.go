@@ -1,25 +1,76 @@
 package models
 
 import (
+	"encoding/json"
 	"time"
 
 	"github.com/globalsign/mgo/bson"
 )
 
 type UserAccount struct {
-	CreatedAt    time.Time     `json:"created_at" bson:"created_at"`
-	UpdatedAt    time.Time     `json:"updated_at" bson:"updated_at"`
-	ID           bson.ObjectId `json:"id,omitempty" bson:"_id,omitempty"`
-	FirstName    string        `json:"first_name" bson:"first_name" validate:"required"`
-	LastName     string        `json:"last_name" bson:"last_name" validate:"required"`
-	EmailAddress string        `json:"email_address" bson:"email_address" validaet:"required"`
-	Role         UserRole      `json:"role" bson:"role" validate:"required"`
-	IsBanned     bool          `json:"is_banned" bson:"is_banned"`
-	Password     string        `json:"password,omitempty" bson:"password,omitempty"`
-	Needs        string        `json:"needs" bson:"needs"`
-	AddOn        interface{}   `json:"addon" bson:"addon"`
+	CreatedAt      time.Time     `json:"created_at" bson:"created_at"`
+	UpdatedAt      time.Time     `json:"updated_at" bson:"updated_at"`
+	ID             bson.ObjectId `json:"id,omitempty" bson:"_id,omitempty"`
+	Version        int           `json:"version" bson:"version"`
+	FirstName      string        `json:"first_name" bson:"first_name" validate:"required"`
+	LastName       string        `json:"last_name" bson:"last_name" validate:"required"`
+	EmailAddress   string        `json:"email_address" bson:"email_address" validate:"required,email"`
+	VerifiedAt     *time.Time    `json:"verified_at,omitempty" bson:"verified_at,omitempty"`
+	Role           UserRole      `json:"role" bson:"role" validate:"required"`
+	IsBanned       bool          `json:"is_banned" bson:"is_banned"`
+	SuspendedUntil *time.Time    `json:"suspended_until,omitempty" bson:"suspended_until,omitempty"`
+	DeletedAt      *time.Time    `json:"deleted_at,omitempty" bson:"deleted_at,omitempty"`
+	PurgedAt       *time.Time    `json:"purged_at,omitempty" bson:"purged_at,omitempty"`
+	Password       string        `json:"password,omitempty" bson:"password,omitempty" validate:"required,min=8"`
+
+	// DeletedEmailAddress keeps the address of a soft-deleted account for a restore,
+	// the account itself gives the address up so it can be registered again meanwhile.
+	DeletedEmailAddress string `json:"-" bson:"deleted_email_address,omitempty"`
+
+	// TokensRevokedAt rejects the access tokens issued before it, it's set whenever the password changes.
+	TokensRevokedAt *time.Time `json:"-" bson:"tokens_revoked_at,omitempty"`
+
+	// Only the profile of the account's role is ever set, each has its own endpoints.
+	TravelerProfile *TravelerProfile `json:"traveler_profile,omitempty" bson:"traveler_profile,omitempty"`
+	BuyerProfile    *BuyerProfile    `json:"buyer_profile,omitempty" bson:"buyer_profile,omitempty"`
+
+	Notifications *NotificationPreferences `json:"notifications,omitempty" bson:"notifications,omitempty"`
+
+	// Rating sums up the reviews the account got, it's only ever worked out from the reviews themselves.
+	Rating *RatingSummary `json:"rating,omitempty" bson:"rating,omitempty"`
+
+	// Outbox holds the notifications about changes to the account the relay didn't queue yet.
+	Outbox []*OutboxMessage `json:"-" bson:"outbox,omitempty"`
+}
+
+// MarshalJSON never exposes the stored password hash to the client,
+// the password field is only meant to be read from requests.
+func (m UserAccount) MarshalJSON() ([]byte, error) {
+	type userAccount UserAccount
+	account := userAccount(m)
+	account.Password = ""
+
+	return json.Marshal(account)
+}
+
+// Roles known by the authorization policies, a traveler is what the app calls a jastiper.
+const (
+	RoleBuyer         = "buyer"
+	RoleTraveler      = "traveler"
+	RoleAdmin         = "admin"
+	RoleContentEditor = "content-editor"
+)
+
+// IsSuspended reports whether the account is still banned at the given time,
+// a suspension with an end time lifts itself once that time has passed.
+func (m *UserAccount) IsSuspended(at time.Time) bool {
+	if !m.IsBanned {
+		return false
+	}
+
+	return m.SuspendedUntil == nil || m.SuspendedUntil.After(at)
 }
 
 type UserRole struct {
-	RoleName string `json:"role_name" bson:"role_name"`
+	RoleName string `json:"role_name" bson:"role_name" validate:"required,oneof=buyer traveler admin content-editor"`
 }
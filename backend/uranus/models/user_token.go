@@ -0,0 +1,39 @@
+package models
+
+import (
+	"time"
+
+	"github.com/globalsign/mgo/bson"
+)
+
+// Purposes of the one-time tokens sent to users.
+const (
+	TokenPurposeEmailVerification = "email_verification"
+	TokenPurposePasswordReset     = "password_reset"
+)
+
+// UserToken is a single-use token sent to a user, only the hash of the token is stored.
+type UserToken struct {
+	ID        bson.ObjectId `json:"id,omitempty" bson:"_id,omitempty"`
+	UserID    bson.ObjectId `json:"user_id" bson:"user_id"`
+	Purpose   string        `json:"purpose" bson:"purpose"`
+	TokenHash string        `json:"-" bson:"token_hash"`
+	CreatedAt time.Time     `json:"created_at" bson:"created_at"`
+	ExpiresAt time.Time     `json:"expires_at" bson:"expires_at"`
+}
+
+// EmailVerification is the payload sent by the client to confirm an email address.
+type EmailVerification struct {
+	Token string `json:"token" validate:"required"`
+}
+
+// ForgotPassword is the payload sent by the client to request a password reset token.
+type ForgotPassword struct {
+	EmailAddress string `json:"email_address" validate:"required,email"`
+}
+
+// PasswordReset is the payload sent by the client to set a new password with a reset token.
+type PasswordReset struct {
+	Token    string `json:"token" validate:"required"`
+	Password string `json:"password" validate:"required,min=8"`
+}
@@ -0,0 +1,50 @@
+package uranus
+
+import (
+	"context"
+
+	"github.com/fidellr/jastip/backend/uranus/models"
+)
+
+type NotificationUsecase interface {
+	RelayOutbox(ctx context.Context) (int, error)
+	DeliverPending(ctx context.Context) (int, error)
+	FetchInbox(ctx context.Context, filter *InboxFilter) ([]*models.InboxItem, Page, error)
+	MarkInboxRead(ctx context.Context, itemID string) error
+}
+
+// Notifier delivers notifications on one channel, an error makes the outbox try again later.
+type Notifier interface {
+	Channel() string
+	Notify(ctx context.Context, recipient *models.UserAccount, m *models.Notification) error
+}
+
+// InboxSort lists the newest inbox items first.
+const InboxSort = "-created_at"
+
+// InboxFilter pages through a user's in-app inbox, Unread leaves out what they already read.
+type InboxFilter struct {
+	Num    int
+	Cursor string
+
+	UserID string
+	Unread bool
+}
+
+// OrderMovedMessages tell the buyer and the traveler of the order about the transition, except whoever made it.
+func OrderMovedMessages(order *models.Order, transition models.OrderTransition, actorID string) []*models.OutboxMessage {
+	data := map[string]string{
+		"order_id": order.ID.Hex(),
+		"status":   transition.To,
+		"reason":   transition.Reason,
+	}
+
+	messages := make([]*models.OutboxMessage, 0, 2)
+	for _, partyID := range []string{order.BuyerID.Hex(), order.TravelerID.Hex()} {
+		if partyID != actorID {
+			messages = append(messages, models.NewOutboxMessage(partyID, models.NotifyOrderStatusChanged, data))
+		}
+	}
+
+	return messages
+}
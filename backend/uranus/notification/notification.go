@@ -0,0 +1,355 @@
+package notification
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/globalsign/mgo/bson"
+
+	"github.com/fidellr/jastip/backend/uranus"
+	"github.com/fidellr/jastip/backend/uranus/auth"
+	"github.com/fidellr/jastip/backend/uranus/models"
+	"github.com/fidellr/jastip/backend/uranus/repository"
+)
+
+type service struct {
+	repository     repository.NotificationRepository
+	userRepository repository.UserAccountRepository
+	notifiers      map[string]uranus.Notifier
+	maxAttempts    int
+	backoff        time.Duration
+	maxBackoff     time.Duration
+	lease          time.Duration
+	batchSize      int
+	contextTimeout time.Duration
+}
+
+// RelayOutbox queues the notifications waiting in the outboxes of the documents they tell about, one batch at a time,
+// and tells how many it queued. A message that can't be queued right now stays in its outbox for the next round.
+func (s *service) RelayOutbox(ctx context.Context) (int, error) {
+	if ctx == nil {
+		err := uranus.ErrContextNil
+		return 0, err
+	}
+
+	fetchCtx, cancel := context.WithTimeout(ctx, s.contextTimeout)
+	messages, err := s.repository.FetchOutbox(fetchCtx, s.batchSize)
+	cancel()
+	if err != nil {
+		return 0, err
+	}
+
+	relayed := 0
+	for _, message := range messages {
+		if err = s.relay(ctx, message); err != nil {
+			log.Printf("Failed to relay %s notification %s of %s %s : %s", message.Kind, message.ID.Hex(), message.Source, message.SourceID.Hex(), err.Error())
+			continue
+		}
+
+		relayed++
+	}
+
+	return relayed, nil
+}
+
+// relay queues the outbox message and takes it out of its document, a message nobody can be told about is dropped.
+func (s *service) relay(ctx context.Context, m *models.OutboxMessage) error {
+	ctx, cancel := context.WithTimeout(ctx, s.contextTimeout)
+	defer cancel()
+
+	if !bson.IsObjectIdHex(m.UserID) {
+		log.Printf("Dropped %s notification %s, %q is not a user ID", m.Kind, m.ID.Hex(), m.UserID)
+		return s.repository.ClearOutboxMessage(ctx, m)
+	}
+
+	err := s.queue(ctx, m)
+	if err == uranus.ErrNotFound {
+		log.Printf("Dropped %s notification %s, user %s is gone", m.Kind, m.ID.Hex(), m.UserID)
+		err = nil
+	}
+
+	if err != nil {
+		return err
+	}
+
+	return s.repository.ClearOutboxMessage(ctx, m)
+}
+
+// queue queues the message on every channel the user picked for the kind and there is a Notifier for,
+// push only when the user registered a device. Queuing the same message again queues nothing new.
+func (s *service) queue(ctx context.Context, m *models.OutboxMessage) error {
+	user, err := s.userRepository.GetUserByID(ctx, m.UserID)
+	if err != nil {
+		return err
+	}
+
+	if user.DeletedAt != nil {
+		return nil
+	}
+
+	prefs := user.Notifications
+	language := prefs.Lang()
+	subject, body, err := Render(m.Kind, language, m.Data)
+	if err != nil {
+		// The message won't render any better next time, it's dropped rather than tried forever.
+		log.Printf("Dropped %s notification %s : %s", m.Kind, m.ID.Hex(), err.Error())
+		return nil
+	}
+
+	now := time.Now()
+	queued := make([]*models.Notification, 0)
+	for _, channel := range prefs.ChannelsFor(m.Kind) {
+		if _, ok := s.notifiers[channel]; !ok {
+			continue
+		}
+
+		if channel == models.NotificationPush && len(prefs.PushTokens) == 0 {
+			continue
+		}
+
+		queued = append(queued, &models.Notification{
+			ID:            bson.NewObjectId(),
+			CreatedAt:     now,
+			UpdatedAt:     now,
+			UserID:        user.ID,
+			Kind:          m.Kind,
+			Channel:       channel,
+			Language:      language,
+			Subject:       subject,
+			Body:          body,
+			Data:          m.Data,
+			Status:        models.NotificationPending,
+			NextAttemptAt: now,
+			Key:           m.ID.Hex() + ":" + channel,
+		})
+	}
+
+	return s.repository.StoreNotifications(ctx, queued)
+}
+
+// DeliverPending delivers the notifications that are due, one batch at a time, and tells how many went out.
+// A failed delivery is tried again after a backoff that doubles every attempt until the attempts run out.
+func (s *service) DeliverPending(ctx context.Context) (int, error) {
+	if ctx == nil {
+		err := uranus.ErrContextNil
+		return 0, err
+	}
+
+	sent := 0
+	for i := 0; i < s.batchSize; i++ {
+		claimCtx, cancel := context.WithTimeout(ctx, s.contextTimeout)
+		notification, err := s.repository.ClaimNotification(claimCtx, time.Now(), s.lease)
+		cancel()
+		if err == uranus.ErrNotFound {
+			break
+		}
+
+		if err != nil {
+			return sent, err
+		}
+
+		if s.deliver(ctx, notification) {
+			sent++
+		}
+	}
+
+	return sent, nil
+}
+
+func (s *service) FetchInbox(ctx context.Context, filter *uranus.InboxFilter) ([]*models.InboxItem, uranus.Page, error) {
+	if ctx == nil {
+		err := uranus.ErrContextNil
+		return nil, uranus.Page{}, err
+	}
+
+	principal, ok := auth.FromContext(ctx)
+	if !ok {
+		return nil, uranus.Page{}, &uranus.ForbiddenError{Action: string(auth.ActionReadAccount)}
+	}
+
+	// Everyone reads their own inbox only.
+	filter.UserID = principal.UserID
+
+	ctx, cancel := context.WithTimeout(ctx, s.contextTimeout)
+	defer cancel()
+
+	if filter.Num == 0 {
+		filter.Num = int(20)
+	}
+
+	return s.repository.FetchInbox(ctx, filter)
+}
+
+func (s *service) MarkInboxRead(ctx context.Context, itemID string) error {
+	if ctx == nil {
+		return uranus.ErrContextNil
+	}
+
+	principal, ok := auth.FromContext(ctx)
+	if !ok {
+		return &uranus.ForbiddenError{Action: string(auth.ActionReadAccount)}
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, s.contextTimeout)
+	defer cancel()
+
+	return s.repository.MarkInboxRead(ctx, itemID, principal.UserID, time.Now())
+}
+
+// deliver hands the notification to its channel's Notifier and records the outcome, reporting whether it was sent.
+func (s *service) deliver(ctx context.Context, m *models.Notification) bool {
+	ctx, cancel := context.WithTimeout(ctx, s.contextTimeout)
+	defer cancel()
+
+	now := time.Now()
+	m.Attempts++
+	m.UpdatedAt = now
+
+	err := s.notify(ctx, m)
+	switch {
+	case err == nil:
+		m.Status = models.NotificationSent
+		m.SentAt = &now
+		m.LastError = ""
+	case m.Attempts >= s.maxAttempts:
+		m.Status = models.NotificationFailed
+		m.LastError = err.Error()
+	default:
+		m.Status = models.NotificationPending
+		m.NextAttemptAt = now.Add(s.backoffAfter(m.Attempts))
+		m.LastError = err.Error()
+	}
+
+	if err != nil {
+		log.Printf("Failed to deliver %s notification %s, attempt %d : %s", m.Channel, m.ID.Hex(), m.Attempts, err.Error())
+	}
+
+	// A worker whose lease ran out leaves the outcome to the worker that took the notification over.
+	if updateErr := s.repository.UpdateNotification(ctx, m); updateErr == uranus.ErrStaleStatus {
+		log.Printf("Lease on notification %s ran out, another worker records its delivery", m.ID.Hex())
+	} else if updateErr != nil {
+		log.Printf("Failed to record delivery of notification %s : %s", m.ID.Hex(), updateErr.Error())
+	}
+
+	return err == nil
+}
+
+func (s *service) notify(ctx context.Context, m *models.Notification) error {
+	notifier, ok := s.notifiers[m.Channel]
+	if !ok {
+		m.Attempts = s.maxAttempts
+		return uranus.ConstraintErrorf("No notifier delivers on %s", m.Channel)
+	}
+
+	recipient, err := s.userRepository.GetUserByID(ctx, m.UserID.Hex())
+	if err == uranus.ErrNotFound {
+		m.Attempts = s.maxAttempts
+	}
+
+	if err != nil {
+		return err
+	}
+
+	return notifier.Notify(ctx, recipient, m)
+}
+
+// backoffAfter is how long to wait after the given number of failed attempts.
+func (s *service) backoffAfter(attempts int) time.Duration {
+	wait := s.backoff
+	for i := 1; i < attempts && wait < s.maxBackoff; i++ {
+		wait *= 2
+	}
+
+	if wait > s.maxBackoff {
+		wait = s.maxBackoff
+	}
+
+	return wait
+}
+
+type requirement func(*service)
+
+func Repository(repository repository.NotificationRepository) requirement {
+	return func(s *service) {
+		s.repository = repository
+	}
+}
+
+func UserAccountRepository(userRepository repository.UserAccountRepository) requirement {
+	return func(s *service) {
+		s.userRepository = userRepository
+	}
+}
+
+// Notifiers sets who delivers on each channel, notifications aren't queued on a channel nobody delivers on.
+func Notifiers(notifiers ...uranus.Notifier) requirement {
+	return func(s *service) {
+		for _, notifier := range notifiers {
+			s.notifiers[notifier.Channel()] = notifier
+		}
+	}
+}
+
+// MaxAttempts sets how often a notification is tried before it's given up on.
+func MaxAttempts(attempts int) requirement {
+	return func(s *service) {
+		if attempts > 0 {
+			s.maxAttempts = attempts
+		}
+	}
+}
+
+// Backoff sets the wait after the first failed attempt, it doubles every attempt up to max.
+func Backoff(backoff time.Duration, max time.Duration) requirement {
+	return func(s *service) {
+		if backoff > 0 {
+			s.backoff = backoff
+		}
+
+		if max > 0 {
+			s.maxBackoff = max
+		}
+	}
+}
+
+// Lease sets how long a worker holds a notification it's delivering before another worker may take it over.
+func Lease(lease time.Duration) requirement {
+	return func(s *service) {
+		if lease > 0 {
+			s.lease = lease
+		}
+	}
+}
+
+// BatchSize caps how many notifications one DeliverPending call delivers and how many documents of each outbox
+// one RelayOutbox call reads.
+func BatchSize(size int) requirement {
+	return func(s *service) {
+		if size > 0 {
+			s.batchSize = size
+		}
+	}
+}
+
+func Timeout(timeout time.Duration) requirement {
+	return func(s *service) {
+		s.contextTimeout = timeout
+	}
+}
+
+func NewService(reqs ...requirement) uranus.NotificationUsecase {
+	s := &service{
+		notifiers:   make(map[string]uranus.Notifier),
+		maxAttempts: 5,
+		backoff:     30 * time.Second,
+		maxBackoff:  time.Hour,
+		lease:       time.Minute,
+		batchSize:   50,
+	}
+	for _, option := range reqs {
+		option(s)
+	}
+
+	return s
+}
@@ -0,0 +1,248 @@
+package notification
+
+import (
+	"bytes"
+	"strings"
+	"text/template"
+
+	"github.com/fidellr/jastip/backend/uranus"
+	"github.com/fidellr/jastip/backend/uranus/models"
+)
+
+// message is the subject and body template of one kind in one language, both fill in the notification's data.
+type message struct {
+	Subject string
+	Body    string
+}
+
+// messages holds the templates of every kind in Indonesian and English.
+var messages = map[string]map[string]message{
+	models.NotifyOfferReceived: {
+		models.LanguageIndonesian: {
+			Subject: "Penawaran baru untuk {{short .item}}",
+			Body:    "Jastiper menawarkan {{short .item}} seharga {{.price}} dengan fee {{.fee}}. Buka aplikasi untuk menerima atau menolaknya.",
+		},
+		models.LanguageEnglish: {
+			Subject: "New offer for {{short .item}}",
+			Body:    "A traveler offers {{short .item}} for {{.price}} with a {{.fee}} fee. Open the app to accept or decline it.",
+		},
+	},
+	models.NotifyOfferAccepted: {
+		models.LanguageIndonesian: {
+			Subject: "Penawaranmu untuk {{short .item}} diterima",
+			Body:    "Pembeli menerima penawaranmu untuk {{short .item}}. Pesanan {{.order_id}} menunggu pembayaran.",
+		},
+		models.LanguageEnglish: {
+			Subject: "Your offer for {{short .item}} was accepted",
+			Body:    "The buyer accepted your offer for {{short .item}}. Order {{.order_id}} is awaiting payment.",
+		},
+	},
+	models.NotifyOrderStatusChanged: {
+		models.LanguageIndonesian: {
+			Subject: "Pesanan {{.order_id}} {{status .status}}",
+			Body:    "Status pesanan {{.order_id}} sekarang {{status .status}}.{{if .reason}} Catatan: {{.reason}}{{end}}",
+		},
+		models.LanguageEnglish: {
+			Subject: "Order {{.order_id}} {{status .status}}",
+			Body:    "Order {{.order_id}} is now {{status .status}}.{{if .reason}} Note: {{.reason}}{{end}}",
+		},
+	},
+	models.NotifyReviewReceived: {
+		models.LanguageIndonesian: {
+			Subject: "Kamu dapat ulasan {{.rating}} bintang",
+			Body:    "Pesanan {{.order_id}} diulas dengan {{.rating}} bintang.{{if .comment}} \"{{short .comment}}\"{{end}}",
+		},
+		models.LanguageEnglish: {
+			Subject: "You got a {{.rating}} star review",
+			Body:    "Order {{.order_id}} was reviewed with {{.rating}} stars.{{if .comment}} \"{{short .comment}}\"{{end}}",
+		},
+	},
+	models.NotifyDisputeOpened: {
+		models.LanguageIndonesian: {
+			Subject: "Pesanan {{.order_id}} disengketakan",
+			Body:    "Pembeli membuka sengketa atas pesanan {{.order_id}} karena {{dispute .reason}}. Tanggapi di diskusi sengketa sebelum admin memutuskannya.",
+		},
+		models.LanguageEnglish: {
+			Subject: "Order {{.order_id}} was disputed",
+			Body:    "The buyer disputed order {{.order_id}} as {{dispute .reason}}. Respond in the dispute's discussion before an admin decides it.",
+		},
+	},
+	models.NotifyDisputeMessage: {
+		models.LanguageIndonesian: {
+			Subject: "Pesan baru di sengketa pesanan {{.order_id}}",
+			Body:    "{{dispute .party}}: \"{{short .body}}\"",
+		},
+		models.LanguageEnglish: {
+			Subject: "New message in the dispute of order {{.order_id}}",
+			Body:    "{{dispute .party}}: \"{{short .body}}\"",
+		},
+	},
+	models.NotifyDisputeEscalated: {
+		models.LanguageIndonesian: {
+			Subject: "Sengketa pesanan {{.order_id}} dieskalasi",
+			Body:    "Sengketa pesanan {{.order_id}} belum selesai tepat waktu dan sekarang ditangani langsung oleh admin.",
+		},
+		models.LanguageEnglish: {
+			Subject: "Dispute of order {{.order_id}} was escalated",
+			Body:    "The dispute of order {{.order_id}} wasn't settled in time and is now handled by the admins directly.",
+		},
+	},
+	models.NotifyDisputeResolved: {
+		models.LanguageIndonesian: {
+			Subject: "Sengketa pesanan {{.order_id}} selesai",
+			Body:    "Admin memutuskan sengketa pesanan {{.order_id}} dengan {{dispute .outcome}}{{if eq .outcome \"partial_refund\"}} sebesar {{.amount}}{{end}}. Catatan: {{.note}}",
+		},
+		models.LanguageEnglish: {
+			Subject: "Dispute of order {{.order_id}} was resolved",
+			Body:    "An admin resolved the dispute of order {{.order_id}} with {{dispute .outcome}}{{if eq .outcome \"partial_refund\"}} of {{.amount}}{{end}}. Note: {{.note}}",
+		},
+	},
+	models.NotifyAccountSuspended: {
+		models.LanguageIndonesian: {
+			Subject: "Akunmu ditangguhkan",
+			Body:    "Akun jastip-mu ditangguhkan{{if .until}} sampai {{.until}}{{end}}. Alasan: {{reason .reason}}",
+		},
+		models.LanguageEnglish: {
+			Subject: "Your account was suspended",
+			Body:    "Your jastip account was suspended{{if .until}} until {{.until}}{{end}}. Reason: {{reason .reason}}",
+		},
+	},
+}
+
+// statusLabels are the order statuses as users read them.
+var statusLabels = map[string]map[string]string{
+	models.LanguageIndonesian: {
+		models.OrderAwaitingPayment: "menunggu pembayaran",
+		models.OrderPaid:            "sudah dibayar",
+		models.OrderPurchased:       "sudah dibelikan",
+		models.OrderInTransit:       "dalam perjalanan",
+		models.OrderDelivered:       "sudah diantar",
+		models.OrderCompleted:       "selesai",
+		models.OrderCancelled:       "dibatalkan",
+		models.OrderDisputed:        "dalam sengketa",
+	},
+	models.LanguageEnglish: {
+		models.OrderAwaitingPayment: "awaiting payment",
+		models.OrderPaid:            "paid",
+		models.OrderPurchased:       "purchased",
+		models.OrderInTransit:       "in transit",
+		models.OrderDelivered:       "delivered",
+		models.OrderCompleted:       "completed",
+		models.OrderCancelled:       "cancelled",
+		models.OrderDisputed:        "disputed",
+	},
+}
+
+// suspensionLabels are the reason codes of suspensions as users read them.
+var suspensionLabels = map[string]map[string]string{
+	models.LanguageIndonesian: {
+		models.SuspensionLateDelivery:    "pengiriman terlambat",
+		models.SuspensionFraud:           "penipuan",
+		models.SuspensionAbusiveBehavior: "perilaku kasar",
+		models.SuspensionPolicyViolation: "pelanggaran kebijakan",
+		models.SuspensionOther:           "lainnya",
+	},
+	models.LanguageEnglish: {
+		models.SuspensionLateDelivery:    "late delivery",
+		models.SuspensionFraud:           "fraud",
+		models.SuspensionAbusiveBehavior: "abusive behavior",
+		models.SuspensionPolicyViolation: "policy violation",
+		models.SuspensionOther:           "other",
+	},
+}
+
+// disputeLabels are the reasons, outcomes and parties of disputes as users read them.
+var disputeLabels = map[string]map[string]string{
+	models.LanguageIndonesian: {
+		models.DisputeDamaged:       "barang rusak",
+		models.DisputeCounterfeit:   "barang palsu",
+		models.DisputeWrongItem:     "barang salah",
+		models.DisputeNotReceived:   "barang tidak diterima",
+		models.DisputeOther:         "alasan lain",
+		models.DisputeFullRefund:    "pengembalian dana penuh",
+		models.DisputePartialRefund: "pengembalian dana sebagian",
+		models.DisputeRelease:       "pembayaran ke jastiper",
+		models.OrderPartyBuyer:      "Pembeli",
+		models.OrderPartyTraveler:   "Jastiper",
+		models.OrderPartyAdmin:      "Admin",
+	},
+	models.LanguageEnglish: {
+		models.DisputeDamaged:       "damaged",
+		models.DisputeCounterfeit:   "counterfeit",
+		models.DisputeWrongItem:     "the wrong item",
+		models.DisputeNotReceived:   "not received",
+		models.DisputeOther:         "another reason",
+		models.DisputeFullRefund:    "a full refund",
+		models.DisputePartialRefund: "a partial refund",
+		models.DisputeRelease:       "a release to the traveler",
+		models.OrderPartyBuyer:      "Buyer",
+		models.OrderPartyTraveler:   "Traveler",
+		models.OrderPartyAdmin:      "Admin",
+	},
+}
+
+// Render writes the notification of the kind in the language, falling back to Indonesian.
+func Render(kind string, language string, data map[string]string) (subject string, body string, err error) {
+	languages, ok := messages[kind]
+	if !ok {
+		return "", "", uranus.ConstraintErrorf("Unknown notification kind %s", kind)
+	}
+
+	msg, ok := languages[language]
+	if !ok {
+		language = models.LanguageIndonesian
+		msg = languages[language]
+	}
+
+	funcs := template.FuncMap{
+		"short":   short,
+		"status":  label(statusLabels[language]),
+		"reason":  label(suspensionLabels[language]),
+		"dispute": label(disputeLabels[language]),
+	}
+
+	if subject, err = execute(msg.Subject, funcs, data); err != nil {
+		return "", "", err
+	}
+
+	if body, err = execute(msg.Body, funcs, data); err != nil {
+		return "", "", err
+	}
+
+	return subject, body, nil
+}
+
+// label looks codes up in the labels, a code without one is shown as it is.
+func label(labels map[string]string) func(string) string {
+	return func(code string) string {
+		if text, ok := labels[code]; ok {
+			return text
+		}
+
+		return code
+	}
+}
+
+// short cuts a text down to its first 60 letters, enough to tell which item a notification is about.
+func short(text string) string {
+	runes := []rune(strings.TrimSpace(text))
+	if len(runes) <= 60 {
+		return string(runes)
+	}
+
+	return strings.TrimSpace(string(runes[:60])) + "…"
+}
+
+func execute(text string, funcs template.FuncMap, data map[string]string) (string, error) {
+	tmpl, err := template.New("notification").Funcs(funcs).Option("missingkey=zero").Parse(text)
+	if err != nil {
+		return "", err
+	}
+
+	var out bytes.Buffer
+	if err = tmpl.Execute(&out, data); err != nil {
+		return "", err
+	}
+
+	return out.String(), nil
+}
@@ -0,0 +1,33 @@
+package notifier
+
+import (
+	"context"
+
+	"github.com/fidellr/jastip/backend/uranus"
+	"github.com/fidellr/jastip/backend/uranus/models"
+)
+
+type emailNotifier struct {
+	mailer uranus.Mailer
+}
+
+// NewEmail returns a Notifier that mails notifications to the user's email address through the mailer.
+func NewEmail(mailer uranus.Mailer) uranus.Notifier {
+	return &emailNotifier{mailer: mailer}
+}
+
+func (n *emailNotifier) Channel() string {
+	return models.NotificationEmail
+}
+
+func (n *emailNotifier) Notify(ctx context.Context, recipient *models.UserAccount, m *models.Notification) error {
+	if ctx == nil {
+		return uranus.ErrContextNil
+	}
+
+	return n.mailer.Send(ctx, &models.Mail{
+		To:      recipient.EmailAddress,
+		Subject: m.Subject,
+		Body:    m.Body,
+	})
+}
@@ -0,0 +1,66 @@
+package notifier
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/fidellr/jastip/backend/uranus"
+	"github.com/fidellr/jastip/backend/uranus/models"
+)
+
+// Fake is a Notifier that only remembers what it delivered, for local development and tests.
+type Fake struct {
+	mu         sync.Mutex
+	channel    string
+	failTimes  int
+	deliveries []*models.Notification
+}
+
+type fakeRequirement func(*Fake)
+
+// FailTimes makes the first n deliveries fail, to see the outbox retry.
+func FailTimes(n int) fakeRequirement {
+	return func(f *Fake) {
+		f.failTimes = n
+	}
+}
+
+// NewFake returns a fake Notifier delivering on the channel.
+func NewFake(channel string, reqs ...fakeRequirement) *Fake {
+	f := &Fake{channel: channel}
+	for _, req := range reqs {
+		req(f)
+	}
+
+	return f
+}
+
+func (f *Fake) Channel() string {
+	return f.channel
+}
+
+func (f *Fake) Notify(ctx context.Context, recipient *models.UserAccount, m *models.Notification) error {
+	if ctx == nil {
+		return uranus.ErrContextNil
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.failTimes > 0 {
+		f.failTimes--
+		return fmt.Errorf("Fake %s notifier failed on purpose", f.channel)
+	}
+
+	f.deliveries = append(f.deliveries, m)
+	return nil
+}
+
+// Deliveries lists the notifications delivered so far.
+func (f *Fake) Deliveries() []*models.Notification {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	return append([]*models.Notification(nil), f.deliveries...)
+}
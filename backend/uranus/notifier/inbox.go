@@ -0,0 +1,41 @@
+package notifier
+
+import (
+	"context"
+
+	"github.com/globalsign/mgo/bson"
+
+	"github.com/fidellr/jastip/backend/uranus"
+	"github.com/fidellr/jastip/backend/uranus/models"
+	"github.com/fidellr/jastip/backend/uranus/repository"
+)
+
+type inboxNotifier struct {
+	repository repository.NotificationRepository
+}
+
+// NewInbox returns a Notifier that puts notifications into the user's in-app inbox.
+func NewInbox(repository repository.NotificationRepository) uranus.Notifier {
+	return &inboxNotifier{repository: repository}
+}
+
+func (n *inboxNotifier) Channel() string {
+	return models.NotificationInApp
+}
+
+func (n *inboxNotifier) Notify(ctx context.Context, recipient *models.UserAccount, m *models.Notification) error {
+	if ctx == nil {
+		return uranus.ErrContextNil
+	}
+
+	return n.repository.StoreInboxItem(ctx, &models.InboxItem{
+		ID:             bson.NewObjectId(),
+		CreatedAt:      m.CreatedAt,
+		NotificationID: m.ID,
+		UserID:         recipient.ID,
+		Kind:           m.Kind,
+		Subject:        m.Subject,
+		Body:           m.Body,
+		Data:           m.Data,
+	})
+}
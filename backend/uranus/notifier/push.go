@@ -0,0 +1,122 @@
+package notifier
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/fidellr/jastip/backend/uranus"
+	"github.com/fidellr/jastip/backend/uranus/models"
+)
+
+// FCMEndpoint is the legacy HTTP endpoint of Firebase Cloud Messaging.
+const FCMEndpoint = "https://fcm.googleapis.com/fcm/send"
+
+type fcmNotifier struct {
+	serverKey string
+	endpoint  string
+	client    *http.Client
+}
+
+type fcmRequirement func(*fcmNotifier)
+
+// ServerKey sets the key FCM authorizes the requests with.
+func ServerKey(key string) fcmRequirement {
+	return func(n *fcmNotifier) {
+		n.serverKey = key
+	}
+}
+
+// Endpoint overrides where the pushes are posted, FCMEndpoint by default.
+func Endpoint(endpoint string) fcmRequirement {
+	return func(n *fcmNotifier) {
+		if endpoint != "" {
+			n.endpoint = endpoint
+		}
+	}
+}
+
+// NewFCM returns a Notifier that pushes notifications to the user's devices through Firebase Cloud Messaging.
+func NewFCM(reqs ...fcmRequirement) uranus.Notifier {
+	n := &fcmNotifier{
+		endpoint: FCMEndpoint,
+		client:   &http.Client{Timeout: 10 * time.Second},
+	}
+	for _, req := range reqs {
+		req(n)
+	}
+
+	return n
+}
+
+type fcmMessage struct {
+	RegistrationIDs []string          `json:"registration_ids"`
+	Notification    fcmNotification   `json:"notification"`
+	Data            map[string]string `json:"data,omitempty"`
+}
+
+type fcmNotification struct {
+	Title string `json:"title"`
+	Body  string `json:"body"`
+}
+
+type fcmResponse struct {
+	Success int `json:"success"`
+	Failure int `json:"failure"`
+}
+
+func (n *fcmNotifier) Channel() string {
+	return models.NotificationPush
+}
+
+// Notify counts as delivered once any of the user's devices took the push.
+func (n *fcmNotifier) Notify(ctx context.Context, recipient *models.UserAccount, m *models.Notification) error {
+	if ctx == nil {
+		return uranus.ErrContextNil
+	}
+
+	if recipient.Notifications == nil || len(recipient.Notifications.PushTokens) == 0 {
+		return nil
+	}
+
+	payload, err := json.Marshal(&fcmMessage{
+		RegistrationIDs: recipient.Notifications.PushTokens,
+		Notification:    fcmNotification{Title: m.Subject, Body: m.Body},
+		Data:            m.Data,
+	})
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequest(http.MethodPost, n.endpoint, bytes.NewReader(payload))
+	if err != nil {
+		return err
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "key="+n.serverKey)
+
+	res, err := n.client.Do(req.WithContext(ctx))
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode >= http.StatusMultipleChoices {
+		return fmt.Errorf("FCM answered with %d", res.StatusCode)
+	}
+
+	result := new(fcmResponse)
+	if err = json.NewDecoder(res.Body).Decode(result); err != nil {
+		return err
+	}
+
+	if result.Success == 0 {
+		return fmt.Errorf("FCM delivered to none of %d devices", result.Failure)
+	}
+
+	return nil
+}
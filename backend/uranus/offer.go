@@ -0,0 +1,39 @@
+package uranus
+
+import (
+	"context"
+
+	"github.com/fidellr/jastip/backend/uranus/models"
+)
+
+type OfferUsecase interface {
+	CreateOffer(ctx context.Context, m *models.Offer) error
+	FetchOffers(ctx context.Context, filter *OfferFilter) ([]*models.Offer, Page, error)
+	GetOfferByID(ctx context.Context, offerID string) (*models.Offer, error)
+	AcceptOffer(ctx context.Context, offerID string) (*models.Order, error)
+	DeclineOffer(ctx context.Context, offerID string) (*models.Offer, error)
+	WithdrawOffer(ctx context.Context, offerID string) (*models.Offer, error)
+	FetchMatches(ctx context.Context, tripID string, num int) ([]*models.Match, error)
+}
+
+// OrderFollower keeps the purchase request and the accepted offer of an order in step with the order once it ended,
+// the request of a completed order is fulfilled and the request of a cancelled one takes offers again.
+type OrderFollower interface {
+	FollowOrder(ctx context.Context, order *models.Order) error
+}
+
+// OfferSort lists the newest offers first.
+const OfferSort = "-created_at"
+
+// OfferFilter narrows offers down to a request, a trip, a traveler or a status.
+// PartyID keeps the offers the user is the buyer or the traveler of.
+type OfferFilter struct {
+	Num    int
+	Cursor string
+
+	RequestID  string
+	TripID     string
+	TravelerID string
+	PartyID    string
+	Status     string
+}
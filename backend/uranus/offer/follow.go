@@ -0,0 +1,39 @@
+package offer
+
+import (
+	"context"
+
+	"github.com/fidellr/jastip/backend/uranus"
+	"github.com/fidellr/jastip/backend/uranus/models"
+)
+
+// FollowOrder fulfills the purchase request of a completed order. A cancelled order cancels its offer, freeing
+// the trip's luggage, and the request takes offers again until the sweeper expires it past its deadline.
+// Steps done before are skipped, so it can simply run again after a failure.
+func (s *service) FollowOrder(ctx context.Context, order *models.Order) error {
+	if ctx == nil {
+		return uranus.ErrContextNil
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, s.contextTimeout)
+	defer cancel()
+
+	var err error
+	switch order.Status {
+	case models.OrderCompleted:
+		err = s.purchaseRequestRepository.UpdatePurchaseRequestStatus(ctx, order.RequestID.Hex(), models.PurchaseRequestMatched, models.PurchaseRequestFulfilled)
+	case models.OrderCancelled:
+		err = s.repository.UpdateOfferStatus(ctx, order.OfferID.Hex(), models.OfferAccepted, models.OfferCancelled)
+		if err != nil && err != uranus.ErrStaleStatus {
+			return err
+		}
+
+		err = s.purchaseRequestRepository.UpdatePurchaseRequestStatus(ctx, order.RequestID.Hex(), models.PurchaseRequestMatched, models.PurchaseRequestOpen)
+	}
+
+	if err == uranus.ErrStaleStatus {
+		return nil
+	}
+
+	return err
+}
@@ -0,0 +1,97 @@
+package offer
+
+import (
+	"context"
+	"math"
+	"sort"
+	"strings"
+
+	"github.com/fidellr/jastip/backend/uranus"
+	"github.com/fidellr/jastip/backend/uranus/auth"
+	"github.com/fidellr/jastip/backend/uranus/models"
+)
+
+// matchCandidates caps how many open requests get scored for one trip.
+const matchCandidates = 200
+
+// Weights of the parts of a match score, they add up to 1.
+const (
+	destinationWeight = 0.3
+	deadlineWeight    = 0.4
+	capacityWeight    = 0.3
+)
+
+// FetchMatches ranks the open requests the trip could take, requests that don't fit the luggage left are skipped.
+func (s *service) FetchMatches(ctx context.Context, tripID string, num int) ([]*models.Match, error) {
+	if ctx == nil {
+		err := uranus.ErrContextNil
+		return nil, err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, s.contextTimeout)
+	defer cancel()
+
+	trip, err := s.tripRepository.GetTripByID(ctx, tripID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err = auth.AuthorizeOwner(ctx, auth.ActionManageTrips, trip.TravelerID.Hex()); err != nil {
+		return nil, err
+	}
+
+	if num == 0 {
+		num = int(20)
+	}
+
+	remaining, err := s.remainingCapacity(ctx, trip)
+	if err != nil {
+		return nil, err
+	}
+
+	candidates, err := s.purchaseRequestRepository.FetchMatchCandidates(ctx, trip.Destination.Country, trip.Categories, arrival(trip), matchCandidates)
+	if err != nil {
+		return nil, err
+	}
+
+	matches := make([]*models.Match, 0, len(candidates))
+	for _, request := range candidates {
+		if request.TotalWeightKG() > remaining {
+			continue
+		}
+
+		matches = append(matches, &models.Match{Request: request, Score: score(trip, request, remaining)})
+	}
+
+	sort.SliceStable(matches, func(i, j int) bool {
+		return matches[i].Score > matches[j].Score
+	})
+
+	if len(matches) > num {
+		matches = matches[:num]
+	}
+
+	return matches, nil
+}
+
+// score rates how well the request fits the trip between 0 and 1.
+func score(trip *models.Trip, request *models.PurchaseRequest, remaining float64) float64 {
+	// Candidates are always bought in the trip's country, the same city is better still.
+	destination := 0.5
+	if request.DestinationCity != "" && strings.EqualFold(request.DestinationCity, trip.Destination.City) {
+		destination = 1
+	}
+
+	// The closer the deadline is to the traveler's return, the more this trip is the buyer's chance.
+	slackDays := request.Deadline.Sub(arrival(trip)).Hours() / 24
+	deadline := 1 / (1 + slackDays/7)
+
+	// Light items leave room for more requests, unknown weights sit in the middle.
+	capacity := 0.5
+	if weight := request.TotalWeightKG(); weight > 0 && remaining > 0 {
+		capacity = 1 - weight/remaining
+	}
+
+	total := destinationWeight*destination + deadlineWeight*deadline + capacityWeight*capacity
+	return math.Round(total*1000) / 1000
+}
@@ -0,0 +1,519 @@
+package offer
+
+import (
+	"context"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/globalsign/mgo/bson"
+
+	"github.com/fidellr/jastip/backend/uranus"
+	"github.com/fidellr/jastip/backend/uranus/auth"
+	"github.com/fidellr/jastip/backend/uranus/models"
+	"github.com/fidellr/jastip/backend/uranus/repository"
+)
+
+type service struct {
+	repository                repository.OfferRepository
+	purchaseRequestRepository repository.PurchaseRequestRepository
+	tripRepository            repository.TripRepository
+	orderRepository           repository.OrderRepository
+	converter                 uranus.Converter
+	feeCalculator             uranus.FeeCalculator
+	customs                   uranus.CustomsChecker
+	settlementCurrency        string
+	validator                 uranus.Validate
+	contextTimeout            time.Duration
+}
+
+func (s *service) CreateOffer(ctx context.Context, m *models.Offer) (err error) {
+	if ctx == nil {
+		err = uranus.ErrContextNil
+		return err
+	}
+
+	if err = auth.Authorize(ctx, auth.ActionMakeOffer); err != nil {
+		return err
+	}
+
+	m.QuotedPrice.Currency = strings.ToUpper(m.QuotedPrice.Currency)
+	m.Fee.Currency = strings.ToUpper(m.Fee.Currency)
+	if m.Fee.Currency == "" {
+		m.Fee.Currency = m.QuotedPrice.Currency
+	}
+
+	if err = s.validator.ValidateStruct(m); err != nil {
+		return err
+	}
+
+	if m.QuotedPrice.Amount <= 0 || m.Fee.Amount < 0 {
+		return uranus.ConstraintErrorf("Quoted price has to be above zero and the fee can't be negative")
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, s.contextTimeout)
+	defer cancel()
+
+	trip, err := s.tripRepository.GetTripByID(ctx, m.TripID.Hex())
+	if err != nil {
+		return err
+	}
+
+	// Offers are made by the traveler of the trip, admins may make one on their behalf.
+	if err = auth.AuthorizeOwner(ctx, auth.ActionManageTrips, trip.TravelerID.Hex()); err != nil {
+		return err
+	}
+
+	request, err := s.purchaseRequestRepository.GetPurchaseRequestByID(ctx, m.RequestID.Hex())
+	if err != nil {
+		return err
+	}
+
+	if err = s.checkFit(ctx, trip, request, m); err != nil {
+		return err
+	}
+
+	hasPending, err := s.repository.HasPendingOffer(ctx, request.ID.Hex(), trip.TravelerID.Hex())
+	if err != nil {
+		return err
+	}
+
+	if hasPending {
+		return uranus.ConstraintErrorf("Traveler already has a pending offer on this request, withdraw it first")
+	}
+
+	m.ID = bson.NewObjectId()
+	m.TravelerID = trip.TravelerID
+	m.BuyerID = request.BuyerID
+	m.WeightKG = request.TotalWeightKG()
+	m.Status = models.OfferPending
+	m.CreatedAt = time.Now()
+	m.UpdatedAt = time.Now()
+	m.Outbox = []*models.OutboxMessage{models.NewOutboxMessage(m.BuyerID.Hex(), models.NotifyOfferReceived, map[string]string{
+		"offer_id":   m.ID.Hex(),
+		"request_id": request.ID.Hex(),
+		"item":       request.ItemDescription,
+		"price":      m.QuotedPrice.String(),
+		"fee":        m.Fee.String(),
+	})}
+
+	return s.repository.StoreOffer(ctx, m)
+}
+
+func (s *service) FetchOffers(ctx context.Context, filter *uranus.OfferFilter) ([]*models.Offer, uranus.Page, error) {
+	if ctx == nil {
+		err := uranus.ErrContextNil
+		return nil, uranus.Page{}, err
+	}
+
+	// Buyers and travelers only see the offers they are part of.
+	partyID, err := auth.PartyScope(ctx, auth.ActionManageOrders)
+	if err != nil {
+		return nil, uranus.Page{}, err
+	}
+
+	if partyID != "" {
+		filter.PartyID = partyID
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, s.contextTimeout)
+	defer cancel()
+
+	if filter.Num == 0 {
+		filter.Num = int(20)
+	}
+
+	return s.repository.FetchOffers(ctx, filter)
+}
+
+func (s *service) GetOfferByID(ctx context.Context, offerID string) (*models.Offer, error) {
+	if ctx == nil {
+		err := uranus.ErrContextNil
+		return nil, err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, s.contextTimeout)
+	defer cancel()
+
+	offer, err := s.repository.GetOfferByID(ctx, offerID)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, _, err = auth.AuthorizeParty(ctx, auth.ActionManageOrders, offer.PartyOf); err != nil {
+		return nil, err
+	}
+
+	return offer, nil
+}
+
+// AcceptOffer matches the request to the offer, declines every other pending offer on the request
+// and places the order the buyer pays for next.
+func (s *service) AcceptOffer(ctx context.Context, offerID string) (*models.Order, error) {
+	if ctx == nil {
+		err := uranus.ErrContextNil
+		return nil, err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, s.contextTimeout)
+	defer cancel()
+
+	offer, err := s.repository.GetOfferByID(ctx, offerID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err = auth.AuthorizeOwner(ctx, auth.ActionManagePurchaseRequests, offer.BuyerID.Hex()); err != nil {
+		return nil, err
+	}
+
+	if !offer.CanMoveTo(models.OfferAccepted) {
+		return nil, uranus.ConstraintErrorf("Offer is %s and can't be accepted", offer.Status)
+	}
+
+	trip, err := s.tripRepository.GetTripByID(ctx, offer.TripID.Hex())
+	if err != nil {
+		return nil, err
+	}
+
+	if trip.Status != models.TripOpen {
+		return nil, uranus.ConstraintErrorf("Trip is %s and doesn't take items anymore", trip.Status)
+	}
+
+	remaining, err := s.remainingCapacity(ctx, trip)
+	if err != nil {
+		return nil, err
+	}
+
+	if offer.WeightKG > remaining {
+		return nil, uranus.ConstraintErrorf("Trip has %.1f kg left, the request needs %.1f kg", remaining, offer.WeightKG)
+	}
+
+	// The order is worked out before anything is locked, customs may still turn it down.
+	requestID := offer.RequestID.Hex()
+	request, err := s.purchaseRequestRepository.GetPurchaseRequestByID(ctx, requestID)
+	if err != nil {
+		return nil, err
+	}
+
+	order := models.NewOrder(request, offer, time.Now())
+	if err = s.total(ctx, request, order); err != nil {
+		return nil, err
+	}
+
+	check, err := s.customs.CheckOrder(ctx, order)
+	if err != nil {
+		return nil, err
+	}
+
+	if err = uranus.CustomsErrorOf(check); err != nil {
+		return nil, err
+	}
+
+	order.Customs = check
+	order.Outbox = []*models.OutboxMessage{models.NewOutboxMessage(offer.TravelerID.Hex(), models.NotifyOfferAccepted, map[string]string{
+		"offer_id": offer.ID.Hex(),
+		"order_id": order.ID.Hex(),
+		"item":     request.ItemDescription,
+	})}
+
+	// Matching the request first makes it the lock, a second accept on the same request fails here.
+	err = s.purchaseRequestRepository.UpdatePurchaseRequestStatus(ctx, requestID, models.PurchaseRequestOpen, models.PurchaseRequestMatched)
+	if err != nil {
+		return nil, err
+	}
+
+	if err = s.repository.UpdateOfferStatus(ctx, offerID, models.OfferPending, models.OfferAccepted); err != nil {
+		// The traveler withdrew meanwhile, the request goes back to taking offers.
+		return nil, s.unlock(ctx, requestID, "", err)
+	}
+
+	// Another accept on the same trip may have taken the luggage meanwhile. Both accepts count each other's offer
+	// here, so at worst both back off instead of overbooking the trip.
+	if remaining, err = s.remainingCapacity(ctx, trip); err != nil {
+		return nil, s.unlock(ctx, requestID, offerID, err)
+	}
+
+	if remaining < 0 {
+		err = uranus.ConstraintErrorf("Trip has no room left for the %.1f kg of the request", offer.WeightKG)
+		return nil, s.unlock(ctx, requestID, offerID, err)
+	}
+
+	if err = s.orderRepository.StoreOrder(ctx, order); err != nil {
+		return nil, s.unlock(ctx, requestID, offerID, err)
+	}
+
+	// The order is placed, offers a failure leaves pending can't be accepted anyway while the request is matched.
+	if _, err = s.repository.DeclinePendingOffers(ctx, requestID); err != nil {
+		log.Printf("Failed to decline the other offers on purchase request %s : %s", requestID, err.Error())
+	}
+
+	offer.Status = models.OfferAccepted
+	offer.UpdatedAt = order.CreatedAt
+
+	return order, nil
+}
+
+func (s *service) DeclineOffer(ctx context.Context, offerID string) (*models.Offer, error) {
+	if ctx == nil {
+		err := uranus.ErrContextNil
+		return nil, err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, s.contextTimeout)
+	defer cancel()
+
+	offer, err := s.repository.GetOfferByID(ctx, offerID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err = auth.AuthorizeOwner(ctx, auth.ActionManagePurchaseRequests, offer.BuyerID.Hex()); err != nil {
+		return nil, err
+	}
+
+	return s.moveOffer(ctx, offer, models.OfferDeclined)
+}
+
+func (s *service) WithdrawOffer(ctx context.Context, offerID string) (*models.Offer, error) {
+	if ctx == nil {
+		err := uranus.ErrContextNil
+		return nil, err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, s.contextTimeout)
+	defer cancel()
+
+	offer, err := s.repository.GetOfferByID(ctx, offerID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err = auth.AuthorizeOwner(ctx, auth.ActionManageTrips, offer.TravelerID.Hex()); err != nil {
+		return nil, err
+	}
+
+	return s.moveOffer(ctx, offer, models.OfferWithdrawn)
+}
+
+func (s *service) moveOffer(ctx context.Context, offer *models.Offer, status string) (*models.Offer, error) {
+	if !offer.CanMoveTo(status) {
+		return nil, uranus.ConstraintErrorf("Offer can't go from %s to %s", offer.Status, status)
+	}
+
+	if err := s.repository.UpdateOfferStatus(ctx, offer.ID.Hex(), offer.Status, status); err != nil {
+		return nil, err
+	}
+
+	offer.Status = status
+	offer.UpdatedAt = time.Now()
+	return offer, nil
+}
+
+// unlock undoes the locks of an accept that failed with err, the request takes offers again
+// and the offer, when one is given, goes back to pending. Both are tried even when one of them fails,
+// a failed rollback is only logged and err is what the caller gets back either way.
+func (s *service) unlock(ctx context.Context, requestID, offerID string, err error) error {
+	if offerID != "" {
+		if rollbackErr := s.repository.UpdateOfferStatus(ctx, offerID, models.OfferAccepted, models.OfferPending); rollbackErr != nil {
+			log.Printf("Failed to put offer %s back to pending : %s", offerID, rollbackErr.Error())
+		}
+	}
+
+	if rollbackErr := s.purchaseRequestRepository.UpdatePurchaseRequestStatus(ctx, requestID, models.PurchaseRequestMatched, models.PurchaseRequestOpen); rollbackErr != nil {
+		log.Printf("Failed to reopen purchase request %s : %s", requestID, rollbackErr.Error())
+	}
+
+	return err
+}
+
+// total works out what the buyer pays for the order in the settlement currency,
+// recording the rates and the fee schedule it took.
+func (s *service) total(ctx context.Context, request *models.PurchaseRequest, order *models.Order) error {
+	items, itemsRate, err := s.converter.Convert(ctx, order.ItemPrice.Times(order.Quantity), s.settlementCurrency, order.CreatedAt)
+	if err != nil {
+		return err
+	}
+
+	fee, feeRate, err := s.converter.Convert(ctx, order.Fee, s.settlementCurrency, order.CreatedAt)
+	if err != nil {
+		return err
+	}
+
+	if itemsRate != nil {
+		order.Rates = append(order.Rates, *itemsRate)
+	}
+
+	// A fee in the item's currency took the same rate, it's only recorded once.
+	if feeRate != nil && (itemsRate == nil || feeRate.From != itemsRate.From) {
+		order.Rates = append(order.Rates, *feeRate)
+	}
+
+	fees, err := s.feeCalculator.CalculateFees(ctx, &models.FeeQuote{
+		Country:     request.DestinationCountry,
+		Category:    request.Category,
+		Items:       items,
+		TravelerFee: fee,
+		At:          order.CreatedAt,
+	})
+	if err != nil {
+		return err
+	}
+
+	order.Fees = fees
+	order.Total = fees.Total
+	return nil
+}
+
+// checkFit rejects an offer the trip can't deliver on.
+func (s *service) checkFit(ctx context.Context, trip *models.Trip, request *models.PurchaseRequest, m *models.Offer) error {
+	if trip.Status != models.TripOpen {
+		return uranus.ConstraintErrorf("Trip is %s, only open trips can take requests", trip.Status)
+	}
+
+	if request.Status != models.PurchaseRequestOpen {
+		return uranus.ConstraintErrorf("Purchase request is %s and doesn't take offers anymore", request.Status)
+	}
+
+	if trip.Destination.Country != request.DestinationCountry {
+		return uranus.ConstraintErrorf("Trip goes to %s but the item is bought in %s", trip.Destination.Country, request.DestinationCountry)
+	}
+
+	if !trip.Accepts(request.Category) {
+		return uranus.ConstraintErrorf("Trip doesn't take %s items", request.Category)
+	}
+
+	quoted, _, err := s.converter.Convert(ctx, m.QuotedPrice, request.MaxPrice.Currency, time.Now())
+	if err != nil {
+		return err
+	}
+
+	if quoted.Amount > request.MaxPrice.Amount {
+		return uranus.ConstraintErrorf("Quoted price %s is above the buyer's max price %s", quoted, request.MaxPrice)
+	}
+
+	if m.ExpectedDeliveryAt.Before(arrival(trip)) {
+		return uranus.ConstraintErrorf("Item can't be delivered before the trip is back")
+	}
+
+	if m.ExpectedDeliveryAt.After(request.Deadline) {
+		return uranus.ConstraintErrorf("Expected delivery is after the buyer's deadline %s", request.Deadline.Format(time.RFC3339))
+	}
+
+	remaining, err := s.remainingCapacity(ctx, trip)
+	if err != nil {
+		return err
+	}
+
+	if request.TotalWeightKG() > remaining {
+		return uranus.ConstraintErrorf("Trip has %.1f kg left, the request needs %.1f kg", remaining, request.TotalWeightKG())
+	}
+
+	return nil
+}
+
+// remainingCapacity is the luggage weight the trip has left after its accepted offers.
+func (s *service) remainingCapacity(ctx context.Context, trip *models.Trip) (float64, error) {
+	accepted, err := s.repository.FetchOffersByTrip(ctx, trip.ID.Hex(), models.OfferAccepted)
+	if err != nil {
+		return 0, err
+	}
+
+	remaining := trip.LuggageCapacityKG
+	for _, offer := range accepted {
+		remaining -= offer.WeightKG
+	}
+
+	return remaining, nil
+}
+
+type requirement func(*service)
+
+func Repository(repository repository.OfferRepository) requirement {
+	return func(s *service) {
+		s.repository = repository
+	}
+}
+
+func PurchaseRequestRepository(purchaseRequestRepository repository.PurchaseRequestRepository) requirement {
+	return func(s *service) {
+		s.purchaseRequestRepository = purchaseRequestRepository
+	}
+}
+
+func TripRepository(tripRepository repository.TripRepository) requirement {
+	return func(s *service) {
+		s.tripRepository = tripRepository
+	}
+}
+
+func OrderRepository(orderRepository repository.OrderRepository) requirement {
+	return func(s *service) {
+		s.orderRepository = orderRepository
+	}
+}
+
+func Converter(converter uranus.Converter) requirement {
+	return func(s *service) {
+		s.converter = converter
+	}
+}
+
+func FeeCalculator(feeCalculator uranus.FeeCalculator) requirement {
+	return func(s *service) {
+		s.feeCalculator = feeCalculator
+	}
+}
+
+func Customs(customs uranus.CustomsChecker) requirement {
+	return func(s *service) {
+		s.customs = customs
+	}
+}
+
+// SettlementCurrency sets the currency buyers pay their orders in.
+func SettlementCurrency(currency string) requirement {
+	return func(s *service) {
+		s.settlementCurrency = currency
+	}
+}
+
+func Timeout(timeout time.Duration) requirement {
+	return func(s *service) {
+		s.contextTimeout = timeout
+	}
+}
+
+func Validator(validator uranus.Validate) requirement {
+	return func(s *service) {
+		s.validator = validator
+	}
+}
+
+func NewService(reqs ...requirement) uranus.OfferUsecase {
+	return newService(reqs...)
+}
+
+// NewFollower returns the side of the offer service that moves requests and offers along with their orders,
+// for the order and dispute services to call once an order ended.
+func NewFollower(reqs ...requirement) uranus.OrderFollower {
+	return newService(reqs...)
+}
+
+func newService(reqs ...requirement) *service {
+	s := new(service)
+	for _, option := range reqs {
+		option(s)
+	}
+
+	return s
+}
+
+// arrival is when the traveler is back with the items, the departure for one way trips.
+func arrival(trip *models.Trip) time.Time {
+	if trip.ReturnAt != nil {
+		return *trip.ReturnAt
+	}
+
+	return trip.DepartureAt
+}
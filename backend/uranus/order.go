@@ -0,0 +1,28 @@
+package uranus
+
+import (
+	"context"
+
+	"github.com/fidellr/jastip/backend/uranus/models"
+)
+
+type OrderUsecase interface {
+	FetchOrders(ctx context.Context, filter *OrderFilter) ([]*models.Order, Page, error)
+	GetOrderByID(ctx context.Context, orderID string) (*models.Order, error)
+	MoveOrder(ctx context.Context, orderID string, move *models.OrderMove) (*models.Order, error)
+}
+
+// OrderSort lists the newest orders first.
+const OrderSort = "-created_at"
+
+// OrderFilter narrows orders down to a buyer, a traveler or a status,
+// PartyID matches orders where the user is either the buyer or the traveler.
+type OrderFilter struct {
+	Num    int
+	Cursor string
+
+	PartyID    string
+	BuyerID    string
+	TravelerID string
+	Status     string
+}
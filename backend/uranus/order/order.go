@@ -0,0 +1,215 @@
+package order
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/globalsign/mgo/bson"
+
+	"github.com/fidellr/jastip/backend/uranus"
+	"github.com/fidellr/jastip/backend/uranus/auth"
+	"github.com/fidellr/jastip/backend/uranus/models"
+	"github.com/fidellr/jastip/backend/uranus/repository"
+)
+
+type service struct {
+	repository     repository.OrderRepository
+	escrow         uranus.Escrow
+	follower       uranus.OrderFollower
+	validator      uranus.Validate
+	contextTimeout time.Duration
+}
+
+// FetchOrders lists the caller's own orders, only admins may list the orders of others.
+func (s *service) FetchOrders(ctx context.Context, filter *uranus.OrderFilter) ([]*models.Order, uranus.Page, error) {
+	if ctx == nil {
+		err := uranus.ErrContextNil
+		return nil, uranus.Page{}, err
+	}
+
+	partyID, err := auth.PartyScope(ctx, auth.ActionManageOrders)
+	if err != nil {
+		return nil, uranus.Page{}, err
+	}
+
+	if partyID != "" {
+		filter.PartyID = partyID
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, s.contextTimeout)
+	defer cancel()
+
+	if filter.Num == 0 {
+		filter.Num = int(20)
+	}
+
+	return s.repository.FetchOrders(ctx, filter)
+}
+
+func (s *service) GetOrderByID(ctx context.Context, orderID string) (*models.Order, error) {
+	if ctx == nil {
+		err := uranus.ErrContextNil
+		return nil, err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, s.contextTimeout)
+	defer cancel()
+
+	order, _, err := s.partyOrder(ctx, orderID)
+	if err != nil {
+		return nil, err
+	}
+
+	return order, nil
+}
+
+// MoveOrder moves the order to another status on behalf of the caller,
+// a move the transition table doesn't allow for the caller's party returns a *uranus.IllegalTransitionError.
+func (s *service) MoveOrder(ctx context.Context, orderID string, move *models.OrderMove) (*models.Order, error) {
+	if ctx == nil {
+		err := uranus.ErrContextNil
+		return nil, err
+	}
+
+	if err := s.validator.ValidateStruct(move); err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, s.contextTimeout)
+	defer cancel()
+
+	order, party, err := s.partyOrder(ctx, orderID)
+	if err != nil {
+		return nil, err
+	}
+
+	// Orders only go into and out of disputed along with a dispute, its outcome is what settles the escrow.
+	if order.Status == models.OrderDisputed || move.Status == models.OrderDisputed {
+		return nil, uranus.ConstraintErrorf("Orders only go into and out of %s through the dispute endpoints", models.OrderDisputed)
+	}
+
+	principal, _ := auth.FromContext(ctx)
+	transition := models.OrderTransition{
+		From:   order.Status,
+		To:     move.Status,
+		Party:  party,
+		Reason: move.Reason,
+		At:     time.Now(),
+	}
+
+	if bson.IsObjectIdHex(principal.UserID) {
+		transition.ActorID = bson.ObjectIdHex(principal.UserID)
+	}
+
+	if err = s.appendTransition(ctx, order, transition); err != nil {
+		return nil, err
+	}
+
+	// The order has moved whatever happens next. A request that fails to follow or an escrow that fails
+	// to settle leaves the order unsettled, and the settlement sweeper does both again.
+	if order.HasEnded() {
+		if err = s.follower.FollowOrder(ctx, order); err == nil {
+			err = s.settle(ctx, order)
+		}
+
+		if err != nil {
+			log.Printf("Failed to settle order %s, the sweeper tries again : %s", order.ID.Hex(), err.Error())
+		}
+	}
+
+	return order, nil
+}
+
+// settle pays the traveler out of escrow once the order completed, or refunds the buyer once it got cancelled.
+func (s *service) settle(ctx context.Context, order *models.Order) error {
+	switch order.Status {
+	case models.OrderCompleted:
+		return s.escrow.Release(ctx, order)
+	case models.OrderCancelled:
+		return s.escrow.Refund(ctx, order)
+	}
+
+	return nil
+}
+
+// appendTransition checks the transition against the table before storing it,
+// the order is updated in place so the caller gets it back with its new history.
+func (s *service) appendTransition(ctx context.Context, order *models.Order, transition models.OrderTransition) error {
+	if !order.CanMoveTo(transition.To) {
+		return &uranus.IllegalTransitionError{From: order.Status, To: transition.To}
+	}
+
+	if !order.CanMove(transition.Party, transition.To) {
+		return &uranus.IllegalTransitionError{From: order.Status, To: transition.To, Party: transition.Party}
+	}
+
+	// The buyer and the traveler hear about the move through the order's outbox, whoever made it excepted.
+	messages := uranus.OrderMovedMessages(order, transition, transition.ActorID.Hex())
+	if err := s.repository.AppendOrderTransition(ctx, order.ID.Hex(), transition, messages...); err != nil {
+		return err
+	}
+
+	order.Status = transition.To
+	order.UpdatedAt = transition.At
+	order.History = append(order.History, transition)
+	return nil
+}
+
+// partyOrder loads an order the caller takes part in, along with the party they play on it.
+// Admins that aren't a party of the order act as the admin party.
+func (s *service) partyOrder(ctx context.Context, orderID string) (*models.Order, string, error) {
+	order, err := s.repository.GetOrderByID(ctx, orderID)
+	if err != nil {
+		return nil, "", err
+	}
+
+	_, party, err := auth.AuthorizeParty(ctx, auth.ActionManageOrders, order.PartyOf)
+	if err != nil {
+		return nil, "", err
+	}
+
+	return order, party, nil
+}
+
+type requirement func(*service)
+
+func Repository(repository repository.OrderRepository) requirement {
+	return func(s *service) {
+		s.repository = repository
+	}
+}
+
+func Escrow(escrow uranus.Escrow) requirement {
+	return func(s *service) {
+		s.escrow = escrow
+	}
+}
+
+// Follower moves the purchase request and the offer of an order along once the order ended.
+func Follower(follower uranus.OrderFollower) requirement {
+	return func(s *service) {
+		s.follower = follower
+	}
+}
+
+func Timeout(timeout time.Duration) requirement {
+	return func(s *service) {
+		s.contextTimeout = timeout
+	}
+}
+
+func Validator(validator uranus.Validate) requirement {
+	return func(s *service) {
+		s.validator = validator
+	}
+}
+
+func NewService(reqs ...requirement) uranus.OrderUsecase {
+	s := new(service)
+	for _, option := range reqs {
+		option(s)
+	}
+
+	return s
+}
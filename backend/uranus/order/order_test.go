@@ -0,0 +1,158 @@
+package order
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/globalsign/mgo/bson"
+
+	"github.com/fidellr/jastip/backend/uranus"
+	"github.com/fidellr/jastip/backend/uranus/auth"
+	"github.com/fidellr/jastip/backend/uranus/models"
+	"github.com/fidellr/jastip/backend/uranus/repository"
+)
+
+// memoryOrders moves an order only while it's still in the transition's from status, like the mongo repository.
+type memoryOrders struct {
+	repository.OrderRepository
+	mu     sync.Mutex
+	orders map[string]models.Order
+}
+
+func (r *memoryOrders) GetOrderByID(ctx context.Context, orderID string) (*models.Order, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	order, ok := r.orders[orderID]
+	if !ok {
+		return nil, uranus.ErrNotFound
+	}
+
+	return &order, nil
+}
+
+func (r *memoryOrders) AppendOrderTransition(ctx context.Context, orderID string, transition models.OrderTransition, outbox ...*models.OutboxMessage) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	order, ok := r.orders[orderID]
+	if !ok {
+		return uranus.ErrNotFound
+	}
+
+	if order.Status != transition.From {
+		return uranus.ErrStaleStatus
+	}
+
+	order.Status = transition.To
+	order.History = append(order.History, transition)
+	order.Outbox = append(order.Outbox, outbox...)
+	r.orders[orderID] = order
+	return nil
+}
+
+type noopEscrow struct{}
+
+func (noopEscrow) Release(ctx context.Context, order *models.Order) error { return nil }
+func (noopEscrow) Refund(ctx context.Context, order *models.Order) error  { return nil }
+func (noopEscrow) RefundPart(ctx context.Context, order *models.Order, amount int64) error {
+	return nil
+}
+
+type noopFollower struct{}
+
+func (noopFollower) FollowOrder(ctx context.Context, order *models.Order) error { return nil }
+
+func newTestService(order models.Order) (uranus.OrderUsecase, *memoryOrders) {
+	orders := &memoryOrders{orders: map[string]models.Order{order.ID.Hex(): order}}
+	return NewService(
+		Repository(orders),
+		Escrow(noopEscrow{}),
+		Follower(noopFollower{}),
+		Timeout(time.Second),
+		Validator(uranus.NewValidator()),
+	), orders
+}
+
+func asUser(userID bson.ObjectId, roleName string) context.Context {
+	return auth.NewContext(context.Background(), &models.Principal{UserID: userID.Hex(), Role: models.UserRole{RoleName: roleName}})
+}
+
+func TestMoveOrder(t *testing.T) {
+	buyerID, travelerID, adminID := bson.NewObjectId(), bson.NewObjectId(), bson.NewObjectId()
+	buyer := asUser(buyerID, models.RoleBuyer)
+	traveler := asUser(travelerID, models.RoleTraveler)
+	admin := asUser(adminID, models.RoleAdmin)
+
+	tests := []struct {
+		name    string
+		ctx     context.Context
+		from    string
+		to      string
+		wantErr bool
+	}{
+		{name: "traveler marks a paid order purchased", ctx: traveler, from: models.OrderPaid, to: models.OrderPurchased},
+		{name: "buyer can't mark an order purchased", ctx: buyer, from: models.OrderPaid, to: models.OrderPurchased, wantErr: true},
+		{name: "buyer completes a delivered order", ctx: buyer, from: models.OrderDelivered, to: models.OrderCompleted},
+		{name: "admin cancels a paid order", ctx: admin, from: models.OrderPaid, to: models.OrderCancelled},
+		{name: "nobody skips a status", ctx: admin, from: models.OrderPaid, to: models.OrderDelivered, wantErr: true},
+		{name: "ended orders don't move", ctx: admin, from: models.OrderCompleted, to: models.OrderCancelled, wantErr: true},
+		{name: "buyer can't dispute through a move", ctx: buyer, from: models.OrderDelivered, to: models.OrderDisputed, wantErr: true},
+		{name: "admin can't dispute through a move", ctx: admin, from: models.OrderPaid, to: models.OrderDisputed, wantErr: true},
+		{name: "admin can't take an order out of disputed through a move", ctx: admin, from: models.OrderDisputed, to: models.OrderCompleted, wantErr: true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			order := models.Order{ID: bson.NewObjectId(), BuyerID: buyerID, TravelerID: travelerID, Status: test.from}
+			service, orders := newTestService(order)
+
+			moved, err := service.MoveOrder(test.ctx, order.ID.Hex(), &models.OrderMove{Status: test.to})
+			if (err != nil) != test.wantErr {
+				t.Fatalf("MoveOrder(%s -> %s) error = %v, wantErr %v", test.from, test.to, err, test.wantErr)
+			}
+
+			stored := orders.orders[order.ID.Hex()]
+			if test.wantErr {
+				if stored.Status != test.from || len(stored.History) != 0 {
+					t.Errorf("refused move changed the order to %s with %d transitions", stored.Status, len(stored.History))
+				}
+
+				return
+			}
+
+			if moved.Status != test.to || stored.Status != test.to || len(stored.History) != 1 {
+				t.Errorf("order is %s, stored %s with %d transitions, want %s with 1", moved.Status, stored.Status, len(stored.History), test.to)
+			}
+		})
+	}
+}
+
+func TestAppendTransitionRejectsStaleStatus(t *testing.T) {
+	order := models.Order{ID: bson.NewObjectId(), BuyerID: bson.NewObjectId(), TravelerID: bson.NewObjectId(), Status: models.OrderPaid}
+	orders := &memoryOrders{orders: map[string]models.Order{order.ID.Hex(): order}}
+	s := &service{repository: orders, contextTimeout: time.Second}
+
+	// Both the traveler and an admin loaded the paid order, the traveler's move lands first.
+	travelers, admins := order, order
+	purchase := models.OrderTransition{From: models.OrderPaid, To: models.OrderPurchased, Party: models.OrderPartyTraveler, At: time.Now()}
+	if err := s.appendTransition(context.Background(), &travelers, purchase); err != nil {
+		t.Fatalf("first move: %v", err)
+	}
+
+	cancel := models.OrderTransition{From: models.OrderPaid, To: models.OrderCancelled, Party: models.OrderPartyAdmin, At: time.Now()}
+	if err := s.appendTransition(context.Background(), &admins, cancel); err != uranus.ErrStaleStatus {
+		t.Fatalf("stale move error = %v, want %v", err, uranus.ErrStaleStatus)
+	}
+
+	if admins.Status != models.OrderPaid || len(admins.History) != 0 {
+		t.Errorf("stale move changed the caller's order to %s", admins.Status)
+	}
+
+	stored := orders.orders[order.ID.Hex()]
+	if stored.Status != models.OrderPurchased || len(stored.History) != 1 {
+		t.Errorf("stored order is %s with %d transitions, want %s with 1", stored.Status, len(stored.History), models.OrderPurchased)
+	}
+}
@@ -0,0 +1,56 @@
+package uranus
+
+import (
+	"encoding/json"
+)
+
+// MergePatch applies an RFC 7396 JSON merge patch to the JSON document target.
+// Only the top level fields listed in mutable may appear in the patch.
+func MergePatch(target, patch []byte, mutable ...string) ([]byte, error) {
+	var patchDoc map[string]interface{}
+	if err := json.Unmarshal(patch, &patchDoc); err != nil {
+		return nil, ConstraintErrorf("Merge patch must be a JSON object: %s", err.Error())
+	}
+
+	allowed := make(map[string]bool, len(mutable))
+	for _, field := range mutable {
+		allowed[field] = true
+	}
+
+	for field := range patchDoc {
+		if !allowed[field] {
+			return nil, ConstraintErrorf("Field %s can not be patched", field)
+		}
+	}
+
+	targetDoc := make(map[string]interface{})
+	if err := json.Unmarshal(target, &targetDoc); err != nil {
+		return nil, err
+	}
+
+	return json.Marshal(mergeObject(targetDoc, patchDoc))
+}
+
+func mergeObject(target, patch map[string]interface{}) map[string]interface{} {
+	for key, value := range patch {
+		if value == nil {
+			delete(target, key)
+			continue
+		}
+
+		patchObject, isObject := value.(map[string]interface{})
+		if !isObject {
+			target[key] = value
+			continue
+		}
+
+		targetObject, ok := target[key].(map[string]interface{})
+		if !ok {
+			targetObject = make(map[string]interface{})
+		}
+
+		target[key] = mergeObject(targetObject, patchObject)
+	}
+
+	return target
+}
@@ -0,0 +1,86 @@
+package uranus
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+func TestMergePatch(t *testing.T) {
+	target := `{"id":"5c1","first_name":"Ana","role":{"role_name":"buyer"},"address":{"city":"Jakarta","zip":"10110"},"tags":["a","b"]}`
+
+	tests := []struct {
+		name    string
+		patch   string
+		mutable []string
+		want    string
+		wantErr bool
+	}{
+		{
+			name:    "replaces a field",
+			patch:   `{"first_name":"Ani"}`,
+			mutable: []string{"first_name", "address"},
+			want:    `{"id":"5c1","first_name":"Ani","role":{"role_name":"buyer"},"address":{"city":"Jakarta","zip":"10110"},"tags":["a","b"]}`,
+		},
+		{
+			name:    "merges nested objects and removes nulls",
+			patch:   `{"address":{"city":"Bandung","zip":null}}`,
+			mutable: []string{"address"},
+			want:    `{"id":"5c1","first_name":"Ana","role":{"role_name":"buyer"},"address":{"city":"Bandung"},"tags":["a","b"]}`,
+		},
+		{
+			name:    "replaces arrays whole",
+			patch:   `{"tags":["c"]}`,
+			mutable: []string{"tags"},
+			want:    `{"id":"5c1","first_name":"Ana","role":{"role_name":"buyer"},"address":{"city":"Jakarta","zip":"10110"},"tags":["c"]}`,
+		},
+		{
+			name:    "rejects a protected field",
+			patch:   `{"role":{"role_name":"admin"}}`,
+			mutable: []string{"first_name", "address"},
+			wantErr: true,
+		},
+		{
+			name:    "rejects removing a protected field",
+			patch:   `{"id":null}`,
+			mutable: []string{"first_name"},
+			wantErr: true,
+		},
+		{
+			name:    "rejects a protected field next to allowed ones",
+			patch:   `{"first_name":"Ani","role":{"role_name":"admin"}}`,
+			mutable: []string{"first_name"},
+			wantErr: true,
+		},
+		{
+			name:    "rejects a patch that isn't an object",
+			patch:   `["first_name"]`,
+			mutable: []string{"first_name"},
+			wantErr: true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got, err := MergePatch([]byte(target), []byte(test.patch), test.mutable...)
+			if test.wantErr {
+				if _, ok := err.(ConstraintError); !ok {
+					t.Fatalf("MergePatch error = %v, want a ConstraintError", err)
+				}
+
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("MergePatch: %v", err)
+			}
+
+			var gotDoc, wantDoc map[string]interface{}
+			json.Unmarshal(got, &gotDoc)
+			json.Unmarshal([]byte(test.want), &wantDoc)
+			if !reflect.DeepEqual(gotDoc, wantDoc) {
+				t.Errorf("MergePatch = %s, want %s", got, test.want)
+			}
+		})
+	}
+}
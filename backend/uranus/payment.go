@@ -0,0 +1,35 @@
+package uranus
+
+import (
+	"context"
+
+	"github.com/fidellr/jastip/backend/uranus/models"
+)
+
+type PaymentUsecase interface {
+	PayOrder(ctx context.Context, orderID string) (*models.Payment, error)
+	HandleWebhook(ctx context.Context, payload []byte, signature string) error
+	SettleOrder(ctx context.Context, orderID string) error
+	SettleEndedOrders(ctx context.Context) (int, error)
+	FetchJournalEntries(ctx context.Context, orderID string) ([]*models.JournalEntry, error)
+	GetBalance(ctx context.Context, account string) (*models.LedgerBalance, error)
+}
+
+// Escrow holds a buyer's payment until the order ends, then pays the traveler or refunds the buyer.
+// RefundPart gives the buyer part of the money back and leaves the rest held for Release.
+type Escrow interface {
+	Release(ctx context.Context, order *models.Order) error
+	Refund(ctx context.Context, order *models.Order) error
+	RefundPart(ctx context.Context, order *models.Order, amount int64) error
+}
+
+// PaymentGateway collects payments from buyers and pays them back, Refund pays back the amount of the charge
+// once per key, a refund asked again under the same key is not paid twice.
+// Charge charges the payment under its GatewayRef, which is stored before the charge so the gateway's webhook
+// always finds the payment. It returns the outcome when the gateway answers right away,
+// a nil event means the outcome comes later through the gateway's webhook.
+type PaymentGateway interface {
+	Charge(ctx context.Context, m *models.Payment) (*models.PaymentEvent, error)
+	Refund(ctx context.Context, m *models.Payment, amount int64, key string) error
+	ParseWebhook(payload []byte, signature string) (*models.PaymentEvent, error)
+}
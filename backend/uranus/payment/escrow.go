@@ -0,0 +1,224 @@
+package payment
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/fidellr/jastip/backend/uranus"
+	"github.com/fidellr/jastip/backend/uranus/models"
+)
+
+// Release pays out whatever the order's escrow holds once the order completed,
+// the platform fees and taxes of the order's fee breakdown first and the rest to the traveler.
+func (s *service) Release(ctx context.Context, order *models.Order) error {
+	if ctx == nil {
+		return uranus.ErrContextNil
+	}
+
+	escrow := models.EscrowAccount(order.ID)
+	held, err := s.held(ctx, escrow)
+	if err != nil {
+		return err
+	}
+
+	if held <= 0 {
+		return s.markSettled(ctx, order)
+	}
+
+	currency := order.Total.Currency
+	entry := &models.JournalEntry{
+		Key:         "release:" + order.ID.Hex(),
+		OrderID:     order.ID,
+		Description: fmt.Sprintf("Escrow of order %s released", order.ID.Hex()),
+		Currency:    currency,
+		Lines:       []models.JournalLine{{Account: escrow, Debit: held}},
+	}
+
+	accounts := []*models.LedgerAccount{}
+	remaining := held
+	if order.Fees != nil {
+		platformFees, err := order.Fees.Sum(models.FeePlatform)
+		if err != nil {
+			return err
+		}
+
+		taxes, err := order.Fees.Sum(models.FeeTax)
+		if err != nil {
+			return err
+		}
+
+		shares := []struct {
+			account *models.LedgerAccount
+			amount  int64
+		}{
+			{&models.LedgerAccount{Code: models.PlatformRevenueAccount(currency), Type: models.LedgerRevenue, Currency: currency}, platformFees.Amount},
+			{&models.LedgerAccount{Code: models.TaxPayableAccount(currency), Type: models.LedgerLiability, Currency: currency}, taxes.Amount},
+		}
+
+		for _, share := range shares {
+			amount := share.amount
+			if amount > remaining {
+				amount = remaining
+			}
+
+			if amount <= 0 {
+				continue
+			}
+
+			entry.Lines = append(entry.Lines, models.JournalLine{Account: share.account.Code, Credit: amount})
+			accounts = append(accounts, share.account)
+			remaining -= amount
+		}
+	}
+
+	if remaining > 0 {
+		traveler := &models.LedgerAccount{Code: models.TravelerAccount(order.TravelerID), Type: models.LedgerLiability, Currency: currency}
+		entry.Lines = append(entry.Lines, models.JournalLine{Account: traveler.Code, Credit: remaining})
+		accounts = append(accounts, traveler)
+	}
+
+	if err = s.post(ctx, entry, accounts...); err != nil {
+		return err
+	}
+
+	return s.markSettled(ctx, order)
+}
+
+// Refund pays whatever the order's escrow holds back to the buyer through the gateway, once the order got cancelled.
+func (s *service) Refund(ctx context.Context, order *models.Order) error {
+	if ctx == nil {
+		return uranus.ErrContextNil
+	}
+
+	escrow := models.EscrowAccount(order.ID)
+	held, err := s.held(ctx, escrow)
+	if err != nil {
+		return err
+	}
+
+	if held <= 0 {
+		return s.markSettled(ctx, order)
+	}
+
+	entry := &models.JournalEntry{
+		Key:         "refund:" + order.ID.Hex(),
+		OrderID:     order.ID,
+		Description: fmt.Sprintf("Escrow of order %s refunded to the buyer", order.ID.Hex()),
+		Currency:    order.Total.Currency,
+		Lines: []models.JournalLine{
+			{Account: escrow, Debit: held},
+			{Account: models.GatewayClearingAccount(order.Total.Currency), Credit: held},
+		},
+	}
+
+	payment, err := s.claimRefund(ctx, order, models.PaymentRefunded, held, held)
+	switch err {
+	case nil:
+		if err = s.gateway.Refund(ctx, payment, held, entry.Key); err != nil {
+			return err
+		}
+	case uranus.ErrNotFound:
+	default:
+		return err
+	}
+
+	if err = s.post(ctx, entry); err != nil {
+		return err
+	}
+
+	return s.markSettled(ctx, order)
+}
+
+// RefundPart pays part of what the order's escrow holds back to the buyer through the gateway,
+// like a dispute settled with a partial refund. The rest stays in escrow for Release.
+func (s *service) RefundPart(ctx context.Context, order *models.Order, amount int64) error {
+	if ctx == nil {
+		return uranus.ErrContextNil
+	}
+
+	escrow := models.EscrowAccount(order.ID)
+	held, err := s.held(ctx, escrow)
+	if err != nil {
+		return err
+	}
+
+	entry := &models.JournalEntry{
+		Key:         "refund-part:" + order.ID.Hex(),
+		OrderID:     order.ID,
+		Description: fmt.Sprintf("Part of the escrow of order %s refunded to the buyer", order.ID.Hex()),
+		Currency:    order.Total.Currency,
+		Lines: []models.JournalLine{
+			{Account: escrow, Debit: amount},
+			{Account: models.GatewayClearingAccount(order.Total.Currency), Credit: amount},
+		},
+	}
+
+	// A retry finds the payment partially refunded and posts the entry again,
+	// which is a no-op once it was posted even if the rest was released meanwhile.
+	payment, err := s.claimRefund(ctx, order, models.PaymentPartiallyRefunded, amount, held)
+	if err != nil {
+		return err
+	}
+
+	if err = s.gateway.Refund(ctx, payment, amount, entry.Key); err != nil {
+		return err
+	}
+
+	return s.post(ctx, entry)
+}
+
+// claimRefund marks the order's succeeded payment with the refund status before any money moves, the compare-and-set
+// on its status lets only one of concurrent settlements claim it. Whoever finds the payment claimed already gets it
+// as it is and refunds it again under the entry's key, the gateway pays a key only once.
+// The amount is checked against what the escrow holds only when the payment is claimed.
+func (s *service) claimRefund(ctx context.Context, order *models.Order, status string, amount int64, held int64) (*models.Payment, error) {
+	payment, err := s.repository.GetPaymentByOrder(ctx, order.ID.Hex(), models.PaymentSucceeded)
+	if err == nil {
+		if amount <= 0 || amount > held {
+			return nil, uranus.ConstraintErrorf("Escrow of order %s holds %d, %d can't be refunded from it", order.ID.Hex(), held, amount)
+		}
+
+		payment.Status = status
+		payment.Refunded += amount
+		payment.UpdatedAt = time.Now()
+		if err = s.repository.UpdatePayment(ctx, payment, models.PaymentSucceeded); err == nil {
+			return payment, nil
+		}
+	}
+
+	if err != uranus.ErrNotFound && err != uranus.ErrStaleStatus {
+		return nil, err
+	}
+
+	return s.repository.GetPaymentByOrder(ctx, order.ID.Hex(), status)
+}
+
+// markSettled records that the escrow of an ended order was paid out, the settlement sweeper skips it from then on.
+func (s *service) markSettled(ctx context.Context, order *models.Order) error {
+	if !order.HasEnded() || order.SettledAt != nil {
+		return nil
+	}
+
+	now := time.Now()
+	if err := s.orderRepository.MarkOrderSettled(ctx, order.ID.Hex(), now); err != nil {
+		return err
+	}
+
+	order.SettledAt = &now
+	return nil
+}
+
+// held is what an escrow account still holds, nothing when the order was never paid.
+func (s *service) held(ctx context.Context, escrow string) (int64, error) {
+	balance, err := s.balance(ctx, escrow)
+	if err == uranus.ErrNotFound {
+		return 0, nil
+	}
+
+	if err != nil {
+		return 0, err
+	}
+
+	return balance.Balance, nil
+}
@@ -0,0 +1,525 @@
+package payment
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/globalsign/mgo/bson"
+
+	"github.com/fidellr/jastip/backend/uranus"
+	"github.com/fidellr/jastip/backend/uranus/models"
+	"github.com/fidellr/jastip/backend/uranus/repository"
+)
+
+type memoryLedger struct {
+	mu       sync.Mutex
+	accounts map[string]*models.LedgerAccount
+	entries  []*models.JournalEntry
+}
+
+func (r *memoryLedger) EnsureAccount(ctx context.Context, m *models.LedgerAccount) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.accounts[m.Code]; !ok {
+		r.accounts[m.Code] = m
+	}
+
+	return nil
+}
+
+func (r *memoryLedger) GetAccount(ctx context.Context, code string) (*models.LedgerAccount, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	account, ok := r.accounts[code]
+	if !ok {
+		return nil, uranus.ErrNotFound
+	}
+
+	return account, nil
+}
+
+func (r *memoryLedger) StoreJournalEntry(ctx context.Context, m *models.JournalEntry) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, entry := range r.entries {
+		if entry.Key == m.Key {
+			return uranus.ErrAlreadyPosted
+		}
+	}
+
+	r.entries = append(r.entries, m)
+	return nil
+}
+
+func (r *memoryLedger) FetchJournalEntries(ctx context.Context, orderID string) ([]*models.JournalEntry, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	entries := make([]*models.JournalEntry, 0)
+	for _, entry := range r.entries {
+		if entry.OrderID.Hex() == orderID {
+			entries = append(entries, entry)
+		}
+	}
+
+	return entries, nil
+}
+
+func (r *memoryLedger) GetBalance(ctx context.Context, code string) (*models.LedgerBalance, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	balance := &models.LedgerBalance{Account: code}
+	for _, entry := range r.entries {
+		for _, line := range entry.Lines {
+			if line.Account == code {
+				balance.Debits += line.Debit
+				balance.Credits += line.Credit
+			}
+		}
+	}
+
+	return balance, nil
+}
+
+type memoryPayments struct {
+	mu       sync.Mutex
+	payments map[bson.ObjectId]models.Payment
+}
+
+func (r *memoryPayments) StorePayment(ctx context.Context, m *models.Payment) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.payments[m.ID] = *m
+	return nil
+}
+
+func (r *memoryPayments) GetPaymentByGatewayRef(ctx context.Context, gatewayRef string) (*models.Payment, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, payment := range r.payments {
+		if payment.GatewayRef == gatewayRef {
+			return &payment, nil
+		}
+	}
+
+	return nil, uranus.ErrNotFound
+}
+
+func (r *memoryPayments) GetPaymentByOrder(ctx context.Context, orderID string, status string) (*models.Payment, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, payment := range r.payments {
+		if payment.OrderID.Hex() == orderID && payment.Status == status {
+			return &payment, nil
+		}
+	}
+
+	return nil, uranus.ErrNotFound
+}
+
+func (r *memoryPayments) UpdatePayment(ctx context.Context, m *models.Payment, from string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.payments[m.ID].Status != from {
+		return uranus.ErrStaleStatus
+	}
+
+	r.payments[m.ID] = *m
+	return nil
+}
+
+// memoryOrders only knows the orders it was given, the escrow doesn't need the rest of the repository.
+type memoryOrders struct {
+	repository.OrderRepository
+	mu      sync.Mutex
+	orders  map[string]*models.Order
+	settled map[string]bool
+}
+
+func (r *memoryOrders) GetOrderByID(ctx context.Context, orderID string) (*models.Order, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	order, ok := r.orders[orderID]
+	if !ok {
+		return nil, uranus.ErrNotFound
+	}
+
+	copied := *order
+	return &copied, nil
+}
+
+func (r *memoryOrders) MarkOrderSettled(ctx context.Context, orderID string, at time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.settled[orderID] = true
+	return nil
+}
+
+// memoryGateway pays each refund key once, failNext makes the next refund fail before anything is paid.
+type memoryGateway struct {
+	uranus.PaymentGateway
+	mu       sync.Mutex
+	refunds  map[string]int64
+	failNext bool
+}
+
+func (g *memoryGateway) Refund(ctx context.Context, m *models.Payment, amount int64, key string) error {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	if g.failNext {
+		g.failNext = false
+		return errors.New("gateway unavailable")
+	}
+
+	if _, ok := g.refunds[key]; !ok {
+		g.refunds[key] = amount
+	}
+
+	return nil
+}
+
+func (g *memoryGateway) refunded() int64 {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	var total int64
+	for _, amount := range g.refunds {
+		total += amount
+	}
+
+	return total
+}
+
+type escrowFixture struct {
+	service  *service
+	ledger   *memoryLedger
+	payments *memoryPayments
+	orders   *memoryOrders
+	gateway  *memoryGateway
+	order    *models.Order
+	payment  *models.Payment
+}
+
+func idr(amount int64) models.Money {
+	return models.Money{Amount: amount, Currency: "IDR"}
+}
+
+// newEscrowFixture has an order of 1.200.000 with 80.000 in platform fees and 110.000 in taxes, paid but not captured yet.
+func newEscrowFixture(status string) *escrowFixture {
+	f := &escrowFixture{
+		ledger:   &memoryLedger{accounts: make(map[string]*models.LedgerAccount)},
+		payments: &memoryPayments{payments: make(map[bson.ObjectId]models.Payment)},
+		orders:   &memoryOrders{orders: make(map[string]*models.Order), settled: make(map[string]bool)},
+		gateway:  &memoryGateway{refunds: make(map[string]int64)},
+	}
+
+	f.order = &models.Order{
+		ID:         bson.NewObjectId(),
+		BuyerID:    bson.NewObjectId(),
+		TravelerID: bson.NewObjectId(),
+		Status:     status,
+		Total:      idr(1200000),
+		Fees: &models.FeeBreakdown{
+			Items: idr(910000),
+			Total: idr(1200000),
+			Lines: []models.FeeLine{
+				{Kind: models.FeePlatform, Rule: "service", Amount: idr(50000)},
+				{Kind: models.FeePlatform, Rule: "commission", Amount: idr(30000)},
+				{Kind: models.FeeTraveler, Amount: idr(100000)},
+				{Kind: models.FeeTax, Rule: "vat", Amount: idr(110000)},
+			},
+		},
+	}
+	f.orders.orders[f.order.ID.Hex()] = f.order
+
+	f.payment = &models.Payment{
+		ID:         bson.NewObjectId(),
+		OrderID:    f.order.ID,
+		BuyerID:    f.order.BuyerID,
+		Amount:     f.order.Total,
+		GatewayRef: "pay_test",
+		Status:     models.PaymentPending,
+	}
+	f.payments.payments[f.payment.ID] = *f.payment
+
+	f.service = newService(
+		Repository(f.payments),
+		LedgerRepository(f.ledger),
+		OrderRepository(f.orders),
+		Gateway(f.gateway),
+		Timeout(time.Second),
+	)
+
+	return f
+}
+
+// capture settles the pending payment as succeeded, a capture that lost the race to another one is stale.
+func (f *escrowFixture) capture(t *testing.T) {
+	payment := *f.payment
+	event := &models.PaymentEvent{GatewayRef: payment.GatewayRef, Status: models.PaymentSucceeded, OccurredAt: time.Now()}
+	if err := f.service.settle(context.Background(), &payment, event); err != nil && err != uranus.ErrStaleStatus {
+		t.Fatalf("capture: %v", err)
+	}
+}
+
+// balances nets every account on its credit side.
+func (f *escrowFixture) balances() map[string]int64 {
+	balances := make(map[string]int64)
+	for _, entry := range f.ledger.entries {
+		for _, line := range entry.Lines {
+			balances[line.Account] += line.Credit - line.Debit
+		}
+	}
+
+	return balances
+}
+
+func (f *escrowFixture) keys() []string {
+	keys := make([]string, 0, len(f.ledger.entries))
+	for _, entry := range f.ledger.entries {
+		keys = append(keys, entry.Key[:len(entry.Key)-len(f.order.ID.Hex())-1])
+	}
+
+	sort.Strings(keys)
+	return keys
+}
+
+func TestEscrow(t *testing.T) {
+	tests := []struct {
+		name     string
+		status   string
+		settle   func(f *escrowFixture) error
+		keys     []string
+		balances func(f *escrowFixture) map[string]int64
+		refunded int64
+		payment  string
+	}{
+		{
+			name:   "release splits off platform fees and tax and pays the traveler the rest",
+			status: models.OrderCompleted,
+			settle: func(f *escrowFixture) error {
+				return f.service.Release(context.Background(), f.order)
+			},
+			keys: []string{"capture", "release"},
+			balances: func(f *escrowFixture) map[string]int64 {
+				return map[string]int64{
+					models.GatewayClearingAccount("IDR"):       -1200000,
+					models.EscrowAccount(f.order.ID):           0,
+					models.PlatformRevenueAccount("IDR"):       80000,
+					models.TaxPayableAccount("IDR"):            110000,
+					models.TravelerAccount(f.order.TravelerID): 1010000,
+				}
+			},
+			payment: models.PaymentSucceeded,
+		},
+		{
+			name:   "refund pays the whole escrow back to the buyer",
+			status: models.OrderCancelled,
+			settle: func(f *escrowFixture) error {
+				return f.service.Refund(context.Background(), f.order)
+			},
+			keys: []string{"capture", "refund"},
+			balances: func(f *escrowFixture) map[string]int64 {
+				return map[string]int64{
+					models.GatewayClearingAccount("IDR"): 0,
+					models.EscrowAccount(f.order.ID):     0,
+				}
+			},
+			refunded: 1200000,
+			payment:  models.PaymentRefunded,
+		},
+		{
+			name:   "release after a partial refund pays the traveler only the remainder",
+			status: models.OrderCompleted,
+			settle: func(f *escrowFixture) error {
+				if err := f.service.RefundPart(context.Background(), f.order, 300000); err != nil {
+					return err
+				}
+
+				return f.service.Release(context.Background(), f.order)
+			},
+			keys: []string{"capture", "refund-part", "release"},
+			balances: func(f *escrowFixture) map[string]int64 {
+				return map[string]int64{
+					models.GatewayClearingAccount("IDR"):       -900000,
+					models.EscrowAccount(f.order.ID):           0,
+					models.PlatformRevenueAccount("IDR"):       80000,
+					models.TaxPayableAccount("IDR"):            110000,
+					models.TravelerAccount(f.order.TravelerID): 710000,
+				}
+			},
+			refunded: 300000,
+			payment:  models.PaymentPartiallyRefunded,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			f := newEscrowFixture(test.status)
+
+			// Every step runs twice, like a retried webhook or a settlement the sweeper picks up again.
+			for i := 0; i < 2; i++ {
+				f.capture(t)
+				if err := test.settle(f); err != nil {
+					t.Fatalf("settle #%d: %v", i+1, err)
+				}
+			}
+
+			var debits, credits int64
+			for _, entry := range f.ledger.entries {
+				if !entry.IsBalanced() {
+					t.Errorf("entry %s is not balanced: %+v", entry.Key, entry.Lines)
+				}
+
+				for _, line := range entry.Lines {
+					debits += line.Debit
+					credits += line.Credit
+				}
+			}
+
+			if debits != credits {
+				t.Errorf("journal debits %d and credits %d don't net to zero", debits, credits)
+			}
+
+			if keys := f.keys(); !equalStrings(keys, test.keys) {
+				t.Errorf("posted %v, want %v", keys, test.keys)
+			}
+
+			balances := f.balances()
+			for account, want := range test.balances(f) {
+				if balances[account] != want {
+					t.Errorf("balance of %s = %d, want %d", account, balances[account], want)
+				}
+			}
+
+			if refunded := f.gateway.refunded(); refunded != test.refunded {
+				t.Errorf("gateway refunded %d, want %d", refunded, test.refunded)
+			}
+
+			payment := f.payments.payments[f.payment.ID]
+			if payment.Status != test.payment || payment.Refunded != test.refunded {
+				t.Errorf("payment is %s with %d refunded, want %s with %d", payment.Status, payment.Refunded, test.payment, test.refunded)
+			}
+
+			if !f.orders.settled[f.order.ID.Hex()] {
+				t.Errorf("order wasn't marked settled")
+			}
+		})
+	}
+}
+
+func TestRefundRetriesFailedGatewayRefund(t *testing.T) {
+	f := newEscrowFixture(models.OrderCancelled)
+	f.order.Status = models.OrderCompleted
+	f.capture(t)
+	f.order.Status = models.OrderCancelled
+
+	f.gateway.failNext = true
+	if err := f.service.Refund(context.Background(), f.order); err == nil {
+		t.Fatalf("refund with a failing gateway succeeded")
+	}
+
+	if err := f.service.Refund(context.Background(), f.order); err != nil {
+		t.Fatalf("retried refund: %v", err)
+	}
+
+	if refunded := f.gateway.refunded(); refunded != 1200000 {
+		t.Errorf("gateway refunded %d, want 1200000", refunded)
+	}
+
+	if payment := f.payments.payments[f.payment.ID]; payment.Refunded != 1200000 {
+		t.Errorf("payment has %d refunded, want 1200000", payment.Refunded)
+	}
+}
+
+func TestConcurrentRefundsPayOnce(t *testing.T) {
+	f := newEscrowFixture(models.OrderCompleted)
+	f.capture(t)
+	f.order.Status = models.OrderCancelled
+
+	var wg sync.WaitGroup
+	errs := make(chan error, 4)
+	for i := 0; i < cap(errs); i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			order := *f.order
+			errs <- f.service.Refund(context.Background(), &order)
+		}()
+	}
+
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		if err != nil {
+			t.Errorf("refund: %v", err)
+		}
+	}
+
+	if refunded := f.gateway.refunded(); refunded != 1200000 {
+		t.Errorf("gateway refunded %d, want 1200000", refunded)
+	}
+
+	if payment := f.payments.payments[f.payment.ID]; payment.Refunded != 1200000 {
+		t.Errorf("payment has %d refunded, want 1200000", payment.Refunded)
+	}
+}
+
+func TestRefundPartBounds(t *testing.T) {
+	tests := []struct {
+		name    string
+		amount  int64
+		wantErr bool
+	}{
+		{name: "nothing", amount: 0, wantErr: true},
+		{name: "negative", amount: -1, wantErr: true},
+		{name: "more than held", amount: 1200001, wantErr: true},
+		{name: "everything held", amount: 1200000},
+		{name: "part", amount: 1},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			f := newEscrowFixture(models.OrderCompleted)
+			f.capture(t)
+
+			err := f.service.RefundPart(context.Background(), f.order, test.amount)
+			if (err != nil) != test.wantErr {
+				t.Fatalf("RefundPart(%d) error = %v, wantErr %v", test.amount, err, test.wantErr)
+			}
+
+			if test.wantErr && f.payments.payments[f.payment.ID].Status != models.PaymentSucceeded {
+				t.Errorf("rejected refund claimed the payment")
+			}
+		})
+	}
+}
+
+func equalStrings(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+
+	return true
+}
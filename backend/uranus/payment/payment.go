@@ -0,0 +1,412 @@
+package payment
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/globalsign/mgo/bson"
+
+	"github.com/fidellr/jastip/backend/uranus"
+	"github.com/fidellr/jastip/backend/uranus/auth"
+	"github.com/fidellr/jastip/backend/uranus/models"
+	"github.com/fidellr/jastip/backend/uranus/repository"
+)
+
+// unsettledBatch caps how many unsettled orders one sweep settles.
+const unsettledBatch = 100
+
+type service struct {
+	repository       repository.PaymentRepository
+	ledgerRepository repository.LedgerRepository
+	orderRepository  repository.OrderRepository
+	gateway          uranus.PaymentGateway
+	follower         uranus.OrderFollower
+	contextTimeout   time.Duration
+}
+
+// PayOrder charges the buyer for an order awaiting payment, the money goes into the order's escrow once the charge succeeds.
+func (s *service) PayOrder(ctx context.Context, orderID string) (*models.Payment, error) {
+	if ctx == nil {
+		err := uranus.ErrContextNil
+		return nil, err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, s.contextTimeout)
+	defer cancel()
+
+	order, err := s.orderRepository.GetOrderByID(ctx, orderID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err = auth.AuthorizeOwner(ctx, auth.ActionManagePayments, order.BuyerID.Hex()); err != nil {
+		return nil, err
+	}
+
+	if order.Status != models.OrderAwaitingPayment {
+		return nil, uranus.ConstraintErrorf("Order is %s, only orders awaiting payment can be paid", order.Status)
+	}
+
+	_, err = s.repository.GetPaymentByOrder(ctx, orderID, models.PaymentPending)
+	if err == nil {
+		return nil, uranus.ConstraintErrorf("Order already has a pending payment, wait for the gateway to settle it")
+	}
+
+	if err != uranus.ErrNotFound {
+		return nil, err
+	}
+
+	payment := &models.Payment{
+		ID:        bson.NewObjectId(),
+		CreatedAt: time.Now(),
+		UpdatedAt: time.Now(),
+		OrderID:   order.ID,
+		BuyerID:   order.BuyerID,
+		Amount:    order.Total,
+		Status:    models.PaymentPending,
+	}
+	payment.GatewayRef = "pay_" + payment.ID.Hex()
+
+	if err = s.repository.StorePayment(ctx, payment); err != nil {
+		return nil, err
+	}
+
+	event, err := s.gateway.Charge(ctx, payment)
+	if err != nil {
+		event = &models.PaymentEvent{
+			GatewayRef:    payment.GatewayRef,
+			Status:        models.PaymentFailed,
+			FailureReason: err.Error(),
+			OccurredAt:    time.Now(),
+		}
+	}
+
+	if event == nil {
+		payment.UpdatedAt = time.Now()
+		if err = s.repository.UpdatePayment(ctx, payment, models.PaymentPending); err != nil {
+			return nil, err
+		}
+
+		return payment, nil
+	}
+
+	if err = s.settle(ctx, payment, event); err != nil {
+		return nil, err
+	}
+
+	return payment, nil
+}
+
+// HandleWebhook settles the payment the gateway reports on, a callback for an already settled payment is ignored
+// so the gateway may deliver the same callback more than once.
+func (s *service) HandleWebhook(ctx context.Context, payload []byte, signature string) error {
+	if ctx == nil {
+		return uranus.ErrContextNil
+	}
+
+	event, err := s.gateway.ParseWebhook(payload, signature)
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, s.contextTimeout)
+	defer cancel()
+
+	payment, err := s.repository.GetPaymentByGatewayRef(ctx, event.GatewayRef)
+	if err != nil {
+		return err
+	}
+
+	if payment.Status != models.PaymentPending {
+		return nil
+	}
+
+	return s.settle(ctx, payment, event)
+}
+
+// SettleOrder releases or refunds the escrow of an order that ended, for when doing so failed as the order moved.
+func (s *service) SettleOrder(ctx context.Context, orderID string) error {
+	if ctx == nil {
+		return uranus.ErrContextNil
+	}
+
+	if err := auth.Authorize(ctx, auth.ActionManagePayments); err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, s.contextTimeout)
+	defer cancel()
+
+	order, err := s.orderRepository.GetOrderByID(ctx, orderID)
+	if err != nil {
+		return err
+	}
+
+	return s.settleOrder(ctx, order)
+}
+
+// settleOrder releases the escrow of a completed order or refunds the escrow of a cancelled one.
+func (s *service) settleOrder(ctx context.Context, order *models.Order) error {
+	switch order.Status {
+	case models.OrderCompleted:
+		return s.Release(ctx, order)
+	case models.OrderCancelled:
+		return s.Refund(ctx, order)
+	}
+
+	return uranus.ConstraintErrorf("Order is %s, only completed or cancelled orders can be settled", order.Status)
+}
+
+// SettleEndedOrders follows up and settles the orders that ended without their escrow being paid out,
+// like when settling failed right after the order moved. Orders that ended out of a dispute are left to
+// the dispute service, which pays out along the dispute's outcome.
+func (s *service) SettleEndedOrders(ctx context.Context) (int, error) {
+	if ctx == nil {
+		return 0, uranus.ErrContextNil
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, s.contextTimeout)
+	defer cancel()
+
+	// Orders that only just ended are still being settled by whoever moved them.
+	orders, err := s.orderRepository.FetchUnsettledOrders(ctx, time.Now().Add(-s.contextTimeout), unsettledBatch)
+	if err != nil {
+		return 0, err
+	}
+
+	settled := 0
+	for _, order := range orders {
+		if err = s.follower.FollowOrder(ctx, order); err == nil {
+			err = s.settleOrder(ctx, order)
+		}
+
+		if err != nil {
+			log.Printf("Failed to settle order %s : %s", order.ID.Hex(), err.Error())
+			continue
+		}
+
+		settled++
+	}
+
+	return settled, nil
+}
+
+func (s *service) FetchJournalEntries(ctx context.Context, orderID string) ([]*models.JournalEntry, error) {
+	if ctx == nil {
+		err := uranus.ErrContextNil
+		return nil, err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, s.contextTimeout)
+	defer cancel()
+
+	order, err := s.orderRepository.GetOrderByID(ctx, orderID)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, _, err = auth.AuthorizeParty(ctx, auth.ActionManagePayments, order.PartyOf); err != nil {
+		return nil, err
+	}
+
+	return s.ledgerRepository.FetchJournalEntries(ctx, orderID)
+}
+
+// GetBalance gets the balance of a ledger account, travelers may read their own account.
+func (s *service) GetBalance(ctx context.Context, account string) (*models.LedgerBalance, error) {
+	if ctx == nil {
+		err := uranus.ErrContextNil
+		return nil, err
+	}
+
+	principal, _ := auth.FromContext(ctx)
+	if principal == nil || !bson.IsObjectIdHex(principal.UserID) || account != models.TravelerAccount(bson.ObjectIdHex(principal.UserID)) {
+		if err := auth.Authorize(ctx, auth.ActionManagePayments); err != nil {
+			return nil, err
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, s.contextTimeout)
+	defer cancel()
+
+	return s.balance(ctx, account)
+}
+
+// settle records the outcome of a pending payment, a successful one is held in escrow and marks the order paid.
+func (s *service) settle(ctx context.Context, payment *models.Payment, event *models.PaymentEvent) error {
+	switch event.Status {
+	case models.PaymentSucceeded:
+		escrow := models.EscrowAccount(payment.OrderID)
+		clearing := models.GatewayClearingAccount(payment.Amount.Currency)
+		entry := &models.JournalEntry{
+			Key:         "capture:" + payment.ID.Hex(),
+			OrderID:     payment.OrderID,
+			Description: fmt.Sprintf("Payment %s of %s held in escrow", payment.GatewayRef, payment.Amount),
+			Currency:    payment.Amount.Currency,
+			Lines: []models.JournalLine{
+				{Account: clearing, Debit: payment.Amount.Amount},
+				{Account: escrow, Credit: payment.Amount.Amount},
+			},
+		}
+
+		accounts := []*models.LedgerAccount{
+			{Code: clearing, Type: models.LedgerAsset, Currency: payment.Amount.Currency},
+			{Code: escrow, Type: models.LedgerLiability, Currency: payment.Amount.Currency},
+		}
+
+		if err := s.post(ctx, entry, accounts...); err != nil {
+			return err
+		}
+	case models.PaymentFailed:
+		payment.FailureReason = event.FailureReason
+	default:
+		return uranus.ConstraintErrorf("Unknown payment status %s", event.Status)
+	}
+
+	payment.Status = event.Status
+	payment.UpdatedAt = time.Now()
+	if err := s.repository.UpdatePayment(ctx, payment, models.PaymentPending); err != nil {
+		return err
+	}
+
+	if payment.Status != models.PaymentSucceeded {
+		return nil
+	}
+
+	return s.markPaid(ctx, payment)
+}
+
+// markPaid moves the order of a successful payment to paid,
+// the buyer may have cancelled the order while the payment was pending, the money goes back then.
+func (s *service) markPaid(ctx context.Context, payment *models.Payment) error {
+	order, err := s.orderRepository.GetOrderByID(ctx, payment.OrderID.Hex())
+	if err != nil {
+		return err
+	}
+
+	if !order.CanMove(models.OrderPartySystem, models.OrderPaid) {
+		if order.Status == models.OrderCancelled {
+			return s.Refund(ctx, order)
+		}
+
+		return nil
+	}
+
+	transition := models.OrderTransition{
+		From:   order.Status,
+		To:     models.OrderPaid,
+		Party:  models.OrderPartySystem,
+		Reason: fmt.Sprintf("Payment %s succeeded", payment.GatewayRef),
+		At:     time.Now(),
+	}
+
+	messages := uranus.OrderMovedMessages(order, transition, "")
+	if err = s.orderRepository.AppendOrderTransition(ctx, order.ID.Hex(), transition, messages...); err != nil {
+		return err
+	}
+
+	order.Status = models.OrderPaid
+	return nil
+}
+
+// post opens the accounts the entry needs and posts it, an entry posted before is not an error.
+func (s *service) post(ctx context.Context, entry *models.JournalEntry, accounts ...*models.LedgerAccount) error {
+	if !entry.IsBalanced() {
+		return uranus.ConstraintErrorf("Journal entry %s is not balanced", entry.Key)
+	}
+
+	for _, account := range accounts {
+		account.CreatedAt = time.Now()
+		if err := s.ledgerRepository.EnsureAccount(ctx, account); err != nil {
+			return err
+		}
+	}
+
+	entry.ID = bson.NewObjectId()
+	entry.CreatedAt = time.Now()
+	if err := s.ledgerRepository.StoreJournalEntry(ctx, entry); err != nil && err != uranus.ErrAlreadyPosted {
+		return err
+	}
+
+	return nil
+}
+
+// balance gets the sums of an account and nets them on the side the account grows.
+func (s *service) balance(ctx context.Context, code string) (*models.LedgerBalance, error) {
+	account, err := s.ledgerRepository.GetAccount(ctx, code)
+	if err != nil {
+		return nil, err
+	}
+
+	balance, err := s.ledgerRepository.GetBalance(ctx, code)
+	if err != nil {
+		return nil, err
+	}
+
+	balance.Currency = account.Currency
+	balance.Balance = balance.Credits - balance.Debits
+	if account.Type == models.LedgerAsset {
+		balance.Balance = balance.Debits - balance.Credits
+	}
+
+	return balance, nil
+}
+
+type requirement func(*service)
+
+func Repository(repository repository.PaymentRepository) requirement {
+	return func(s *service) {
+		s.repository = repository
+	}
+}
+
+func LedgerRepository(ledgerRepository repository.LedgerRepository) requirement {
+	return func(s *service) {
+		s.ledgerRepository = ledgerRepository
+	}
+}
+
+func OrderRepository(orderRepository repository.OrderRepository) requirement {
+	return func(s *service) {
+		s.orderRepository = orderRepository
+	}
+}
+
+func Gateway(gateway uranus.PaymentGateway) requirement {
+	return func(s *service) {
+		s.gateway = gateway
+	}
+}
+
+// Follower moves the purchase request and the offer of an order along when the sweeper settles it.
+func Follower(follower uranus.OrderFollower) requirement {
+	return func(s *service) {
+		s.follower = follower
+	}
+}
+
+func Timeout(timeout time.Duration) requirement {
+	return func(s *service) {
+		s.contextTimeout = timeout
+	}
+}
+
+func NewService(reqs ...requirement) uranus.PaymentUsecase {
+	return newService(reqs...)
+}
+
+// NewEscrow returns the escrow side of the payment service, for the order service to settle orders that ended.
+func NewEscrow(reqs ...requirement) uranus.Escrow {
+	return newService(reqs...)
+}
+
+func newService(reqs ...requirement) *service {
+	s := new(service)
+	for _, option := range reqs {
+		option(s)
+	}
+
+	return s
+}
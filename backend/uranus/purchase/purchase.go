@@ -0,0 +1,281 @@
+package purchase
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/globalsign/mgo/bson"
+
+	"github.com/fidellr/jastip/backend/uranus"
+	"github.com/fidellr/jastip/backend/uranus/auth"
+	"github.com/fidellr/jastip/backend/uranus/models"
+	"github.com/fidellr/jastip/backend/uranus/repository"
+)
+
+type service struct {
+	repository      repository.PurchaseRequestRepository
+	userRepository  repository.UserAccountRepository
+	orderRepository repository.OrderRepository
+	customs         uranus.CustomsChecker
+	validator       uranus.Validate
+	contextTimeout  time.Duration
+}
+
+func (s *service) CreatePurchaseRequest(ctx context.Context, m *models.PurchaseRequest) (err error) {
+	if ctx == nil {
+		err = uranus.ErrContextNil
+		return err
+	}
+
+	if err = auth.Authorize(ctx, auth.ActionRequestPurchase); err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, s.contextTimeout)
+	defer cancel()
+
+	// Buyers post their own requests, only admins may post one on behalf of another buyer.
+	principal, _ := auth.FromContext(ctx)
+	if m.BuyerID == "" || m.BuyerID.Hex() == principal.UserID {
+		if !bson.IsObjectIdHex(principal.UserID) {
+			return uranus.ConstraintErrorf("Purchase request has no buyer")
+		}
+
+		m.BuyerID = bson.ObjectIdHex(principal.UserID)
+	} else if err = auth.Authorize(ctx, auth.ActionManagePurchaseRequests); err != nil {
+		return err
+	}
+
+	buyer, err := s.userRepository.GetUserByID(ctx, m.BuyerID.Hex())
+	if err != nil {
+		return err
+	}
+
+	if buyer.Role.RoleName != models.RoleBuyer {
+		return uranus.ConstraintErrorf("Only buyers can request purchases, %s is a %s", buyer.ID.Hex(), buyer.Role.RoleName)
+	}
+
+	if err = s.validateRequest(m, time.Now()); err != nil {
+		return err
+	}
+
+	if err = s.checkCustoms(ctx, m); err != nil {
+		return err
+	}
+
+	m.ID = bson.NewObjectId()
+	m.Status = models.PurchaseRequestOpen
+	m.CreatedAt = time.Now()
+	m.UpdatedAt = time.Now()
+
+	return s.repository.StorePurchaseRequest(ctx, m)
+}
+
+func (s *service) FetchPurchaseRequests(ctx context.Context, filter *uranus.PurchaseRequestFilter) ([]*models.PurchaseRequest, uranus.Page, error) {
+	if ctx == nil {
+		err := uranus.ErrContextNil
+		return nil, uranus.Page{}, err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, s.contextTimeout)
+	defer cancel()
+
+	if filter.Num == 0 {
+		filter.Num = int(20)
+	}
+
+	filter.DestinationCountry = strings.ToUpper(filter.DestinationCountry)
+	return s.repository.FetchPurchaseRequests(ctx, filter)
+}
+
+func (s *service) GetPurchaseRequestByID(ctx context.Context, requestID string) (*models.PurchaseRequest, error) {
+	if ctx == nil {
+		err := uranus.ErrContextNil
+		return nil, err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, s.contextTimeout)
+	defer cancel()
+
+	return s.repository.GetPurchaseRequestByID(ctx, requestID)
+}
+
+// UpdatePurchaseRequestByID lets the buyer change a request until a traveler got matched to it.
+func (s *service) UpdatePurchaseRequestByID(ctx context.Context, requestID string, m *models.PurchaseRequest) (err error) {
+	if ctx == nil {
+		err = uranus.ErrContextNil
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, s.contextTimeout)
+	defer cancel()
+
+	existing, err := s.ownRequest(ctx, requestID)
+	if err != nil {
+		return err
+	}
+
+	if existing.Status != models.PurchaseRequestOpen {
+		return uranus.ConstraintErrorf("Purchase request is %s, only open requests can be changed", existing.Status)
+	}
+
+	now := time.Now()
+	if err = s.validateRequest(m, now); err != nil {
+		return err
+	}
+
+	m.ID = existing.ID
+	m.BuyerID = existing.BuyerID
+	m.Status = existing.Status
+	m.CreatedAt = existing.CreatedAt
+	m.UpdatedAt = now
+
+	if err = s.checkCustoms(ctx, m); err != nil {
+		return err
+	}
+
+	return s.repository.UpdatePurchaseRequestByID(ctx, requestID, m)
+}
+
+func (s *service) CancelPurchaseRequest(ctx context.Context, requestID string) (err error) {
+	if ctx == nil {
+		err = uranus.ErrContextNil
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, s.contextTimeout)
+	defer cancel()
+
+	existing, err := s.ownRequest(ctx, requestID)
+	if err != nil {
+		return err
+	}
+
+	if !existing.CanMoveTo(models.PurchaseRequestCancelled) {
+		return uranus.ConstraintErrorf("Purchase request is %s and can't be cancelled anymore", existing.Status)
+	}
+
+	// A matched request has an order, the order is what gets cancelled while it's still going.
+	if existing.Status == models.PurchaseRequestMatched {
+		orders, err := s.orderRepository.FetchOrdersByRequest(ctx, requestID)
+		if err != nil {
+			return err
+		}
+
+		for _, order := range orders {
+			if !order.HasEnded() {
+				return uranus.ConstraintErrorf("Purchase request has order %s that is %s, cancel the order instead", order.ID.Hex(), order.Status)
+			}
+		}
+	}
+
+	return s.repository.UpdatePurchaseRequestStatus(ctx, requestID, existing.Status, models.PurchaseRequestCancelled)
+}
+
+// ExpireOverdueRequests expires every open request whose deadline has passed without a match.
+func (s *service) ExpireOverdueRequests(ctx context.Context) (int, error) {
+	if ctx == nil {
+		err := uranus.ErrContextNil
+		return 0, err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, s.contextTimeout)
+	defer cancel()
+
+	return s.repository.ExpireOverdueRequests(ctx, time.Now())
+}
+
+// ownRequest loads a request the caller is allowed to change, the buyer of the request or an admin.
+func (s *service) ownRequest(ctx context.Context, requestID string) (*models.PurchaseRequest, error) {
+	existing, err := s.repository.GetPurchaseRequestByID(ctx, requestID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err = auth.AuthorizeOwner(ctx, auth.ActionManagePurchaseRequests, existing.BuyerID.Hex()); err != nil {
+		return nil, err
+	}
+
+	return existing, nil
+}
+
+func (s *service) validateRequest(m *models.PurchaseRequest, now time.Time) error {
+	m.MaxPrice.Currency = strings.ToUpper(m.MaxPrice.Currency)
+	m.DestinationCountry = strings.ToUpper(m.DestinationCountry)
+	if err := s.validator.ValidateStruct(m); err != nil {
+		return err
+	}
+
+	if m.MaxPrice.Amount <= 0 {
+		return uranus.ConstraintErrorf("Max price has to be above zero")
+	}
+
+	if !m.Deadline.After(now) {
+		return uranus.ConstraintErrorf("Deadline %s has already passed", m.Deadline.Format(time.RFC3339))
+	}
+
+	return nil
+}
+
+// checkCustoms stops a request customs won't let in and keeps the check's warnings and estimated duty on it.
+func (s *service) checkCustoms(ctx context.Context, m *models.PurchaseRequest) error {
+	check, err := s.customs.CheckPurchaseRequest(ctx, m)
+	if err != nil {
+		return err
+	}
+
+	if err = uranus.CustomsErrorOf(check); err != nil {
+		return err
+	}
+
+	m.Customs = check
+	return nil
+}
+
+type requirement func(*service)
+
+func Repository(repository repository.PurchaseRequestRepository) requirement {
+	return func(s *service) {
+		s.repository = repository
+	}
+}
+
+func UserAccountRepository(userRepository repository.UserAccountRepository) requirement {
+	return func(s *service) {
+		s.userRepository = userRepository
+	}
+}
+
+func OrderRepository(orderRepository repository.OrderRepository) requirement {
+	return func(s *service) {
+		s.orderRepository = orderRepository
+	}
+}
+
+func Customs(customs uranus.CustomsChecker) requirement {
+	return func(s *service) {
+		s.customs = customs
+	}
+}
+
+func Timeout(timeout time.Duration) requirement {
+	return func(s *service) {
+		s.contextTimeout = timeout
+	}
+}
+
+func Validator(validator uranus.Validate) requirement {
+	return func(s *service) {
+		s.validator = validator
+	}
+}
+
+func NewService(reqs ...requirement) uranus.PurchaseRequestUsecase {
+	s := new(service)
+	for _, option := range reqs {
+		option(s)
+	}
+
+	return s
+}
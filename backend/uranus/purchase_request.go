@@ -0,0 +1,30 @@
+package uranus
+
+import (
+	"context"
+
+	"github.com/fidellr/jastip/backend/uranus/models"
+)
+
+type PurchaseRequestUsecase interface {
+	CreatePurchaseRequest(ctx context.Context, m *models.PurchaseRequest) error
+	FetchPurchaseRequests(ctx context.Context, filter *PurchaseRequestFilter) ([]*models.PurchaseRequest, Page, error)
+	GetPurchaseRequestByID(ctx context.Context, requestID string) (*models.PurchaseRequest, error)
+	UpdatePurchaseRequestByID(ctx context.Context, requestID string, m *models.PurchaseRequest) error
+	CancelPurchaseRequest(ctx context.Context, requestID string) error
+	ExpireOverdueRequests(ctx context.Context) (int, error)
+}
+
+// PurchaseRequestSort lists the newest purchase requests first.
+const PurchaseRequestSort = "-created_at"
+
+// PurchaseRequestFilter narrows purchase requests down to a buyer, a country, a category or a status.
+type PurchaseRequestFilter struct {
+	Num    int
+	Cursor string
+
+	BuyerID            string
+	DestinationCountry string
+	Category           string
+	Status             string
+}
@@ -0,0 +1,161 @@
+package rate
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/globalsign/mgo/bson"
+
+	"github.com/fidellr/jastip/backend/uranus"
+	"github.com/fidellr/jastip/backend/uranus/auth"
+	"github.com/fidellr/jastip/backend/uranus/models"
+	"github.com/fidellr/jastip/backend/uranus/repository"
+)
+
+type service struct {
+	repository     repository.ExchangeRateRepository
+	validator      uranus.Validate
+	contextTimeout time.Duration
+}
+
+// UploadRates saves a batch of rates, the whole batch is rejected when one rate is invalid.
+// Without a caller in ctx the rates come from the command line and are trusted.
+func (s *service) UploadRates(ctx context.Context, rates []*models.ExchangeRate) (int, error) {
+	if ctx == nil {
+		err := uranus.ErrContextNil
+		return 0, err
+	}
+
+	principal, ok := auth.FromContext(ctx)
+	if ok {
+		if err := auth.Authorize(ctx, auth.ActionManageExchangeRates); err != nil {
+			return 0, err
+		}
+	}
+
+	if len(rates) == 0 {
+		return 0, uranus.ConstraintErrorf("No exchange rates to upload")
+	}
+
+	now := time.Now()
+	for i, rate := range rates {
+		rate.Base = strings.ToUpper(rate.Base)
+		rate.Quote = strings.ToUpper(rate.Quote)
+		if err := s.validator.ValidateStruct(rate); err != nil {
+			return 0, uranus.ConstraintErrorf("Rate %d: %s", i+1, err.Error())
+		}
+
+		rate.ID = bson.NewObjectId()
+		rate.CreatedAt = now
+		rate.EffectiveAt = rate.EffectiveAt.UTC()
+		if ok && bson.IsObjectIdHex(principal.UserID) {
+			rate.UploadedBy = bson.ObjectIdHex(principal.UserID)
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, s.contextTimeout)
+	defer cancel()
+
+	return s.repository.StoreRates(ctx, rates)
+}
+
+func (s *service) FetchRates(ctx context.Context, filter *uranus.ExchangeRateFilter) ([]*models.ExchangeRate, error) {
+	if ctx == nil {
+		err := uranus.ErrContextNil
+		return nil, err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, s.contextTimeout)
+	defer cancel()
+
+	if filter.Num == 0 {
+		filter.Num = int(50)
+	}
+
+	filter.Base = strings.ToUpper(filter.Base)
+	filter.Quote = strings.ToUpper(filter.Quote)
+	return s.repository.FetchRates(ctx, filter)
+}
+
+// Convert converts with the rate of the pair in effect at the given time,
+// the inverse pair is used when only that one was uploaded.
+func (s *service) Convert(ctx context.Context, m models.Money, currency string, at time.Time) (models.Money, *models.AppliedRate, error) {
+	if ctx == nil {
+		err := uranus.ErrContextNil
+		return models.Money{}, nil, err
+	}
+
+	if !models.IsCurrency(m.Currency) {
+		return models.Money{}, nil, &models.UnknownCurrencyError{Currency: m.Currency}
+	}
+
+	if !models.IsCurrency(currency) {
+		return models.Money{}, nil, &models.UnknownCurrencyError{Currency: currency}
+	}
+
+	if m.Currency == currency {
+		return m, nil, nil
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, s.contextTimeout)
+	defer cancel()
+
+	applied, err := s.effectiveRate(ctx, m.Currency, currency, at)
+	if err != nil {
+		return models.Money{}, nil, err
+	}
+
+	return applied.Convert(m), applied, nil
+}
+
+func (s *service) effectiveRate(ctx context.Context, from, to string, at time.Time) (*models.AppliedRate, error) {
+	rate, err := s.repository.GetEffectiveRate(ctx, from, to, at)
+	if err == nil {
+		return &models.AppliedRate{RateID: rate.ID, From: from, To: to, Rate: rate.Rate, EffectiveAt: rate.EffectiveAt}, nil
+	}
+
+	if err != uranus.ErrNotFound {
+		return nil, err
+	}
+
+	rate, err = s.repository.GetEffectiveRate(ctx, to, from, at)
+	if err == uranus.ErrNotFound {
+		return nil, uranus.ErrNoExchangeRate
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &models.AppliedRate{RateID: rate.ID, From: from, To: to, Rate: 1 / rate.Rate, EffectiveAt: rate.EffectiveAt}, nil
+}
+
+type requirement func(*service)
+
+func Repository(repository repository.ExchangeRateRepository) requirement {
+	return func(s *service) {
+		s.repository = repository
+	}
+}
+
+func Timeout(timeout time.Duration) requirement {
+	return func(s *service) {
+		s.contextTimeout = timeout
+	}
+}
+
+func Validator(validator uranus.Validate) requirement {
+	return func(s *service) {
+		s.validator = validator
+	}
+}
+
+func NewService(reqs ...requirement) uranus.ExchangeRateUsecase {
+	s := new(service)
+	for _, option := range reqs {
+		option(s)
+	}
+
+	return s
+}
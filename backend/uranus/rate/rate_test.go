@@ -0,0 +1,120 @@
+package rate
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/globalsign/mgo/bson"
+
+	"github.com/fidellr/jastip/backend/uranus"
+	"github.com/fidellr/jastip/backend/uranus/models"
+	"github.com/fidellr/jastip/backend/uranus/repository"
+)
+
+// memoryRates knows one rate per pair.
+type memoryRates struct {
+	repository.ExchangeRateRepository
+	rates []*models.ExchangeRate
+}
+
+func (r *memoryRates) GetEffectiveRate(ctx context.Context, base, quote string, at time.Time) (*models.ExchangeRate, error) {
+	for _, rate := range r.rates {
+		if rate.Base == base && rate.Quote == quote && !rate.EffectiveAt.After(at) {
+			return rate, nil
+		}
+	}
+
+	return nil, uranus.ErrNotFound
+}
+
+func TestConvert(t *testing.T) {
+	effectiveAt := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	usdJPY := &models.ExchangeRate{ID: bson.NewObjectId(), Base: "USD", Quote: "JPY", Rate: 150, EffectiveAt: effectiveAt}
+	s := NewService(Repository(&memoryRates{rates: []*models.ExchangeRate{usdJPY}}), Timeout(time.Second))
+
+	tests := []struct {
+		name     string
+		money    models.Money
+		currency string
+		at       time.Time
+		want     models.Money
+		rate     float64
+		err      error
+	}{
+		{
+			name:     "pair as uploaded",
+			money:    models.Money{Amount: 1250, Currency: "USD"},
+			currency: "JPY",
+			at:       effectiveAt,
+			want:     models.Money{Amount: 1875, Currency: "JPY"},
+			rate:     150,
+		},
+		{
+			name:     "inverse pair",
+			money:    models.Money{Amount: 1875, Currency: "JPY"},
+			currency: "USD",
+			at:       effectiveAt.Add(time.Hour),
+			want:     models.Money{Amount: 1250, Currency: "USD"},
+			rate:     1.0 / 150,
+		},
+		{
+			name:     "same currency",
+			money:    models.Money{Amount: 1875, Currency: "JPY"},
+			currency: "JPY",
+			at:       effectiveAt,
+			want:     models.Money{Amount: 1875, Currency: "JPY"},
+		},
+		{
+			name:     "before the rate took effect",
+			money:    models.Money{Amount: 1250, Currency: "USD"},
+			currency: "JPY",
+			at:       effectiveAt.Add(-time.Hour),
+			err:      uranus.ErrNoExchangeRate,
+		},
+		{
+			name:     "no pair either way",
+			money:    models.Money{Amount: 1250, Currency: "USD"},
+			currency: "IDR",
+			at:       effectiveAt,
+			err:      uranus.ErrNoExchangeRate,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got, applied, err := s.Convert(context.Background(), test.money, test.currency, test.at)
+			if err != test.err {
+				t.Fatalf("Convert error = %v, want %v", err, test.err)
+			}
+
+			if err != nil {
+				return
+			}
+
+			if got != test.want {
+				t.Errorf("Convert = %v, want %v", got, test.want)
+			}
+
+			if test.rate == 0 {
+				if applied != nil {
+					t.Errorf("same currency applied rate %+v", applied)
+				}
+
+				return
+			}
+
+			if applied.Rate != test.rate || applied.From != test.money.Currency || applied.To != test.currency || applied.RateID != usdJPY.ID {
+				t.Errorf("applied %+v, want %s -> %s at %v from rate %s", applied, test.money.Currency, test.currency, test.rate, usdJPY.ID.Hex())
+			}
+		})
+	}
+}
+
+func TestConvertRejectsUnknownCurrency(t *testing.T) {
+	s := NewService(Repository(&memoryRates{}), Timeout(time.Second))
+	_, _, err := s.Convert(context.Background(), models.Money{Amount: 100, Currency: "USD"}, "XXX", time.Now())
+	if _, ok := err.(*models.UnknownCurrencyError); !ok {
+		t.Errorf("Convert to XXX error = %v, want an UnknownCurrencyError", err)
+	}
+}
@@ -0,0 +1,19 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/fidellr/jastip/backend/uranus"
+	"github.com/fidellr/jastip/backend/uranus/models"
+)
+
+// ChatRepository repo
+type ChatRepository interface {
+	UpsertConversation(ctx context.Context, m *models.Conversation) (*models.Conversation, error)
+	FetchConversations(ctx context.Context, filter *uranus.ConversationFilter) ([]*models.Conversation, uranus.Page, error)
+	GetConversationByID(ctx context.Context, conversationID string) (*models.Conversation, error)
+	StoreMessage(ctx context.Context, m *models.ChatMessage) error
+	FetchMessages(ctx context.Context, filter *uranus.ChatMessageFilter) ([]*models.ChatMessage, uranus.Page, error)
+	GetMessageByID(ctx context.Context, messageID string) (*models.ChatMessage, error)
+	MarkRead(ctx context.Context, conversationID string, userID string, read models.ChatRead) error
+}
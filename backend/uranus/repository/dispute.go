@@ -0,0 +1,25 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/fidellr/jastip/backend/uranus"
+	"github.com/fidellr/jastip/backend/uranus/models"
+)
+
+// DisputeRepository repo
+type DisputeRepository interface {
+	StoreDispute(ctx context.Context, m *models.Dispute) error
+	RemoveDispute(ctx context.Context, disputeID string) error
+	FetchDisputes(ctx context.Context, filter *uranus.DisputeFilter) ([]*models.Dispute, uranus.Page, error)
+	GetDisputeByID(ctx context.Context, disputeID string) (*models.Dispute, error)
+	FetchOverdueDisputes(ctx context.Context, at time.Time) ([]*models.Dispute, error)
+	EscalateDispute(ctx context.Context, disputeID string, at time.Time, outbox ...*models.OutboxMessage) error
+	AssignMediator(ctx context.Context, disputeID string, mediatorID string) error
+	ResolveDispute(ctx context.Context, disputeID string, resolution *models.DisputeResolution, outbox ...*models.OutboxMessage) error
+	FetchUnsettledDisputes(ctx context.Context, resolvedBefore time.Time, limit int) ([]*models.Dispute, error)
+	MarkDisputeSettled(ctx context.Context, disputeID string, at time.Time) error
+	StoreDisputeMessage(ctx context.Context, m *models.DisputeMessage) error
+	FetchDisputeMessages(ctx context.Context, disputeID string) ([]*models.DisputeMessage, error)
+}
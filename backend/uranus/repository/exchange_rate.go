@@ -0,0 +1,16 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/fidellr/jastip/backend/uranus"
+	"github.com/fidellr/jastip/backend/uranus/models"
+)
+
+// ExchangeRateRepository repo
+type ExchangeRateRepository interface {
+	StoreRates(ctx context.Context, rates []*models.ExchangeRate) (int, error)
+	FetchRates(ctx context.Context, filter *uranus.ExchangeRateFilter) ([]*models.ExchangeRate, error)
+	GetEffectiveRate(ctx context.Context, base, quote string, at time.Time) (*models.ExchangeRate, error)
+}
@@ -0,0 +1,16 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/fidellr/jastip/backend/uranus/models"
+)
+
+// FeeScheduleRepository repo
+type FeeScheduleRepository interface {
+	StoreFeeSchedule(ctx context.Context, m *models.FeeSchedule) error
+	FetchFeeSchedules(ctx context.Context) ([]*models.FeeSchedule, error)
+	GetLatestFeeSchedule(ctx context.Context) (*models.FeeSchedule, error)
+	GetEffectiveFeeSchedule(ctx context.Context, at time.Time) (*models.FeeSchedule, error)
+}
@@ -0,0 +1,16 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/fidellr/jastip/backend/uranus/models"
+)
+
+// LedgerRepository repo
+type LedgerRepository interface {
+	EnsureAccount(ctx context.Context, m *models.LedgerAccount) error
+	GetAccount(ctx context.Context, code string) (*models.LedgerAccount, error)
+	StoreJournalEntry(ctx context.Context, m *models.JournalEntry) error
+	FetchJournalEntries(ctx context.Context, orderID string) ([]*models.JournalEntry, error)
+	GetBalance(ctx context.Context, code string) (*models.LedgerBalance, error)
+}
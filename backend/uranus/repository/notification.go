@@ -0,0 +1,21 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/fidellr/jastip/backend/uranus"
+	"github.com/fidellr/jastip/backend/uranus/models"
+)
+
+// NotificationRepository repo
+type NotificationRepository interface {
+	FetchOutbox(ctx context.Context, limit int) ([]*models.OutboxMessage, error)
+	ClearOutboxMessage(ctx context.Context, m *models.OutboxMessage) error
+	StoreNotifications(ctx context.Context, m []*models.Notification) error
+	ClaimNotification(ctx context.Context, at time.Time, lease time.Duration) (*models.Notification, error)
+	UpdateNotification(ctx context.Context, m *models.Notification) error
+	StoreInboxItem(ctx context.Context, m *models.InboxItem) error
+	FetchInbox(ctx context.Context, filter *uranus.InboxFilter) ([]*models.InboxItem, uranus.Page, error)
+	MarkInboxRead(ctx context.Context, itemID string, userID string, at time.Time) error
+}
@@ -0,0 +1,19 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/fidellr/jastip/backend/uranus"
+	"github.com/fidellr/jastip/backend/uranus/models"
+)
+
+// OfferRepository repo
+type OfferRepository interface {
+	StoreOffer(ctx context.Context, m *models.Offer) error
+	FetchOffers(ctx context.Context, filter *uranus.OfferFilter) ([]*models.Offer, uranus.Page, error)
+	GetOfferByID(ctx context.Context, offerID string) (*models.Offer, error)
+	FetchOffersByTrip(ctx context.Context, tripID string, status string) ([]*models.Offer, error)
+	HasPendingOffer(ctx context.Context, requestID string, travelerID string) (bool, error)
+	UpdateOfferStatus(ctx context.Context, offerID string, from, to string) error
+	DeclinePendingOffers(ctx context.Context, requestID string) (int, error)
+}
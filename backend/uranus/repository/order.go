@@ -0,0 +1,21 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/fidellr/jastip/backend/uranus"
+	"github.com/fidellr/jastip/backend/uranus/models"
+)
+
+// OrderRepository repo
+type OrderRepository interface {
+	StoreOrder(ctx context.Context, m *models.Order) error
+	FetchOrders(ctx context.Context, filter *uranus.OrderFilter) ([]*models.Order, uranus.Page, error)
+	GetOrderByID(ctx context.Context, orderID string) (*models.Order, error)
+	FetchOrdersByTrip(ctx context.Context, tripID string) ([]*models.Order, error)
+	FetchOrdersByRequest(ctx context.Context, requestID string) ([]*models.Order, error)
+	AppendOrderTransition(ctx context.Context, orderID string, transition models.OrderTransition, outbox ...*models.OutboxMessage) error
+	FetchUnsettledOrders(ctx context.Context, endedBefore time.Time, limit int) ([]*models.Order, error)
+	MarkOrderSettled(ctx context.Context, orderID string, at time.Time) error
+}
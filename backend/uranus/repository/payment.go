@@ -0,0 +1,15 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/fidellr/jastip/backend/uranus/models"
+)
+
+// PaymentRepository repo
+type PaymentRepository interface {
+	StorePayment(ctx context.Context, m *models.Payment) error
+	GetPaymentByGatewayRef(ctx context.Context, gatewayRef string) (*models.Payment, error)
+	GetPaymentByOrder(ctx context.Context, orderID string, status string) (*models.Payment, error)
+	UpdatePayment(ctx context.Context, m *models.Payment, from string) error
+}
@@ -0,0 +1,20 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/fidellr/jastip/backend/uranus"
+	"github.com/fidellr/jastip/backend/uranus/models"
+)
+
+// PurchaseRequestRepository repo
+type PurchaseRequestRepository interface {
+	StorePurchaseRequest(ctx context.Context, m *models.PurchaseRequest) error
+	FetchPurchaseRequests(ctx context.Context, filter *uranus.PurchaseRequestFilter) ([]*models.PurchaseRequest, uranus.Page, error)
+	GetPurchaseRequestByID(ctx context.Context, requestID string) (*models.PurchaseRequest, error)
+	UpdatePurchaseRequestByID(ctx context.Context, requestID string, m *models.PurchaseRequest) error
+	UpdatePurchaseRequestStatus(ctx context.Context, requestID string, from, to string) error
+	ExpireOverdueRequests(ctx context.Context, at time.Time) (int, error)
+	FetchMatchCandidates(ctx context.Context, country string, categories []string, deadlineAfter time.Time, limit int) ([]*models.PurchaseRequest, error)
+}
@@ -0,0 +1,18 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/fidellr/jastip/backend/uranus"
+	"github.com/fidellr/jastip/backend/uranus/models"
+)
+
+// ReviewRepository repo
+type ReviewRepository interface {
+	StoreReview(ctx context.Context, m *models.Review) error
+	UpdateReview(ctx context.Context, m *models.Review, at time.Time) error
+	GetReviewByID(ctx context.Context, reviewID string) (*models.Review, error)
+	FetchReviews(ctx context.Context, filter *uranus.ReviewFilter) ([]*models.Review, uranus.Page, error)
+	CountRatings(ctx context.Context, revieweeID string) (map[int]int, error)
+}
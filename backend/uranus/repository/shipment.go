@@ -0,0 +1,14 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/fidellr/jastip/backend/uranus/models"
+)
+
+// ShipmentRepository repo
+type ShipmentRepository interface {
+	StoreShipmentEvent(ctx context.Context, m *models.ShipmentEvent) error
+	FetchShipmentEvents(ctx context.Context, orderID string) ([]*models.ShipmentEvent, error)
+	RemoveShipmentEvent(ctx context.Context, id string) error
+}
@@ -0,0 +1,15 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/fidellr/jastip/backend/uranus/models"
+)
+
+// SuspensionRepository keeps the suspension history of every user.
+type SuspensionRepository interface {
+	StoreSuspension(ctx context.Context, m *models.Suspension) error
+	FetchSuspensionsByUser(ctx context.Context, userID string) ([]*models.Suspension, error)
+	LiftSuspensions(ctx context.Context, userID, liftedBy, note string, liftedAt time.Time) error
+}
@@ -0,0 +1,19 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/fidellr/jastip/backend/uranus"
+	"github.com/fidellr/jastip/backend/uranus/models"
+)
+
+// TripRepository repo
+type TripRepository interface {
+	StoreTrip(ctx context.Context, m *models.Trip) error
+	FetchTrips(ctx context.Context, filter *uranus.TripFilter) ([]*models.Trip, uranus.Page, error)
+	GetTripByID(ctx context.Context, tripID string) (*models.Trip, error)
+	UpdateTripByID(ctx context.Context, tripID string, m *models.Trip) error
+	RemoveTripByID(ctx context.Context, tripID string) error
+	CloseDepartedTrips(ctx context.Context, departedBefore time.Time) (int, error)
+}
@@ -2,6 +2,7 @@ package repository
 
 import (
 	"context"
+	"time"
 
 	"github.com/fidellr/jastip/backend/uranus"
 
@@ -11,9 +12,22 @@ import (
 // UserAccountRepository repo
 type UserAccountRepository interface {
 	CreateUserAccount(ctx context.Context, userAccountM *models.UserAccount) error
-	Fetch(ctx context.Context, filter *uranus.Filter) ([]*models.UserAccount, string, error)
+	Fetch(ctx context.Context, filter *uranus.Filter) ([]*models.UserAccount, uranus.Page, error)
 	GetUserByID(ctx context.Context, uuid string) (*models.UserAccount, error)
-	SuspendAccount(ctx context.Context, uuid string) (bool, error)
-	RemoveAccount(ctx context.Context, uuid string) (bool, error)
-	UpdateUserByID(ctx context.Context, uuid string, userAccountM *models.UserAccount) error
+	GetUserByEmail(ctx context.Context, email string) (*models.UserAccount, error)
+	SuspendAccount(ctx context.Context, uuid string, until *time.Time, outbox ...*models.OutboxMessage) (bool, error)
+	ReinstateAccount(ctx context.Context, uuid string) (bool, error)
+	FetchExpiredSuspensions(ctx context.Context, at time.Time) ([]*models.UserAccount, error)
+	RemoveAccount(ctx context.Context, uuid string, deletedAt time.Time) (bool, error)
+	RestoreAccount(ctx context.Context, uuid string, deletedAfter time.Time) (bool, error)
+	FetchDeletedBefore(ctx context.Context, deletedBefore time.Time) ([]*models.UserAccount, error)
+	AnonymizeAccount(ctx context.Context, uuid string, purgedAt time.Time) error
+	PurgeAccount(ctx context.Context, uuid string) error
+	UpdateUserByID(ctx context.Context, uuid string, version int, userAccountM *models.UserAccount) error
+	MarkEmailVerified(ctx context.Context, uuid string, verifiedAt time.Time) error
+	UpdatePassword(ctx context.Context, uuid string, passwordHash string) error
+	UpdateTravelerProfile(ctx context.Context, uuid string, profile *models.TravelerProfile) error
+	UpdateBuyerProfile(ctx context.Context, uuid string, profile *models.BuyerProfile) error
+	UpdateNotificationPreferences(ctx context.Context, uuid string, prefs *models.NotificationPreferences) error
+	UpdateRatingSummary(ctx context.Context, uuid string, summary *models.RatingSummary) error
 }
@@ -0,0 +1,14 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/fidellr/jastip/backend/uranus/models"
+)
+
+// UserTokenRepository stores the one-time tokens sent to users.
+type UserTokenRepository interface {
+	StoreToken(ctx context.Context, m *models.UserToken) error
+	ConsumeToken(ctx context.Context, purpose, tokenHash string) (*models.UserToken, error)
+	RemoveTokensByUser(ctx context.Context, userID, purpose string) error
+}
@@ -0,0 +1,29 @@
+package uranus
+
+import (
+	"context"
+
+	"github.com/fidellr/jastip/backend/uranus/models"
+)
+
+type ReviewUsecase interface {
+	CreateReview(ctx context.Context, orderID string, m *models.Review) error
+	UpdateReview(ctx context.Context, reviewID string, m *models.Review) (*models.Review, error)
+	GetReviewByID(ctx context.Context, reviewID string) (*models.Review, error)
+	FetchReviews(ctx context.Context, filter *ReviewFilter) ([]*models.Review, Page, error)
+	GetRatingSummary(ctx context.Context, userID string) (*models.RatingSummary, error)
+}
+
+// ReviewSort lists the newest reviews first.
+const ReviewSort = "-created_at"
+
+// ReviewFilter narrows reviews down to the ones a user got or gave, on an order or with a rating.
+type ReviewFilter struct {
+	Num    int
+	Cursor string
+
+	RevieweeID string
+	ReviewerID string
+	OrderID    string
+	Rating     int
+}
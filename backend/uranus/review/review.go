@@ -0,0 +1,245 @@
+package review
+
+import (
+	"context"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/globalsign/mgo/bson"
+
+	"github.com/fidellr/jastip/backend/uranus"
+	"github.com/fidellr/jastip/backend/uranus/auth"
+	"github.com/fidellr/jastip/backend/uranus/models"
+	"github.com/fidellr/jastip/backend/uranus/repository"
+)
+
+type service struct {
+	repository      repository.ReviewRepository
+	orderRepository repository.OrderRepository
+	userRepository  repository.UserAccountRepository
+	editWindow      time.Duration
+	validator       uranus.Validate
+	contextTimeout  time.Duration
+}
+
+// CreateReview has the buyer or the traveler of a completed order review the other party, once per order.
+func (s *service) CreateReview(ctx context.Context, orderID string, m *models.Review) (err error) {
+	if ctx == nil {
+		err = uranus.ErrContextNil
+		return err
+	}
+
+	m.Comment = strings.TrimSpace(m.Comment)
+	if err = s.validator.ValidateStruct(m); err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, s.contextTimeout)
+	defer cancel()
+
+	order, err := s.orderRepository.GetOrderByID(ctx, orderID)
+	if err != nil {
+		return err
+	}
+
+	// No role may review for someone else, so only the buyer and the traveler get through.
+	principal, party, err := auth.AuthorizeParty(ctx, auth.ActionEditReview, order.PartyOf)
+	if err != nil {
+		return err
+	}
+
+	switch party {
+	case models.OrderPartyBuyer:
+		m.RevieweeID = order.TravelerID
+	case models.OrderPartyTraveler:
+		m.RevieweeID = order.BuyerID
+	}
+
+	if order.Status != models.OrderCompleted {
+		return uranus.ConstraintErrorf("Order is %s, it can only be reviewed once it's completed", order.Status)
+	}
+
+	now := time.Now()
+	m.ID = bson.NewObjectId()
+	m.CreatedAt = now
+	m.UpdatedAt = now
+	m.OrderID = order.ID
+	m.ReviewerID = bson.ObjectIdHex(principal.UserID)
+	m.Party = party
+	m.EditableUntil = now.Add(s.editWindow)
+	m.Outbox = []*models.OutboxMessage{models.NewOutboxMessage(m.RevieweeID.Hex(), models.NotifyReviewReceived, map[string]string{
+		"review_id": m.ID.Hex(),
+		"order_id":  order.ID.Hex(),
+		"rating":    strconv.Itoa(m.Rating),
+		"comment":   m.Comment,
+	})}
+
+	if err = s.repository.StoreReview(ctx, m); err != nil {
+		return err
+	}
+
+	s.refreshRating(ctx, m.RevieweeID.Hex())
+	return nil
+}
+
+// UpdateReview changes the rating, comment and photos of the caller's own review within its edit window.
+func (s *service) UpdateReview(ctx context.Context, reviewID string, m *models.Review) (*models.Review, error) {
+	if ctx == nil {
+		err := uranus.ErrContextNil
+		return nil, err
+	}
+
+	m.Comment = strings.TrimSpace(m.Comment)
+	if err := s.validator.ValidateStruct(m); err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, s.contextTimeout)
+	defer cancel()
+
+	existing, err := s.repository.GetReviewByID(ctx, reviewID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err = auth.AuthorizeOwner(ctx, auth.ActionEditReview, existing.ReviewerID.Hex()); err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	if !now.Before(existing.EditableUntil) {
+		return nil, uranus.ConstraintErrorf("Review could only be edited until %s", existing.EditableUntil.Format(time.RFC3339))
+	}
+
+	existing.Rating = m.Rating
+	existing.Comment = m.Comment
+	existing.ImageIDs = m.ImageIDs
+	existing.UpdatedAt = now
+
+	if err = s.repository.UpdateReview(ctx, existing, now); err != nil {
+		return nil, err
+	}
+
+	s.refreshRating(ctx, existing.RevieweeID.Hex())
+	return existing, nil
+}
+
+func (s *service) GetReviewByID(ctx context.Context, reviewID string) (*models.Review, error) {
+	if ctx == nil {
+		err := uranus.ErrContextNil
+		return nil, err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, s.contextTimeout)
+	defer cancel()
+
+	return s.repository.GetReviewByID(ctx, reviewID)
+}
+
+// FetchReviews lists reviews for anyone, they are what users build their reputation on.
+func (s *service) FetchReviews(ctx context.Context, filter *uranus.ReviewFilter) ([]*models.Review, uranus.Page, error) {
+	if ctx == nil {
+		err := uranus.ErrContextNil
+		return nil, uranus.Page{}, err
+	}
+
+	if filter.Rating < 0 || filter.Rating > 5 {
+		return nil, uranus.Page{}, uranus.ConstraintErrorf("Rating has to be between 1 and 5")
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, s.contextTimeout)
+	defer cancel()
+
+	if filter.Num == 0 {
+		filter.Num = int(20)
+	}
+
+	return s.repository.FetchReviews(ctx, filter)
+}
+
+// GetRatingSummary tells how the user was rated, a user nobody reviewed yet has an empty summary.
+func (s *service) GetRatingSummary(ctx context.Context, userID string) (*models.RatingSummary, error) {
+	if ctx == nil {
+		err := uranus.ErrContextNil
+		return nil, err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, s.contextTimeout)
+	defer cancel()
+
+	user, err := s.userRepository.GetUserByID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if user.Rating == nil {
+		return models.NewRatingSummary(nil, user.CreatedAt), nil
+	}
+
+	return user.Rating, nil
+}
+
+// refreshRating works the user's rating summary out from all their reviews again. A failure is only logged,
+// the review itself is stored and the next review of the user fixes the summary.
+func (s *service) refreshRating(ctx context.Context, userID string) {
+	counts, err := s.repository.CountRatings(ctx, userID)
+	if err == nil {
+		err = s.userRepository.UpdateRatingSummary(ctx, userID, models.NewRatingSummary(counts, time.Now()))
+	}
+
+	if err != nil {
+		log.Printf("Failed to refresh the rating of %s : %s", userID, err.Error())
+	}
+}
+
+type requirement func(*service)
+
+func Repository(repository repository.ReviewRepository) requirement {
+	return func(s *service) {
+		s.repository = repository
+	}
+}
+
+func OrderRepository(orderRepository repository.OrderRepository) requirement {
+	return func(s *service) {
+		s.orderRepository = orderRepository
+	}
+}
+
+func UserAccountRepository(userRepository repository.UserAccountRepository) requirement {
+	return func(s *service) {
+		s.userRepository = userRepository
+	}
+}
+
+// EditWindow sets how long after writing it a reviewer may still change their review.
+func EditWindow(window time.Duration) requirement {
+	return func(s *service) {
+		if window > 0 {
+			s.editWindow = window
+		}
+	}
+}
+
+func Timeout(timeout time.Duration) requirement {
+	return func(s *service) {
+		s.contextTimeout = timeout
+	}
+}
+
+func Validator(validator uranus.Validate) requirement {
+	return func(s *service) {
+		s.validator = validator
+	}
+}
+
+func NewService(reqs ...requirement) uranus.ReviewUsecase {
+	s := &service{editWindow: 7 * 24 * time.Hour}
+	for _, option := range reqs {
+		option(s)
+	}
+
+	return s
+}
@@ -0,0 +1,18 @@
+package uranus
+
+import (
+	"context"
+
+	"github.com/fidellr/jastip/backend/uranus/models"
+)
+
+type ShipmentUsecase interface {
+	AppendShipmentEvent(ctx context.Context, orderID string, m *models.ShipmentEvent) error
+	FetchShipmentEvents(ctx context.Context, orderID string) ([]*models.ShipmentEvent, error)
+	GetShipmentTimeline(ctx context.Context, orderID string) (*models.ShipmentTimeline, error)
+}
+
+// CourierTracker looks parcels up at domestic couriers, an unknown courier or tracking number is uranus.ErrNotFound.
+type CourierTracker interface {
+	Track(ctx context.Context, courier string, trackingNumber string) (*models.CourierTracking, error)
+}
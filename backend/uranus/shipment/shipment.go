@@ -0,0 +1,269 @@
+package shipment
+
+import (
+	"context"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/globalsign/mgo/bson"
+
+	"github.com/fidellr/jastip/backend/uranus"
+	"github.com/fidellr/jastip/backend/uranus/auth"
+	"github.com/fidellr/jastip/backend/uranus/models"
+	"github.com/fidellr/jastip/backend/uranus/repository"
+)
+
+type service struct {
+	repository      repository.ShipmentRepository
+	orderRepository repository.OrderRepository
+	tracker         uranus.CourierTracker
+	validator       uranus.Validate
+	contextTimeout  time.Duration
+}
+
+// AppendShipmentEvent posts the next step of getting the order's item to the buyer, for the traveler or an admin.
+// Purchased, departed and delivered events move the order on to purchased, in transit and delivered.
+func (s *service) AppendShipmentEvent(ctx context.Context, orderID string, m *models.ShipmentEvent) (err error) {
+	if ctx == nil {
+		err = uranus.ErrContextNil
+		return err
+	}
+
+	m.Courier = strings.ToLower(strings.TrimSpace(m.Courier))
+	m.TrackingNumber = strings.TrimSpace(m.TrackingNumber)
+	if err = s.validator.ValidateStruct(m); err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, s.contextTimeout)
+	defer cancel()
+
+	order, err := s.orderRepository.GetOrderByID(ctx, orderID)
+	if err != nil {
+		return err
+	}
+
+	// Only the traveler posts shipment events, the buyer can't speak for the shipment.
+	principal, party, err := auth.AuthorizeParty(ctx, auth.ActionManageOrders, func(userID string) string {
+		if party := order.PartyOf(userID); party == models.OrderPartyTraveler {
+			return party
+		}
+
+		return ""
+	})
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	if m.OccurredAt.IsZero() {
+		m.OccurredAt = now
+	}
+
+	if err = s.checkEvent(ctx, order, m, now); err != nil {
+		return err
+	}
+
+	status := m.Moves()
+	if status != "" && !order.CanMove(party, status) {
+		return &uranus.IllegalTransitionError{From: order.Status, To: status, Party: party}
+	}
+
+	m.ID = bson.NewObjectId()
+	m.OrderID = order.ID
+	m.CreatedAt = now
+	if bson.IsObjectIdHex(principal.UserID) {
+		m.ActorID = bson.ObjectIdHex(principal.UserID)
+	}
+
+	// The event is stored first, there is one of each type per order so two posts of it can't both go through.
+	// An order that can't move anymore takes the event back out, it never shows an event its status didn't follow.
+	if err = s.repository.StoreShipmentEvent(ctx, m); err != nil {
+		return err
+	}
+
+	if status != "" {
+		transition := models.OrderTransition{
+			From:   order.Status,
+			To:     status,
+			Party:  party,
+			Reason: "Shipment " + strings.Replace(m.Type, "_", " ", -1),
+			At:     now,
+		}
+
+		if bson.IsObjectIdHex(principal.UserID) {
+			transition.ActorID = bson.ObjectIdHex(principal.UserID)
+		}
+
+		messages := uranus.OrderMovedMessages(order, transition, principal.UserID)
+		if err = s.orderRepository.AppendOrderTransition(ctx, orderID, transition, messages...); err != nil {
+			if removeErr := s.repository.RemoveShipmentEvent(ctx, m.ID.Hex()); removeErr != nil {
+				log.Printf("Failed to take back shipment event %s of order %s : %s", m.ID.Hex(), orderID, removeErr.Error())
+			}
+
+			return err
+		}
+
+		order.Status = status
+	}
+
+	return nil
+}
+
+func (s *service) FetchShipmentEvents(ctx context.Context, orderID string) ([]*models.ShipmentEvent, error) {
+	if ctx == nil {
+		err := uranus.ErrContextNil
+		return nil, err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, s.contextTimeout)
+	defer cancel()
+
+	if _, err := s.partyOrder(ctx, orderID); err != nil {
+		return nil, err
+	}
+
+	return s.repository.FetchShipmentEvents(ctx, orderID)
+}
+
+// GetShipmentTimeline puts the order's events together with the courier's checkpoints once the item was handed to one.
+// A courier that can't be reached leaves its checkpoints out rather than failing the timeline.
+func (s *service) GetShipmentTimeline(ctx context.Context, orderID string) (*models.ShipmentTimeline, error) {
+	if ctx == nil {
+		err := uranus.ErrContextNil
+		return nil, err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, s.contextTimeout)
+	defer cancel()
+
+	order, err := s.partyOrder(ctx, orderID)
+	if err != nil {
+		return nil, err
+	}
+
+	events, err := s.repository.FetchShipmentEvents(ctx, orderID)
+	if err != nil {
+		return nil, err
+	}
+
+	timeline := &models.ShipmentTimeline{OrderID: order.ID, Status: order.Status, Events: events}
+	for _, event := range events {
+		if event.Type != models.ShipmentHandedToCourier {
+			continue
+		}
+
+		tracking, err := s.tracker.Track(ctx, event.Courier, event.TrackingNumber)
+		if err != nil {
+			log.Printf("Failed to track %s parcel %s : %s", event.Courier, event.TrackingNumber, err.Error())
+			break
+		}
+
+		timeline.Courier = tracking
+	}
+
+	return timeline, nil
+}
+
+// checkEvent rejects an event that doesn't fit where the order is.
+func (s *service) checkEvent(ctx context.Context, order *models.Order, m *models.ShipmentEvent, now time.Time) error {
+	if m.OccurredAt.After(now) {
+		return uranus.ConstraintErrorf("Shipment event can't happen in the future")
+	}
+
+	if status := m.OrderStatus(); order.Status != status {
+		return uranus.ConstraintErrorf("Order is %s, a %s event can only be posted while it's %s", order.Status, m.Type, status)
+	}
+
+	if m.NeedsPhoto() && len(m.ImageIDs) == 0 {
+		return uranus.ConstraintErrorf("A %s event needs a photo", m.Type)
+	}
+
+	events, err := s.repository.FetchShipmentEvents(ctx, order.ID.Hex())
+	if err != nil {
+		return err
+	}
+
+	if len(events) > 0 {
+		last := events[len(events)-1]
+		if !m.After(last) {
+			return uranus.ConstraintErrorf("Order is already %s, a %s event can't follow", last.Type, m.Type)
+		}
+	}
+
+	if m.Type != models.ShipmentHandedToCourier {
+		m.Courier = ""
+		m.TrackingNumber = ""
+		return nil
+	}
+
+	if m.Courier == "" || m.TrackingNumber == "" {
+		return uranus.ConstraintErrorf("Handing over to a courier needs the courier and the tracking number")
+	}
+
+	if _, err = s.tracker.Track(ctx, m.Courier, m.TrackingNumber); err != nil {
+		if err == uranus.ErrNotFound {
+			return uranus.ConstraintErrorf("Courier %s doesn't know tracking number %s", m.Courier, m.TrackingNumber)
+		}
+
+		return err
+	}
+
+	return nil
+}
+
+// partyOrder loads an order the caller is the buyer or the traveler of, admins may load any order.
+func (s *service) partyOrder(ctx context.Context, orderID string) (*models.Order, error) {
+	order, err := s.orderRepository.GetOrderByID(ctx, orderID)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, _, err = auth.AuthorizeParty(ctx, auth.ActionManageOrders, order.PartyOf); err != nil {
+		return nil, err
+	}
+
+	return order, nil
+}
+
+type requirement func(*service)
+
+func Repository(repository repository.ShipmentRepository) requirement {
+	return func(s *service) {
+		s.repository = repository
+	}
+}
+
+func OrderRepository(orderRepository repository.OrderRepository) requirement {
+	return func(s *service) {
+		s.orderRepository = orderRepository
+	}
+}
+
+func CourierTracker(tracker uranus.CourierTracker) requirement {
+	return func(s *service) {
+		s.tracker = tracker
+	}
+}
+
+func Timeout(timeout time.Duration) requirement {
+	return func(s *service) {
+		s.contextTimeout = timeout
+	}
+}
+
+func Validator(validator uranus.Validate) requirement {
+	return func(s *service) {
+		s.validator = validator
+	}
+}
+
+func NewService(reqs ...requirement) uranus.ShipmentUsecase {
+	s := new(service)
+	for _, option := range reqs {
+		option(s)
+	}
+
+	return s
+}
@@ -0,0 +1,33 @@
+package uranus
+
+import (
+	"context"
+	"time"
+
+	"github.com/fidellr/jastip/backend/uranus/models"
+)
+
+type TripUsecase interface {
+	CreateTrip(ctx context.Context, m *models.Trip) error
+	FetchTrips(ctx context.Context, filter *TripFilter) ([]*models.Trip, Page, error)
+	GetTripByID(ctx context.Context, tripID string) (*models.Trip, error)
+	UpdateTripByID(ctx context.Context, tripID string, m *models.Trip) error
+	RemoveTripByID(ctx context.Context, tripID string) error
+	CloseDepartedTrips(ctx context.Context) (int, error)
+}
+
+// TripSort lists trips by the soonest departure first.
+const TripSort = "departure_at"
+
+// TripFilter narrows trips down to a destination and a departure window.
+type TripFilter struct {
+	Num    int
+	Cursor string
+
+	TravelerID         string
+	DestinationCountry string
+	DestinationCity    string
+	DepartureFrom      time.Time
+	DepartureTo        time.Time
+	Status             string
+}
@@ -0,0 +1,249 @@
+package trip
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/globalsign/mgo/bson"
+
+	"github.com/fidellr/jastip/backend/uranus"
+	"github.com/fidellr/jastip/backend/uranus/auth"
+	"github.com/fidellr/jastip/backend/uranus/models"
+	"github.com/fidellr/jastip/backend/uranus/repository"
+)
+
+type service struct {
+	repository     repository.TripRepository
+	userRepository repository.UserAccountRepository
+	validator      uranus.Validate
+	contextTimeout time.Duration
+}
+
+func (s *service) CreateTrip(ctx context.Context, m *models.Trip) (err error) {
+	if ctx == nil {
+		err = uranus.ErrContextNil
+		return err
+	}
+
+	if err = auth.Authorize(ctx, auth.ActionPlanTrip); err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, s.contextTimeout)
+	defer cancel()
+
+	// Travelers plan their own trips, only admins may plan one on behalf of another traveler.
+	principal, _ := auth.FromContext(ctx)
+	if m.TravelerID == "" || m.TravelerID.Hex() == principal.UserID {
+		if !bson.IsObjectIdHex(principal.UserID) {
+			return uranus.ConstraintErrorf("Trip has no traveler")
+		}
+
+		m.TravelerID = bson.ObjectIdHex(principal.UserID)
+	} else if err = auth.Authorize(ctx, auth.ActionManageTrips); err != nil {
+		return err
+	}
+
+	traveler, err := s.userRepository.GetUserByID(ctx, m.TravelerID.Hex())
+	if err != nil {
+		return err
+	}
+
+	if traveler.Role.RoleName != models.RoleTraveler {
+		return uranus.ConstraintErrorf("Only travelers can have trips, %s is a %s", traveler.ID.Hex(), traveler.Role.RoleName)
+	}
+
+	if m.Status == "" {
+		m.Status = models.TripPlanned
+	}
+
+	if err = s.validateTrip(m, time.Now()); err != nil {
+		return err
+	}
+
+	m.ID = bson.NewObjectId()
+	m.ClosedAt = nil
+	m.CreatedAt = time.Now()
+	m.UpdatedAt = time.Now()
+
+	return s.repository.StoreTrip(ctx, m)
+}
+
+func (s *service) FetchTrips(ctx context.Context, filter *uranus.TripFilter) ([]*models.Trip, uranus.Page, error) {
+	if ctx == nil {
+		err := uranus.ErrContextNil
+		return nil, uranus.Page{}, err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, s.contextTimeout)
+	defer cancel()
+
+	if filter.Num == 0 {
+		filter.Num = int(20)
+	}
+
+	filter.DestinationCountry = strings.ToUpper(filter.DestinationCountry)
+	return s.repository.FetchTrips(ctx, filter)
+}
+
+func (s *service) GetTripByID(ctx context.Context, tripID string) (*models.Trip, error) {
+	if ctx == nil {
+		err := uranus.ErrContextNil
+		return nil, err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, s.contextTimeout)
+	defer cancel()
+
+	return s.repository.GetTripByID(ctx, tripID)
+}
+
+func (s *service) UpdateTripByID(ctx context.Context, tripID string, m *models.Trip) (err error) {
+	if ctx == nil {
+		err = uranus.ErrContextNil
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, s.contextTimeout)
+	defer cancel()
+
+	existing, err := s.ownTrip(ctx, tripID)
+	if err != nil {
+		return err
+	}
+
+	if m.Status == "" {
+		m.Status = existing.Status
+	}
+
+	if !existing.CanMoveTo(m.Status) {
+		return uranus.ConstraintErrorf("Trip can't go from %s to %s", existing.Status, m.Status)
+	}
+
+	now := time.Now()
+	if err = s.validateTrip(m, now); err != nil {
+		return err
+	}
+
+	m.ID = existing.ID
+	m.TravelerID = existing.TravelerID
+	m.CreatedAt = existing.CreatedAt
+	m.UpdatedAt = now
+	m.ClosedAt = existing.ClosedAt
+	if m.Status == models.TripClosed && existing.Status != models.TripClosed {
+		m.ClosedAt = &now
+	}
+
+	if m.Status == models.TripPlanned || m.Status == models.TripOpen {
+		m.ClosedAt = nil
+	}
+
+	return s.repository.UpdateTripByID(ctx, tripID, m)
+}
+
+func (s *service) RemoveTripByID(ctx context.Context, tripID string) (err error) {
+	if ctx == nil {
+		err = uranus.ErrContextNil
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, s.contextTimeout)
+	defer cancel()
+
+	existing, err := s.ownTrip(ctx, tripID)
+	if err != nil {
+		return err
+	}
+
+	if existing.Status == models.TripCompleted {
+		return uranus.ConstraintErrorf("Completed trips are kept for the buyers' history")
+	}
+
+	return s.repository.RemoveTripByID(ctx, tripID)
+}
+
+// CloseDepartedTrips closes every planned or open trip whose departure has passed,
+// buyers can't ask a traveler who already left.
+func (s *service) CloseDepartedTrips(ctx context.Context) (int, error) {
+	if ctx == nil {
+		err := uranus.ErrContextNil
+		return 0, err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, s.contextTimeout)
+	defer cancel()
+
+	return s.repository.CloseDepartedTrips(ctx, time.Now())
+}
+
+// ownTrip loads a trip the caller is allowed to change, the traveler of the trip or an admin.
+func (s *service) ownTrip(ctx context.Context, tripID string) (*models.Trip, error) {
+	existing, err := s.repository.GetTripByID(ctx, tripID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err = auth.AuthorizeOwner(ctx, auth.ActionManageTrips, existing.TravelerID.Hex()); err != nil {
+		return nil, err
+	}
+
+	return existing, nil
+}
+
+func (s *service) validateTrip(m *models.Trip, now time.Time) error {
+	m.Origin.Country = strings.ToUpper(m.Origin.Country)
+	m.Destination.Country = strings.ToUpper(m.Destination.Country)
+	if err := s.validator.ValidateStruct(m); err != nil {
+		return err
+	}
+
+	if m.Origin == m.Destination {
+		return uranus.ConstraintErrorf("Trip must go somewhere else than %s", m.Origin.Country)
+	}
+
+	if m.ReturnAt != nil && !m.ReturnAt.After(m.DepartureAt) {
+		return uranus.ConstraintErrorf("Trip must return after it departs")
+	}
+
+	if (m.Status == models.TripPlanned || m.Status == models.TripOpen) && !m.DepartureAt.After(now) {
+		return uranus.ConstraintErrorf("Trip departing at %s has already left", m.DepartureAt.Format(time.RFC3339))
+	}
+
+	return nil
+}
+
+type requirement func(*service)
+
+func Repository(repository repository.TripRepository) requirement {
+	return func(s *service) {
+		s.repository = repository
+	}
+}
+
+func UserAccountRepository(userRepository repository.UserAccountRepository) requirement {
+	return func(s *service) {
+		s.userRepository = userRepository
+	}
+}
+
+func Timeout(timeout time.Duration) requirement {
+	return func(s *service) {
+		s.contextTimeout = timeout
+	}
+}
+
+func Validator(validator uranus.Validate) requirement {
+	return func(s *service) {
+		s.validator = validator
+	}
+}
+
+func NewService(reqs ...requirement) uranus.TripUsecase {
+	s := new(service)
+	for _, option := range reqs {
+		option(s)
+	}
+
+	return s
+}
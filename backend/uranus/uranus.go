@@ -16,17 +16,77 @@ var (
 
 type UserAccountUsecase interface {
 	CreateUserAccount(ctx context.Context, userAccountM *models.UserAccount) error
-	Fetch(ctx context.Context, filter *Filter) ([]*models.UserAccount, string, error)
+	Fetch(ctx context.Context, filter *Filter) ([]*models.UserAccount, Page, error)
 	GetUserByID(ctx context.Context, uuid string) (*models.UserAccount, error)
-	SuspendAccount(ctx context.Context, uuid string) (bool, error)
+	SuspendAccount(ctx context.Context, uuid string, suspension *models.Suspension) (bool, error)
+	ReinstateAccount(ctx context.Context, uuid string, reinstatement *models.Reinstatement) (bool, error)
+	FetchSuspensions(ctx context.Context, uuid string) ([]*models.Suspension, error)
+	LiftExpiredSuspensions(ctx context.Context) (int, error)
 	RemoveAccount(ctx context.Context, uuid string) (bool, error)
+	RestoreAccount(ctx context.Context, uuid string) (bool, error)
+	PurgeDeletedAccounts(ctx context.Context, hard bool) (int, error)
 	UpdateUserByID(ctx context.Context, uuid string, userAccountM *models.UserAccount) error
+	PatchUserByID(ctx context.Context, uuid string, version int, patch []byte) (*models.UserAccount, error)
+	Login(ctx context.Context, credential *models.Credential) (*models.AccessToken, error)
+	VerifyEmail(ctx context.Context, token string) error
+	ForgotPassword(ctx context.Context, email string) error
+	ResetPassword(ctx context.Context, reset *models.PasswordReset) error
+	IsTokenRevoked(ctx context.Context, principal *models.Principal) (bool, error)
+	GetTravelerProfile(ctx context.Context, uuid string) (*models.TravelerProfile, error)
+	UpdateTravelerProfile(ctx context.Context, uuid string, profile *models.TravelerProfile) error
+	GetBuyerProfile(ctx context.Context, uuid string) (*models.BuyerProfile, error)
+	UpdateBuyerProfile(ctx context.Context, uuid string, profile *models.BuyerProfile) error
+	GetNotificationPreferences(ctx context.Context, uuid string) (*models.NotificationPreferences, error)
+	UpdateNotificationPreferences(ctx context.Context, uuid string, prefs *models.NotificationPreferences) error
+}
+
+// Mailer sends emails to users, e.g. the email verification token.
+type Mailer interface {
+	Send(ctx context.Context, mail *models.Mail) error
+}
+
+// TokenManager signs access tokens for authenticated accounts and verifies them back.
+type TokenManager interface {
+	Sign(userAccountM *models.UserAccount) (*models.AccessToken, error)
+	Verify(token string) (*models.Principal, error)
+}
+
+// TokenRevocations tells whether a verified access token was revoked since it was issued.
+type TokenRevocations interface {
+	IsTokenRevoked(ctx context.Context, principal *models.Principal) (bool, error)
 }
 
 type Filter struct {
 	Num      int
 	Cursor   string
 	RoleName string
+
+	// Query is matched against first name, last name and email address through the text index.
+	Query       string
+	IsBanned    *bool
+	IsVerified  *bool
+	CreatedFrom time.Time
+	CreatedTo   time.Time
+
+	// Sort is one of the sortable fields, prefixed with - for descending order.
+	Sort string
+}
+
+// DefaultSort lists the newest accounts first.
+const DefaultSort = "-created_at"
+
+// sortableFields are the only account fields Fetch can sort on, anything else would need its own index.
+var sortableFields = map[string]bool{
+	"created_at":    true,
+	"first_name":    true,
+	"last_name":     true,
+	"email_address": true,
+}
+
+// IsSortable reports whether Fetch can sort on the given sort.
+func IsSortable(sort string) bool {
+	field, _ := sortField(sort)
+	return sortableFields[field]
 }
 
 type ErrValidation struct {
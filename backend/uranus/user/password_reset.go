@@ -0,0 +1,107 @@
+package user
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/fidellr/jastip/backend/uranus"
+	"github.com/fidellr/jastip/backend/uranus/models"
+)
+
+const passwordResetMailBody = `Hi %s,
+
+Someone asked to reset the password of your Jastip account. Use the code below to set a new password:
+
+%s
+
+The code expires at %s. If you did not ask for it, you can ignore this email.`
+
+func (s *service) ForgotPassword(ctx context.Context, email string) error {
+	if ctx == nil {
+		return uranus.ErrContextNil
+	}
+
+	forgot := &models.ForgotPassword{EmailAddress: normalizeEmail(email)}
+	if err := s.validator.ValidateStruct(forgot); err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, s.contextTimeout)
+	defer cancel()
+
+	user, err := s.repository.GetUserByEmail(ctx, forgot.EmailAddress)
+	if err != nil {
+		// Unknown addresses succeed silently so the endpoint can't be used to probe accounts.
+		if err == uranus.ErrNotFound {
+			return nil
+		}
+
+		return err
+	}
+
+	// For the same reason a reset that can't be sent is only logged, a known address answers like an unknown one.
+	if err = s.sendPasswordReset(ctx, user); err != nil {
+		log.Printf("Failed to send password reset to %s : %s", user.EmailAddress, err.Error())
+	}
+
+	return nil
+}
+
+func (s *service) sendPasswordReset(ctx context.Context, user *models.UserAccount) error {
+	token, tokenHash, err := generateToken()
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	userToken := &models.UserToken{
+		UserID:    user.ID,
+		Purpose:   models.TokenPurposePasswordReset,
+		TokenHash: tokenHash,
+		CreatedAt: now,
+		ExpiresAt: now.Add(s.passwordResetTTL),
+	}
+	if err = s.userTokenRepository.StoreToken(ctx, userToken); err != nil {
+		return err
+	}
+
+	return s.mailer.Send(ctx, &models.Mail{
+		To:      user.EmailAddress,
+		Subject: "Reset your Jastip password",
+		Body:    fmt.Sprintf(passwordResetMailBody, user.FirstName, token, userToken.ExpiresAt.Format(time.RFC1123)),
+	})
+}
+
+func (s *service) ResetPassword(ctx context.Context, reset *models.PasswordReset) error {
+	if ctx == nil {
+		return uranus.ErrContextNil
+	}
+
+	if err := s.validator.ValidateStruct(reset); err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, s.contextTimeout)
+	defer cancel()
+
+	userToken, err := s.userTokenRepository.ConsumeToken(ctx, models.TokenPurposePasswordReset, hashToken(reset.Token))
+	if err != nil {
+		return err
+	}
+
+	passwordHash, err := hashPassword(reset.Password)
+	if err != nil {
+		return errors.Wrap(err, "error hashing password")
+	}
+
+	userID := userToken.UserID.Hex()
+	if err = s.repository.UpdatePassword(ctx, userID, passwordHash); err != nil {
+		return err
+	}
+
+	return s.userTokenRepository.RemoveTokensByUser(ctx, userID, models.TokenPurposePasswordReset)
+}
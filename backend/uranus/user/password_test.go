@@ -0,0 +1,33 @@
+package user
+
+import "testing"
+
+func TestHashPassword(t *testing.T) {
+	hashed, err := hashPassword("correct horse battery staple")
+	if err != nil {
+		t.Fatalf("hashPassword: %v", err)
+	}
+
+	if hashed == "correct horse battery staple" {
+		t.Fatal("hashPassword stored the password as it is")
+	}
+
+	if !comparePassword(hashed, "correct horse battery staple") {
+		t.Error("comparePassword refused the password it was hashed from")
+	}
+
+	for _, password := range []string{"", "correct horse battery stapl", "Correct horse battery staple"} {
+		if comparePassword(hashed, password) {
+			t.Errorf("comparePassword accepted %q", password)
+		}
+	}
+
+	again, err := hashPassword("correct horse battery staple")
+	if err != nil {
+		t.Fatalf("hashPassword: %v", err)
+	}
+
+	if again == hashed {
+		t.Error("hashing the same password twice gave the same hash, it isn't salted")
+	}
+}
@@ -0,0 +1,65 @@
+package user
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/fidellr/jastip/backend/uranus"
+	"github.com/fidellr/jastip/backend/uranus/auth"
+	"github.com/fidellr/jastip/backend/uranus/models"
+)
+
+// mutableAccountFields are the only fields a client may send in a merge patch.
+var mutableAccountFields = []string{
+	"first_name",
+	"last_name",
+	"email_address",
+	"password",
+	"role",
+}
+
+func (s *service) PatchUserByID(ctx context.Context, id string, version int, patch []byte) (*models.UserAccount, error) {
+	if ctx == nil {
+		err := uranus.ErrContextNil
+		return nil, err
+	}
+
+	if err := auth.AuthorizeOwner(ctx, auth.ActionUpdateAccount, id); err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, s.contextTimeout)
+	defer cancel()
+
+	existing, err := s.repository.GetUserByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if !uranus.VersionMatches(version, existing.Version) {
+		return nil, uranus.ErrVersionConflict
+	}
+
+	// The JSON form of an account never holds the password,
+	// so the merged account only carries one when the patch sets it.
+	target, err := json.Marshal(existing)
+	if err != nil {
+		return nil, err
+	}
+
+	merged, err := uranus.MergePatch(target, patch, mutableAccountFields...)
+	if err != nil {
+		return nil, err
+	}
+
+	m := new(models.UserAccount)
+	if err = json.Unmarshal(merged, m); err != nil {
+		return nil, uranus.ConstraintErrorf("%s", err.Error())
+	}
+
+	if err = s.saveAccount(ctx, existing, m); err != nil {
+		return nil, err
+	}
+
+	return m, nil
+}
@@ -0,0 +1,152 @@
+package user
+
+import (
+	"context"
+	"strings"
+
+	"github.com/fidellr/jastip/backend/uranus"
+	"github.com/fidellr/jastip/backend/uranus/auth"
+	"github.com/fidellr/jastip/backend/uranus/models"
+)
+
+func (s *service) GetTravelerProfile(ctx context.Context, id string) (*models.TravelerProfile, error) {
+	user, err := s.profileOwner(ctx, auth.ActionReadAccount, id, models.RoleTraveler)
+	if err != nil {
+		return nil, err
+	}
+
+	if user.TravelerProfile == nil {
+		return nil, uranus.ErrNotFound
+	}
+
+	return user.TravelerProfile, nil
+}
+
+func (s *service) UpdateTravelerProfile(ctx context.Context, id string, profile *models.TravelerProfile) error {
+	if _, err := s.profileOwner(ctx, auth.ActionUpdateAccount, id, models.RoleTraveler); err != nil {
+		return err
+	}
+
+	for i := range profile.FrequentRoutes {
+		route := &profile.FrequentRoutes[i]
+		route.OriginCountry = strings.ToUpper(route.OriginCountry)
+		route.DestinationCountry = strings.ToUpper(route.DestinationCountry)
+		if route.OriginCity == route.DestinationCity && route.OriginCountry == route.DestinationCountry {
+			return uranus.ConstraintErrorf("Route %d starts and ends in %s", i, route.OriginCity)
+		}
+	}
+
+	if err := s.validator.ValidateStruct(profile); err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, s.contextTimeout)
+	defer cancel()
+
+	return s.repository.UpdateTravelerProfile(ctx, id, profile)
+}
+
+func (s *service) GetBuyerProfile(ctx context.Context, id string) (*models.BuyerProfile, error) {
+	user, err := s.profileOwner(ctx, auth.ActionReadAccount, id, models.RoleBuyer)
+	if err != nil {
+		return nil, err
+	}
+
+	if user.BuyerProfile == nil {
+		return nil, uranus.ErrNotFound
+	}
+
+	return user.BuyerProfile, nil
+}
+
+func (s *service) UpdateBuyerProfile(ctx context.Context, id string, profile *models.BuyerProfile) error {
+	if _, err := s.profileOwner(ctx, auth.ActionUpdateAccount, id, models.RoleBuyer); err != nil {
+		return err
+	}
+
+	profile.Shipping.Country = strings.ToUpper(profile.Shipping.Country)
+	if err := s.validator.ValidateStruct(profile); err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, s.contextTimeout)
+	defer cancel()
+
+	return s.repository.UpdateBuyerProfile(ctx, id, profile)
+}
+
+// GetNotificationPreferences tells how the user is notified, the defaults when they never set them.
+func (s *service) GetNotificationPreferences(ctx context.Context, id string) (*models.NotificationPreferences, error) {
+	if ctx == nil {
+		err := uranus.ErrContextNil
+		return nil, err
+	}
+
+	if err := auth.AuthorizeOwner(ctx, auth.ActionReadAccount, id); err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, s.contextTimeout)
+	defer cancel()
+
+	user, err := s.repository.GetUserByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if user.Notifications == nil {
+		return &models.NotificationPreferences{
+			Language: user.Notifications.Lang(),
+			Channels: user.Notifications.ChannelsFor(""),
+		}, nil
+	}
+
+	return user.Notifications, nil
+}
+
+func (s *service) UpdateNotificationPreferences(ctx context.Context, id string, prefs *models.NotificationPreferences) error {
+	if ctx == nil {
+		return uranus.ErrContextNil
+	}
+
+	if err := auth.AuthorizeOwner(ctx, auth.ActionUpdateAccount, id); err != nil {
+		return err
+	}
+
+	prefs.Language = strings.ToLower(prefs.Language)
+	if err := s.validator.ValidateStruct(prefs); err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, s.contextTimeout)
+	defer cancel()
+
+	return s.repository.UpdateNotificationPreferences(ctx, id, prefs)
+}
+
+// profileOwner loads the account a profile belongs to once the caller may perform the action on it,
+// a profile only exists for accounts of its own role.
+func (s *service) profileOwner(ctx context.Context, action auth.Action, id string, roleName string) (*models.UserAccount, error) {
+	if ctx == nil {
+		err := uranus.ErrContextNil
+		return nil, err
+	}
+
+	if err := auth.AuthorizeOwner(ctx, action, id); err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, s.contextTimeout)
+	defer cancel()
+
+	user, err := s.repository.GetUserByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if user.Role.RoleName != roleName {
+		return nil, uranus.ErrProfileRoleMismatch
+	}
+
+	return user, nil
+}
@@ -0,0 +1,109 @@
+package user
+
+import (
+	"context"
+	"time"
+
+	"github.com/fidellr/jastip/backend/uranus"
+	"github.com/fidellr/jastip/backend/uranus/auth"
+	"github.com/fidellr/jastip/backend/uranus/models"
+)
+
+// RemoveAccount soft-deletes the account, it stays restorable until the retention period ends.
+func (s *service) RemoveAccount(ctx context.Context, id string) (bool, error) {
+	if ctx == nil {
+		err := uranus.ErrContextNil
+		return false, err
+	}
+
+	if err := auth.AuthorizeOwner(ctx, auth.ActionRemoveAccount, id); err != nil {
+		return false, err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, s.contextTimeout)
+	defer cancel()
+
+	isRemoved, err := s.repository.RemoveAccount(ctx, id, time.Now())
+	if !isRemoved || err != nil {
+		return false, err
+	}
+
+	return true, nil
+}
+
+func (s *service) RestoreAccount(ctx context.Context, id string) (bool, error) {
+	if ctx == nil {
+		err := uranus.ErrContextNil
+		return false, err
+	}
+
+	if err := auth.AuthorizeOwner(ctx, auth.ActionRemoveAccount, id); err != nil {
+		return false, err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, s.contextTimeout)
+	defer cancel()
+
+	isRestored, err := s.repository.RestoreAccount(ctx, id, time.Now().Add(-s.retentionPeriod))
+	if err == uranus.ErrNotFound {
+		return false, uranus.ConstraintErrorf("Account %s is not deleted or its retention period has ended", id)
+	}
+
+	if err == uranus.ErrEmailTaken {
+		return false, uranus.ConstraintErrorf("Account %s can't be restored, its email address was registered by another account", id)
+	}
+
+	if !isRestored || err != nil {
+		return false, err
+	}
+
+	return true, nil
+}
+
+// PurgeDeletedAccounts anonymizes, or removes when hard is set, every account
+// deleted longer than the retention period ago. It returns how many accounts were purged.
+// Each account gets its own timeout, a long backlog doesn't run out of time halfway.
+func (s *service) PurgeDeletedAccounts(ctx context.Context, hard bool) (int, error) {
+	if ctx == nil {
+		err := uranus.ErrContextNil
+		return 0, err
+	}
+
+	now := time.Now()
+	users, err := s.fetchPurgeable(ctx, now)
+	if err != nil {
+		return 0, err
+	}
+
+	for i, user := range users {
+		if err = s.purgeAccount(ctx, user.ID.Hex(), hard, now); err != nil {
+			return i, err
+		}
+	}
+
+	return len(users), nil
+}
+
+func (s *service) fetchPurgeable(ctx context.Context, now time.Time) ([]*models.UserAccount, error) {
+	ctx, cancel := context.WithTimeout(ctx, s.contextTimeout)
+	defer cancel()
+
+	return s.repository.FetchDeletedBefore(ctx, now.Add(-s.retentionPeriod))
+}
+
+func (s *service) purgeAccount(ctx context.Context, id string, hard bool, now time.Time) (err error) {
+	ctx, cancel := context.WithTimeout(ctx, s.contextTimeout)
+	defer cancel()
+
+	for _, purpose := range []string{models.TokenPurposeEmailVerification, models.TokenPurposePasswordReset} {
+		if err = s.userTokenRepository.RemoveTokensByUser(ctx, id, purpose); err != nil {
+			return err
+		}
+	}
+
+	if hard {
+		return s.repository.PurgeAccount(ctx, id)
+	}
+
+	return s.repository.AnonymizeAccount(ctx, id, now)
+}
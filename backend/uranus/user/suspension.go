@@ -0,0 +1,152 @@
+package user
+
+import (
+	"context"
+	"time"
+
+	"github.com/fidellr/jastip/backend/uranus"
+	"github.com/fidellr/jastip/backend/uranus/auth"
+	"github.com/fidellr/jastip/backend/uranus/models"
+)
+
+// systemActor is recorded as the actor when uranus lifts an expired suspension by itself.
+const systemActor = "system"
+
+func (s *service) SuspendAccount(ctx context.Context, id string, suspension *models.Suspension) (bool, error) {
+	if ctx == nil {
+		err := uranus.ErrContextNil
+		return false, err
+	}
+
+	if err := auth.Authorize(ctx, auth.ActionSuspendAccount); err != nil {
+		return false, err
+	}
+
+	if err := s.validator.ValidateStruct(suspension); err != nil {
+		return false, err
+	}
+
+	now := time.Now()
+	if suspension.EndsAt != nil && !suspension.EndsAt.After(now) {
+		return false, uranus.ConstraintErrorf("Suspension must end in the future, got %s", suspension.EndsAt.Format(time.RFC3339))
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, s.contextTimeout)
+	defer cancel()
+
+	user, err := s.repository.GetUserByID(ctx, id)
+	if err != nil {
+		return false, err
+	}
+
+	principal, _ := auth.FromContext(ctx)
+	suspension.ID = ""
+	suspension.UserID = user.ID
+	suspension.SuspendedBy = principal.UserID
+	suspension.StartedAt = now
+	suspension.LiftedAt = nil
+	suspension.LiftedBy = ""
+	suspension.LiftNote = ""
+
+	// A new suspension supersedes whatever suspension is still running.
+	if err = s.suspensionRepository.LiftSuspensions(ctx, id, principal.UserID, "superseded", now); err != nil {
+		return false, err
+	}
+
+	if err = s.suspensionRepository.StoreSuspension(ctx, suspension); err != nil {
+		return false, err
+	}
+
+	data := map[string]string{"reason": suspension.ReasonCode}
+	if suspension.EndsAt != nil {
+		data["until"] = suspension.EndsAt.Format("2006-01-02 15:04 MST")
+	}
+
+	message := models.NewOutboxMessage(id, models.NotifyAccountSuspended, data)
+	isSuspsended, err := s.repository.SuspendAccount(ctx, id, suspension.EndsAt, message)
+	if !isSuspsended || err != nil {
+		return false, err
+	}
+
+	return true, nil
+}
+
+func (s *service) ReinstateAccount(ctx context.Context, id string, reinstatement *models.Reinstatement) (bool, error) {
+	if ctx == nil {
+		err := uranus.ErrContextNil
+		return false, err
+	}
+
+	if err := auth.Authorize(ctx, auth.ActionSuspendAccount); err != nil {
+		return false, err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, s.contextTimeout)
+	defer cancel()
+
+	user, err := s.repository.GetUserByID(ctx, id)
+	if err != nil {
+		return false, err
+	}
+
+	if !user.IsBanned {
+		return false, uranus.ConstraintErrorf("Account %s is not suspended", id)
+	}
+
+	principal, _ := auth.FromContext(ctx)
+	if err = s.liftSuspension(ctx, id, principal.UserID, reinstatement.Note); err != nil {
+		return false, err
+	}
+
+	return true, nil
+}
+
+func (s *service) FetchSuspensions(ctx context.Context, id string) ([]*models.Suspension, error) {
+	if ctx == nil {
+		err := uranus.ErrContextNil
+		return nil, err
+	}
+
+	if err := auth.AuthorizeOwner(ctx, auth.ActionSuspendAccount, id); err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, s.contextTimeout)
+	defer cancel()
+
+	return s.suspensionRepository.FetchSuspensionsByUser(ctx, id)
+}
+
+// LiftExpiredSuspensions reinstates every account whose suspension has run out,
+// it returns how many accounts were reinstated.
+func (s *service) LiftExpiredSuspensions(ctx context.Context) (int, error) {
+	if ctx == nil {
+		err := uranus.ErrContextNil
+		return 0, err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, s.contextTimeout)
+	defer cancel()
+
+	users, err := s.repository.FetchExpiredSuspensions(ctx, time.Now())
+	if err != nil {
+		return 0, err
+	}
+
+	for i, user := range users {
+		if err = s.liftSuspension(ctx, user.ID.Hex(), systemActor, "suspension expired"); err != nil {
+			return i, err
+		}
+	}
+
+	return len(users), nil
+}
+
+func (s *service) liftSuspension(ctx context.Context, id, actor, note string) error {
+	if err := s.suspensionRepository.LiftSuspensions(ctx, id, actor, note, time.Now()); err != nil {
+		return err
+	}
+
+	_, err := s.repository.ReinstateAccount(ctx, id)
+	return err
+}
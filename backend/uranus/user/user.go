@@ -2,19 +2,30 @@ package user
 
 import (
 	"context"
+	"log"
+	"strings"
 	"time"
 
 	"github.com/fidellr/jastip/backend/uranus/models"
+	"github.com/globalsign/mgo/bson"
 	"github.com/pkg/errors"
 
 	uranus "github.com/fidellr/jastip/backend/uranus"
+	"github.com/fidellr/jastip/backend/uranus/auth"
 	"github.com/fidellr/jastip/backend/uranus/repository"
 )
 
 type service struct {
-	repository     repository.UserAccountRepository
-	validator      uranus.Validate
-	contextTimeout time.Duration
+	repository           repository.UserAccountRepository
+	userTokenRepository  repository.UserTokenRepository
+	suspensionRepository repository.SuspensionRepository
+	validator            uranus.Validate
+	tokenManager         uranus.TokenManager
+	mailer               uranus.Mailer
+	contextTimeout       time.Duration
+	verificationTTL      time.Duration
+	passwordResetTTL     time.Duration
+	retentionPeriod      time.Duration
 }
 
 func (s *service) CreateUserAccount(ctx context.Context, m *models.UserAccount) (err error) {
@@ -23,10 +34,25 @@ func (s *service) CreateUserAccount(ctx context.Context, m *models.UserAccount)
 		return err
 	}
 
+	m.EmailAddress = normalizeEmail(m.EmailAddress)
 	if err = s.validator.ValidateStruct(m); err != nil {
 		return err
 	}
 
+	if !auth.IsSelfServiceRole(m.Role.RoleName) {
+		if err = auth.Authorize(ctx, auth.ActionAssignRole); err != nil {
+			return err
+		}
+	}
+
+	m.Password, err = hashPassword(m.Password)
+	if err != nil {
+		return errors.Wrap(err, "error hashing password")
+	}
+
+	m.IsBanned = false
+	m.VerifiedAt = nil
+	m.Version = 1
 	m.CreatedAt = time.Now()
 	m.UpdatedAt = time.Now()
 
@@ -38,18 +64,27 @@ func (s *service) CreateUserAccount(ctx context.Context, m *models.UserAccount)
 	ctx, cancel := context.WithTimeout(ctx, s.contextTimeout)
 	defer cancel()
 
+	m.ID = bson.NewObjectId()
 	err = s.repository.CreateUserAccount(ctx, m)
 	if err != nil {
 		return err
 	}
 
+	if err = s.sendVerification(ctx, m); err != nil {
+		log.Printf("Failed to send verification email to %s : %s", m.EmailAddress, err.Error())
+	}
+
 	return nil
 }
 
-func (s *service) Fetch(ctx context.Context, filter *uranus.Filter) ([]*models.UserAccount, string, error) {
+func (s *service) Fetch(ctx context.Context, filter *uranus.Filter) ([]*models.UserAccount, uranus.Page, error) {
 	if ctx == nil {
 		err := uranus.ErrContextNil
-		return nil, "", err
+		return nil, uranus.Page{}, err
+	}
+
+	if err := auth.Authorize(ctx, auth.ActionFetchAccounts); err != nil {
+		return nil, uranus.Page{}, err
 	}
 
 	ctx, cancel := context.WithTimeout(ctx, s.contextTimeout)
@@ -59,12 +94,20 @@ func (s *service) Fetch(ctx context.Context, filter *uranus.Filter) ([]*models.U
 		filter.Num = int(20)
 	}
 
-	users, nextCursor, err := s.repository.Fetch(ctx, filter)
+	if filter.Sort == "" {
+		filter.Sort = uranus.DefaultSort
+	}
+
+	if !uranus.IsSortable(filter.Sort) {
+		return nil, uranus.Page{}, uranus.ErrInvalidSort
+	}
+
+	users, page, err := s.repository.Fetch(ctx, filter)
 	if err != nil {
-		return nil, nextCursor, err
+		return nil, page, err
 	}
 
-	return users, nextCursor, nil
+	return users, page, nil
 }
 
 func (s *service) GetUserByID(ctx context.Context, id string) (*models.UserAccount, error) {
@@ -73,6 +116,10 @@ func (s *service) GetUserByID(ctx context.Context, id string) (*models.UserAccou
 		return nil, err
 	}
 
+	if err := auth.AuthorizeOwner(ctx, auth.ActionReadAccount, id); err != nil {
+		return nil, err
+	}
+
 	ctx, cancel := context.WithTimeout(ctx, s.contextTimeout)
 	defer cancel()
 
@@ -84,57 +131,174 @@ func (s *service) GetUserByID(ctx context.Context, id string) (*models.UserAccou
 	return user, nil
 }
 
-func (s *service) SuspendAccount(ctx context.Context, id string) (bool, error) {
+func (s *service) UpdateUserByID(ctx context.Context, id string, m *models.UserAccount) (err error) {
 	if ctx == nil {
-		err := uranus.ErrContextNil
-		return false, err
+		err = uranus.ErrContextNil
+		return err
+	}
+
+	if err = auth.AuthorizeOwner(ctx, auth.ActionUpdateAccount, id); err != nil {
+		return err
 	}
 
 	ctx, cancel := context.WithTimeout(ctx, s.contextTimeout)
 	defer cancel()
 
-	isSuspsended, err := s.repository.SuspendAccount(ctx, id)
-	if !isSuspsended || err != nil {
-		return false, err
+	existing, err := s.repository.GetUserByID(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	if !uranus.VersionMatches(m.Version, existing.Version) {
+		return uranus.ErrVersionConflict
 	}
 
-	return true, nil
+	return s.saveAccount(ctx, existing, m)
 }
 
-func (s *service) RemoveAccount(ctx context.Context, id string) (bool, error) {
+// saveAccount replaces the stored account with m, keeping every field the client is not allowed to set.
+// An empty password keeps the current one, and the write fails if the account changed since existing was read.
+func (s *service) saveAccount(ctx context.Context, existing, m *models.UserAccount) (err error) {
+	// Only callers allowed to assign roles may change the role.
+	if m.Role != existing.Role {
+		if err = auth.Authorize(ctx, auth.ActionAssignRole); err != nil {
+			return err
+		}
+	}
+
+	m.ID = existing.ID
+	m.Version = existing.Version + 1
+	m.CreatedAt = existing.CreatedAt
+	m.IsBanned = existing.IsBanned
+	m.SuspendedUntil = existing.SuspendedUntil
+	m.DeletedAt = existing.DeletedAt
+	m.PurgedAt = existing.PurgedAt
+	m.TravelerProfile = existing.TravelerProfile
+	m.BuyerProfile = existing.BuyerProfile
+	m.Notifications = existing.Notifications
+	m.Rating = existing.Rating
+	m.TokensRevokedAt = existing.TokensRevokedAt
+	m.Outbox = existing.Outbox
+	m.UpdatedAt = time.Now()
+	m.EmailAddress = normalizeEmail(m.EmailAddress)
+
+	// A changed email address has to be verified again.
+	emailChanged := m.EmailAddress != existing.EmailAddress
+	m.VerifiedAt = existing.VerifiedAt
+	if emailChanged {
+		m.VerifiedAt = nil
+	}
+
+	passwordChanged := m.Password != ""
+	if !passwordChanged {
+		m.Password = existing.Password
+	}
+
+	if err = s.validator.ValidateStruct(m); err != nil {
+		return err
+	}
+
+	if passwordChanged {
+		m.Password, err = hashPassword(m.Password)
+		if err != nil {
+			return errors.Wrap(err, "error hashing password")
+		}
+
+		m.TokensRevokedAt = &m.UpdatedAt
+	}
+
+	err = s.repository.UpdateUserByID(ctx, existing.ID.Hex(), existing.Version, m)
+	if err != nil {
+		return err
+	}
+
+	if emailChanged {
+		// Links sent to the previous address must not verify the new one.
+		if err = s.userTokenRepository.RemoveTokensByUser(ctx, existing.ID.Hex(), models.TokenPurposeEmailVerification); err != nil {
+			return err
+		}
+
+		if err = s.sendVerification(ctx, m); err != nil {
+			log.Printf("Failed to send verification email to %s : %s", m.EmailAddress, err.Error())
+		}
+	}
+
+	return nil
+}
+
+func (s *service) Login(ctx context.Context, c *models.Credential) (*models.AccessToken, error) {
 	if ctx == nil {
 		err := uranus.ErrContextNil
-		return false, err
+		return nil, err
+	}
+
+	c.EmailAddress = normalizeEmail(c.EmailAddress)
+	if err := s.validator.ValidateStruct(c); err != nil {
+		return nil, err
 	}
 
 	ctx, cancel := context.WithTimeout(ctx, s.contextTimeout)
 	defer cancel()
 
-	isRemoved, err := s.repository.RemoveAccount(ctx, id)
-	if !isRemoved || err != nil {
-		return false, err
+	user, err := s.repository.GetUserByEmail(ctx, c.EmailAddress)
+	if err != nil {
+		if err == uranus.ErrNotFound {
+			return nil, uranus.ErrInvalidCredentials
+		}
+
+		return nil, err
+	}
+
+	if !comparePassword(user.Password, c.Password) {
+		return nil, uranus.ErrInvalidCredentials
+	}
+
+	if user.IsSuspended(time.Now()) {
+		return nil, uranus.ErrAccountSuspended
 	}
 
-	return true, nil
+	if user.IsBanned {
+		if err = s.liftSuspension(ctx, user.ID.Hex(), systemActor, "suspension expired"); err != nil {
+			return nil, err
+		}
+	}
+
+	return s.tokenManager.Sign(user)
 }
 
-func (s *service) UpdateUserByID(ctx context.Context, id string, m *models.UserAccount) (err error) {
+// IsTokenRevoked reports whether the access token no longer stands for a live account: the account was
+// removed since, or its password changed after the token was issued. Tokens carry their issue time in
+// whole seconds, so one issued in the same second as the revocation still passes.
+func (s *service) IsTokenRevoked(ctx context.Context, principal *models.Principal) (bool, error) {
 	if ctx == nil {
-		err = uranus.ErrContextNil
-		return err
+		return false, uranus.ErrContextNil
+	}
+
+	if !bson.IsObjectIdHex(principal.UserID) {
+		return true, nil
 	}
 
 	ctx, cancel := context.WithTimeout(ctx, s.contextTimeout)
 	defer cancel()
 
-	m.UpdatedAt = time.Now()
-
-	err = s.repository.UpdateUserByID(ctx, id, m)
+	user, err := s.repository.GetUserByID(ctx, principal.UserID)
 	if err != nil {
-		return err
+		if err == uranus.ErrNotFound {
+			return true, nil
+		}
+
+		return false, err
 	}
 
-	return nil
+	if user.TokensRevokedAt == nil {
+		return false, nil
+	}
+
+	return principal.IssuedAt.Before(user.TokensRevokedAt.Truncate(time.Second)), nil
+}
+
+func normalizeEmail(email string) string {
+	return strings.ToLower(strings.TrimSpace(email))
 }
 
 type requirement func(*service)
@@ -157,8 +321,61 @@ func Validator(validator uranus.Validate) requirement {
 	}
 }
 
+func TokenManager(tokenManager uranus.TokenManager) requirement {
+	return func(s *service) {
+		s.tokenManager = tokenManager
+	}
+}
+
+func UserTokenRepository(userTokenRepository repository.UserTokenRepository) requirement {
+	return func(s *service) {
+		s.userTokenRepository = userTokenRepository
+	}
+}
+
+func SuspensionRepository(suspensionRepository repository.SuspensionRepository) requirement {
+	return func(s *service) {
+		s.suspensionRepository = suspensionRepository
+	}
+}
+
+func Mailer(mailer uranus.Mailer) requirement {
+	return func(s *service) {
+		s.mailer = mailer
+	}
+}
+
+func VerificationTTL(ttl time.Duration) requirement {
+	return func(s *service) {
+		if ttl > 0 {
+			s.verificationTTL = ttl
+		}
+	}
+}
+
+func PasswordResetTTL(ttl time.Duration) requirement {
+	return func(s *service) {
+		if ttl > 0 {
+			s.passwordResetTTL = ttl
+		}
+	}
+}
+
+// RetentionPeriod sets how long a removed account can still be restored before it gets purged.
+func RetentionPeriod(period time.Duration) requirement {
+	return func(s *service) {
+		if period > 0 {
+			s.retentionPeriod = period
+		}
+	}
+}
+
 func NewService(req ...requirement) uranus.UserAccountUsecase {
-	s := new(service)
+	s := &service{
+		verificationTTL:  24 * time.Hour,
+		passwordResetTTL: time.Hour,
+		retentionPeriod:  30 * 24 * time.Hour,
+	}
 	for _, option := range req {
 		option(s)
 	}
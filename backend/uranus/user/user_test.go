@@ -0,0 +1,66 @@
+package user
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/globalsign/mgo/bson"
+
+	"github.com/fidellr/jastip/backend/uranus"
+	"github.com/fidellr/jastip/backend/uranus/models"
+	"github.com/fidellr/jastip/backend/uranus/repository"
+)
+
+type memoryUsers struct {
+	repository.UserAccountRepository
+	users map[string]*models.UserAccount
+}
+
+func (r *memoryUsers) GetUserByID(ctx context.Context, uuid string) (*models.UserAccount, error) {
+	user, ok := r.users[uuid]
+	if !ok {
+		return nil, uranus.ErrNotFound
+	}
+
+	return user, nil
+}
+
+func TestIsTokenRevoked(t *testing.T) {
+	// The password changed half a second into the second, tokens only know the whole second they were issued in.
+	revokedAt := time.Date(2026, 3, 1, 10, 0, 0, 500000000, time.UTC)
+	revoked := &models.UserAccount{ID: bson.NewObjectId(), TokensRevokedAt: &revokedAt}
+	live := &models.UserAccount{ID: bson.NewObjectId()}
+
+	s := NewService(
+		Repository(&memoryUsers{users: map[string]*models.UserAccount{revoked.ID.Hex(): revoked, live.ID.Hex(): live}}),
+		Timeout(time.Second),
+	)
+
+	tests := []struct {
+		name     string
+		userID   string
+		issuedAt time.Time
+		want     bool
+	}{
+		{name: "issued a second before the revocation", userID: revoked.ID.Hex(), issuedAt: revokedAt.Truncate(time.Second).Add(-time.Second), want: true},
+		{name: "issued in the second of the revocation", userID: revoked.ID.Hex(), issuedAt: revokedAt.Truncate(time.Second)},
+		{name: "issued after the revocation", userID: revoked.ID.Hex(), issuedAt: revokedAt.Truncate(time.Second).Add(time.Second)},
+		{name: "account never revoked its tokens", userID: live.ID.Hex(), issuedAt: revokedAt.Add(-time.Hour)},
+		{name: "account is gone", userID: bson.NewObjectId().Hex(), issuedAt: revokedAt, want: true},
+		{name: "user ID isn't an ObjectId", userID: "admin", issuedAt: revokedAt, want: true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got, err := s.IsTokenRevoked(context.Background(), &models.Principal{UserID: test.userID, IssuedAt: test.issuedAt})
+			if err != nil {
+				t.Fatalf("IsTokenRevoked: %v", err)
+			}
+
+			if got != test.want {
+				t.Errorf("IsTokenRevoked = %v, want %v", got, test.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,73 @@
+package user
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/fidellr/jastip/backend/uranus"
+	"github.com/fidellr/jastip/backend/uranus/models"
+)
+
+const verificationMailBody = `Hi %s,
+
+Welcome to Jastip! Use the code below to verify your email address:
+
+%s
+
+The code expires at %s.`
+
+// sendVerification issues a new email verification token and mails it to the account owner.
+func (s *service) sendVerification(ctx context.Context, m *models.UserAccount) error {
+	token, tokenHash, err := generateToken()
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	userToken := &models.UserToken{
+		UserID:    m.ID,
+		Purpose:   models.TokenPurposeEmailVerification,
+		TokenHash: tokenHash,
+		CreatedAt: now,
+		ExpiresAt: now.Add(s.verificationTTL),
+	}
+	if err = s.userTokenRepository.StoreToken(ctx, userToken); err != nil {
+		return err
+	}
+
+	return s.mailer.Send(ctx, &models.Mail{
+		To:      m.EmailAddress,
+		Subject: "Verify your Jastip email address",
+		Body:    fmt.Sprintf(verificationMailBody, m.FirstName, token, userToken.ExpiresAt.Format(time.RFC1123)),
+	})
+}
+
+func (s *service) VerifyEmail(ctx context.Context, token string) error {
+	if ctx == nil {
+		return uranus.ErrContextNil
+	}
+
+	if err := s.validator.ValidateStruct(&models.EmailVerification{Token: token}); err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, s.contextTimeout)
+	defer cancel()
+
+	userToken, err := s.userTokenRepository.ConsumeToken(ctx, models.TokenPurposeEmailVerification, hashToken(token))
+	if err != nil {
+		return err
+	}
+
+	if err = s.repository.MarkEmailVerified(ctx, userToken.UserID.Hex(), time.Now()); err != nil {
+		return err
+	}
+
+	if err = s.userTokenRepository.RemoveTokensByUser(ctx, userToken.UserID.Hex(), models.TokenPurposeEmailVerification); err != nil {
+		log.Printf("Failed to remove remaining verification tokens : %s", err.Error())
+	}
+
+	return nil
+}
@@ -2,6 +2,8 @@ package uranus
 
 import (
 	_validator "gopkg.in/go-playground/validator.v9"
+
+	"github.com/fidellr/jastip/backend/uranus/models"
 )
 
 // Validate is struct for validate
@@ -21,6 +23,10 @@ func (v Validate) ValidateStruct(data interface{}) error {
 // NewValidator is function to init validator
 func NewValidator() Validate {
 	val := _validator.New()
+	val.RegisterValidation("currency", func(fl _validator.FieldLevel) bool {
+		return models.IsCurrency(fl.Field().String())
+	})
+
 	return Validate{
 		validator: val,
 	}